@@ -1,13 +1,30 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/todmy/doc-analyzer/internal/anomaly"
 	"github.com/todmy/doc-analyzer/internal/api"
+	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/charset"
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+	"github.com/todmy/doc-analyzer/internal/storage"
 )
 
 func main() {
@@ -34,16 +51,433 @@ func main() {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	openRouterKey := os.Getenv("OPENROUTER_API_KEY")
 	anthropicKey := os.Getenv("ANTHROPIC_API_KEY")
+	openAIKey := os.Getenv("OPENAI_API_KEY")
 
-	server := api.NewServer(api.ServerConfig{
-		DB:              db,
-		JWTSecret:       jwtSecret,
-		OpenRouterKey:   openRouterKey,
-		AnthropicAPIKey: anthropicKey,
-	})
+	// LLM provider for contradiction detection (anthropic or openai)
+	llmProvider, err := contradiction.ParseProvider(os.Getenv("LLM_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Invalid LLM_PROVIDER: %v", err)
+	}
+
+	// Optional custom contradiction prompt (a text/template file) and
+	// comma-separated contradiction types added to the built-in ones
+	var contradictionPrompt string
+	if path := os.Getenv("CONTRADICTION_PROMPT_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read CONTRADICTION_PROMPT_FILE: %v", err)
+		}
+		contradictionPrompt = string(b)
+	}
+	var contradictionTypes []contradiction.ContradictionType
+	if v := os.Getenv("CONTRADICTION_TYPES"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				contradictionTypes = append(contradictionTypes, contradiction.ContradictionType(t))
+			}
+		}
+	}
+
+	// Per-IP throttling of login, register and token refresh
+	authRatePerMinute := 10.0
+	if v := os.Getenv("AUTH_RATE_PER_MINUTE"); v != "" {
+		authRatePerMinute, err = strconv.ParseFloat(v, 64)
+		if err != nil || authRatePerMinute < 0 {
+			log.Fatalf("Invalid AUTH_RATE_PER_MINUTE %q", v)
+		}
+	}
+	authRateBurst := 5
+	if v := os.Getenv("AUTH_RATE_BURST"); v != "" {
+		authRateBurst, err = strconv.Atoi(v)
+		if err != nil || authRateBurst < 1 {
+			log.Fatalf("Invalid AUTH_RATE_BURST %q", v)
+		}
+	}
+
+	// Embedding model; its dimension must match the statements.embedding column
+	embeddingModel := os.Getenv("EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = embeddings.DefaultModel
+	}
+	if openRouterKey != "" {
+		if err := checkEmbeddingDimension(db, embeddingModel, os.Getenv("EMBEDDING_DIMENSION_CHECK")); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Optional throttling of embedding requests (useful on cold caches)
+	var embeddingRPS float64
+	if v := os.Getenv("EMBEDDING_RPS"); v != "" {
+		embeddingRPS, err = strconv.ParseFloat(v, 64)
+		if err != nil || embeddingRPS < 0 {
+			log.Fatalf("Invalid EMBEDDING_RPS %q", v)
+		}
+	}
+	embeddingBurst := 1
+	if v := os.Getenv("EMBEDDING_BURST"); v != "" {
+		embeddingBurst, err = strconv.Atoi(v)
+		if err != nil || embeddingBurst < 1 {
+			log.Fatalf("Invalid EMBEDDING_BURST %q", v)
+		}
+	}
+
+	// How to analyze projects whose embeddings have mixed dimensions
+	embeddingReconcile, err := embeddings.ParseReconcileMode(os.Getenv("EMBEDDING_RECONCILE"))
+	if err != nil {
+		log.Fatalf("Invalid EMBEDDING_RECONCILE: %v", err)
+	}
+
+	// Optional tuning of the embedding index scans of similarity search
+	var vectorEFSearch, vectorProbes int
+	if v := os.Getenv("HNSW_EF_SEARCH"); v != "" {
+		vectorEFSearch, err = strconv.Atoi(v)
+		if err != nil || vectorEFSearch < 1 {
+			log.Fatalf("Invalid HNSW_EF_SEARCH %q", v)
+		}
+	}
+	if v := os.Getenv("IVFFLAT_PROBES"); v != "" {
+		vectorProbes, err = strconv.Atoi(v)
+		if err != nil || vectorProbes < 1 {
+			log.Fatalf("Invalid IVFFLAT_PROBES %q", v)
+		}
+	}
+
+	// Optional merging of clusters that overlap in the 2D/3D projection
+	var clusterMergeDistance float64
+	if v := os.Getenv("CLUSTER_MERGE_DISTANCE"); v != "" {
+		clusterMergeDistance, err = strconv.ParseFloat(v, 64)
+		if err != nil || clusterMergeDistance < 0 {
+			log.Fatalf("Invalid CLUSTER_MERGE_DISTANCE %q", v)
+		}
+	}
+
+	// Minimum average number of points per automatically chosen visualization cluster
+	var clusterMinPoints int
+	if v := os.Getenv("CLUSTER_MIN_POINTS"); v != "" {
+		clusterMinPoints, err = strconv.Atoi(v)
+		if err != nil || clusterMinPoints <= 0 {
+			log.Fatalf("Invalid CLUSTER_MIN_POINTS %q", v)
+		}
+	}
+
+	// Iteration limit and convergence tolerance of k-means
+	var clusterMaxIter int
+	if v := os.Getenv("CLUSTER_MAX_ITER"); v != "" {
+		clusterMaxIter, err = strconv.Atoi(v)
+		if err != nil || clusterMaxIter <= 0 {
+			log.Fatalf("Invalid CLUSTER_MAX_ITER %q", v)
+		}
+	}
+	var clusterTolerance float64
+	if v := os.Getenv("CLUSTER_TOLERANCE"); v != "" {
+		clusterTolerance, err = strconv.ParseFloat(v, 64)
+		if err != nil || clusterTolerance <= 0 {
+			log.Fatalf("Invalid CLUSTER_TOLERANCE %q", v)
+		}
+	}
+
+	// In-memory cache of analysis responses keyed by parameters and statement set
+	analysisCacheSize := 256
+	if v := os.Getenv("ANALYSIS_CACHE_SIZE"); v != "" {
+		analysisCacheSize, err = strconv.Atoi(v)
+		if err != nil || analysisCacheSize < 0 {
+			log.Fatalf("Invalid ANALYSIS_CACHE_SIZE %q", v)
+		}
+	}
+	analysisCacheTTL := 10 * time.Minute
+	if v := os.Getenv("ANALYSIS_CACHE_TTL"); v != "" {
+		analysisCacheTTL, err = time.ParseDuration(v)
+		if err != nil || analysisCacheTTL <= 0 {
+			log.Fatalf("Invalid ANALYSIS_CACHE_TTL %q", v)
+		}
+	}
+
+	// Background cleanup of abandoned multipart temp files and finished
+	// upload jobs; 0 disables the janitor or one kind of cleanup
+	janitorInterval, err := durationFromEnv("JANITOR_INTERVAL", 10*time.Minute)
+	if err != nil {
+		log.Fatalf("Invalid JANITOR_INTERVAL: %v", err)
+	}
+	tempFileMaxAge, err := durationFromEnv("TEMP_FILE_MAX_AGE", time.Hour)
+	if err != nil {
+		log.Fatalf("Invalid TEMP_FILE_MAX_AGE: %v", err)
+	}
+	jobRetention, err := durationFromEnv("JOB_RETENTION", 24*time.Hour)
+	if err != nil {
+		log.Fatalf("Invalid JOB_RETENTION: %v", err)
+	}
+	tempDir, err := setupTempDir(os.Getenv("UPLOAD_TEMP_DIR"))
+	if err != nil {
+		log.Fatalf("Invalid UPLOAD_TEMP_DIR: %v", err)
+	}
+
+	// Optional comma-separated list of hex colors for cluster legends
+	var clusterPalette []string
+	if v := os.Getenv("CLUSTER_PALETTE"); v != "" {
+		hexColor := regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+		for _, c := range strings.Split(v, ",") {
+			c = strings.TrimSpace(c)
+			if !hexColor.MatchString(c) {
+				log.Fatalf("Invalid CLUSTER_PALETTE color %q (expected #rrggbb)", c)
+			}
+			clusterPalette = append(clusterPalette, c)
+		}
+	}
+
+	// Browser origins allowed to call the API (default: localhost)
+	var corsOrigins []string
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		corsOrigins = strings.Split(v, ",")
+	}
+
+	// Save the statements that can be inserted when some rows of an upload fail
+	skipFailedStatements := false
+	if v := os.Getenv("SKIP_FAILED_STATEMENTS"); v != "" {
+		skipFailedStatements, err = strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid SKIP_FAILED_STATEMENTS %q", v)
+		}
+	}
+
+	// Encoding assumed for uploads that are neither UTF-8 nor UTF-16
+	uploadEncodingFallback, err := charset.ParseFallback(os.Getenv("UPLOAD_ENCODING_FALLBACK"))
+	if err != nil {
+		log.Fatalf("Invalid UPLOAD_ENCODING_FALLBACK: %v", err)
+	}
+
+	// Size limit of a single uploaded document
+	var maxUploadSize int64
+	if v := os.Getenv("MAX_UPLOAD_SIZE_MB"); v != "" {
+		mb, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || mb <= 0 {
+			log.Fatalf("Invalid MAX_UPLOAD_SIZE_MB %q", v)
+		}
+		maxUploadSize = mb << 20
+	}
+
+	// Size limit of batch uploads and the extracted content of zip archives
+	var maxArchiveSize int64
+	if v := os.Getenv("MAX_ARCHIVE_SIZE_MB"); v != "" {
+		mb, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || mb <= 0 {
+			log.Fatalf("Invalid MAX_ARCHIVE_SIZE_MB %q", v)
+		}
+		maxArchiveSize = mb << 20
+	}
+
+	// Optional password reset; tokens are POSTed to a webhook that emails them
+	var resetSender auth.ResetSender
+	if v := os.Getenv("PASSWORD_RESET_WEBHOOK_URL"); v != "" {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Fatalf("Invalid PASSWORD_RESET_WEBHOOK_URL %q", v)
+		}
+		resetSender = auth.NewWebhookResetSender(v, os.Getenv("PASSWORD_RESET_WEBHOOK_SECRET"), nil)
+	}
+
+	serverConfig := api.ServerConfig{
+		DB:                     db,
+		JWTSecret:              jwtSecret,
+		OpenRouterKey:          openRouterKey,
+		AnthropicAPIKey:        anthropicKey,
+		AuthRatePerMinute:      authRatePerMinute,
+		AuthRateBurst:          authRateBurst,
+		ResetSender:            resetSender,
+		EmbeddingModel:         embeddingModel,
+		EmbeddingRPS:           embeddingRPS,
+		EmbeddingBurst:         embeddingBurst,
+		EmbeddingReconcile:     embeddingReconcile,
+		VectorEFSearch:         vectorEFSearch,
+		VectorProbes:           vectorProbes,
+		ClusterMergeDistance:   clusterMergeDistance,
+		ClusterMinPoints:       clusterMinPoints,
+		ClusterMaxIter:         clusterMaxIter,
+		ClusterTolerance:       clusterTolerance,
+		AnalysisCacheSize:      analysisCacheSize,
+		AnalysisCacheTTL:       analysisCacheTTL,
+		ClusterPalette:         clusterPalette,
+		SkipFailedStatements:   skipFailedStatements,
+		UploadEncodingFallback: uploadEncodingFallback,
+		MaxUploadSize:          maxUploadSize,
+		MaxArchiveSize:         maxArchiveSize,
+		JanitorInterval:        janitorInterval,
+		TempDir:                tempDir,
+		TempFileMaxAge:         tempFileMaxAge,
+		JobRetention:           jobRetention,
+		LLMProvider:            llmProvider,
+		OpenAIAPIKey:           openAIKey,
+		LLMBaseURL:             os.Getenv("LLM_BASE_URL"),
+		LLMModel:               os.Getenv("LLM_MODEL"),
+
+		ContradictionPromptTemplate: contradictionPrompt,
+		ContradictionTypes:          contradictionTypes,
+
+		LogFormat:   os.Getenv("LOG_FORMAT"),
+		CORSOrigins: corsOrigins,
+	}
+
+	// Optional overrides of the default analysis parameters
+	if err := analysisDefaultsFromEnv(&serverConfig); err != nil {
+		log.Fatalf("Invalid analysis defaults: %v", err)
+	}
+
+	server, err := api.NewServer(serverConfig)
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+
+	// SIGINT and SIGTERM stop the server gracefully, along with its janitor
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	fmt.Printf("Starting doc-analyzer server on port %s\n", port)
-	if err := server.Run(":" + port); err != nil {
+	if err := server.Run(ctx, ":"+port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// durationFromEnv parses the duration in the named environment variable,
+// returning def when it is unset. Negative durations are rejected.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q must not be negative", v)
+	}
+	return d, nil
+}
+
+// setupTempDir creates dir, or a doc-analyzer directory in os.TempDir when
+// empty, and points TMPDIR at it so multipart uploads spill there. The
+// janitor can then sweep it without touching other processes' temp files.
+// mime/multipart always spills to os.TempDir, so this cannot be scoped to
+// uploads: every temp file the process creates afterwards lands in dir.
+func setupTempDir(dir string) (string, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "doc-analyzer")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	if err := os.Setenv("TMPDIR", dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// analysisDefaultsFromEnv reads the optional overrides of each analysis
+// service's default parameters into cfg. Unset variables leave the
+// corresponding field zero so the service keeps its built-in default.
+func analysisDefaultsFromEnv(cfg *api.ServerConfig) error {
+	if v := os.Getenv("CLUSTER_DEFAULT_K"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil || k < 1 {
+			return fmt.Errorf("CLUSTER_DEFAULT_K %q must be a positive integer", v)
+		}
+		cfg.ClusterDefaultK = k
+	}
+
+	fractions := []struct {
+		name string
+		dst  *float64
+	}{
+		{"SIMILARITY_THRESHOLD", &cfg.SimilarityThreshold},
+		{"ANOMALY_THRESHOLD", &cfg.AnomalyThreshold},
+		{"CONTRADICTION_MIN_SIMILARITY", &cfg.ContradictionMinSimilarity},
+		{"CONTRADICTION_MIN_CONFIDENCE", &cfg.ContradictionMinConfidence},
+	}
+	for _, f := range fractions {
+		v := os.Getenv(f.name)
+		if v == "" {
+			continue
+		}
+		x, err := strconv.ParseFloat(v, 64)
+		if err != nil || x <= 0 || x > 1 {
+			return fmt.Errorf("%s %q must be in (0, 1]", f.name, v)
+		}
+		*f.dst = x
+	}
+
+	if v := os.Getenv("SIMILARITY_METRIC"); v != "" {
+		metric, err := similarity.ParseMetric(v)
+		if err != nil {
+			return err
+		}
+		cfg.SimilarityMetric = metric
+	}
+
+	if v := os.Getenv("CONTRADICTION_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("CONTRADICTION_BATCH_SIZE %q must be a positive integer", v)
+		}
+		cfg.ContradictionBatchSize = n
+	}
+
+	if v := os.Getenv("ANOMALY_DETECTOR"); v != "" {
+		detector, err := anomaly.ParseDetector(v)
+		if err != nil {
+			return err
+		}
+		cfg.AnomalyDetector = detector
+	}
+
+	return nil
+}
+
+// checkEmbeddingDimension compares the embedding model's dimension with the
+// declared dimension of statements.embedding, so a model change fails at
+// startup rather than on the first insert. mode is fail (default), warn,
+// resize or off; with warn a mismatch is only logged, with resize the column
+// is changed to the model's dimension, clearing the stored embeddings.
+func checkEmbeddingDimension(db *sql.DB, model, mode string) error {
+	switch mode {
+	case "off":
+		return nil
+	case "", "fail", "warn", "resize":
+	default:
+		return fmt.Errorf("invalid EMBEDDING_DIMENSION_CHECK %q (expected fail, warn, resize or off)", mode)
+	}
+
+	dim, ok := embeddings.LookupEmbeddingDimension(model)
+	if !ok {
+		log.Printf("[config] unknown embedding model %s, skipping dimension check", model)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := storage.CheckEmbeddingDimension(ctx, db, dim)
+
+	var mismatch *storage.EmbeddingDimensionError
+	if !errors.As(err, &mismatch) {
+		return err
+	}
+	if mode == "resize" {
+		if err := storage.ResizeEmbeddingColumn(ctx, db, dim); err != nil {
+			return err
+		}
+		log.Printf("[config] resized statements.embedding from vector(%d) to vector(%d) for %s and cleared stored embeddings; "+
+			"re-embed each project with POST /api/v1/projects/{id}/reembed", mismatch.Column, dim, model)
+		return nil
+	}
+	err = fmt.Errorf("embedding model %s produces %d-dimensional vectors but statements.embedding is vector(%d). "+
+		"Start once with EMBEDDING_DIMENSION_CHECK=resize to change the column to vector(%d), which clears the stored embeddings, "+
+		"then re-embed each project with POST /api/v1/projects/{id}/reembed; "+
+		"or set EMBEDDING_MODEL to a model with %d dimensions",
+		model, dim, mismatch.Column, dim, mismatch.Column)
+	if mode == "warn" {
+		log.Printf("[config] warning: %v", err)
+		return nil
+	}
+	return err
+}
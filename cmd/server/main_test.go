@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/todmy/doc-analyzer/internal/anomaly"
+	"github.com/todmy/doc-analyzer/internal/api"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+)
+
+func TestAnalysisDefaultsFromEnv(t *testing.T) {
+	t.Setenv("CLUSTER_DEFAULT_K", "7")
+	t.Setenv("SIMILARITY_THRESHOLD", "0.8")
+	t.Setenv("SIMILARITY_METRIC", "dot")
+	t.Setenv("ANOMALY_DETECTOR", "distance")
+	t.Setenv("ANOMALY_THRESHOLD", "0.55")
+	t.Setenv("CONTRADICTION_MIN_SIMILARITY", "0.6")
+
+	var cfg api.ServerConfig
+	if err := analysisDefaultsFromEnv(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ClusterDefaultK != 7 {
+		t.Errorf("expected k 7, got %d", cfg.ClusterDefaultK)
+	}
+	if cfg.SimilarityThreshold != 0.8 {
+		t.Errorf("expected similarity threshold 0.8, got %v", cfg.SimilarityThreshold)
+	}
+	if cfg.SimilarityMetric != similarity.MetricDotProduct {
+		t.Errorf("expected dot metric, got %q", cfg.SimilarityMetric)
+	}
+	if cfg.AnomalyDetector != anomaly.DetectorDistance {
+		t.Errorf("expected distance detector, got %q", cfg.AnomalyDetector)
+	}
+	if cfg.AnomalyThreshold != 0.55 {
+		t.Errorf("expected anomaly threshold 0.55, got %v", cfg.AnomalyThreshold)
+	}
+	if cfg.ContradictionMinSimilarity != 0.6 {
+		t.Errorf("expected contradiction min similarity 0.6, got %v", cfg.ContradictionMinSimilarity)
+	}
+}
+
+func TestAnalysisDefaultsFromEnv_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"CLUSTER_DEFAULT_K":            "0",
+		"SIMILARITY_THRESHOLD":         "1.5",
+		"SIMILARITY_METRIC":            "manhattan",
+		"ANOMALY_DETECTOR":             "magic",
+		"ANOMALY_THRESHOLD":            "abc",
+		"CONTRADICTION_MIN_SIMILARITY": "-0.1",
+	}
+	for name, value := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			var cfg api.ServerConfig
+			if err := analysisDefaultsFromEnv(&cfg); err == nil {
+				t.Errorf("expected error for %s=%s", name, value)
+			}
+		})
+	}
+}
+
+func TestCheckEmbeddingDimension_Mismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	columnQuery := `SELECT atttypmod FROM pg_attribute WHERE attrelid = 'statements'::regclass AND attname = 'embedding'`
+	mock.ExpectQuery(columnQuery).WillReturnRows(sqlmock.NewRows([]string{"atttypmod"}).AddRow(1536))
+	mock.ExpectQuery(columnQuery).WillReturnRows(sqlmock.NewRows([]string{"atttypmod"}).AddRow(1536))
+
+	err = checkEmbeddingDimension(db, embeddings.ModelTextEmbedding3Large, "")
+	if err == nil {
+		t.Fatal("expected a dimension mismatch to fail startup")
+	}
+	if !strings.Contains(err.Error(), "vector(1536)") || !strings.Contains(err.Error(), "EMBEDDING_DIMENSION_CHECK=resize") {
+		t.Errorf("expected migration guidance in error, got %q", err)
+	}
+
+	if err := checkEmbeddingDimension(db, embeddings.ModelTextEmbedding3Large, "warn"); err != nil {
+		t.Errorf("expected warn mode to only log, got %v", err)
+	}
+
+	// off skips the query entirely
+	if err := checkEmbeddingDimension(db, embeddings.ModelTextEmbedding3Large, "off"); err != nil {
+		t.Errorf("expected no error with the check off, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if err := checkEmbeddingDimension(db, embeddings.DefaultModel, "strict"); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
+
+func TestCheckEmbeddingDimension_Resize(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	// text-embedding-3-large exceeds the HNSW limit, so no index is rebuilt
+	mock.ExpectQuery(`SELECT atttypmod FROM pg_attribute`).WillReturnRows(sqlmock.NewRows([]string{"atttypmod"}).AddRow(1536))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DROP INDEX IF EXISTS idx_statements_embedding`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE statements ALTER COLUMN embedding TYPE vector\(3072\) USING NULL`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE statements SET embedding_model = ''`).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	if err := checkEmbeddingDimension(db, embeddings.ModelTextEmbedding3Large, "resize"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A matching column is left alone
+	mock.ExpectQuery(`SELECT atttypmod FROM pg_attribute`).WillReturnRows(sqlmock.NewRows([]string{"atttypmod"}).AddRow(3072))
+	if err := checkEmbeddingDimension(db, embeddings.ModelTextEmbedding3Large, "resize"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSetupTempDir(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("TMPDIR", base)
+
+	dir, err := setupTempDir("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(base, "doc-analyzer"); dir != want {
+		t.Errorf("expected %s, got %s", want, dir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("expected the directory to exist, got %v", err)
+	}
+	if os.TempDir() != dir {
+		t.Errorf("expected os.TempDir to point at %s, got %s", dir, os.TempDir())
+	}
+
+	custom := filepath.Join(base, "uploads", "tmp")
+	if dir, err := setupTempDir(custom); err != nil || dir != custom {
+		t.Errorf("expected %s, got %s (%v)", custom, dir, err)
+	}
+	if os.TempDir() != custom {
+		t.Errorf("expected os.TempDir to point at %s, got %s", custom, os.TempDir())
+	}
+}
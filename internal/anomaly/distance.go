@@ -13,14 +13,28 @@ func NewDistanceAnomalyDetector() *DistanceAnomalyDetector {
 	return &DistanceAnomalyDetector{}
 }
 
+// Neighbor is a nearby point, by index into the embeddings, and its distance
+type Neighbor struct {
+	Index    int
+	Distance float64
+}
+
 // Detect computes anomaly scores based on average distance to k-nearest neighbors
 // embeddings: slice of embedding vectors
 // k: number of nearest neighbors to consider
 // Returns: anomaly scores (0-1), where higher score = more anomalous
 func (d *DistanceAnomalyDetector) Detect(embeddings [][]float32, k int) []float64 {
+	scores, _ := d.DetectWithNeighbors(embeddings, k)
+	return scores
+}
+
+// DetectWithNeighbors computes the scores of Detect along with the k nearest
+// neighbors of each point, closest first, whose average distance makes the
+// point's raw score
+func (d *DistanceAnomalyDetector) DetectWithNeighbors(embeddings [][]float32, k int) ([]float64, [][]Neighbor) {
 	n := len(embeddings)
 	if n == 0 {
-		return []float64{}
+		return []float64{}, [][]Neighbor{}
 	}
 
 	// Ensure k is valid
@@ -32,21 +46,24 @@ func (d *DistanceAnomalyDetector) Detect(embeddings [][]float32, k int) []float6
 	}
 
 	scores := make([]float64, n)
+	neighbors := make([][]Neighbor, n)
 
 	// For each point, compute average distance to k-nearest neighbors
 	for i := 0; i < n; i++ {
-		distances := make([]float64, 0, n-1)
+		distances := make([]Neighbor, 0, n-1)
 
 		// Compute distances to all other points
 		for j := 0; j < n; j++ {
 			if i != j {
 				dist := euclideanDistance(embeddings[i], embeddings[j])
-				distances = append(distances, dist)
+				distances = append(distances, Neighbor{Index: j, Distance: dist})
 			}
 		}
 
-		// Sort distances to find k-nearest neighbors
-		sort.Float64s(distances)
+		// Sort distances to find k-nearest neighbors, ties by index
+		sort.SliceStable(distances, func(a, b int) bool {
+			return distances[a].Distance < distances[b].Distance
+		})
 
 		// Compute average distance to k-nearest neighbors
 		avgDist := 0.0
@@ -55,17 +72,18 @@ func (d *DistanceAnomalyDetector) Detect(embeddings [][]float32, k int) []float6
 			actualK = len(distances)
 		}
 		for ki := 0; ki < actualK; ki++ {
-			avgDist += distances[ki]
+			avgDist += distances[ki].Distance
 		}
 		if actualK > 0 {
 			avgDist /= float64(actualK)
 		}
 
 		scores[i] = avgDist
+		neighbors[i] = distances[:actualK:actualK]
 	}
 
 	// Normalize scores to 0-1 range
-	return normalizeScores(scores)
+	return normalizeScores(scores), neighbors
 }
 
 // euclideanDistance computes the Euclidean distance between two vectors
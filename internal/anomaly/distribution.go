@@ -0,0 +1,95 @@
+package anomaly
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// DefaultHistogramBuckets is the default number of histogram buckets
+const DefaultHistogramBuckets = 10
+
+// HistogramBucket counts scores in the half-open range [Min, Max)
+// (the last bucket also includes Max)
+type HistogramBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Distribution summarizes a set of anomaly scores
+type Distribution struct {
+	Count       int                `json:"count"`
+	Mean        float64            `json:"mean"`
+	StdDev      float64            `json:"stddev"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	Histogram   []HistogramBucket  `json:"histogram"`
+}
+
+// distributionPercentiles are the percentiles reported in a Distribution
+var distributionPercentiles = []struct {
+	name string
+	p    float64
+}{
+	{"p25", 0.25},
+	{"p50", 0.50},
+	{"p75", 0.75},
+	{"p90", 0.90},
+	{"p95", 0.95},
+	{"p99", 0.99},
+}
+
+// ComputeDistribution builds a histogram over the 0-1 score range plus
+// summary statistics
+func ComputeDistribution(scores []float64, buckets int) Distribution {
+	if buckets <= 0 {
+		buckets = DefaultHistogramBuckets
+	}
+
+	dist := Distribution{
+		Count:       len(scores),
+		Percentiles: make(map[string]float64, len(distributionPercentiles)),
+		Histogram:   make([]HistogramBucket, buckets),
+	}
+
+	width := 1.0 / float64(buckets)
+	for i := range dist.Histogram {
+		dist.Histogram[i] = HistogramBucket{
+			Min: float64(i) * width,
+			Max: float64(i+1) * width,
+		}
+	}
+
+	if len(scores) == 0 {
+		return dist
+	}
+
+	sorted := make([]float64, len(scores))
+	copy(sorted, scores)
+	sort.Float64s(sorted)
+
+	dist.Mean, dist.StdDev = stat.MeanStdDev(sorted, nil)
+	if len(sorted) == 1 {
+		dist.StdDev = 0
+	}
+	dist.Min = sorted[0]
+	dist.Max = sorted[len(sorted)-1]
+	for _, pc := range distributionPercentiles {
+		dist.Percentiles[pc.name] = stat.Quantile(pc.p, stat.Empirical, sorted, nil)
+	}
+
+	for _, score := range sorted {
+		idx := int(score / width)
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= buckets {
+			idx = buckets - 1
+		}
+		dist.Histogram[idx].Count++
+	}
+
+	return dist
+}
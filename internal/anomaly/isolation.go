@@ -1,6 +1,8 @@
 package anomaly
 
 import (
+	"encoding/binary"
+	"hash/fnv"
 	"math"
 	"math/rand"
 )
@@ -10,6 +12,7 @@ type IsolationForest struct {
 	Trees      []*IsolationTree
 	NumTrees   int
 	SampleSize int
+	Seed       int64 // Random seed; 0 derives one from the data so results are reproducible
 }
 
 // IsolationTree represents a single tree in the forest
@@ -55,12 +58,18 @@ func (f *IsolationForest) Fit(data [][]float32) {
 
 	maxDepth := int(math.Ceil(math.Log2(float64(sampleSize))))
 
+	seed := f.Seed
+	if seed == 0 {
+		seed = computeDataSeed(data)
+	}
+	rng := rand.New(rand.NewSource(seed))
+
 	f.Trees = make([]*IsolationTree, f.NumTrees)
 	for i := 0; i < f.NumTrees; i++ {
 		// Sample without replacement
-		sample := sampleData(data, sampleSize)
+		sample := sampleData(rng, data, sampleSize)
 		f.Trees[i] = &IsolationTree{
-			Root: buildIsolationTree(sample, 0, maxDepth),
+			Root: buildIsolationTree(rng, sample, 0, maxDepth),
 		}
 	}
 }
@@ -94,7 +103,7 @@ func (f *IsolationForest) Score(data [][]float32) []float64 {
 }
 
 // buildIsolationTree recursively builds an isolation tree
-func buildIsolationTree(data [][]float32, depth, maxDepth int) *IsolationNode {
+func buildIsolationTree(rng *rand.Rand, data [][]float32, depth, maxDepth int) *IsolationNode {
 	n := len(data)
 
 	// Terminal conditions
@@ -107,7 +116,7 @@ func buildIsolationTree(data [][]float32, depth, maxDepth int) *IsolationNode {
 	if numFeatures == 0 {
 		return &IsolationNode{Size: n}
 	}
-	feature := rand.Intn(numFeatures)
+	feature := rng.Intn(numFeatures)
 
 	// Find min/max for this feature
 	minVal := float64(data[0][feature])
@@ -128,7 +137,7 @@ func buildIsolationTree(data [][]float32, depth, maxDepth int) *IsolationNode {
 	}
 
 	// Random split value
-	splitValue := minVal + rand.Float64()*(maxVal-minVal)
+	splitValue := minVal + rng.Float64()*(maxVal-minVal)
 
 	// Partition data
 	var left, right [][]float32
@@ -148,8 +157,8 @@ func buildIsolationTree(data [][]float32, depth, maxDepth int) *IsolationNode {
 	return &IsolationNode{
 		SplitFeature: feature,
 		SplitValue:   splitValue,
-		Left:         buildIsolationTree(left, depth+1, maxDepth),
-		Right:        buildIsolationTree(right, depth+1, maxDepth),
+		Left:         buildIsolationTree(rng, left, depth+1, maxDepth),
+		Right:        buildIsolationTree(rng, right, depth+1, maxDepth),
 	}
 }
 
@@ -185,7 +194,7 @@ func expectedPathLength(n float64) float64 {
 }
 
 // sampleData samples data without replacement
-func sampleData(data [][]float32, sampleSize int) [][]float32 {
+func sampleData(rng *rand.Rand, data [][]float32, sampleSize int) [][]float32 {
 	n := len(data)
 	if sampleSize >= n {
 		result := make([][]float32, n)
@@ -199,7 +208,7 @@ func sampleData(data [][]float32, sampleSize int) [][]float32 {
 		indices[i] = i
 	}
 	for i := 0; i < sampleSize; i++ {
-		j := i + rand.Intn(n-i)
+		j := i + rng.Intn(n-i)
 		indices[i], indices[j] = indices[j], indices[i]
 	}
 
@@ -209,3 +218,20 @@ func sampleData(data [][]float32, sampleSize int) [][]float32 {
 	}
 	return result
 }
+
+// computeDataSeed creates a deterministic seed by hashing the data
+func computeDataSeed(data [][]float32) int64 {
+	h := fnv.New64a()
+	var buf [4]byte
+	for _, point := range data {
+		for _, v := range point {
+			binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+			h.Write(buf[:])
+		}
+	}
+	seed := int64(h.Sum64())
+	if seed == 0 {
+		seed = 42
+	}
+	return seed
+}
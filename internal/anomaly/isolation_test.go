@@ -0,0 +1,70 @@
+package anomaly
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func testData(n, dim int) [][]float32 {
+	rng := rand.New(rand.NewSource(1))
+	data := make([][]float32, n)
+	for i := range data {
+		data[i] = make([]float32, dim)
+		for j := range data[i] {
+			data[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	// One clear outlier
+	for j := range data[0] {
+		data[0][j] += 10
+	}
+	return data
+}
+
+func TestIsolationForest_Deterministic(t *testing.T) {
+	data := testData(50, 8)
+
+	f1 := NewIsolationForest(50, 32)
+	f1.Fit(data)
+	scores1 := f1.Score(data)
+
+	f2 := NewIsolationForest(50, 32)
+	f2.Fit(data)
+	scores2 := f2.Score(data)
+
+	if len(scores1) != len(scores2) {
+		t.Fatalf("score lengths differ: %d vs %d", len(scores1), len(scores2))
+	}
+	for i := range scores1 {
+		if scores1[i] != scores2[i] {
+			t.Fatalf("expected identical scores, index %d differs: %v vs %v", i, scores1[i], scores2[i])
+		}
+	}
+}
+
+func TestIsolationForest_ConfiguredSeed(t *testing.T) {
+	data := testData(50, 8)
+
+	f1 := NewIsolationForest(50, 32)
+	f1.Seed = 7
+	f1.Fit(data)
+
+	f2 := NewIsolationForest(50, 32)
+	f2.Seed = 7
+	f2.Fit(data)
+
+	s1, s2 := f1.Score(data), f2.Score(data)
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			t.Fatalf("expected identical scores with same seed, index %d differs", i)
+		}
+	}
+
+	// The outlier should still stand out
+	for i := 1; i < len(s1); i++ {
+		if s1[i] >= s1[0] {
+			t.Errorf("expected outlier score %v to exceed point %d score %v", s1[0], i, s1[i])
+			break
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package anomaly
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/todmy/doc-analyzer/pkg/models"
 )
 
@@ -13,6 +16,18 @@ const (
 	DetectorEnsemble  DetectorType = "ensemble"
 )
 
+// ParseDetector parses a detector name, defaulting to ensemble when empty
+func ParseDetector(name string) (DetectorType, error) {
+	switch d := DetectorType(strings.ToLower(strings.TrimSpace(name))); d {
+	case "":
+		return DetectorEnsemble, nil
+	case DetectorDistance, DetectorIsolation, DetectorEnsemble:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown anomaly detector %q (expected distance, isolation or ensemble)", name)
+	}
+}
+
 // Config holds anomaly detection service configuration
 type Config struct {
 	Detector   DetectorType
@@ -20,6 +35,7 @@ type Config struct {
 	NumTrees   int     // For isolation forest
 	SampleSize int     // For isolation forest
 	Threshold  float64 // Anomaly threshold (0-1)
+	Seed       int64   // For isolation forest; 0 derives the seed from the data
 }
 
 // DefaultConfig returns default configuration
@@ -54,11 +70,17 @@ func NewService(config Config) *Service {
 	if config.Threshold <= 0 {
 		config.Threshold = DefaultConfig().Threshold
 	}
+	if config.Detector == "" {
+		config.Detector = DefaultConfig().Detector
+	}
+
+	isolationDetector := NewIsolationForest(config.NumTrees, config.SampleSize)
+	isolationDetector.Seed = config.Seed
 
 	return &Service{
 		config:            config,
 		distanceDetector:  NewDistanceAnomalyDetector(),
-		isolationDetector: NewIsolationForest(config.NumTrees, config.SampleSize),
+		isolationDetector: isolationDetector,
 	}
 }
 
@@ -70,6 +92,10 @@ type AnomalyResult struct {
 	Text       string
 	File       string
 	Line       int
+	// Neighbors are the statements nearest to this one, closest first,
+	// explaining its distance score. Set by the distance and ensemble
+	// detectors.
+	Neighbors  []Neighbor
 }
 
 // DetectAnomalies detects anomalies in statements
@@ -86,16 +112,17 @@ func (s *Service) DetectAnomalies(statements []models.Statement) []AnomalyResult
 
 	// Get scores based on detector type
 	var scores []float64
+	var neighbors [][]Neighbor
 	switch s.config.Detector {
 	case DetectorDistance:
-		scores = s.distanceDetector.Detect(embeddings, s.config.K)
+		scores, neighbors = s.distanceDetector.DetectWithNeighbors(embeddings, s.config.K)
 	case DetectorIsolation:
 		s.isolationDetector.Fit(embeddings)
 		scores = s.isolationDetector.Score(embeddings)
 	case DetectorEnsemble:
-		scores = s.ensembleScore(embeddings)
+		scores, neighbors = s.ensembleScore(embeddings)
 	default:
-		scores = s.ensembleScore(embeddings)
+		scores, neighbors = s.ensembleScore(embeddings)
 	}
 
 	// Build results
@@ -109,6 +136,9 @@ func (s *Service) DetectAnomalies(statements []models.Statement) []AnomalyResult
 			File:      stmt.File,
 			Line:      stmt.Line,
 		}
+		if neighbors != nil {
+			results[i].Neighbors = neighbors[i]
+		}
 	}
 
 	return results
@@ -128,10 +158,69 @@ func (s *Service) GetAnomalies(statements []models.Statement) []AnomalyResult {
 	return anomalies
 }
 
-// ensembleScore combines distance and isolation scores
-func (s *Service) ensembleScore(embeddings [][]float32) []float64 {
+// DetectAnomaliesByCluster runs anomaly detection independently within each
+// cluster so scores are relative to a statement's own cluster rather than the
+// whole corpus. labels[i] is the cluster of statements[i]. Statements in
+// singleton clusters have nothing to be compared against and score 0.
+func (s *Service) DetectAnomaliesByCluster(statements []models.Statement, labels []int) []AnomalyResult {
+	if len(statements) == 0 || len(labels) != len(statements) {
+		return []AnomalyResult{}
+	}
+
+	// Group statement indices by cluster label
+	groups := make(map[int][]int)
+	for i, label := range labels {
+		groups[label] = append(groups[label], i)
+	}
+
+	results := make([]AnomalyResult, len(statements))
+	for _, indices := range groups {
+		if len(indices) == 1 {
+			i := indices[0]
+			results[i] = AnomalyResult{
+				Index: i,
+				Text:  statements[i].Text,
+				File:  statements[i].File,
+				Line:  statements[i].Line,
+			}
+			continue
+		}
+
+		members := make([]models.Statement, len(indices))
+		for j, idx := range indices {
+			members[j] = statements[idx]
+		}
+
+		for j, r := range s.DetectAnomalies(members) {
+			r.Index = indices[j]
+			for n := range r.Neighbors {
+				r.Neighbors[n].Index = indices[r.Neighbors[n].Index]
+			}
+			results[indices[j]] = r
+		}
+	}
+
+	return results
+}
+
+// GetAnomaliesByCluster returns only statements flagged as anomalies
+// relative to their own cluster
+func (s *Service) GetAnomaliesByCluster(statements []models.Statement, labels []int) []AnomalyResult {
+	var anomalies []AnomalyResult
+	for _, r := range s.DetectAnomaliesByCluster(statements, labels) {
+		if r.IsAnomaly {
+			anomalies = append(anomalies, r)
+		}
+	}
+
+	return anomalies
+}
+
+// ensembleScore combines distance and isolation scores. It also returns the
+// nearest neighbors found by the distance detector.
+func (s *Service) ensembleScore(embeddings [][]float32) ([]float64, [][]Neighbor) {
 	// Get distance-based scores
-	distScores := s.distanceDetector.Detect(embeddings, s.config.K)
+	distScores, neighbors := s.distanceDetector.DetectWithNeighbors(embeddings, s.config.K)
 
 	// Get isolation forest scores
 	s.isolationDetector.Fit(embeddings)
@@ -143,7 +232,7 @@ func (s *Service) ensembleScore(embeddings [][]float32) []float64 {
 		combined[i] = (distScores[i] + isoScores[i]) / 2.0
 	}
 
-	return combined
+	return combined, neighbors
 }
 
 // SetThreshold updates the anomaly threshold
@@ -153,6 +242,11 @@ func (s *Service) SetThreshold(threshold float64) {
 	}
 }
 
+// GetDetector returns the configured detector type
+func (s *Service) GetDetector() DetectorType {
+	return s.config.Detector
+}
+
 // GetThreshold returns the current anomaly threshold
 func (s *Service) GetThreshold() float64 {
 	return s.config.Threshold
@@ -0,0 +1,87 @@
+package anomaly
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/todmy/doc-analyzer/pkg/models"
+)
+
+func TestService_DetectAnomaliesByCluster(t *testing.T) {
+	svc := NewService(Config{Detector: DetectorDistance, K: 2, Threshold: 0.9})
+
+	statements := []models.Statement{
+		{Text: "a1", Embedding: []float32{0, 0}},
+		{Text: "a2", Embedding: []float32{0.1, 0}},
+		{Text: "a3", Embedding: []float32{0, 0.1}},
+		{Text: "a4", Embedding: []float32{2, 2}}, // far from its cluster
+		{Text: "b1", Embedding: []float32{100, 100}},
+		{Text: "b2", Embedding: []float32{100.1, 100}},
+		{Text: "c1", Embedding: []float32{-50, 50}}, // singleton
+	}
+	labels := []int{0, 0, 0, 0, 1, 1, 2}
+
+	results := svc.DetectAnomaliesByCluster(statements, labels)
+	if len(results) != len(statements) {
+		t.Fatalf("expected %d results, got %d", len(statements), len(results))
+	}
+
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("expected result %d to have index %d, got %d", i, i, r.Index)
+		}
+	}
+
+	if results[6].Score != 0 || results[6].IsAnomaly {
+		t.Errorf("expected singleton cluster to score 0, got %v", results[6].Score)
+	}
+
+	if !results[3].IsAnomaly {
+		t.Errorf("expected a4 to be anomalous within its cluster, score %v", results[3].Score)
+	}
+
+	// Globally, the whole of cluster 1 and the singleton dominate the scores
+	global := svc.DetectAnomalies(statements)
+	if global[3].IsAnomaly {
+		t.Errorf("expected a4 not to be anomalous globally, score %v", global[3].Score)
+	}
+}
+
+func TestService_DetectAnomaliesNeighbors(t *testing.T) {
+	statements := []models.Statement{
+		{Text: "a1", Embedding: []float32{0, 0}},
+		{Text: "a2", Embedding: []float32{1, 0}},
+		{Text: "a3", Embedding: []float32{0, 2}},
+		{Text: "far", Embedding: []float32{10, 0}},
+	}
+
+	results := NewService(Config{Detector: DetectorDistance, K: 2}).DetectAnomalies(statements)
+	want := []Neighbor{{Index: 1, Distance: 9}, {Index: 0, Distance: 10}}
+	if got := results[3].Neighbors; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the outlier's neighbors %v, got %v", want, got)
+	}
+	for i, r := range results {
+		if len(r.Neighbors) != 2 {
+			t.Errorf("statement %d: expected 2 neighbors, got %d", i, len(r.Neighbors))
+		}
+	}
+
+	ensemble := NewService(Config{Detector: DetectorEnsemble, K: 2, Seed: 1}).DetectAnomalies(statements)
+	if !reflect.DeepEqual(ensemble[3].Neighbors, want) {
+		t.Errorf("ensemble: expected the outlier's neighbors %v, got %v", want, ensemble[3].Neighbors)
+	}
+
+	isolation := NewService(Config{Detector: DetectorIsolation, Seed: 1}).DetectAnomalies(statements)
+	if isolation[3].Neighbors != nil {
+		t.Errorf("isolation: expected no neighbors, got %v", isolation[3].Neighbors)
+	}
+
+	// Within clusters, neighbors index the full statement list
+	byCluster := NewService(Config{Detector: DetectorDistance, K: 1}).DetectAnomaliesByCluster(statements, []int{1, 0, 1, 0})
+	if got := byCluster[3].Neighbors; len(got) != 1 || got[0].Index != 1 {
+		t.Errorf("by cluster: expected statement 1 as the only neighbor, got %v", got)
+	}
+	if got := byCluster[2].Neighbors; len(got) != 1 || got[0].Index != 0 {
+		t.Errorf("by cluster: expected statement 0 as the only neighbor, got %v", got)
+	}
+}
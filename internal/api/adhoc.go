@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/todmy/doc-analyzer/internal/clustering"
+	"github.com/todmy/doc-analyzer/pkg/models"
+)
+
+// maxAdhocTexts caps the number of texts accepted by the ad-hoc endpoint.
+// Everything is held in memory and similarity is O(n²), so keep it modest.
+const maxAdhocTexts = 500
+
+// maxAdhocBodySize caps the ad-hoc request body, which is decoded in full
+// before the texts are counted
+const maxAdhocBodySize = 2 << 20
+
+// AdhocAnalysisRequest represents a stateless analysis request
+type AdhocAnalysisRequest struct {
+	Texts     []string `json:"texts"`
+	K         int      `json:"k,omitempty"`
+	Threshold float64  `json:"threshold,omitempty"`
+}
+
+// AdhocAnalysisResponse combines all analysis results for ad-hoc texts
+type AdhocAnalysisResponse struct {
+	Clusters     []ClusterResponse     `json:"clusters"`
+	Labels       []int                 `json:"labels"`
+	K            int                   `json:"k"`
+	RequestedK   int                   `json:"requested_k,omitempty"` // set when k was clamped
+	SimilarPairs []SimilarPairResponse `json:"similar_pairs"`
+	Anomalies    []AnomalyResponse     `json:"anomalies"`
+}
+
+// handleAdhocAnalyze embeds and analyzes a set of texts without persisting anything
+func (s *Server) handleAdhocAnalyze(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdhocBodySize)
+	var req AdhocAnalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds the %d MB limit", maxAdhocBodySize>>20))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// Long texts are truncated like extracted statements
+	texts := make([]string, 0, len(req.Texts))
+	for _, t := range req.Texts {
+		if t = strings.TrimSpace(t); t != "" {
+			if len(t) > maxStatementLength {
+				t = truncateUTF8(t, maxStatementLength) + "..."
+			}
+			texts = append(texts, t)
+		}
+	}
+
+	if len(texts) == 0 {
+		respondError(w, http.StatusBadRequest, "texts are required")
+		return
+	}
+
+	if len(texts) > maxAdhocTexts {
+		respondError(w, http.StatusBadRequest, "too many texts (max 500)")
+		return
+	}
+
+	if req.Threshold < 0 || req.Threshold > 1 {
+		respondError(w, http.StatusBadRequest, "threshold must be between 0 and 1")
+		return
+	}
+
+	if s.embeddingClient == nil {
+		respondError(w, http.StatusServiceUnavailable, "embedding service not configured - set OPENROUTER_API_KEY")
+		return
+	}
+
+	embs, err := s.embeddingClient.EmbedTexts(r.Context(), texts)
+	if err != nil {
+		respondError(w, http.StatusBadGateway, embeddingErrorMessage(err))
+		return
+	}
+
+	modelStatements := make([]models.Statement, len(texts))
+	for i, text := range texts {
+		modelStatements[i] = models.Statement{
+			Text:      text,
+			Position:  i,
+			Line:      i + 1,
+			Embedding: embs[i],
+		}
+	}
+
+	// Clustering
+	var clusterResult *clustering.ClusterResult
+	if req.K > 0 {
+		clusterResult = s.clusteringService.ClusterStatements(modelStatements, req.K)
+	} else {
+		clusterResult = s.clusteringService.AutoCluster(modelStatements, 10)
+	}
+
+	requestedK := 0
+	if req.K > 0 && clusterResult.K < req.K {
+		requestedK = req.K
+	}
+
+	clusters := make([]ClusterResponse, len(clusterResult.Clusters))
+	for i, c := range clusterResult.Clusters {
+		keywords := make([]string, len(c.Keywords))
+		for j, kw := range c.Keywords {
+			keywords[j] = kw.Word
+		}
+		clusters[i] = ClusterResponse{
+			ID:             c.ID,
+			Keywords:       keywords,
+			Size:           c.Size,
+			Density:        c.Density,
+			Representative: clusterRepresentative(modelStatements, c.Representative),
+		}
+	}
+
+	// Similar pairs
+	pairs := s.similarityService.FindSimilarStatements(modelStatements, req.Threshold)
+	similarPairs := make([]SimilarPairResponse, len(pairs))
+	for i, p := range pairs {
+		similarPairs[i] = SimilarPairResponse{
+			Statement1: p.Statement1,
+			Statement2: p.Statement2,
+			Similarity: p.Similarity,
+		}
+	}
+
+	// Anomalies
+	anomalyResults := s.anomalyService.GetAnomalies(modelStatements)
+	anomalies := make([]AnomalyResponse, len(anomalyResults))
+	for i, a := range anomalyResults {
+		anomalies[i] = AnomalyResponse{
+			Text:      a.Text,
+			Line:      a.Line,
+			Score:     a.Score,
+			Neighbors: anomalyNeighbors(a, modelStatements),
+		}
+	}
+
+	respondJSON(w, http.StatusOK, AdhocAnalysisResponse{
+		Clusters:     clusters,
+		Labels:       clusterResult.Labels,
+		K:            clusterResult.K,
+		RequestedK:   requestedK,
+		SimilarPairs: similarPairs,
+		Anomalies:    anomalies,
+	})
+}
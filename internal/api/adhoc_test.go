@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleAdhocAnalyze(t *testing.T) {
+	embSrv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, embSrv.URL)
+	userID := uuid.NewString()
+
+	texts := []string{
+		"refunds are issued within thirty days of purchase",
+		"refunds are issued within thirty days of the purchase date",
+		"the office is closed on public holidays",
+		"our office is closed during public holidays",
+		"quarterly revenue grew by twelve percent",
+		"penguins live mostly in the southern hemisphere",
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{Texts: texts, K: 3})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp AdhocAnalysisResponse
+	decodeJSON(t, rec, &resp)
+
+	if len(resp.Clusters) != 3 {
+		t.Errorf("expected 3 clusters, got %d", len(resp.Clusters))
+	}
+	if len(resp.Labels) != len(texts) {
+		t.Errorf("expected %d labels, got %d", len(texts), len(resp.Labels))
+	}
+	if len(resp.SimilarPairs) == 0 {
+		t.Error("expected similar pairs for near-duplicate texts")
+	}
+	if resp.Anomalies == nil {
+		t.Error("expected anomalies array to be present")
+	}
+
+	if env.projects.writes != 0 || env.documents.writes != 0 || env.statements.writes != 0 {
+		t.Errorf("expected no repository writes, got projects=%d documents=%d statements=%d",
+			env.projects.writes, env.documents.writes, env.statements.writes)
+	}
+}
+
+func TestHandleAdhocAnalyze_Validation(t *testing.T) {
+	embSrv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, embSrv.URL)
+	userID := uuid.NewString()
+
+	rec := env.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for empty texts, got %d", rec.Code)
+	}
+
+	tooMany := make([]string, maxAdhocTexts+1)
+	for i := range tooMany {
+		tooMany[i] = "text"
+	}
+	rec = env.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{Texts: tooMany})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for too many texts, got %d", rec.Code)
+	}
+
+	huge := []string{strings.Repeat("x", maxAdhocBodySize)}
+	rec = env.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{Texts: huge})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413 for an oversized body, got %d", rec.Code)
+	}
+
+	// Texts longer than a statement are truncated before embedding
+	long := strings.Repeat("refunds are issued within thirty days ", 100)
+	rec = env.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{Texts: []string{long, long, "penguins"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for long texts, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp AdhocAnalysisResponse
+	decodeJSON(t, rec, &resp)
+	if len(resp.SimilarPairs) == 0 || len(resp.SimilarPairs[0].Statement1) != maxStatementLength+len("...") {
+		t.Errorf("expected the long texts truncated to %d bytes, got %+v", maxStatementLength, resp.SimilarPairs)
+	}
+
+	noEmb := newTestEnv(t, "")
+	rec = noEmb.do(t, http.MethodPost, "/api/v1/analyze/adhoc", userID, AdhocAnalysisRequest{Texts: []string{"hello"}})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 without embedding client, got %d", rec.Code)
+	}
+}
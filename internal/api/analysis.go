@@ -2,15 +2,23 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
 
-	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/anomaly"
 	"github.com/todmy/doc-analyzer/internal/clustering"
 	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/preprocess"
+	"github.com/todmy/doc-analyzer/internal/similarity"
 	"github.com/todmy/doc-analyzer/internal/storage"
 	"github.com/todmy/doc-analyzer/pkg/models"
 )
@@ -33,22 +41,96 @@ func (s *Server) convertToModelStatements(statements []*storage.Statement) []mod
 			Position:   stmt.Position,
 			Line:       stmt.Line,
 			File:       filename,
+			Section:    stmt.Section,
 			Embedding:  stmt.Embedding.Slice(),
 		}
 	}
 	return result
 }
 
+// reconcileStatements brings statement embeddings to a common dimension
+// using the configured reconcile mode, so projects that mix embedding models
+// can still be analyzed. The number of excluded and projected statements is
+// reported in the X-Embeddings-Excluded and X-Embeddings-Projected headers.
+// Without a reconcile mode, projects with missing embeddings or embeddings
+// of different dimensions or models are rejected with 409 instead of being
+// analyzed on incomparable vectors; it then writes the error response and
+// returns false.
+func (s *Server) reconcileStatements(w http.ResponseWriter, statements []*storage.Statement) ([]*storage.Statement, bool) {
+	if s.embeddingReconcile == embeddings.ReconcileNone {
+		if problem := embeddingProblem(statements); problem != "" {
+			respondError(w, http.StatusConflict, problem)
+			return nil, false
+		}
+		return statements, true
+	}
+
+	vectors := make([][]float32, len(statements))
+	for i, stmt := range statements {
+		vectors[i] = stmt.Embedding.Slice()
+	}
+
+	rec := embeddings.Reconcile(vectors, s.embeddingReconcile)
+	w.Header().Set("X-Embeddings-Excluded", strconv.Itoa(rec.Excluded))
+	w.Header().Set("X-Embeddings-Projected", strconv.Itoa(rec.Projected))
+
+	result := make([]*storage.Statement, len(rec.Indices))
+	for i, idx := range rec.Indices {
+		stmt := statements[idx]
+		if len(stmt.Embedding.Slice()) != rec.Dimension {
+			projected := *stmt
+			projected.Embedding = pgvector.NewVector(rec.Embeddings[i])
+			stmt = &projected
+		}
+		result[i] = stmt
+	}
+	return result, true
+}
+
+// embeddingProblem explains why statements cannot be analyzed as they are:
+// missing embeddings, embeddings of more than one dimension, or embeddings
+// from more than one known model. It returns "" when all are comparable.
+func embeddingProblem(statements []*storage.Statement) string {
+	vectors := make([][]float32, len(statements))
+	var models []string
+	for i, stmt := range statements {
+		vectors[i] = stmt.Embedding.Slice()
+		if m := stmt.EmbeddingModel; m != "" && !slices.Contains(models, m) {
+			models = append(models, m)
+		}
+	}
+
+	var dimErr *embeddings.DimensionError
+	if errors.As(embeddings.ValidateDimensions(vectors), &dimErr) {
+		if dimErr.Missing > 0 {
+			return fmt.Sprintf("%d statements are missing embeddings, run POST /api/v1/projects/{projectID}/reembed", dimErr.Missing)
+		}
+		return fmt.Sprintf("%d statements have embeddings of a different dimension than %d, run POST /api/v1/projects/{projectID}/reembed or set EMBEDDING_RECONCILE",
+			dimErr.Mismatched, dimErr.Dimension)
+	}
+
+	if len(models) > 1 {
+		sort.Strings(models)
+		return "project mixes embeddings of models " + strings.Join(models, ", ") +
+			", run POST /api/v1/projects/{projectID}/reembed"
+	}
+	return ""
+}
+
 // AnalysisRequest represents a request to start analysis
 type AnalysisRequest struct {
 	ProjectID string `json:"project_id"`
 }
 
-// AnalysisStatusResponse represents the analysis status
+// AnalysisStatusResponse represents the analysis status.
+// EmbeddedStatements counts the statements that were stored without an
+// embedding and got one; statements that already had one are skipped.
 type AnalysisStatusResponse struct {
-	ProjectID string `json:"project_id"`
-	Status    string `json:"status"`
-	Progress  int    `json:"progress"`
+	ProjectID          string `json:"project_id"`
+	Status             string `json:"status"`
+	Progress           int    `json:"progress"`
+	EmbeddedStatements int    `json:"embedded_statements"`
+	EmbeddingTokens    int    `json:"embedding_tokens"`
 }
 
 // ClusterResponse represents a cluster in the API response
@@ -57,15 +139,34 @@ type ClusterResponse struct {
 	Keywords []string `json:"keywords"`
 	Size     int      `json:"size"`
 	Density  float64  `json:"density"`
+	// Representative is the member closest to the cluster centroid
+	Representative *RepresentativeStatement `json:"representative,omitempty"`
+}
+
+// RepresentativeStatement is the statement shown as an example of a cluster
+type RepresentativeStatement struct {
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+}
+
+// clusterRepresentative returns the statement at index, as reported by
+// clustering.Cluster.Representative, or nil for an empty cluster
+func clusterRepresentative(statements []models.Statement, index int) *RepresentativeStatement {
+	if index < 0 || index >= len(statements) {
+		return nil
+	}
+	return &RepresentativeStatement{ID: statements[index].ID, Text: statements[index].Text}
 }
 
 // SimilarPairResponse represents a similar pair in the API response
 type SimilarPairResponse struct {
-	Statement1 string  `json:"statement1"`
-	Statement2 string  `json:"statement2"`
-	File1      string  `json:"file1"`
-	File2      string  `json:"file2"`
-	Similarity float64 `json:"similarity"`
+	Statement1  string  `json:"statement1"`
+	Statement2  string  `json:"statement2"`
+	File1       string  `json:"file1"`
+	File2       string  `json:"file2"`
+	DocumentID1 string  `json:"document_id1"`
+	DocumentID2 string  `json:"document_id2"`
+	Similarity  float64 `json:"similarity"`
 }
 
 // AnomalyResponse represents an anomaly in the API response
@@ -74,10 +175,24 @@ type AnomalyResponse struct {
 	File       string  `json:"file"`
 	Line       int     `json:"line"`
 	Score      float64 `json:"score"`
+
+	// Neighbors are the statements nearest to this one, closest first,
+	// explaining its score
+	Neighbors []AnomalyNeighbor `json:"neighbors,omitempty"`
+}
+
+// AnomalyNeighbor is a statement near an anomaly and its embedding distance
+type AnomalyNeighbor struct {
+	ID       string  `json:"id,omitempty"`
+	Text     string  `json:"text"`
+	File     string  `json:"file,omitempty"`
+	Distance float64 `json:"distance"`
 }
 
 // ContradictionResponse represents a contradiction in the API response
 type ContradictionResponse struct {
+	ID          string  `json:"id,omitempty"`
+	Status      string  `json:"status,omitempty"`
 	Statement1  string  `json:"statement1"`
 	Statement2  string  `json:"statement2"`
 	File1       string  `json:"file1"`
@@ -88,140 +203,320 @@ type ContradictionResponse struct {
 	Confidence  float64 `json:"confidence"`
 }
 
-// handleAnalyze starts the analysis pipeline for a project
+// handleAnalyze prepares a project for analysis by embedding the statements
+// stored without an embedding, e.g. because embedding failed on upload. It
+// is idempotent: statements that already have an embedding are never sent
+// again, so retrying after a partial failure only pays for the rest.
+// Embeddings are streamed and stored batch by batch as they are generated,
+// so large documents are never held in memory whole and a failure keeps
+// every batch stored before it.
 func (s *Server) handleAnalyzeImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	// Check if we have embeddings client configured
+	if s.embeddingClient == nil {
+		respondError(w, http.StatusServiceUnavailable, "embedding service not configured - set OPENROUTER_API_KEY")
 		return
 	}
 
-	// Verify project exists and user has access
-	project, err := s.projectRepo.GetByID(r.Context(), pid)
+	missing, err := s.statementRepo.GetMissingEmbeddings(r.Context(), project.ID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
 		return
 	}
 
-	if project == nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
+	pipeline, err := preprocess.Parse(project.Preprocessing)
+	if err != nil {
+		log.Printf("[analyze] ignoring invalid preprocessing of project %s: %v", project.ID, err)
 	}
 
-	claims, ok := auth.GetUserFromContext(r.Context())
-	if !ok || project.UserID.String() != claims.UserID {
-		respondError(w, http.StatusForbidden, "access denied")
-		return
+	response := AnalysisStatusResponse{
+		ProjectID: project.ID.String(),
+		Status:    "ready",
+		Progress:  100,
 	}
+	for _, stmts := range groupByDocument(missing) {
+		docID := stmts[0].DocumentID
+
+		// Embed per document so token usage is attributed to each one
+		var storeErr error
+		tokens, embedErr := s.streamEmbeddings(r.Context(), stmts, pipeline, func(embedded []*storage.Statement) error {
+			if err := s.statementRepo.UpdateEmbeddings(r.Context(), embedded); err != nil {
+				storeErr = err
+				return err
+			}
+			response.EmbeddedStatements += len(embedded)
+			return nil
+		})
+		if tokens > 0 {
+			response.EmbeddingTokens += tokens
+			if err := s.documentRepo.AddEmbeddingTokens(r.Context(), docID, tokens); err != nil {
+				log.Printf("[analyze] failed to record %d embedding tokens: %v", tokens, err)
+			}
+		}
+		if storeErr != nil {
+			log.Printf("[analyze] storing embeddings of document %s failed: %v", docID, storeErr)
+			respondError(w, http.StatusInternalServerError, "failed to store embeddings")
+			return
+		}
 
-	// Check if we have embeddings client configured
-	if s.embeddingClient == nil {
-		respondError(w, http.StatusServiceUnavailable, "embedding service not configured - set OPENROUTER_API_KEY")
-		return
+		message := ""
+		if embedErr != nil {
+			message = embeddingErrorMessage(embedErr)
+		}
+		if err := s.documentRepo.SetEmbeddingError(r.Context(), docID, message); err != nil {
+			log.Printf("[analyze] failed to record the embedding status of document %s: %v", docID, err)
+		}
+		if embedErr != nil {
+			log.Printf("[analyze] embedding document %s failed: %v", docID, embedErr)
+			respondError(w, http.StatusBadGateway, message)
+			return
+		}
 	}
 
-	// Analysis happens synchronously for now (could be made async with job queue)
-	respondJSON(w, http.StatusAccepted, AnalysisStatusResponse{
-		ProjectID: projectID,
-		Status:    "ready",
-		Progress:  100,
-	})
+	respondJSON(w, http.StatusOK, response)
 }
 
-// handleGetClusters returns clustering results for a project
+// groupByDocument splits statements into runs of the same document, keeping
+// their order
+func groupByDocument(statements []*storage.Statement) [][]*storage.Statement {
+	var groups [][]*storage.Statement
+	for i, stmt := range statements {
+		if i == 0 || stmt.DocumentID != statements[i-1].DocumentID {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], stmt)
+	}
+	return groups
+}
+
+// handleGetClusters returns clustering results for a project, ordered by
+// ?sort (size, density or id) and ?order, largest clusters first by default.
+// The last clustering is stored and served until the statements change; new
+// statements are assigned to the last fit's centroids (see clusterProject).
+// ?refit=true forces a full k-means run. ?max_iter runs a preview capped at
+// that many iterations, which is neither cached nor stored.
 // Deprecated: Use GET /api/v1/projects/{projectID}/visualization instead
 func (s *Server) handleGetClustersImpl(w http.ResponseWriter, r *http.Request) {
 	// Add deprecation headers
 	w.Header().Set("Deprecation", "true")
 	w.Header().Set("Link", `</api/v1/projects/{projectID}/visualization>; rel="successor-version"`)
 
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	// Largest clusters first unless ?sort and ?order say otherwise
+	sortBy, order, ok := parseClusterSort(w, r)
+	if !ok {
 		return
 	}
 
+	// ?max_iter caps the k-means iterations of a preview fit
+	maxIter := 0
+	if v := r.URL.Query().Get("max_iter"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > maxClusterIterations {
+			respondError(w, http.StatusBadRequest, "max_iter must be between 1 and "+strconv.Itoa(maxClusterIterations))
+			return
+		}
+		maxIter = parsed
+	}
+
 	// Get statements for project
 	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
 		return
 	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
 
 	if len(statements) == 0 {
 		respondJSON(w, http.StatusOK, []ClusterResponse{})
 		return
 	}
 
-	// Convert to models.Statement
-	modelStatements := s.convertToModelStatements(statements)
-
-	// Get k parameter (optional)
-	k := 0
+	// Get k parameter (optional), defaulting to the project's
+	k := project.Analysis.ClusterK
 	if kStr := r.URL.Query().Get("k"); kStr != "" {
 		if kVal, err := strconv.Atoi(kStr); err == nil && kVal > 0 {
 			k = kVal
 		}
 	}
 
-	// Run clustering
-	var result *clustering.ClusterResult
+	// Clustering clamps k to the number of distinct embeddings; report the
+	// effective k so clients know why fewer clusters came back
 	if k > 0 {
-		result = s.clusteringService.ClusterStatements(modelStatements, k)
-	} else {
-		result = s.clusteringService.AutoCluster(modelStatements, 10)
+		vectors := make([][]float32, len(statements))
+		for i, stmt := range statements {
+			vectors[i] = stmt.Embedding.Slice()
+		}
+		if unique := clustering.CountUnique(vectors); k > unique {
+			w.Header().Set("X-Cluster-K", strconv.Itoa(unique))
+		}
 	}
 
-	// Convert to response
-	response := make([]ClusterResponse, len(result.Clusters))
-	for i, c := range result.Clusters {
-		keywords := make([]string, len(c.Keywords))
-		for j, kw := range c.Keywords {
-			keywords[j] = kw.Word
+	// ?refit=true re-runs k-means on everything instead of reusing centroids
+	refit, _ := strconv.ParseBool(r.URL.Query().Get("refit"))
+
+	// A preview always runs k-means and leaves the cache and stored
+	// clustering alone
+	preview := maxIter > 0
+	refit = refit || preview
+
+	cacheKey := analysisCacheKey(analysisParams{Kind: "clusters", K: k, Sort: sortBy + ":" + order}, statements)
+	if !refit && s.respondCached(w, cacheKey) {
+		return
+	}
+
+	// Serve the stored clustering while the statements are unchanged
+	fingerprint := statementFingerprint(statements)
+	var response []ClusterResponse
+	if !refit {
+		response = s.storedClusters(r.Context(), pid, k, fingerprint, statements)
+	}
+
+	if response != nil {
+		w.Header().Set("X-Clustering", clusteringStored)
+	} else {
+		// Convert to models.Statement
+		modelStatements := s.convertToModelStatements(statements)
+
+		// Run clustering, reusing the stored centroids while the project has not
+		// grown much since the last full fit
+		result, mode := s.clusterProject(r.Context(), pid, modelStatements, k, refit, maxIter)
+		w.Header().Set("X-Clustering", mode)
+		if !preview {
+			s.saveClusters(r.Context(), pid, k, fingerprint, statements, result)
 		}
-		response[i] = ClusterResponse{
-			ID:       c.ID,
-			Keywords: keywords,
-			Size:     c.Size,
-			Density:  c.Density,
+
+		// Convert to response
+		response = make([]ClusterResponse, len(result.Clusters))
+		for i, c := range result.Clusters {
+			keywords := make([]string, len(c.Keywords))
+			for j, kw := range c.Keywords {
+				keywords[j] = kw.Word
+			}
+			response[i] = ClusterResponse{
+				ID:             c.ID,
+				Keywords:       keywords,
+				Size:           c.Size,
+				Density:        c.Density,
+				Representative: clusterRepresentative(modelStatements, c.Representative),
+			}
 		}
 	}
+	sortClusters(response, sortBy, order == "desc")
 
+	if !preview {
+		s.analysisCache.Set(cacheKey, response)
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
+// maxClusterIterations caps ?max_iter on the clusters endpoint
+const maxClusterIterations = 1000
+
+// parseClusterSort reads the sort (size, density or id) and order (asc or
+// desc) query parameters, defaulting to size descending. On invalid values
+// it writes a 400 response and returns false.
+func parseClusterSort(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	sortBy := r.URL.Query().Get("sort")
+	switch sortBy {
+	case "":
+		sortBy = "size"
+	case "size", "density", "id":
+	default:
+		respondError(w, http.StatusBadRequest, "sort must be size, density or id")
+		return "", "", false
+	}
+
+	order := r.URL.Query().Get("order")
+	switch order {
+	case "":
+		order = "desc"
+	case "asc", "desc":
+	default:
+		respondError(w, http.StatusBadRequest, "order must be asc or desc")
+		return "", "", false
+	}
+
+	return sortBy, order, true
+}
+
+// sortClusters orders clusters by size, density or id. Ties keep ascending
+// id order so the output is stable across requests.
+func sortClusters(clusters []ClusterResponse, sortBy string, desc bool) {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		var less, equal bool
+		switch sortBy {
+		case "size":
+			less, equal = a.Size < b.Size, a.Size == b.Size
+		case "density":
+			less, equal = a.Density < b.Density, a.Density == b.Density
+		default:
+			less, equal = a.ID < b.ID, a.ID == b.ID
+		}
+		if equal {
+			return a.ID < b.ID
+		}
+		return less != desc
+	})
+}
+
 // handleGetSimilarPairs returns similar pairs for a project
 func (s *Server) handleGetSimilarPairsImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+	// Parse optional metric parameter, defaulting to the service's
+	metric := s.similarityService.GetMetric()
+	if m := r.URL.Query().Get("metric"); m != "" {
+		parsed, err := similarity.ParseMetric(m)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		metric = parsed
 	}
 
-	// Parse optional threshold parameter
-	threshold := 0.75
+	// Parse optional threshold parameter, defaulting to the project's. Its
+	// range depends on the metric since dot products may exceed 1.
+	threshold := s.similarityThreshold(project)
 	if t := r.URL.Query().Get("threshold"); t != "" {
-		if parsed, err := strconv.ParseFloat(t, 64); err == nil && parsed > 0 && parsed <= 1 {
-			threshold = parsed
+		parsed, err := strconv.ParseFloat(t, 64)
+		if err != nil || !metric.ValidThreshold(parsed) {
+			if metric == similarity.MetricDotProduct {
+				respondError(w, http.StatusBadRequest, "threshold must be a positive number for the dot metric")
+			} else {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("threshold must be in (0, 1] for the %s metric", metric))
+			}
+			return
 		}
+		threshold = parsed
+	}
+
+	// Parse optional cross_doc parameter to keep only pairs across documents
+	crossDoc := false
+	if c := r.URL.Query().Get("cross_doc"); c != "" {
+		parsed, err := strconv.ParseBool(c)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "cross_doc must be true or false")
+			return
+		}
+		crossDoc = parsed
 	}
 
 	// Get statements for project
@@ -230,44 +525,64 @@ func (s *Server) handleGetSimilarPairsImpl(w http.ResponseWriter, r *http.Reques
 		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
 		return
 	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
 
 	if len(statements) == 0 {
 		respondJSON(w, http.StatusOK, []SimilarPairResponse{})
 		return
 	}
 
+	opts := similarity.FindOptions{Threshold: threshold, Metric: metric, CrossDocumentOnly: crossDoc}
+	cacheKey := analysisCacheKey(analysisParams{Kind: "similar", Threshold: threshold, Metric: string(metric), CrossDoc: crossDoc}, statements)
+	if s.respondCached(w, cacheKey) {
+		return
+	}
+
+	response := s.computeSimilarPairs(statements, opts)
+
+	s.analysisCache.Set(cacheKey, response)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// computeSimilarPairs finds the statement pairs selected by opts
+func (s *Server) computeSimilarPairs(statements []*storage.Statement, opts similarity.FindOptions) []SimilarPairResponse {
 	// Convert to models.Statement
 	modelStatements := s.convertToModelStatements(statements)
 
 	// Find similar pairs
-	pairs := s.similarityService.FindSimilarStatements(modelStatements, threshold)
+	pairs := s.similarityService.FindSimilarStatementsWithOptions(modelStatements, opts)
 
 	// Convert to response
 	response := make([]SimilarPairResponse, len(pairs))
 	for i, p := range pairs {
 		response[i] = SimilarPairResponse{
-			Statement1: p.Statement1,
-			Statement2: p.Statement2,
-			File1:      p.File1,
-			File2:      p.File2,
-			Similarity: p.Similarity,
+			Statement1:  p.Statement1,
+			Statement2:  p.Statement2,
+			File1:       p.File1,
+			File2:       p.File2,
+			DocumentID1: p.DocumentID1,
+			DocumentID2: p.DocumentID2,
+			Similarity:  p.Similarity,
 		}
 	}
-
-	respondJSON(w, http.StatusOK, response)
+	return response
 }
 
 // handleGetAnomalies returns anomaly detection results for a project
 func (s *Server) handleGetAnomaliesImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	// Parse optional scope parameter (global or cluster)
+	scope := r.URL.Query().Get("scope")
+	if scope != "" && scope != "global" && scope != "cluster" {
+		respondError(w, http.StatusBadRequest, "scope must be global or cluster")
 		return
 	}
 
@@ -277,58 +592,285 @@ func (s *Server) handleGetAnomaliesImpl(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
 		return
 	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
 
 	if len(statements) == 0 {
 		respondJSON(w, http.StatusOK, []AnomalyResponse{})
 		return
 	}
 
+	params := analysisParams{Kind: "anomalies", Scope: scope}
+	if scope == "cluster" {
+		params.K = project.Analysis.ClusterK
+	}
+	cacheKey := analysisCacheKey(params, statements)
+	if s.respondCached(w, cacheKey) {
+		return
+	}
+
+	response := s.computeAnomalies(r.Context(), project, statements, scope)
+
+	s.analysisCache.Set(cacheKey, response)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// computeAnomalies detects anomalous statements, either across the corpus
+// (scope "" or "global") or within each of the project's clusters (scope
+// "cluster")
+func (s *Server) computeAnomalies(ctx context.Context, project *storage.Project, statements []*storage.Statement, scope string) []AnomalyResponse {
 	// Convert to models.Statement
 	modelStatements := s.convertToModelStatements(statements)
 
-	// Detect anomalies
-	anomalies := s.anomalyService.GetAnomalies(modelStatements)
+	// Detect anomalies, either across the corpus or within each cluster
+	var anomalies []anomaly.AnomalyResult
+	switch scope {
+	case "cluster":
+		labels := s.projectClusterLabels(ctx, project, statements, modelStatements)
+		anomalies = s.anomalyService.GetAnomaliesByCluster(modelStatements, labels)
+	default:
+		anomalies = s.anomalyService.GetAnomalies(modelStatements)
+	}
 
 	// Convert to response
 	response := make([]AnomalyResponse, len(anomalies))
 	for i, a := range anomalies {
 		response[i] = AnomalyResponse{
-			Text:  a.Text,
-			File:  a.File,
-			Line:  a.Line,
-			Score: a.Score,
+			Text:      a.Text,
+			File:      a.File,
+			Line:      a.Line,
+			Score:     a.Score,
+			Neighbors: anomalyNeighbors(a, modelStatements),
 		}
 	}
+	return response
+}
 
-	respondJSON(w, http.StatusOK, response)
+// anomalyNeighbors resolves the neighbors of an anomaly, indexes into
+// statements, to the statements themselves
+func anomalyNeighbors(a anomaly.AnomalyResult, statements []models.Statement) []AnomalyNeighbor {
+	if len(a.Neighbors) == 0 {
+		return nil
+	}
+	neighbors := make([]AnomalyNeighbor, 0, len(a.Neighbors))
+	for _, n := range a.Neighbors {
+		if n.Index < 0 || n.Index >= len(statements) {
+			continue
+		}
+		stmt := statements[n.Index]
+		neighbors = append(neighbors, AnomalyNeighbor{
+			ID:       stmt.ID,
+			Text:     stmt.Text,
+			File:     stmt.File,
+			Distance: n.Distance,
+		})
+	}
+	return neighbors
 }
 
-// handleGetContradictions returns contradiction detection results for a project
-func (s *Server) handleGetContradictionsImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+// maxHistogramBuckets caps the bucket count for score distributions
+const maxHistogramBuckets = 100
+
+// handleGetAnomalyDistribution returns a histogram and summary statistics of
+// anomaly scores across all statements in a project
+func (s *Server) handleGetAnomalyDistribution(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+	pid := project.ID
+
+	buckets := anomaly.DefaultHistogramBuckets
+	if b := r.URL.Query().Get("buckets"); b != "" {
+		parsed, err := strconv.Atoi(b)
+		if err != nil || parsed <= 0 || parsed > maxHistogramBuckets {
+			respondError(w, http.StatusBadRequest, "buckets must be between 1 and 100")
+			return
+		}
+		buckets = parsed
+	}
+
+	// Get statements for project
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+
+	// Convert to models.Statement
+	modelStatements := s.convertToModelStatements(statements)
+
+	// Score every statement, not just the flagged ones
+	results := s.anomalyService.DetectAnomalies(modelStatements)
+	scores := make([]float64, len(results))
+	for i, a := range results {
+		scores[i] = a.Score
+	}
+
+	respondJSON(w, http.StatusOK, struct {
+		anomaly.Distribution
+		Threshold float64 `json:"threshold"`
+	}{
+		Distribution: anomaly.ComputeDistribution(scores, buckets),
+		Threshold:    s.anomalyService.GetThreshold(),
+	})
+}
+
+// AnomalyRangeResponse is a page of statements within an anomaly score band
+type AnomalyRangeResponse struct {
+	Items    []AnomalyResponse `json:"items"`
+	Min      float64           `json:"min"`
+	Max      float64           `json:"max"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Total    int               `json:"total"`
+}
+
+const (
+	defaultAnomalyPageSize = 50
+	maxAnomalyPageSize     = 200
+)
+
+// handleGetAnomalyRange returns statements whose anomaly scores fall within
+// [min, max], sorted by score descending and paginated
+func (s *Server) handleGetAnomalyRange(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
-	pid, err := uuid.Parse(projectID)
+	var err error
+	query := r.URL.Query()
+	minScore, maxScore := 0.0, 1.0
+	if v := query.Get("min"); v != "" {
+		if minScore, err = strconv.ParseFloat(v, 64); err != nil {
+			respondError(w, http.StatusBadRequest, "min must be a number")
+			return
+		}
+	}
+	if v := query.Get("max"); v != "" {
+		if maxScore, err = strconv.ParseFloat(v, 64); err != nil {
+			respondError(w, http.StatusBadRequest, "max must be a number")
+			return
+		}
+	}
+	if minScore > maxScore {
+		respondError(w, http.StatusBadRequest, "min must not exceed max")
+		return
+	}
+
+	page, pageSize, ok := parsePage(w, r, defaultAnomalyPageSize, maxAnomalyPageSize)
+	if !ok {
+		return
+	}
+
+	// Get statements for project
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+
+	// Score every statement and keep those inside the band
+	modelStatements := s.convertToModelStatements(statements)
+	results := s.anomalyService.DetectAnomalies(modelStatements)
+	inRange := make([]anomaly.AnomalyResult, 0)
+	for _, a := range results {
+		if a.Score >= minScore && a.Score <= maxScore {
+			inRange = append(inRange, a)
+		}
+	}
+	sort.SliceStable(inRange, func(i, j int) bool {
+		return inRange[i].Score > inRange[j].Score
+	})
+
+	start, end := pageBounds(page, pageSize, len(inRange))
+
+	items := make([]AnomalyResponse, 0, end-start)
+	for _, a := range inRange[start:end] {
+		items = append(items, AnomalyResponse{
+			Text:      a.Text,
+			File:      a.File,
+			Line:      a.Line,
+			Score:     a.Score,
+			Neighbors: anomalyNeighbors(a, modelStatements),
+		})
+	}
+
+	respondJSON(w, http.StatusOK, AnomalyRangeResponse{
+		Items:    items,
+		Min:      minScore,
+		Max:      maxScore,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    len(inRange),
+	})
+}
+
+// handleGetContradictions returns contradiction detection results for a project
+func (s *Server) handleGetContradictionsImpl(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
 	// Check if contradiction service is configured
 	if s.contradictionService == nil {
-		respondError(w, http.StatusServiceUnavailable, "contradiction detection not configured - set ANTHROPIC_API_KEY")
+		respondError(w, http.StatusServiceUnavailable, "contradiction detection not configured - set ANTHROPIC_API_KEY or LLM_PROVIDER")
 		return
 	}
 
+	// Reuse cached analyses unless ?force=true; ?max_pairs raises or lowers
+	// the pair cap and ?min_confidence the confidence floor for this request
+	// only
+	opts := contradiction.DetectOptions{}
+	opts.Force, _ = strconv.ParseBool(r.URL.Query().Get("force"))
+	if v := r.URL.Query().Get("max_pairs"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "max_pairs must be a positive integer")
+			return
+		}
+		opts.MaxPairs = parsed
+	}
+	if v := r.URL.Query().Get("min_confidence"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "min_confidence must be between 0 and 1")
+			return
+		}
+		opts.MinConfidence = &parsed
+	}
+	threshold, ok := s.candidateThreshold(w, r, project)
+	if !ok {
+		return
+	}
+	opts.MinSimilarity = threshold
+	w.Header().Set("X-Candidate-Threshold", strconv.FormatFloat(threshold, 'f', -1, 64))
+	w.Header().Set("X-Max-Pairs", strconv.Itoa(s.contradictionService.MaxPairs(opts)))
+
 	// Get statements for project
 	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
 		return
 	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
 
 	if len(statements) == 0 {
 		respondJSON(w, http.StatusOK, []ContradictionResponse{})
@@ -339,7 +881,17 @@ func (s *Server) handleGetContradictionsImpl(w http.ResponseWriter, r *http.Requ
 	modelStatements := s.convertToModelStatements(statements)
 
 	// First find similar pairs (contradiction candidates)
-	pairs := s.similarityService.FindSimilarStatements(modelStatements, 0.5)
+	pairs := s.similarityService.FindSimilarStatements(modelStatements, threshold)
+
+	// Prompt in the project's configured language, else the detected one
+	language := contradiction.Language(project.Language)
+	if language == "" {
+		texts := make([]string, len(modelStatements))
+		for i, stmt := range modelStatements {
+			texts[i] = stmt.Text
+		}
+		language = contradiction.DetectLanguage(texts)
+	}
 
 	// Convert to statement pairs for contradiction analysis
 	statementPairs := make([]contradiction.StatementPair, len(pairs))
@@ -351,20 +903,28 @@ func (s *Server) handleGetContradictionsImpl(w http.ResponseWriter, r *http.Requ
 			Statement2ID: modelStatements[p.Index2].ID,
 			File1:        p.File1,
 			File2:        p.File2,
+			Section1:     modelStatements[p.Index1].Section,
+			Section2:     modelStatements[p.Index2].Section,
 			Similarity:   p.Similarity,
+			Language:     language,
 		}
 	}
 
 	// Detect contradictions
-	contradictions, err := s.contradictionService.DetectContradictions(r.Context(), statementPairs)
+	contradictions, err := s.contradictionService.DetectContradictions(r.Context(), statementPairs, opts)
+	if errors.Is(err, contradiction.ErrRateLimited) {
+		respondError(w, http.StatusServiceUnavailable, "contradiction analysis unavailable: the LLM provider is rate limiting or failing, try again later")
+		return
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to detect contradictions")
 		return
 	}
 
-	// Convert to response
+	// Convert to response, recording each contradiction in the review worklist
 	response := make([]ContradictionResponse, len(contradictions))
 	for i, c := range contradictions {
+		stored := s.saveContradiction(r.Context(), pid, c)
 		response[i] = ContradictionResponse{
 			Statement1:  c.Statement1,
 			Statement2:  c.Statement2,
@@ -375,6 +935,10 @@ func (s *Server) handleGetContradictionsImpl(w http.ResponseWriter, r *http.Requ
 			Explanation: c.Explanation,
 			Confidence:  c.Confidence,
 		}
+		if stored != nil {
+			response[i].ID = stored.ID.String()
+			response[i].Status = stored.Status
+		}
 	}
 
 	respondJSON(w, http.StatusOK, response)
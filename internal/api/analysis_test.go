@@ -0,0 +1,1083 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/todmy/doc-analyzer/internal/anomaly"
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+)
+
+func TestHandleGetAnomalyDistribution(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	path := fmt.Sprintf("/api/v1/projects/%s/anomalies/distribution?buckets=5", project.ID)
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp anomaly.Distribution
+	decodeJSON(t, rec, &resp)
+
+	if len(resp.Histogram) != 5 {
+		t.Fatalf("expected 5 buckets, got %d", len(resp.Histogram))
+	}
+
+	total := 0
+	for _, b := range resp.Histogram {
+		total += b.Count
+	}
+	if total != len(texts) {
+		t.Errorf("expected histogram to sum to %d, got %d", len(texts), total)
+	}
+	if resp.Count != len(texts) {
+		t.Errorf("expected count %d, got %d", len(texts), resp.Count)
+	}
+	if _, ok := resp.Percentiles["p50"]; !ok {
+		t.Error("expected p50 percentile")
+	}
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/anomalies/distribution?buckets=0", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid buckets, got %d", rec.Code)
+	}
+}
+
+func TestReconcileMixedDimensions(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	oldTexts := make([]string, 12)
+	for i := range oldTexts {
+		oldTexts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "old.md", oldTexts...)
+	newDoc := env.seedDocument(t, project.ID, "new.md", "alpha beta", "beta gamma", "gamma delta", "delta alpha")
+
+	// Re-embed the new document with a larger model
+	for _, stmt := range env.statements.items {
+		if stmt.DocumentID == newDoc.ID {
+			wide := append(stmt.Embedding.Slice(), fakeEmbedding(stmt.Text+" wide")...)
+			stmt.Embedding = pgvector.NewVector(wide)
+		}
+	}
+
+	path := fmt.Sprintf("/api/v1/projects/%s/anomalies/distribution", project.ID)
+
+	tests := []struct {
+		mode      embeddings.ReconcileMode
+		count     int
+		excluded  string
+		projected string
+	}{
+		{embeddings.ReconcileExclude, 12, "4", "0"},
+		{embeddings.ReconcileProject, 16, "0", "4"},
+	}
+	for _, tt := range tests {
+		env.server.embeddingReconcile = tt.mode
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", tt.mode, rec.Code, rec.Body.String())
+		}
+
+		var resp anomaly.Distribution
+		decodeJSON(t, rec, &resp)
+		if resp.Count != tt.count {
+			t.Errorf("%s: expected %d statements analyzed, got %d", tt.mode, tt.count, resp.Count)
+		}
+		if got := rec.Header().Get("X-Embeddings-Excluded"); got != tt.excluded {
+			t.Errorf("%s: expected %s excluded, got %q", tt.mode, tt.excluded, got)
+		}
+		if got := rec.Header().Get("X-Embeddings-Projected"); got != tt.projected {
+			t.Errorf("%s: expected %s projected, got %q", tt.mode, tt.projected, got)
+		}
+	}
+}
+
+func TestAnalysis_RejectsInvalidEmbeddings(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 6)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	doc := env.seedDocument(t, project.ID, "a.md", texts...)
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+
+	tests := []struct {
+		name      string
+		embedding []float32
+		message   string
+	}{
+		{"missing", nil, "1 statements are missing embeddings"},
+		{"mixed dimensions", append(fakeEmbedding(texts[0]), 1), "1 statements have embeddings of a different dimension"},
+	}
+	for _, tt := range tests {
+		env.statements.items[stmts[0].ID].Embedding = pgvector.NewVector(tt.embedding)
+
+		for _, path := range []string{"clusters", "similar-pairs", "anomalies"} {
+			rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/%s", project.ID, path), userID.String(), nil)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("%s %s: expected status 409, got %d", tt.name, path, rec.Code)
+				continue
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("%s %s: expected %q in %s", tt.name, path, tt.message, rec.Body.String())
+			}
+		}
+	}
+
+	// A reconcile mode analyzes the comparable statements instead
+	env.server.embeddingReconcile = embeddings.ReconcileExclude
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/anomalies", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Embeddings-Excluded") != "1" {
+		t.Errorf("exclude mode: expected status 200 with 1 excluded, got %d and %q", rec.Code, rec.Header().Get("X-Embeddings-Excluded"))
+	}
+}
+
+func TestHandleGetAnomalies_Neighbors(t *testing.T) {
+	env := newTestEnv(t, "")
+	env.server.anomalyService = anomaly.NewService(anomaly.Config{Detector: anomaly.DetectorDistance, K: 2, Threshold: 0.9})
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are issued within thirty days",
+		"refunds are issued within sixty days",
+		"refunds are issued within ninety days",
+		"the office parking garage closes early",
+	)
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/anomalies", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var anomalies []AnomalyResponse
+	decodeJSON(t, rec, &anomalies)
+	if len(anomalies) != 1 || anomalies[0].Text != "the office parking garage closes early" {
+		t.Fatalf("expected the parking statement as the only anomaly, got %+v", anomalies)
+	}
+
+	neighbors := anomalies[0].Neighbors
+	if len(neighbors) != 2 {
+		t.Fatalf("expected 2 neighbors, got %+v", neighbors)
+	}
+	for i, n := range neighbors {
+		if n.ID == "" || !strings.HasPrefix(n.Text, "refunds") || n.File != "a.md" {
+			t.Errorf("neighbor %d: expected a refund statement of a.md with an ID, got %+v", i, n)
+		}
+		if i > 0 && n.Distance < neighbors[i-1].Distance {
+			t.Errorf("expected neighbors closest first, got %+v", neighbors)
+		}
+	}
+}
+
+// fakeLLMBackend answers every prompt with the given response or error
+type fakeLLMBackend struct {
+	response string
+	err      error
+}
+
+func (b fakeLLMBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	return b.response, b.err
+}
+
+func TestHandleGetAnomalies_ClusterScopeUsesProjectClusters(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	rec := env.do(t, http.MethodPut, fmt.Sprintf("/api/v1/projects/%s/config", project.ID), userID.String(), ProjectConfigRequest{ClusterK: 3})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	path := fmt.Sprintf("/api/v1/projects/%s/anomalies?scope=cluster", project.ID)
+	if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The project's k is used and the clustering is stored for the clusters
+	// endpoint to serve
+	stored, _ := env.clusters.GetByProjectID(context.Background(), project.ID)
+	if stored == nil || stored.RequestedK != 3 || len(stored.Clusters) != 3 {
+		t.Fatalf("expected a stored clustering with k=3, got %+v", stored)
+	}
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters", project.ID), userID.String(), nil)
+	if mode := rec.Header().Get("X-Clustering"); mode != clusteringStored {
+		t.Errorf("expected the clusters endpoint to serve the stored clustering, got %q", mode)
+	}
+
+	// A matching stored clustering is reused rather than replaced
+	if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := env.clusters.GetByProjectID(context.Background(), project.ID); got.Clusters[0].ID != stored.Clusters[0].ID {
+		t.Errorf("expected the stored clustering to be reused")
+	}
+}
+
+func TestHandleGetSimilarPairs_Metric(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	texts := []string{
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+	a, b := fakeEmbedding(texts[0]), fakeEmbedding(texts[1])
+
+	similar := func(query string) (*httptest.ResponseRecorder, []SimilarPairResponse) {
+		t.Helper()
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/similar-pairs?threshold=0.1&%s", project.ID, query), userID.String(), nil)
+		var pairs []SimilarPairResponse
+		if rec.Code == http.StatusOK {
+			decodeJSON(t, rec, &pairs)
+		}
+		return rec, pairs
+	}
+
+	tests := []struct {
+		metric string
+		want   float64
+	}{
+		{"", similarity.CosineSimilarity(a, b)},
+		{"cosine", similarity.CosineSimilarity(a, b)},
+		{"dot", similarity.DotProductSimilarity(a, b)},
+		{"euclidean", similarity.EuclideanSimilarity(a, b)},
+	}
+	for _, tt := range tests {
+		rec, pairs := similar("metric=" + tt.metric)
+		if rec.Code != http.StatusOK || len(pairs) != 1 {
+			t.Fatalf("%q: expected status 200 with 1 pair, got %d: %v", tt.metric, rec.Code, pairs)
+		}
+		if math.Abs(pairs[0].Similarity-tt.want) > 1e-9 {
+			t.Errorf("%q: expected similarity %v, got %v", tt.metric, tt.want, pairs[0].Similarity)
+		}
+	}
+	if similarity.EuclideanSimilarity(a, b) >= similarity.CosineSimilarity(a, b) {
+		t.Fatal("test texts should score differently under the euclidean metric")
+	}
+
+	if rec, _ := similar("metric=manhattan"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown metric, got %d", rec.Code)
+	}
+
+	// Requests without a metric use the configured default
+	env.server.similarityService.SetMetric(similarity.MetricDotProduct)
+	if _, pairs := similar(""); len(pairs) != 1 || math.Abs(pairs[0].Similarity-similarity.DotProductSimilarity(a, b)) > 1e-9 {
+		t.Errorf("expected the configured dot metric, got %v", pairs)
+	}
+	env.server.similarityService.SetMetric(similarity.MetricCosine)
+
+	// Dot products of longer embeddings exceed 1, so only that metric
+	// accepts thresholds above 1
+	stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+	for _, stmt := range stmts {
+		scaled := stmt.Embedding.Slice()
+		for i := range scaled {
+			scaled[i] *= 2
+		}
+		env.statements.items[stmt.ID].Embedding = pgvector.NewVector(scaled)
+	}
+	thresholds := []struct {
+		query string
+		code  int
+	}{
+		{"metric=dot&threshold=1.5", http.StatusOK},
+		{"metric=cosine&threshold=1.5", http.StatusBadRequest},
+		{"metric=euclidean&threshold=1.5", http.StatusBadRequest},
+		{"threshold=1.5", http.StatusBadRequest},
+		{"metric=dot&threshold=0", http.StatusBadRequest},
+		{"metric=dot&threshold=high", http.StatusBadRequest},
+	}
+	for _, tt := range thresholds {
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/similar-pairs?%s", project.ID, tt.query), userID.String(), nil)
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.query, tt.code, rec.Code, rec.Body.String())
+		}
+		if tt.code == http.StatusOK {
+			var pairs []SimilarPairResponse
+			decodeJSON(t, rec, &pairs)
+			if len(pairs) != 1 || pairs[0].Similarity <= 1.5 {
+				t.Errorf("%s: expected 1 pair above 1.5, got %v", tt.query, pairs)
+			}
+		}
+	}
+}
+
+func TestHandleGetSimilarPairs_CrossDocument(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	docA := env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+	)
+	docB := env.seedDocument(t, project.ID, "b.md",
+		"refunds are available for ninety days",
+	)
+
+	similar := func(query string) []SimilarPairResponse {
+		t.Helper()
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/similar-pairs?threshold=0.5&%s", project.ID, query), userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var pairs []SimilarPairResponse
+		decodeJSON(t, rec, &pairs)
+		return pairs
+	}
+
+	if pairs := similar("cross_doc=false"); len(pairs) != 3 {
+		t.Fatalf("expected 3 pairs without the filter, got %d", len(pairs))
+	}
+
+	pairs := similar("cross_doc=true")
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 cross-document pairs, got %d: %v", len(pairs), pairs)
+	}
+	for _, p := range pairs {
+		if p.DocumentID1 == p.DocumentID2 {
+			t.Errorf("expected pairs across documents, got %s twice", p.DocumentID1)
+		}
+		ids := []string{p.DocumentID1, p.DocumentID2}
+		if !slices.Contains(ids, docA.ID.String()) || !slices.Contains(ids, docB.ID.String()) {
+			t.Errorf("expected a pair of a.md and b.md, got %v", ids)
+		}
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/similar-pairs?cross_doc=maybe", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid cross_doc, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetContradictions_RateLimited(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+		"refunds are available for ninety days",
+	)
+
+	path := fmt.Sprintf("/api/v1/projects/%s/contradictions", project.ID)
+
+	backend := fakeLLMBackend{err: &contradiction.APIError{StatusCode: http.StatusTooManyRequests}}
+	env.server.contradictionService = contradiction.NewService(
+		contradiction.NewAnalyzerWithBackend(backend), contradiction.DefaultServiceConfig())
+
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 when rate limited, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Analyzed pairs without contradictions are an empty list, not an error
+	backend = fakeLLMBackend{response: `{"is_contradiction": false}`}
+	env.server.contradictionService = contradiction.NewService(
+		contradiction.NewAnalyzerWithBackend(backend), contradiction.DefaultServiceConfig())
+
+	rec = env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list []ContradictionResponse
+	decodeJSON(t, rec, &list)
+	if len(list) != 0 {
+		t.Errorf("expected no contradictions, got %d", len(list))
+	}
+}
+
+func TestHandleGetClusterMetrics(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	path := fmt.Sprintf("/api/v1/projects/%s/clusters/metrics?maxK=5", project.ID)
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ClusterMetricsResponse
+	decodeJSON(t, rec, &resp)
+
+	if len(resp.K) != 5 || len(resp.Inertia) != 5 || len(resp.Silhouette) != 5 {
+		t.Fatalf("expected 5 entries per array, got k=%d inertia=%d silhouette=%d",
+			len(resp.K), len(resp.Inertia), len(resp.Silhouette))
+	}
+	if resp.RecommendedK < 1 || resp.RecommendedK > 5 {
+		t.Errorf("expected recommended k in [1, 5], got %d", resp.RecommendedK)
+	}
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters/metrics?maxK=50", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for maxK above cap, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetClusterElbow(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	path := fmt.Sprintf("/api/v1/projects/%s/clusters/elbow?max_k=5", project.ID)
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ElbowResponse
+	decodeJSON(t, rec, &resp)
+
+	if len(resp.K) != 5 || len(resp.Inertia) != 5 {
+		t.Fatalf("expected 5 entries per array, got k=%d inertia=%d", len(resp.K), len(resp.Inertia))
+	}
+	for i, k := range resp.K {
+		if k != i+1 {
+			t.Errorf("expected k[%d] = %d, got %d", i, i+1, k)
+		}
+	}
+	if resp.ElbowK < 1 || resp.ElbowK > 5 {
+		t.Errorf("expected elbow k in [1, 5], got %d", resp.ElbowK)
+	}
+
+	// Nothing is stored
+	if clustering, _ := env.clusters.GetByProjectID(context.Background(), project.ID); clustering != nil {
+		t.Errorf("expected no stored clustering, got %d clusters", len(clustering.Clusters))
+	}
+
+	for _, query := range []string{"max_k=0", "max_k=50", "max_k=abc"} {
+		rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters/elbow?%s", project.ID, query), userID.String(), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHandleGetClusters_Sort(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	// Three themes of different sizes with disjoint vocabularies
+	var texts []string
+	for i := 0; i < 6; i++ {
+		texts = append(texts, fmt.Sprintf("refund policy purchase money returned %d", i))
+	}
+	for i := 0; i < 3; i++ {
+		texts = append(texts, fmt.Sprintf("server outage downtime incident report %d", i))
+	}
+	texts = append(texts, "holiday calendar office closed christmas")
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	get := func(query string) []ClusterResponse {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/clusters?k=3&%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var clusters []ClusterResponse
+		decodeJSON(t, rec, &clusters)
+		return clusters
+	}
+
+	clusters := get("")
+	if len(clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d", len(clusters))
+	}
+	if !sort.SliceIsSorted(clusters, func(i, j int) bool { return clusters[i].Size > clusters[j].Size }) {
+		t.Errorf("expected largest clusters first by default, got sizes %v", clusterSizes(clusters))
+	}
+	if clusters[0].Size == clusters[len(clusters)-1].Size {
+		t.Fatalf("expected clusters of different sizes, got %v", clusterSizes(clusters))
+	}
+
+	asc := get("sort=size&order=asc")
+	if !sort.SliceIsSorted(asc, func(i, j int) bool { return asc[i].Size < asc[j].Size }) {
+		t.Errorf("expected smallest clusters first, got sizes %v", clusterSizes(asc))
+	}
+	if asc[0].ID == clusters[0].ID {
+		t.Errorf("expected order=asc to change the first cluster, got %d both times", asc[0].ID)
+	}
+
+	byID := get("sort=id&order=asc")
+	for i, c := range byID {
+		if c.ID != i {
+			t.Errorf("sort=id: expected cluster %d at position %d, got %d", i, i, c.ID)
+		}
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters?sort=name", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown sort, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetClusters_ReusesCentroids(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	seed := func(name string, from, n int) {
+		texts := make([]string, n)
+		for i := range texts {
+			texts[i] = fmt.Sprintf("statement number %d about topic %d", from+i, (from+i)%3)
+		}
+		env.seedDocument(t, project.ID, name, texts...)
+	}
+	get := func(query string) string {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/clusters?k=3%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec.Header().Get("X-Clustering")
+	}
+
+	seed("a.md", 0, 20)
+	if got := get(""); got != clusteringFull {
+		t.Errorf("first request: expected a full fit, got %q", got)
+	}
+	model, _ := env.clusterModels.GetByProjectID(context.Background(), project.ID)
+	if model == nil || len(model.Centroids) != 3 || model.FittedStatements != 20 {
+		t.Fatalf("expected 3 stored centroids fitted on 20 statements, got %+v", model)
+	}
+
+	// A small upload is assigned to the stored centroids
+	seed("b.md", 20, 3)
+	if got := get(""); got != clusteringIncremental {
+		t.Errorf("after a small upload: expected incremental, got %q", got)
+	}
+	if got := get("&refit=true"); got != clusteringFull {
+		t.Errorf("refit=true: expected a full fit, got %q", got)
+	}
+
+	// Growing past clusterRefitGrowth since the last fit triggers a refit
+	seed("c.md", 23, 10)
+	if got := get(""); got != clusteringFull {
+		t.Errorf("after a large upload: expected a full fit, got %q", got)
+	}
+	seed("d.md", 33, 2)
+	if got := get("&sort=id"); got != clusteringIncremental {
+		t.Errorf("after the refit: expected incremental, got %q", got)
+	}
+}
+
+func TestHandleGetClusters_MaxIter(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/clusters?%s", project.ID, query)
+		return env.do(t, http.MethodGet, path, userID.String(), nil)
+	}
+
+	if rec := get("k=3"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := env.clusters.GetByProjectID(context.Background(), project.ID)
+	model, _ := env.clusterModels.GetByProjectID(context.Background(), project.ID)
+
+	// max_iter previews a fit even though the stored clustering still matches
+	for _, query := range []string{"k=3&max_iter=1", "max_iter=5"} {
+		rec := get(query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		if mode := rec.Header().Get("X-Clustering"); mode != clusteringPreview {
+			t.Errorf("%s: expected a preview, got %q", query, mode)
+		}
+		var clusters []ClusterResponse
+		decodeJSON(t, rec, &clusters)
+		if len(clusters) == 0 {
+			t.Errorf("%s: expected clusters", query)
+		}
+	}
+
+	// Previews leave the stored clustering and centroids alone
+	if got, _ := env.clusters.GetByProjectID(context.Background(), project.ID); !reflect.DeepEqual(got, stored) {
+		t.Errorf("expected the stored clustering to be kept after previews")
+	}
+	if got, _ := env.clusterModels.GetByProjectID(context.Background(), project.ID); !reflect.DeepEqual(got, model) {
+		t.Errorf("expected the stored cluster model to be kept after previews")
+	}
+	if mode := get("k=3").Header().Get("X-Clustering"); mode != clusteringStored {
+		t.Errorf("expected the stored clustering after previews, got %q", mode)
+	}
+
+	for _, query := range []string{"max_iter=0", "max_iter=5000", "max_iter=many"} {
+		if rec := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHandleGetClusters_ServesStoredClustering(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	get := func(query string) ([]ClusterResponse, string) {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/clusters?%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var clusters []ClusterResponse
+		decodeJSON(t, rec, &clusters)
+		return clusters, rec.Header().Get("X-Clustering")
+	}
+
+	computed, mode := get("k=3")
+	if mode != clusteringFull {
+		t.Fatalf("first request: expected a full fit, got %q", mode)
+	}
+
+	// The clustering and its memberships are persisted
+	stored, _ := env.clusters.GetByProjectID(context.Background(), project.ID)
+	if stored == nil || stored.RequestedK != 3 || len(stored.Clusters) != len(computed) {
+		t.Fatalf("expected the clustering to be stored, got %+v", stored)
+	}
+	for _, c := range computed {
+		if c.Representative == nil || c.Representative.ID == "" || !strings.Contains(c.Representative.Text, "statement number") {
+			t.Errorf("cluster %d: expected a representative statement, got %+v", c.ID, c.Representative)
+		}
+	}
+	members := 0
+	for _, c := range stored.Clusters {
+		if len(c.StatementIDs) != c.Size {
+			t.Errorf("cluster %d: expected %d statement IDs, got %d", c.Label, c.Size, len(c.StatementIDs))
+		}
+		members += len(c.StatementIDs)
+	}
+	if members != len(texts) {
+		t.Errorf("expected %d memberships, got %d", len(texts), members)
+	}
+
+	// Unchanged statements are served from storage with the same result
+	served, mode := get("k=3")
+	if mode != clusteringStored {
+		t.Errorf("unchanged project: expected the stored clustering, got %q", mode)
+	}
+	if !reflect.DeepEqual(served, computed) {
+		t.Errorf("expected stored clusters %v, got %v", computed, served)
+	}
+
+	// A different k, a refit or a changed statement set recompute
+	if _, mode := get("k=2"); mode == clusteringStored {
+		t.Errorf("different k: expected clustering to run")
+	}
+	if _, mode := get("k=3&refit=true"); mode != clusteringFull {
+		t.Errorf("refit=true: expected a full fit, got %q", mode)
+	}
+	env.seedDocument(t, project.ID, "b.md", "statement number 12 about topic 0")
+	if _, mode := get("k=3"); mode == clusteringStored {
+		t.Errorf("after an upload: expected clustering to run")
+	}
+}
+
+func clusterSizes(clusters []ClusterResponse) []int {
+	sizes := make([]int, len(clusters))
+	for i, c := range clusters {
+		sizes[i] = c.Size
+	}
+	return sizes
+}
+
+// countingLLMBackend counts prompts and reports no contradictions
+type countingLLMBackend struct {
+	calls atomic.Int32
+}
+
+func (b *countingLLMBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	b.calls.Add(1)
+	return `{"is_contradiction": false}`, nil
+}
+
+func TestHandleGetContradictions_MaxPairs(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+		"refunds are available for ninety days",
+		"refunds are available for ten days",
+		"refunds are available for seven days",
+	)
+
+	tests := []struct {
+		query     string
+		wantCap   string
+		wantCalls int32
+	}{
+		{"", "100", 10},
+		{"?max_pairs=3", "3", 3},
+		{"?max_pairs=5000", "1000", 10},
+	}
+
+	for _, tt := range tests {
+		backend := &countingLLMBackend{}
+		env.server.contradictionService = contradiction.NewService(
+			contradiction.NewAnalyzerWithBackend(backend), contradiction.DefaultServiceConfig())
+
+		path := fmt.Sprintf("/api/v1/projects/%s/contradictions%s", project.ID, tt.query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Max-Pairs"); got != tt.wantCap {
+			t.Errorf("%q: expected effective cap %s, got %q", tt.query, tt.wantCap, got)
+		}
+		if got := backend.calls.Load(); got != tt.wantCalls {
+			t.Errorf("%q: expected %d pairs analyzed, got %d", tt.query, tt.wantCalls, got)
+		}
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/contradictions?max_pairs=0", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for max_pairs=0, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetContradictions_CandidateThreshold(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"orders ship within two business days",
+		"refunds are available for sixty days",
+		"refunds ship within thirty business days",
+	)
+
+	tests := []struct {
+		query     string
+		wantCalls int32
+	}{
+		{"", 3},
+		{"?candidate_threshold=0.8", 1},
+		// Below the service's own minimum similarity, which must not filter
+		// the candidates again
+		{"?candidate_threshold=0.3", 4},
+	}
+
+	for _, tt := range tests {
+		backend := &countingLLMBackend{}
+		env.server.contradictionService = contradiction.NewService(
+			contradiction.NewAnalyzerWithBackend(backend), contradiction.DefaultServiceConfig())
+
+		path := fmt.Sprintf("/api/v1/projects/%s/contradictions%s", project.ID, tt.query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		if got := backend.calls.Load(); got != tt.wantCalls {
+			t.Errorf("%q: expected %d pairs analyzed, got %d", tt.query, tt.wantCalls, got)
+		}
+	}
+
+	for _, v := range []string{"0", "1.5", "tight"} {
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/contradictions?candidate_threshold=%s", project.ID, v), userID.String(), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for candidate_threshold=%s, got %d", v, rec.Code)
+		}
+	}
+}
+
+// confidenceLLMBackend reports every pair as a contradiction, with low
+// confidence when the prompt mentions "thirty"
+type confidenceLLMBackend struct{}
+
+func (confidenceLLMBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	confidence := 0.8
+	if strings.Contains(prompt, "thirty") {
+		confidence = 0.3
+	}
+	return fmt.Sprintf(`{"is_contradiction": true, "type": "numerical", "severity": "medium", "explanation": "x", "confidence": %g}`, confidence), nil
+}
+
+func TestHandleGetContradictions_MinConfidence(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+		"refunds are available for ninety days",
+	)
+	env.server.contradictionService = contradiction.NewService(
+		contradiction.NewAnalyzerWithBackend(confidenceLLMBackend{}), contradiction.DefaultServiceConfig())
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 1},
+		{"?min_confidence=0", 3},
+		{"?min_confidence=0.3", 3},
+		{"?min_confidence=0.9", 0},
+	}
+
+	for _, tt := range tests {
+		path := fmt.Sprintf("/api/v1/projects/%s/contradictions%s", project.ID, tt.query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		var got []ContradictionResponse
+		decodeJSON(t, rec, &got)
+		if len(got) != tt.want {
+			t.Errorf("%q: expected %d contradictions, got %d", tt.query, tt.want, len(got))
+		}
+		for _, c := range got {
+			if tt.query == "" && c.Confidence < 0.5 {
+				t.Errorf("expected the default to drop confidence %v", c.Confidence)
+			}
+		}
+	}
+
+	for _, v := range []string{"-0.1", "1.5", "high"} {
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/contradictions?min_confidence=%s", project.ID, v), userID.String(), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for min_confidence=%s, got %d", v, rec.Code)
+		}
+	}
+}
+
+func TestHandleGetAnomalyRange(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	texts = append(texts, "completely unrelated sentence on quarterly marine biology budgets")
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	// Find the band from the full distribution so the test does not depend
+	// on exact detector scores
+	stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+	all := env.server.anomalyService.DetectAnomalies(env.server.convertToModelStatements(stmts))
+	scores := make([]float64, len(all))
+	for i, a := range all {
+		scores[i] = a.Score
+	}
+	sort.Float64s(scores)
+	lo, hi := scores[3], scores[len(scores)-3]
+
+	path := fmt.Sprintf("/api/v1/projects/%s/anomalies/range?min=%f&max=%f&page_size=4", project.ID, lo, hi)
+	var total int
+	var seen []float64
+	for page := 1; ; page++ {
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("%s&page=%d", path, page), userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page %d: expected status 200, got %d: %s", page, rec.Code, rec.Body.String())
+		}
+		var resp AnomalyRangeResponse
+		decodeJSON(t, rec, &resp)
+		total = resp.Total
+		if len(resp.Items) == 0 {
+			break
+		}
+		for _, item := range resp.Items {
+			if item.Score < lo || item.Score > hi {
+				t.Errorf("score %f outside band [%f, %f]", item.Score, lo, hi)
+			}
+			seen = append(seen, item.Score)
+		}
+	}
+
+	if len(seen) != total {
+		t.Errorf("expected pages to cover %d statements, got %d", total, len(seen))
+	}
+	if total == 0 || total >= len(texts) {
+		t.Errorf("expected band to select a strict subset, got %d of %d", total, len(texts))
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] > seen[i-1] {
+			t.Fatalf("expected scores sorted descending, got %v", seen)
+		}
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/anomalies/range?min=0.8&max=0.6", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for min > max, got %d", rec.Code)
+	}
+}
+
+func TestProjectScopedHandlers_Ownership(t *testing.T) {
+	env := newTestEnv(t, "")
+	owner := uuid.New()
+	project := env.seedProject(t, owner)
+	doc := env.seedDocument(t, project.ID, "a.md", "refunds are available for thirty days")
+
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/projects/%s"},
+		{http.MethodDelete, "/api/v1/projects/%s"},
+		{http.MethodGet, "/api/v1/projects/%s/documents"},
+		{http.MethodGet, "/api/v1/projects/%s/usage"},
+		{http.MethodGet, "/api/v1/projects/%s/config"},
+		{http.MethodPut, "/api/v1/projects/%s/config"},
+		{http.MethodGet, "/api/v1/projects/%s/analyze/estimate"},
+		{http.MethodPost, "/api/v1/projects/%s/reembed"},
+		{http.MethodGet, "/api/v1/projects/%s/statements"},
+		{http.MethodGet, "/api/v1/projects/%s/statements/search?q=refunds"},
+		{http.MethodDelete, "/api/v1/projects/%s/documents/" + doc.ID.String()},
+		{http.MethodPost, "/api/v1/projects/%s/analyze"},
+		{http.MethodGet, "/api/v1/projects/%s/visualization"},
+		{http.MethodGet, "/api/v1/projects/%s/clusters"},
+		{http.MethodGet, "/api/v1/projects/%s/clusters/metrics"},
+		{http.MethodGet, "/api/v1/projects/%s/clusters/elbow"},
+		{http.MethodPost, "/api/v1/projects/%s/clusters/keywords"},
+		{http.MethodPost, "/api/v1/projects/%s/statements/tag-by-similarity"},
+		{http.MethodGet, "/api/v1/projects/%s/similar-pairs"},
+		{http.MethodPost, "/api/v1/projects/%s/visualization/axes"},
+		{http.MethodPost, "/api/v1/projects/%s/documents"},
+		{http.MethodGet, "/api/v1/projects/%s/anomalies"},
+		{http.MethodGet, "/api/v1/projects/%s/anomalies/distribution"},
+		{http.MethodGet, "/api/v1/projects/%s/anomalies/range"},
+		{http.MethodGet, "/api/v1/projects/%s/contradictions"},
+		{http.MethodGet, "/api/v1/projects/%s/contradictions/review"},
+		{http.MethodGet, "/api/v1/projects/%s/contradictions/graph"},
+		{http.MethodPost, "/api/v1/projects/%s/contradictions/pair"},
+		{http.MethodGet, "/api/v1/projects/%s/export?type=anomalies"},
+		{http.MethodGet, "/api/v1/projects/%s/findings"},
+	}
+
+	for _, rt := range routes {
+		rec := env.do(t, rt.method, fmt.Sprintf(rt.path, project.ID), uuid.NewString(), nil)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s as another user: expected status 403, got %d", rt.method, rt.path, rec.Code)
+		}
+
+		rec = env.do(t, rt.method, fmt.Sprintf(rt.path, uuid.New()), owner.String(), nil)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s for missing project: expected status 404, got %d", rt.method, rt.path, rec.Code)
+		}
+	}
+}
+
+// promptCapturingBackend records prompts and reports no contradictions
+type promptCapturingBackend struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (b *promptCapturingBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prompts = append(b.prompts, prompt)
+	return `{"is_contradiction": false}`, nil
+}
+
+func TestHandleGetContradictions_ProjectLanguage(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+
+	rec := env.do(t, http.MethodPost, "/api/v1/projects", userID.String(), ProjectRequest{Name: "Richtlinien", Language: "de"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created ProjectResponse
+	decodeJSON(t, rec, &created)
+	if created.Language != "de" {
+		t.Fatalf("expected language de, got %q", created.Language)
+	}
+	projectID := uuid.MustParse(created.ID)
+
+	// English text, so the prompt language comes from the configuration
+	env.seedDocument(t, projectID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+	)
+
+	backend := &promptCapturingBackend{}
+	env.server.contradictionService = contradiction.NewService(
+		contradiction.NewAnalyzerWithBackend(backend), contradiction.DefaultServiceConfig())
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/contradictions", projectID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(backend.prompts) == 0 {
+		t.Fatal("expected the pair to be sent to the LLM")
+	}
+	for _, prompt := range backend.prompts {
+		if !strings.Contains(prompt, "Aussage 1") || !strings.Contains(prompt, `"is_contradiction": true`) {
+			t.Errorf("expected German prompt with the JSON contract, got:\n%s", prompt)
+		}
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/v1/projects", userID.String(), ProjectRequest{Name: "x", Language: "xx"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unsupported language, got %d", rec.Code)
+	}
+}
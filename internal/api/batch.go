@@ -0,0 +1,194 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// BatchUploadResponse reports the outcome of every file of a batch upload
+type BatchUploadResponse struct {
+	Files []BulkFileResult `json:"files"`
+}
+
+// isArchive reports whether an uploaded file is a zip archive
+func isArchive(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".zip")
+}
+
+// archiveLimit returns the maximum size of a batch upload and of the
+// extracted content of its archives
+func (s *Server) archiveLimit() int64 {
+	if s.maxArchiveSize > 0 {
+		return s.maxArchiveSize
+	}
+	return maxBulkUploadSize
+}
+
+// handleBatchUpload ingests several uploaded files, expanding zip archives
+// into their documents. Files are ingested in order, so content repeated
+// within the batch is stored once and reported with status "exists". Files
+// of an archive with an unsupported type are skipped with a warning. The
+// whole request is rejected if an archive extracts to more than the archive
+// limit.
+func (s *Server) handleBatchUpload(w http.ResponseWriter, r *http.Request, project *storage.Project, headers []*multipart.FileHeader, opts extractOptions) {
+	limit := s.archiveLimit()
+
+	archives := make(map[*multipart.FileHeader]*zip.Reader)
+	for _, header := range headers {
+		if !isArchive(header.Filename) {
+			continue
+		}
+		archive, file, err := openArchive(header)
+		if file != nil {
+			defer file.Close()
+		}
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("%s: invalid zip archive", header.Filename))
+			return
+		}
+		var size uint64
+		for _, f := range archive.File {
+			size += f.UncompressedSize64
+		}
+		if size > uint64(limit) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("%s: archive exceeds the %d MB limit", header.Filename, limit>>20))
+			return
+		}
+		archives[header] = archive
+	}
+
+	response := BatchUploadResponse{Files: []BulkFileResult{}}
+	for _, header := range headers {
+		if archive, ok := archives[header]; ok {
+			response.Files = append(response.Files, s.ingestArchive(r.Context(), project, archive, opts)...)
+			continue
+		}
+		response.Files = append(response.Files, s.ingestFileHeader(r.Context(), project, header, opts))
+	}
+
+	created, succeeded := 0, 0
+	for _, result := range response.Files {
+		if result.Error != "" || result.Status == "skipped" {
+			continue
+		}
+		succeeded++
+		if result.Status != "exists" {
+			created++
+		}
+	}
+
+	log.Printf("[upload] batch upload for project %s: %d of %d files stored", project.ID, created, len(response.Files))
+
+	switch {
+	case created > 0:
+		respondJSON(w, http.StatusCreated, response)
+	case succeeded > 0:
+		respondJSON(w, http.StatusOK, response)
+	default:
+		respondJSON(w, http.StatusUnprocessableEntity, response)
+	}
+}
+
+// openArchive reads the directory of an uploaded zip archive. The returned
+// file backs the archive entries and must be closed by the caller.
+func openArchive(header *multipart.FileHeader) (*zip.Reader, multipart.File, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	archive, err := zip.NewReader(file, header.Size)
+	return archive, file, err
+}
+
+// ingestFileHeader ingests one uploaded file of a batch
+func (s *Server) ingestFileHeader(ctx context.Context, project *storage.Project, header *multipart.FileHeader, opts extractOptions) BulkFileResult {
+	result := BulkFileResult{UploadResponse: UploadResponse{Filename: header.Filename}}
+
+	if header.Size > s.uploadLimit() {
+		result.Error = fmt.Sprintf("file exceeds the %d MB upload limit", s.uploadLimit()>>20)
+		return result
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		result.Error = "failed to read file"
+		return result
+	}
+	defer file.Close()
+
+	upload, err := s.ingestDocument(ctx, project, header.Filename, file, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.UploadResponse = upload
+	// Duplicates report the existing document; keep the uploaded name so
+	// each result maps to its file
+	result.Filename = header.Filename
+	return result
+}
+
+// ingestArchive ingests the documents of a zip archive. Directories and
+// hidden or macOS metadata entries are ignored; entries of an unsupported
+// type are reported as skipped.
+func (s *Server) ingestArchive(ctx context.Context, project *storage.Project, archive *zip.Reader, opts extractOptions) []BulkFileResult {
+	var results []BulkFileResult
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(path.Base(f.Name), ".") {
+			continue
+		}
+
+		result := BulkFileResult{UploadResponse: UploadResponse{Filename: f.Name}}
+		ext := strings.ToLower(filepath.Ext(f.Name))
+		if !allowedUploadExts[ext] {
+			result.Status = "skipped"
+			result.Warning = fmt.Sprintf("unsupported file type %q, skipped", ext)
+			results = append(results, result)
+			continue
+		}
+		limit := s.uploadLimit()
+		if f.UncompressedSize64 > uint64(limit) {
+			result.Error = fmt.Sprintf("file exceeds the %d MB upload limit", limit>>20)
+			results = append(results, result)
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			result.Error = "failed to read file"
+			results = append(results, result)
+			continue
+		}
+		// The declared size is not trusted; read at most one byte more than
+		// allowed to detect an entry that inflates beyond it
+		content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+		rc.Close()
+		switch {
+		case err != nil:
+			result.Error = "failed to read file"
+		case int64(len(content)) > limit:
+			result.Error = fmt.Sprintf("file exceeds the %d MB upload limit", limit>>20)
+		default:
+			upload, err := s.ingestDocument(ctx, project, f.Name, bytes.NewReader(content), opts)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.UploadResponse = upload
+				result.Filename = f.Name
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// analysisParams identifies an analysis request independently of the caller.
+// Every query parameter that affects a result must be included here so that
+// parameter variations are cached separately.
+type analysisParams struct {
+	Kind       string   `json:"kind"`
+	K          int      `json:"k,omitempty"`
+	Threshold  float64  `json:"threshold,omitempty"`
+	Metric     string   `json:"metric,omitempty"`
+	CrossDoc   bool     `json:"cross_doc,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	Method     string   `json:"method,omitempty"`
+	Dimensions int      `json:"dimensions,omitempty"`
+	Words      []string `json:"words,omitempty"`
+	Sort       string   `json:"sort,omitempty"`
+}
+
+// analysisCacheKey combines the request parameters with a hash of the
+// statement set, so any upload, deletion or re-embedding yields a new key
+func analysisCacheKey(params analysisParams, statements []*storage.Statement) string {
+	h := sha256.New()
+	p, _ := json.Marshal(params)
+	h.Write(p)
+	h.Write([]byte(statementFingerprint(statements)))
+
+	return params.Kind + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// statementFingerprint hashes the IDs and embeddings of statements. It
+// changes with any upload, deletion or re-embedding.
+func statementFingerprint(statements []*storage.Statement) string {
+	h := sha256.New()
+	buf := make([]byte, 4)
+	for _, stmt := range statements {
+		h.Write(stmt.ID[:])
+		for _, v := range stmt.Embedding.Slice() {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+			h.Write(buf)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// analysisCache is an in-memory LRU cache of analysis responses. A nil cache
+// is valid and never stores anything.
+type analysisCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type analysisCacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// newAnalysisCache creates a cache holding up to maxEntries results for ttl.
+// It returns nil (caching disabled) if maxEntries <= 0.
+func newAnalysisCache(maxEntries int, ttl time.Duration) *analysisCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &analysisCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *analysisCache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*analysisCacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry if full
+func (c *analysisCache) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*analysisCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&analysisCacheEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*analysisCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached entries
+func (c *analysisCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// respondCached writes a cached analysis response if one exists for key. It
+// returns false, marking the response as a miss, when the result must be
+// computed.
+func (s *Server) respondCached(w http.ResponseWriter, key string) bool {
+	if s.analysisCache == nil {
+		return false
+	}
+	if cached, ok := s.analysisCache.Get(key); ok {
+		w.Header().Set("X-Analysis-Cache", "hit")
+		respondJSON(w, http.StatusOK, cached)
+		return true
+	}
+	w.Header().Set("X-Analysis-Cache", "miss")
+	return false
+}
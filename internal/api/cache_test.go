@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAnalysisCache_KeyedByParameters(t *testing.T) {
+	env := newTestEnv(t, "")
+	env.server.analysisCache = newAnalysisCache(16, time.Minute)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	get := func(query string) string {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/clusters?%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		return rec.Header().Get("X-Analysis-Cache")
+	}
+
+	if got := get("k=2"); got != "miss" {
+		t.Errorf("first k=2 request: expected miss, got %q", got)
+	}
+	if got := get("k=3"); got != "miss" {
+		t.Errorf("k=3 must not reuse the k=2 result, got %q", got)
+	}
+	if n := env.server.analysisCache.Len(); n != 2 {
+		t.Errorf("expected separate entries for k=2 and k=3, got %d", n)
+	}
+	if got := get("k=2"); got != "hit" {
+		t.Errorf("repeated k=2 request: expected hit, got %q", got)
+	}
+
+	// Adding statements changes the statement-set hash
+	env.seedDocument(t, project.ID, "b.md", "a new statement that changes the set")
+	if got := get("k=2"); got != "miss" {
+		t.Errorf("k=2 after upload: expected miss, got %q", got)
+	}
+}
+
+func TestAnalysisCache_EvictsAndExpires(t *testing.T) {
+	c := newAnalysisCache(2, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1 to remain, got %v %v", v, ok)
+	}
+
+	expiring := newAnalysisCache(2, time.Nanosecond)
+	expiring.Set("a", 1)
+	time.Sleep(time.Millisecond)
+	if _, ok := expiring.Get("a"); ok {
+		t.Error("expected expired entry to be dropped")
+	}
+
+	disabled := newAnalysisCache(0, time.Minute)
+	disabled.Set("a", 1)
+	if _, ok := disabled.Get("a"); ok {
+		t.Error("expected disabled cache to store nothing")
+	}
+}
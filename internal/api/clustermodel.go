@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/clustering"
+	"github.com/todmy/doc-analyzer/internal/storage"
+	"github.com/todmy/doc-analyzer/pkg/models"
+)
+
+// clusterRefitGrowth is how much a project may grow, as a fraction of the
+// statements of the last full fit, before new statements stop being assigned
+// to the existing centroids and k-means is re-run
+const clusterRefitGrowth = 0.25
+
+// Values of the X-Clustering header
+const (
+	clusteringFull        = "full"
+	clusteringIncremental = "incremental"
+	clusteringStored      = "stored"
+	clusteringPreview     = "preview"
+)
+
+// clusterProject clusters a project's statements with k clusters (0 picks k
+// automatically). Statements are assigned to the project's stored centroids
+// when they fit the same k and the project grew by at most
+// clusterRefitGrowth since; otherwise, or when refit is set, k-means runs on
+// everything and the new centroids are stored. maxIter > 0 runs a preview:
+// a full fit capped at maxIter iterations whose centroids are not stored. It
+// returns the result and whether it was a full, incremental or preview
+// clustering.
+func (s *Server) clusterProject(ctx context.Context, projectID uuid.UUID, statements []models.Statement, k int, refit bool, maxIter int) (*clustering.ClusterResult, string) {
+	if s.clusterModelRepo != nil && !refit && maxIter <= 0 {
+		model, err := s.clusterModelRepo.GetByProjectID(ctx, projectID)
+		if err != nil {
+			log.Printf("[clusters] failed to load cluster model of project %s: %v", projectID, err)
+		}
+		if model != nil && model.RequestedK == k && !clusterModelStale(model, len(statements)) {
+			if result := s.clusteringService.AssignToExistingClusters(statements, model.Centroids); result != nil {
+				return result, clusteringIncremental
+			}
+		}
+	}
+
+	var result *clustering.ClusterResult
+	switch {
+	case k > 0:
+		result = s.clusteringService.ClusterStatementsWithMaxIter(statements, k, maxIter)
+	case maxIter > 0:
+		_, elbowK := s.clusteringService.ElbowCurve(statements, 10)
+		result = s.clusteringService.ClusterStatementsWithMaxIter(statements, elbowK, maxIter)
+	default:
+		result = s.clusteringService.AutoCluster(statements, 10)
+	}
+
+	// A preview may be far from converged; keep it out of the stored model
+	if maxIter > 0 {
+		return result, clusteringPreview
+	}
+
+	if s.clusterModelRepo != nil && len(result.Clusters) > 0 {
+		model := &storage.ClusterModel{
+			ProjectID:        projectID,
+			RequestedK:       k,
+			Centroids:        make([][]float32, len(result.Clusters)),
+			FittedStatements: len(statements),
+		}
+		for i, c := range result.Clusters {
+			model.Centroids[i] = c.Centroid
+		}
+		if err := s.clusterModelRepo.Save(ctx, model); err != nil {
+			log.Printf("[clusters] failed to save cluster model of project %s: %v", projectID, err)
+		}
+	}
+
+	return result, clusteringFull
+}
+
+// clusterModelStale reports whether a project with n statements has changed
+// too much since model was fitted to keep using its centroids. Shrinking
+// projects are always refitted since removed statements shaped the centroids.
+func clusterModelStale(model *storage.ClusterModel, n int) bool {
+	if n < model.FittedStatements {
+		return true
+	}
+	return float64(n-model.FittedStatements) > clusterRefitGrowth*float64(model.FittedStatements)
+}
+
+// storedClusters returns the project's stored clustering if it was computed
+// for k from exactly the statements behind fingerprint, or nil. statements
+// supply the text of each cluster's representative.
+func (s *Server) storedClusters(ctx context.Context, projectID uuid.UUID, k int, fingerprint string, statements []*storage.Statement) []ClusterResponse {
+	stored := s.loadClustering(ctx, projectID, k, fingerprint)
+	if stored == nil {
+		return nil
+	}
+
+	texts := make(map[uuid.UUID]string, len(statements))
+	for _, stmt := range statements {
+		texts[stmt.ID] = stmt.Text
+	}
+
+	response := make([]ClusterResponse, len(stored.Clusters))
+	for i, c := range stored.Clusters {
+		response[i] = ClusterResponse{
+			ID:       c.Label,
+			Keywords: c.Keywords,
+			Size:     c.Size,
+			Density:  c.Density,
+		}
+		if text, ok := texts[c.RepresentativeID]; ok {
+			response[i].Representative = &RepresentativeStatement{ID: c.RepresentativeID.String(), Text: text}
+		}
+	}
+	return response
+}
+
+// loadClustering returns the project's stored clustering if it was computed
+// for k from exactly the statements behind fingerprint, or nil
+func (s *Server) loadClustering(ctx context.Context, projectID uuid.UUID, k int, fingerprint string) *storage.Clustering {
+	if s.clusterRepo == nil {
+		return nil
+	}
+	stored, err := s.clusterRepo.GetByProjectID(ctx, projectID)
+	if err != nil {
+		log.Printf("[clusters] failed to load clusters of project %s: %v", projectID, err)
+		return nil
+	}
+	if stored == nil || stored.RequestedK != k || stored.Fingerprint != fingerprint {
+		return nil
+	}
+	return stored
+}
+
+// projectClusterLabels returns the cluster label of each statement using the
+// project's configured k, so cluster-scoped analyses see the same clusters
+// as the clusters endpoint. The stored clustering is reused while the
+// statements are unchanged; otherwise the project is clustered and the
+// result stored.
+func (s *Server) projectClusterLabels(ctx context.Context, project *storage.Project, statements []*storage.Statement, modelStatements []models.Statement) []int {
+	k := project.Analysis.ClusterK
+	fingerprint := statementFingerprint(statements)
+
+	if stored := s.loadClustering(ctx, project.ID, k, fingerprint); stored != nil {
+		byID := make(map[uuid.UUID]int, len(statements))
+		for _, c := range stored.Clusters {
+			for _, id := range c.StatementIDs {
+				byID[id] = c.Label
+			}
+		}
+		labels := make([]int, len(statements))
+		complete := true
+		for i, stmt := range statements {
+			label, ok := byID[stmt.ID]
+			if !ok {
+				complete = false
+				break
+			}
+			labels[i] = label
+		}
+		if complete {
+			return labels
+		}
+	}
+
+	result, _ := s.clusterProject(ctx, project.ID, modelStatements, k, false, 0)
+	s.saveClusters(ctx, project.ID, k, fingerprint, statements, result)
+	return result.Labels
+}
+
+// saveClusters stores result, computed for k from statements, as the
+// project's clustering. Failures are logged since the result is still served.
+func (s *Server) saveClusters(ctx context.Context, projectID uuid.UUID, k int, fingerprint string, statements []*storage.Statement, result *clustering.ClusterResult) {
+	if s.clusterRepo == nil || len(result.Clusters) == 0 {
+		return
+	}
+
+	stored := &storage.Clustering{
+		ProjectID:   projectID,
+		RequestedK:  k,
+		Fingerprint: fingerprint,
+		Clusters:    make([]*storage.Cluster, len(result.Clusters)),
+	}
+	byLabel := make(map[int]*storage.Cluster, len(result.Clusters))
+	for i, c := range result.Clusters {
+		keywords := make([]string, len(c.Keywords))
+		for j, kw := range c.Keywords {
+			keywords[j] = kw.Word
+		}
+		stored.Clusters[i] = &storage.Cluster{
+			Label:    c.ID,
+			Keywords: keywords,
+			Size:     c.Size,
+			Density:  c.Density,
+		}
+		if c.Representative >= 0 && c.Representative < len(statements) {
+			stored.Clusters[i].RepresentativeID = statements[c.Representative].ID
+		}
+		byLabel[c.ID] = stored.Clusters[i]
+	}
+	for i, label := range result.Labels {
+		if c, ok := byLabel[label]; ok && i < len(statements) {
+			c.StatementIDs = append(c.StatementIDs, statements[i].ID)
+		}
+	}
+
+	if err := s.clusterRepo.Replace(ctx, stored); err != nil {
+		log.Printf("[clusters] failed to save clusters of project %s: %v", projectID, err)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// ProjectConfigRequest sets a project's analysis overrides. It replaces the
+// stored overrides; omitted or zero fields fall back to the server default.
+type ProjectConfigRequest struct {
+	// ClusterK is the number of clusters used when a request does not set
+	// ?k (0 chooses k automatically)
+	ClusterK int `json:"cluster_k,omitempty"`
+	// SimilarityThreshold is the default similar-pairs threshold, in (0, 1]
+	SimilarityThreshold float64 `json:"similarity_threshold,omitempty"`
+	// ContradictionMinSimilarity is the similarity a pair needs to be
+	// checked for contradictions, in (0, 1]
+	ContradictionMinSimilarity float64 `json:"contradiction_min_similarity,omitempty"`
+}
+
+// ProjectConfigResponse is the effective analysis configuration of a
+// project: its stored overrides merged with the server defaults
+type ProjectConfigResponse struct {
+	ClusterK                   int      `json:"cluster_k"`
+	SimilarityThreshold        float64  `json:"similarity_threshold"`
+	ContradictionMinSimilarity float64  `json:"contradiction_min_similarity"`
+	AnomalyDetector            string   `json:"anomaly_detector"`
+	AnomalyThreshold           float64  `json:"anomaly_threshold"`
+	EmbeddingModel             string   `json:"embedding_model"`
+	Language                   string   `json:"language"`
+	Preprocessing              []string `json:"preprocessing"`
+	// Overrides names the fields set by the project rather than defaulted
+	Overrides []string `json:"overrides"`
+}
+
+// handleGetProjectConfig returns the effective analysis configuration of a
+// project
+func (s *Server) handleGetProjectConfig(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s.projectConfig(project))
+}
+
+// handleUpdateProjectConfig replaces the analysis overrides of a project and
+// returns the resulting effective configuration
+func (s *Server) handleUpdateProjectConfig(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req ProjectConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.ClusterK < 0 {
+		respondError(w, http.StatusBadRequest, "cluster_k must not be negative")
+		return
+	}
+	if req.SimilarityThreshold < 0 || req.SimilarityThreshold > 1 {
+		respondError(w, http.StatusBadRequest, "similarity_threshold must be between 0 and 1")
+		return
+	}
+	if req.ContradictionMinSimilarity < 0 || req.ContradictionMinSimilarity > 1 {
+		respondError(w, http.StatusBadRequest, "contradiction_min_similarity must be between 0 and 1")
+		return
+	}
+
+	project.Analysis = storage.AnalysisConfig{
+		ClusterK:                   req.ClusterK,
+		SimilarityThreshold:        req.SimilarityThreshold,
+		ContradictionMinSimilarity: req.ContradictionMinSimilarity,
+	}
+	if err := s.projectRepo.Update(r.Context(), project); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s.projectConfig(project))
+}
+
+// projectConfig merges the project's overrides with the server defaults
+func (s *Server) projectConfig(project *storage.Project) ProjectConfigResponse {
+	config := ProjectConfigResponse{
+		ClusterK:                   project.Analysis.ClusterK,
+		SimilarityThreshold:        s.similarityThreshold(project),
+		ContradictionMinSimilarity: s.contradictionMinSimilarity(project),
+		AnomalyDetector:            string(s.anomalyService.GetDetector()),
+		AnomalyThreshold:           s.anomalyService.GetThreshold(),
+		EmbeddingModel:             s.embeddingModel,
+		Language:                   project.Language,
+		Preprocessing:              project.Preprocessing,
+		Overrides:                  []string{},
+	}
+	if s.embeddingClient != nil {
+		config.EmbeddingModel = s.embeddingClient.Model()
+	}
+	if config.EmbeddingModel == "" {
+		config.EmbeddingModel = embeddings.DefaultModel
+	}
+	if config.Preprocessing == nil {
+		config.Preprocessing = []string{}
+	}
+
+	if project.Analysis.ClusterK > 0 {
+		config.Overrides = append(config.Overrides, "cluster_k")
+	}
+	if project.Analysis.SimilarityThreshold > 0 {
+		config.Overrides = append(config.Overrides, "similarity_threshold")
+	}
+	if project.Analysis.ContradictionMinSimilarity > 0 {
+		config.Overrides = append(config.Overrides, "contradiction_min_similarity")
+	}
+
+	return config
+}
+
+// similarityThreshold returns the project's default similar-pairs threshold
+func (s *Server) similarityThreshold(project *storage.Project) float64 {
+	if project.Analysis.SimilarityThreshold > 0 {
+		return project.Analysis.SimilarityThreshold
+	}
+	return s.similarityService.GetThreshold()
+}
+
+// contradictionMinSimilarity returns the similarity a pair of the project
+// needs to be checked for contradictions
+func (s *Server) contradictionMinSimilarity(project *storage.Project) float64 {
+	if project.Analysis.ContradictionMinSimilarity > 0 {
+		return project.Analysis.ContradictionMinSimilarity
+	}
+	if s.contradictionService != nil {
+		return s.contradictionService.MinSimilarity()
+	}
+	return contradiction.DefaultServiceConfig().MinSimilarity
+}
+
+// candidateThreshold returns the similarity a pair needs to become a
+// contradiction candidate: ?candidate_threshold when set, else the project's
+// minimum. On an invalid value it responds 400 and returns false.
+func (s *Server) candidateThreshold(w http.ResponseWriter, r *http.Request, project *storage.Project) (float64, bool) {
+	v := r.URL.Query().Get("candidate_threshold")
+	if v == "" {
+		return s.contradictionMinSimilarity(project), true
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		respondError(w, http.StatusBadRequest, "candidate_threshold must be in (0, 1]")
+		return 0, false
+	}
+	return threshold, true
+}
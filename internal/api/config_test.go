@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+)
+
+func TestHandleGetProjectConfig(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	path := fmt.Sprintf("/api/v1/projects/%s/config", project.ID)
+
+	get := func() ProjectConfigResponse {
+		t.Helper()
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var config ProjectConfigResponse
+		decodeJSON(t, rec, &config)
+		return config
+	}
+
+	// Without overrides everything is the server default
+	config := get()
+	if config.ClusterK != 0 || config.SimilarityThreshold != 0.75 || config.ContradictionMinSimilarity != 0.5 {
+		t.Errorf("expected defaults, got %+v", config)
+	}
+	if config.EmbeddingModel != embeddings.DefaultModel || len(config.Overrides) != 0 {
+		t.Errorf("expected the default model and no overrides, got %+v", config)
+	}
+
+	rec := env.do(t, http.MethodPut, path, userID.String(), ProjectConfigRequest{
+		ClusterK:            4,
+		SimilarityThreshold: 0.9,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Stored overrides win, unset fields keep their defaults
+	config = get()
+	if config.ClusterK != 4 || config.SimilarityThreshold != 0.9 {
+		t.Errorf("expected the stored overrides, got %+v", config)
+	}
+	if config.ContradictionMinSimilarity != 0.5 || config.AnomalyThreshold != env.server.anomalyService.GetThreshold() {
+		t.Errorf("expected defaults for unset fields, got %+v", config)
+	}
+	if fmt.Sprint(config.Overrides) != "[cluster_k similarity_threshold]" {
+		t.Errorf("expected cluster_k and similarity_threshold overridden, got %v", config.Overrides)
+	}
+
+	// The overrides apply to analysis requests without explicit parameters
+	env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days",
+		"refunds are available for sixty days",
+		"support answers within one business day",
+		"support answers within two business days",
+		"annual plans renew automatically",
+	)
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters", project.ID), userID.String(), nil)
+	var clusters []ClusterResponse
+	decodeJSON(t, rec, &clusters)
+	if len(clusters) != 4 {
+		t.Errorf("expected the project's k of 4, got %d clusters", len(clusters))
+	}
+
+	rec = env.do(t, http.MethodPut, path, userID.String(), ProjectConfigRequest{SimilarityThreshold: 1.5})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a threshold above 1, got %d", rec.Code)
+	}
+
+	rec = env.do(t, http.MethodGet, path, uuid.NewString(), nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for another user, got %d", rec.Code)
+	}
+}
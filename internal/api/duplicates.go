@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// DuplicateDocument is one copy of a document uploaded to several projects
+type DuplicateDocument struct {
+	DocumentID      string `json:"document_id"`
+	ProjectID       string `json:"project_id"`
+	ProjectName     string `json:"project_name"`
+	Filename        string `json:"filename"`
+	EmbeddingTokens int    `json:"embedding_tokens"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// DuplicateGroup is a set of documents with the same content, oldest first
+type DuplicateGroup struct {
+	ContentHash string              `json:"content_hash"`
+	Documents   []DuplicateDocument `json:"documents"`
+	// RedundantEmbeddingTokens were spent embedding the copies after the
+	// first
+	RedundantEmbeddingTokens int `json:"redundant_embedding_tokens"`
+}
+
+// DuplicateDocumentsResponse lists the user's documents uploaded to more
+// than one project
+type DuplicateDocumentsResponse struct {
+	Groups                   []DuplicateGroup `json:"groups"`
+	RedundantDocuments       int              `json:"redundant_documents"`
+	RedundantEmbeddingTokens int              `json:"redundant_embedding_tokens"`
+}
+
+// handleListDuplicateDocuments groups the authenticated user's documents by
+// content hash across all their projects and reports the hashes held by
+// more than one document
+func (s *Server) handleListDuplicateDocuments(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	uid, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	documents, err := s.documentRepo.ListDuplicatesByUser(r.Context(), uid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch documents")
+		return
+	}
+
+	projects, err := s.projectRepo.GetByUserID(r.Context(), uid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch projects")
+		return
+	}
+	projectNames := make(map[uuid.UUID]string, len(projects))
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	response := DuplicateDocumentsResponse{Groups: []DuplicateGroup{}}
+	for _, group := range groupByContentHash(documents) {
+		dg := DuplicateGroup{ContentHash: group[0].ContentHash, Documents: make([]DuplicateDocument, len(group))}
+		for i, d := range group {
+			dg.Documents[i] = DuplicateDocument{
+				DocumentID:      d.ID.String(),
+				ProjectID:       d.ProjectID.String(),
+				ProjectName:     projectNames[d.ProjectID],
+				Filename:        d.Filename,
+				EmbeddingTokens: d.EmbeddingTokens,
+				CreatedAt:       d.CreatedAt.Format(time.RFC3339),
+			}
+			if i > 0 {
+				dg.RedundantEmbeddingTokens += d.EmbeddingTokens
+			}
+		}
+		response.Groups = append(response.Groups, dg)
+		response.RedundantDocuments += len(group) - 1
+		response.RedundantEmbeddingTokens += dg.RedundantEmbeddingTokens
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// groupByContentHash splits documents ordered by content hash into runs of
+// equal hash
+func groupByContentHash(documents []*storage.Document) [][]*storage.Document {
+	var groups [][]*storage.Document
+	for i, d := range documents {
+		if i == 0 || d.ContentHash != documents[i-1].ContentHash {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], d)
+	}
+	return groups
+}
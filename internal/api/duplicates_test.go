@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleListDuplicateDocuments(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID, otherID := uuid.New(), uuid.New()
+
+	spec := "Refunds are available for thirty days after the purchase date.\n"
+	first := env.seedProject(t, userID)
+	second := env.seedProject(t, userID)
+	other := env.seedProject(t, otherID)
+	for _, upload := range []struct {
+		project  uuid.UUID
+		user     uuid.UUID
+		filename string
+		content  string
+	}{
+		{first.ID, userID, "spec.md", spec},
+		{first.ID, userID, "notes.md", "Annual plans renew automatically unless they are cancelled first.\n"},
+		{second.ID, userID, "spec-copy.md", spec},
+		{other.ID, otherID, "spec.md", spec},
+	} {
+		if rec := env.upload(t, upload.project, upload.user.String(), upload.filename, upload.content); rec.Code != http.StatusCreated {
+			t.Fatalf("upload %s: expected status 201, got %d: %s", upload.filename, rec.Code, rec.Body.String())
+		}
+	}
+	// Tokens of the later copy were spent needlessly
+	docs, _ := env.documents.GetByProjectID(context.Background(), second.ID)
+	env.documents.AddEmbeddingTokens(context.Background(), docs[0].ID, 15)
+
+	rec := env.do(t, http.MethodGet, "/api/v1/documents/duplicates", userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp DuplicateDocumentsResponse
+	decodeJSON(t, rec, &resp)
+
+	if len(resp.Groups) != 1 || len(resp.Groups[0].Documents) != 2 {
+		t.Fatalf("expected one group of 2 documents, got %+v", resp.Groups)
+	}
+	projects := map[string]bool{}
+	for _, d := range resp.Groups[0].Documents {
+		projects[d.ProjectID] = true
+	}
+	if !projects[first.ID.String()] || !projects[second.ID.String()] {
+		t.Errorf("expected copies in both of the user's projects, got %+v", resp.Groups[0].Documents)
+	}
+	if resp.RedundantDocuments != 1 || resp.RedundantEmbeddingTokens != 15 {
+		t.Errorf("expected 1 redundant document of 15 tokens, got %d of %d", resp.RedundantDocuments, resp.RedundantEmbeddingTokens)
+	}
+
+	// A user without duplicates gets an empty list
+	rec = env.do(t, http.MethodGet, "/api/v1/documents/duplicates", otherID.String(), nil)
+	decodeJSON(t, rec, &resp)
+	if rec.Code != http.StatusOK || len(resp.Groups) != 0 {
+		t.Errorf("expected no duplicates for the other user, got %d: %+v", rec.Code, resp.Groups)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ElbowResponse is the k-means inertia curve of a project's embeddings.
+// Inertia is indexed by position in K.
+type ElbowResponse struct {
+	K       []int     `json:"k"`
+	Inertia []float64 `json:"inertia"`
+	// ElbowK is the knee of the curve, the k automatic clustering uses
+	ElbowK int `json:"elbow_k"`
+}
+
+// handleGetClusterElbow returns the inertia of k-means on the full
+// embeddings for k = 1..max_k, with the detected elbow, without storing a
+// clustering. max_k is clamped to the number of distinct embeddings.
+func (s *Server) handleGetClusterElbow(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	maxK := 10
+	if v := r.URL.Query().Get("max_k"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > maxMetricsK {
+			respondError(w, http.StatusBadRequest, "max_k must be between 1 and "+strconv.Itoa(maxMetricsK))
+			return
+		}
+		maxK = parsed
+	}
+
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+
+	cacheKey := analysisCacheKey(analysisParams{Kind: "cluster_elbow", K: maxK}, statements)
+	if len(statements) > 0 && s.respondCached(w, cacheKey) {
+		return
+	}
+
+	inertias, elbowK := s.clusteringService.ElbowCurve(s.convertToModelStatements(statements), maxK)
+	ks := make([]int, len(inertias))
+	for i := range ks {
+		ks[i] = i + 1
+	}
+
+	response := ElbowResponse{K: ks, Inertia: inertias, ElbowK: elbowK}
+	s.analysisCache.Set(cacheKey, response)
+	respondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/jsonpath"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// charsPerToken is the rough number of characters per embedding token used
+// for estimates; the provider reports exact counts only after the fact
+const charsPerToken = 4
+
+// AnalysisEstimateResponse is a dry-run estimate of what embedding and
+// contradiction analysis would cost for a project. Candidate pairs are
+// counted from the stored embeddings, so statements that were never
+// embedded do not contribute.
+type AnalysisEstimateResponse struct {
+	Statements         int      `json:"statements"`
+	EmbeddedStatements int      `json:"embedded_statements"`
+	Characters         int      `json:"characters"`
+	EmbeddingTokens    int      `json:"embedding_tokens"`
+	Model              string   `json:"model,omitempty"`
+	EstimatedCostUSD   *float64 `json:"estimated_cost_usd,omitempty"`
+	MinSimilarity      float64  `json:"min_similarity"`
+	CandidatePairs     int      `json:"candidate_pairs"`
+	MaxPairs           int      `json:"max_pairs"`
+	EstimatedLLMCalls  int      `json:"estimated_llm_calls"`
+}
+
+// handleGetAnalyzeEstimate estimates embedding tokens and contradiction
+// candidates for a project without calling any external API
+func (s *Server) handleGetAnalyzeEstimate(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+
+	response := AnalysisEstimateResponse{Statements: len(statements)}
+	embedded := make([]*storage.Statement, 0, len(statements))
+	for _, stmt := range statements {
+		response.Characters += len([]rune(stmt.Text))
+		if len(stmt.Embedding.Slice()) > 0 {
+			embedded = append(embedded, stmt)
+		}
+	}
+	response.EmbeddedStatements = len(embedded)
+	response.EmbeddingTokens = (response.Characters + charsPerToken - 1) / charsPerToken
+
+	if s.embeddingClient != nil {
+		response.Model = s.embeddingClient.Model()
+		if price, ok := embeddings.PricePerMillionTokens(response.Model); ok {
+			cost := float64(response.EmbeddingTokens) / 1e6 * price
+			response.EstimatedCostUSD = &cost
+		}
+	}
+
+	// Use the project's and configured thresholds when contradiction
+	// detection is set up, else the defaults it would start with;
+	// ?candidate_threshold previews a tighter or looser one
+	response.MinSimilarity, ok = s.candidateThreshold(w, r, project)
+	if !ok {
+		return
+	}
+	response.MaxPairs = contradiction.DefaultServiceConfig().MaxPairsToAnalyze
+	if s.contradictionService != nil {
+		response.MaxPairs = s.contradictionService.MaxPairs(contradiction.DetectOptions{})
+	}
+
+	embedded, ok = s.reconcileStatements(w, embedded)
+	if !ok {
+		return
+	}
+	vectors := make([][]float32, len(embedded))
+	for i, stmt := range embedded {
+		vectors[i] = stmt.Embedding.Slice()
+	}
+	response.CandidatePairs = len(similarity.FindSimilarPairs(vectors, response.MinSimilarity))
+	response.EstimatedLLMCalls = min(response.CandidatePairs, response.MaxPairs)
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// EmbeddingEstimateRequest is a file's content and its extraction mode, the
+// file type it would be uploaded as (md, txt, json, csv or xml; default txt)
+type EmbeddingEstimateRequest struct {
+	Content string `json:"content"`
+	Mode    string `json:"mode,omitempty"`
+	// TextColumns selects the CSV columns holding statement text, as the
+	// text_columns upload field does
+	TextColumns []string `json:"text_columns,omitempty"`
+	// JSONPath selects the JSON values to extract, as the json_path upload
+	// field does
+	JSONPath string `json:"json_path,omitempty"`
+	// XMLElements names the XML elements to extract, as the xml_elements
+	// upload field does
+	XMLElements []string `json:"xml_elements,omitempty"`
+}
+
+// EmbeddingEstimateResponse estimates the embedding tokens and cost of
+// uploading a file. Repeated statements are embedded once on upload, so
+// only unique statements count towards the tokens.
+type EmbeddingEstimateResponse struct {
+	Statements       int      `json:"statements"`
+	UniqueStatements int      `json:"unique_statements"`
+	Characters       int      `json:"characters"`
+	EmbeddingTokens  int      `json:"embedding_tokens"`
+	Model            string   `json:"model"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+}
+
+// handleEstimateEmbeddings extracts statements from content as an upload
+// would and estimates their embedding cost with the configured model. Nothing
+// is embedded or stored, so it works without an embedding API key.
+func (s *Server) handleEstimateEmbeddings(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.uploadLimit())
+	var req EmbeddingEstimateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("content exceeds the %d MB upload limit", s.uploadLimit()>>20))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ext := "." + strings.ToLower(strings.TrimPrefix(req.Mode, "."))
+	if req.Mode == "" {
+		ext = ".txt"
+	}
+	if !allowedUploadExts[ext] {
+		respondError(w, http.StatusBadRequest, "mode must be md, txt, json, csv or xml")
+		return
+	}
+
+	opts := extractOptions{TextColumns: req.TextColumns, XMLElements: req.XMLElements}
+	if req.JSONPath != "" {
+		path, err := jsonpath.Parse(req.JSONPath)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.JSONPath = path
+	}
+
+	statements, err := extractStatements(req.Content, uuid.Nil, ext, opts)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	texts := make([]string, len(statements))
+	for i, stmt := range statements {
+		texts[i] = stmt.Text
+	}
+	unique, _ := dedupeTexts(texts)
+
+	response := EmbeddingEstimateResponse{
+		Statements:       len(statements),
+		UniqueStatements: len(unique),
+		Model:            s.embeddingModel,
+	}
+	for _, text := range unique {
+		response.Characters += len([]rune(text))
+	}
+	response.EmbeddingTokens = (response.Characters + charsPerToken - 1) / charsPerToken
+
+	if s.embeddingClient != nil {
+		response.Model = s.embeddingClient.Model()
+	}
+	if response.Model == "" {
+		response.Model = embeddings.DefaultModel
+	}
+	if price, ok := embeddings.PricePerMillionTokens(response.Model); ok {
+		cost := float64(response.EmbeddingTokens) / 1e6 * price
+		response.EstimatedCostUSD = &cost
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleGetAnalyzeEstimate(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := []string{
+		"refunds are available for thirty days after purchase",
+		"refunds are available for sixty days after purchase",
+		"support tickets get answered within one business day",
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/analyze/estimate", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp AnalysisEstimateResponse
+	decodeJSON(t, rec, &resp)
+
+	chars := 0
+	for _, text := range texts {
+		chars += len(text)
+	}
+	if resp.Statements != 3 || resp.EmbeddedStatements != 3 {
+		t.Errorf("expected 3 statements, all embedded, got %d and %d", resp.Statements, resp.EmbeddedStatements)
+	}
+	if resp.Characters != chars {
+		t.Errorf("expected %d characters, got %d", chars, resp.Characters)
+	}
+	if want := (chars + 3) / 4; resp.EmbeddingTokens != want {
+		t.Errorf("expected %d estimated tokens, got %d", want, resp.EmbeddingTokens)
+	}
+	if resp.MinSimilarity != 0.5 {
+		t.Errorf("expected the default threshold 0.5, got %v", resp.MinSimilarity)
+	}
+	if resp.CandidatePairs != 1 {
+		t.Errorf("expected only the two refund statements to be a candidate pair, got %d", resp.CandidatePairs)
+	}
+	if resp.EstimatedLLMCalls != 1 {
+		t.Errorf("expected 1 estimated LLM call, got %d", resp.EstimatedLLMCalls)
+	}
+}
+
+func TestHandleEstimateEmbeddings_ScalesWithInput(t *testing.T) {
+	// No embedding URL: estimates must not need an API key
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+
+	estimate := func(paragraphs int) EmbeddingEstimateResponse {
+		t.Helper()
+		parts := make([]string, paragraphs)
+		for i := range parts {
+			parts[i] = fmt.Sprintf("Paragraph %d explains a distinct policy detail that customers should know about.", i)
+		}
+		body := EmbeddingEstimateRequest{Content: strings.Join(parts, "\n\n"), Mode: "md"}
+		rec := env.do(t, http.MethodPost, "/api/v1/embeddings/estimate", userID.String(), body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp EmbeddingEstimateResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	small, large := estimate(5), estimate(50)
+	if small.Statements != 5 || large.Statements != 50 {
+		t.Fatalf("expected 5 and 50 statements, got %d and %d", small.Statements, large.Statements)
+	}
+	if small.EmbeddingTokens <= 0 || large.EmbeddingTokens < 9*small.EmbeddingTokens {
+		t.Errorf("expected tokens to scale with input, got %d and %d", small.EmbeddingTokens, large.EmbeddingTokens)
+	}
+	if small.EstimatedCostUSD == nil || large.EstimatedCostUSD == nil || *large.EstimatedCostUSD <= *small.EstimatedCostUSD {
+		t.Errorf("expected the cost to grow with input, got %v and %v", small.EstimatedCostUSD, large.EstimatedCostUSD)
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/v1/embeddings/estimate", userID.String(), EmbeddingEstimateRequest{Content: "x", Mode: "pdf"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown mode, got %d", rec.Code)
+	}
+}
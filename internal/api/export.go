@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/todmy/doc-analyzer/internal/similarity"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// exportTable is an analysis result flattened for export: JSON is written as
+// is, CSV as header plus rows
+type exportTable struct {
+	json   interface{}
+	header []string
+	rows   [][]string
+}
+
+// handleExport downloads analysis results for spreadsheets. The type
+// parameter selects similar-pairs, anomalies or contradictions; format is
+// csv (default) or json. similar-pairs accepts threshold and anomalies
+// scope, as on their endpoints. Contradictions are exported from the review
+// worklist rather than re-run through the LLM, optionally filtered by status.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	kind := query.Get("type")
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		respondError(w, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+
+	var table *exportTable
+	switch kind {
+	case "similar-pairs":
+		threshold := s.similarityThreshold(project)
+		if v := query.Get("threshold"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > 1 {
+				respondError(w, http.StatusBadRequest, "threshold must be in (0, 1]")
+				return
+			}
+			threshold = parsed
+		}
+		statements, ok := s.exportStatements(w, r, project)
+		if !ok {
+			return
+		}
+		table = similarPairsTable(s.computeSimilarPairs(statements, similarity.FindOptions{Threshold: threshold}))
+
+	case "anomalies":
+		scope := query.Get("scope")
+		if scope != "" && scope != "global" && scope != "cluster" {
+			respondError(w, http.StatusBadRequest, "scope must be global or cluster")
+			return
+		}
+		statements, ok := s.exportStatements(w, r, project)
+		if !ok {
+			return
+		}
+		table = anomaliesTable(s.computeAnomalies(r.Context(), project, statements, scope))
+
+	case "contradictions":
+		status := query.Get("status")
+		if status != "" && !storage.IsValidReviewStatus(status) {
+			respondError(w, http.StatusBadRequest, "status must be open, accepted, or dismissed")
+			return
+		}
+		items, err := s.contradictionRepo.GetByProjectID(r.Context(), project.ID, status)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to fetch contradictions")
+			return
+		}
+		response := make([]ContradictionResponse, len(items))
+		for i, c := range items {
+			response[i] = contradictionToResponse(c)
+		}
+		table = contradictionsTable(response)
+
+	default:
+		respondError(w, http.StatusBadRequest, "type must be similar-pairs, anomalies or contradictions")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, kind, format))
+	if format == "json" {
+		respondJSON(w, http.StatusOK, table.json)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	cw := csv.NewWriter(w)
+	cw.Write(table.header)
+	for _, row := range table.rows {
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("[export] failed to write %s csv: %v", kind, err)
+	}
+}
+
+// exportStatements fetches and reconciles a project's statements. On
+// failure it writes the error response and returns false.
+func (s *Server) exportStatements(w http.ResponseWriter, r *http.Request, project *storage.Project) ([]*storage.Statement, bool) {
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return nil, false
+	}
+	return s.reconcileStatements(w, statements)
+}
+
+func similarPairsTable(pairs []SimilarPairResponse) *exportTable {
+	t := &exportTable{
+		json:   pairs,
+		header: []string{"statement1", "file1", "statement2", "file2", "similarity"},
+	}
+	for _, p := range pairs {
+		t.rows = append(t.rows, []string{p.Statement1, p.File1, p.Statement2, p.File2, formatScore(p.Similarity)})
+	}
+	return t
+}
+
+func anomaliesTable(anomalies []AnomalyResponse) *exportTable {
+	t := &exportTable{
+		json:   anomalies,
+		header: []string{"text", "file", "line", "score"},
+	}
+	for _, a := range anomalies {
+		t.rows = append(t.rows, []string{a.Text, a.File, strconv.Itoa(a.Line), formatScore(a.Score)})
+	}
+	return t
+}
+
+func contradictionsTable(contradictions []ContradictionResponse) *exportTable {
+	t := &exportTable{
+		json: contradictions,
+		header: []string{"id", "status", "statement1", "file1", "statement2", "file2",
+			"type", "severity", "confidence", "explanation"},
+	}
+	for _, c := range contradictions {
+		t.rows = append(t.rows, []string{c.ID, c.Status, c.Statement1, c.File1, c.Statement2, c.File2,
+			c.Type, c.Severity, formatScore(c.Confidence), c.Explanation})
+	}
+	return t
+}
+
+// formatScore writes a score with enough precision for spreadsheets
+func formatScore(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleExport(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "policy.md",
+		"refunds are available for 30 days",
+		"refunds are never available",
+		"refunds are available for 30 days, says the policy",
+	)
+	seedContradictions(t, env, project.ID, doc)
+
+	export := func(query string) ([][]string, http.Header) {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/export?%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: invalid csv: %v", query, err)
+		}
+		return records, rec.Header()
+	}
+
+	records, header := export("type=similar-pairs&threshold=0.5")
+	if ct := header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv content type, got %q", ct)
+	}
+	if cd := header.Get("Content-Disposition"); cd != `attachment; filename="similar-pairs.csv"` {
+		t.Errorf("unexpected content disposition %q", cd)
+	}
+	if strings.Join(records[0], ",") != "statement1,file1,statement2,file2,similarity" {
+		t.Errorf("unexpected header row %v", records[0])
+	}
+	if len(records) < 2 || records[1][1] != "policy.md" {
+		t.Errorf("expected similar pair rows, got %v", records)
+	}
+
+	records, _ = export("type=contradictions")
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 contradiction rows, got %v", records)
+	}
+	if records[1][6] != "direct" || records[1][7] != "high" {
+		t.Errorf("unexpected contradiction row %v", records[1])
+	}
+
+	records, _ = export("type=anomalies")
+	if strings.Join(records[0], ",") != "text,file,line,score" {
+		t.Errorf("unexpected anomalies header %v", records[0])
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/export?type=contradictions&format=json", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("json export: expected status 200, got %d", rec.Code)
+	}
+	var items []ContradictionResponse
+	decodeJSON(t, rec, &items)
+	if len(items) != 2 {
+		t.Errorf("expected 2 contradictions in json export, got %d", len(items))
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="contradictions.json"` {
+		t.Errorf("unexpected content disposition %q", cd)
+	}
+
+	for _, query := range []string{"", "type=clusters", "type=anomalies&format=xml", "type=anomalies&scope=x", "type=similar-pairs&threshold=2"} {
+		path := fmt.Sprintf("/api/v1/projects/%s/export?%s", project.ID, query)
+		if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
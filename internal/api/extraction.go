@@ -2,8 +2,15 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -11,6 +18,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/pgvector/pgvector-go"
 
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/jsonpath"
+	"github.com/todmy/doc-analyzer/internal/preprocess"
 	"github.com/todmy/doc-analyzer/internal/storage"
 )
 
@@ -31,20 +41,71 @@ const (
 	maxStatementLength = 1000
 )
 
-// extractStatements extracts statements from document content based on file extension
-func extractStatements(content string, documentID uuid.UUID, ext string) []*storage.Statement {
+// autoTextColumn selects the CSV column with the longest text
+const autoTextColumn = "auto"
+
+// extractOptions controls how statements are extracted from a document
+type extractOptions struct {
+	// TextColumns names the CSV header columns holding the statement text,
+	// or is ["auto"] to pick the column with the longest text. Empty joins
+	// every field of a row.
+	TextColumns []string
+	// JSONPath selects the JSON values statements are extracted from; nil
+	// extracts every string in the document
+	JSONPath *jsonpath.Path
+	// XMLElements names the XML elements whose text becomes a statement,
+	// including the text of nested elements. Empty takes the text of every
+	// leaf element.
+	XMLElements []string
+}
+
+// parseExtractOptions reads the text_columns, json_path and xml_elements
+// upload fields. An invalid JSON path is an error.
+func parseExtractOptions(r *http.Request) (extractOptions, error) {
+	opts := extractOptions{
+		TextColumns: parseTextColumns(r.FormValue("text_columns")),
+		XMLElements: parseTextColumns(r.FormValue("xml_elements")),
+	}
+	if expr := strings.TrimSpace(r.FormValue("json_path")); expr != "" {
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			return extractOptions{}, err
+		}
+		opts.JSONPath = path
+	}
+	return opts, nil
+}
+
+// parseTextColumns splits a comma-separated list such as text_columns
+func parseTextColumns(value string) []string {
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// extractStatements extracts statements from document content based on file
+// extension. It fails only for options that do not fit the content, such as
+// an unknown CSV column.
+func extractStatements(content string, documentID uuid.UUID, ext string, opts extractOptions) ([]*storage.Statement, error) {
 	switch ext {
 	case ".json":
-		return extractStatementsFromJSON(content, documentID)
+		return extractStatementsFromJSON(content, documentID, opts.JSONPath), nil
 	case ".csv":
-		return extractStatementsFromCSV(content, documentID)
+		return extractStatementsFromCSV(content, documentID, opts.TextColumns)
+	case ".xml":
+		return extractStatementsFromXML(content, documentID, opts.XMLElements), nil
 	default:
-		return extractStatementsFromText(content, documentID)
+		return extractStatementsFromText(content, documentID), nil
 	}
 }
 
-// extractStatementsFromJSON extracts statements from JSON content
-func extractStatementsFromJSON(content string, documentID uuid.UUID) []*storage.Statement {
+// extractStatementsFromJSON extracts statements from JSON content. With path
+// only the strings within the selected values are extracted.
+func extractStatementsFromJSON(content string, documentID uuid.UUID, path *jsonpath.Path) []*storage.Statement {
 	var statements []*storage.Statement
 	var data interface{}
 
@@ -53,7 +114,13 @@ func extractStatementsFromJSON(content string, documentID uuid.UUID) []*storage.
 	}
 
 	position := 0
-	extractJSONStrings(data, documentID, &statements, &position)
+	if path == nil {
+		extractJSONStrings(data, documentID, &statements, &position)
+		return statements
+	}
+	for _, value := range path.Select(data) {
+		extractJSONStrings(value, documentID, &statements, &position)
+	}
 	return statements
 }
 
@@ -85,20 +152,44 @@ func extractJSONStrings(data interface{}, documentID uuid.UUID, statements *[]*s
 	}
 }
 
-// extractStatementsFromCSV extracts statements from CSV content
-func extractStatementsFromCSV(content string, documentID uuid.UUID) []*storage.Statement {
+// extractStatementsFromCSV extracts statements from CSV content. With
+// textColumns the first row is the header naming the columns and each
+// following row contributes the text of those columns; otherwise all fields
+// of every row are combined.
+func extractStatementsFromCSV(content string, documentID uuid.UUID, textColumns []string) ([]*storage.Statement, error) {
 	var statements []*storage.Statement
 	reader := csv.NewReader(strings.NewReader(content))
 
 	records, err := reader.ReadAll()
 	if err != nil {
-		return statements
+		return statements, nil
+	}
+
+	var columns []int
+	start := 0
+	if len(textColumns) > 0 && len(records) > 0 {
+		columns, err = csvTextColumns(records, textColumns)
+		if err != nil {
+			return nil, err
+		}
+		start = 1
 	}
 
 	position := 0
-	for lineNum, record := range records {
-		// Combine all fields in the row
-		rowText := strings.Join(record, " ")
+	for lineNum := start; lineNum < len(records); lineNum++ {
+		record := records[lineNum]
+
+		// Combine the text columns, or all fields, of the row
+		fields := record
+		if columns != nil {
+			fields = make([]string, 0, len(columns))
+			for _, c := range columns {
+				if c < len(record) {
+					fields = append(fields, record[c])
+				}
+			}
+		}
+		rowText := strings.Join(fields, " ")
 		rowText = strings.TrimSpace(rowText)
 
 		if len(rowText) >= minStatementLength {
@@ -116,10 +207,150 @@ func extractStatementsFromCSV(content string, documentID uuid.UUID) []*storage.S
 		}
 	}
 
+	return statements, nil
+}
+
+// csvTextColumns resolves column names against the header row of records,
+// matching case-insensitively. "auto" selects the column holding the most
+// text.
+func csvTextColumns(records [][]string, names []string) ([]int, error) {
+	header := records[0]
+
+	if len(names) == 1 && strings.EqualFold(names[0], autoTextColumn) {
+		best, bestLen := 0, -1
+		for c := range header {
+			total := 0
+			for _, record := range records[1:] {
+				if c < len(record) {
+					total += utf8.RuneCountInString(strings.TrimSpace(record[c]))
+				}
+			}
+			if total > bestLen {
+				best, bestLen = c, total
+			}
+		}
+		return []int{best}, nil
+	}
+
+	columns := make([]int, 0, len(names))
+	for _, name := range names {
+		found := -1
+		for c, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				found = c
+				break
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("text column %q not found in CSV header", name)
+		}
+		columns = append(columns, found)
+	}
+	return columns, nil
+}
+
+// xmlFrame is an open element while walking an XML document
+type xmlFrame struct {
+	name       string
+	line       int
+	text       strings.Builder
+	hasChild   bool
+	collecting bool // a selected element gathering all nested text
+}
+
+// extractStatementsFromXML extracts statements from XML content such as
+// DocBook or DITA. With elements, the whitespace-normalized text of each
+// selected element, nested markup included, becomes a statement; otherwise
+// the text of every leaf element does. Element names match the local name
+// case-insensitively. Attributes, comments and processing instructions are
+// ignored and CDATA sections count as text. Statements found before a
+// syntax error are kept.
+func extractStatementsFromXML(content string, documentID uuid.UUID, elements []string) []*storage.Statement {
+	var statements []*storage.Statement
+
+	selected := make(map[string]bool, len(elements))
+	for _, name := range elements {
+		selected[strings.ToLower(name)] = true
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	// Content is already transcoded to UTF-8 whatever the declaration says
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var stack []*xmlFrame
+	collector := -1 // index in stack of the selected element gathering text
+	position := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) > 0 {
+				stack[len(stack)-1].hasChild = true
+			}
+			line, _ := decoder.InputPos()
+			frame := &xmlFrame{name: strings.ToLower(t.Name.Local), line: line}
+			if collector < 0 && selected[frame.name] {
+				frame.collecting = true
+				collector = len(stack)
+			}
+			stack = append(stack, frame)
+
+		case xml.CharData:
+			switch {
+			case collector >= 0:
+				stack[collector].text.WriteString(" ")
+				stack[collector].text.Write(t)
+			case len(stack) > 0 && len(selected) == 0:
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			emit := frame.collecting || (len(selected) == 0 && !frame.hasChild)
+			if frame.collecting {
+				collector = -1
+			}
+			if !emit {
+				continue
+			}
+
+			text := strings.Join(strings.Fields(frame.text.String()), " ")
+			if len(text) < minStatementLength {
+				continue
+			}
+			if len(text) > maxStatementLength {
+				text = truncateUTF8(text, maxStatementLength) + "..."
+			}
+			statements = append(statements, &storage.Statement{
+				DocumentID: documentID,
+				Text:       text,
+				Position:   position,
+				Line:       frame.line,
+				Embedding:  pgvector.NewVector(nil),
+			})
+			position++
+		}
+	}
+
 	return statements
 }
 
-// extractStatementsFromText extracts statements from markdown/text content
+// extractStatementsFromText extracts statements from markdown/text content.
+// Headers are not statements themselves; each statement records the header
+// hierarchy it sits under as its section.
 func extractStatementsFromText(content string, documentID uuid.UUID) []*storage.Statement {
 	var statements []*storage.Statement
 
@@ -128,6 +359,7 @@ func extractStatementsFromText(content string, documentID uuid.UUID) []*storage.
 
 	position := 0
 	line := 1
+	var headers []string // current header title per level
 
 	for _, para := range paragraphs {
 		para = strings.TrimSpace(para)
@@ -138,12 +370,37 @@ func extractStatementsFromText(content string, documentID uuid.UUID) []*storage.
 			continue
 		}
 
-		// Skip code blocks and headers
-		if strings.HasPrefix(para, "```") || strings.HasPrefix(para, "#") {
+		// Skip code blocks
+		if strings.HasPrefix(para, "```") {
 			line += strings.Count(para, "\n") + 1
 			continue
 		}
 
+		// Track headers; text directly below a header line belongs to it
+		for {
+			level, title, ok := parseMarkdownHeader(para)
+			if !ok {
+				break
+			}
+			if len(headers) >= level {
+				headers = headers[:level-1]
+			}
+			for len(headers) < level-1 {
+				headers = append(headers, "")
+			}
+			headers = append(headers, title)
+
+			line++
+			rest := ""
+			if i := strings.IndexByte(para, '\n'); i >= 0 {
+				rest = strings.TrimSpace(para[i+1:])
+			}
+			para = rest
+		}
+		if para == "" {
+			continue
+		}
+
 		// Clean the paragraph
 		para = cleanText(para)
 
@@ -163,6 +420,7 @@ func extractStatementsFromText(content string, documentID uuid.UUID) []*storage.
 			Text:       para,
 			Position:   position,
 			Line:       line,
+			Section:    sectionPath(headers),
 			Embedding:  pgvector.NewVector(nil), // Will be filled by embedding generation
 		})
 
@@ -173,6 +431,37 @@ func extractStatementsFromText(content string, documentID uuid.UUID) []*storage.
 	return statements
 }
 
+// parseMarkdownHeader parses an ATX header ("## Refund Policy") on the first
+// line of text, returning its level and title
+func parseMarkdownHeader(text string) (int, string, bool) {
+	first, _, _ := strings.Cut(text, "\n")
+	level := 0
+	for level < len(first) && first[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(first) && first[level] != ' ' && first[level] != '\t') {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(first[level:])
+	// Closing hashes are decoration: "## Refunds ##"
+	if trimmed := strings.TrimRight(title, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		title = strings.TrimSpace(trimmed)
+	}
+	return level, title, true
+}
+
+// sectionPath joins the current header hierarchy, skipping levels without a
+// header, e.g. "Billing > Refund Policy"
+func sectionPath(headers []string) string {
+	parts := make([]string, 0, len(headers))
+	for _, h := range headers {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
 // splitIntoParagraphs splits content into paragraphs
 func splitIntoParagraphs(content string) []string {
 	// Normalize line endings
@@ -249,33 +538,157 @@ func cleanText(text string) string {
 	return strings.TrimSpace(text)
 }
 
-// generateEmbeddingsForStatements generates embeddings for statements using the embedding client
+// embeddingErrorMessage turns an embedding failure into an actionable message
+// for API clients, without exposing raw provider responses
+func embeddingErrorMessage(err error) string {
+	var apiErr *embeddings.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.UserMessage()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "embedding provider timed out - try again later"
+	}
+	return "embedding generation failed"
+}
+
+// generateEmbeddingsForStatements generates embeddings for the statements
+// that have none yet using the embedding client, so a retry after a partial
+// failure only pays for the missing ones
 func (s *Server) generateEmbeddingsForStatements(ctx context.Context, statements []*storage.Statement) error {
+	_, err := s.generateEmbeddingsWithProgress(ctx, withoutEmbedding(statements), nil, nil)
+	return err
+}
+
+// withoutEmbedding returns the statements that have no embedding
+func withoutEmbedding(statements []*storage.Statement) []*storage.Statement {
+	var missing []*storage.Statement
+	for _, stmt := range statements {
+		if len(stmt.Embedding.Slice()) == 0 {
+			missing = append(missing, stmt)
+		}
+	}
+	return missing
+}
+
+// generateEmbeddingsWithProgress is generateEmbeddingsForStatements embedding
+// the text produced by the project's preprocessing pipeline (the statement
+// text itself is left unchanged) and reporting batch progress to progress,
+// which may be nil. It returns the tokens consumed, which are also set when
+// some batches failed; the statements of the batches that succeeded then
+// keep their embeddings and the others are left without.
+func (s *Server) generateEmbeddingsWithProgress(ctx context.Context, statements []*storage.Statement, pipeline preprocess.Pipeline, progress embeddings.ProgressFunc) (int, error) {
 	if s.embeddingClient == nil {
 		// If no embedding client, store statements without embeddings
-		return nil
+		return 0, nil
 	}
 
 	if len(statements) == 0 {
-		return nil
+		return 0, nil
 	}
 
-	// Extract texts
-	texts := make([]string, len(statements))
-	for i, stmt := range statements {
-		texts[i] = stmt.Text
+	texts, index := embeddingTexts(statements, pipeline)
+
+	// Progress is reported in statements, not unique texts
+	uniqueProgress := progress
+	if progress != nil && len(texts) < len(statements) {
+		uniqueProgress = func(embedded, total int) {
+			if embedded >= total {
+				progress(len(statements), len(statements))
+				return
+			}
+			progress(embedded*len(statements)/total, len(statements))
+		}
 	}
 
 	// Generate embeddings
-	embeddings, err := s.embeddingClient.EmbedTexts(ctx, texts)
-	if err != nil {
-		return err
+	vectors, tokens, err := s.embeddingClient.EmbedTextsWithUsage(ctx, texts, uniqueProgress)
+
+	// Fan the embeddings back out to every statement
+	model := s.embeddingClient.Model()
+	for i, stmt := range statements {
+		if index[i] >= len(vectors) || len(vectors[index[i]]) == 0 {
+			continue
+		}
+		stmt.Embedding = pgvector.NewVector(vectors[index[i]])
+		stmt.EmbeddingModel = model
+	}
+
+	return tokens, err
+}
+
+// streamEmbeddings is generateEmbeddingsWithProgress handing the statements
+// of each batch to persist as soon as the batch is embedded, so they can be
+// stored incrementally instead of after the whole document. Statements
+// sharing a text are handed over with the batch embedding that text. An
+// error from persist stops embedding and is returned.
+func (s *Server) streamEmbeddings(ctx context.Context, statements []*storage.Statement, pipeline preprocess.Pipeline, persist func([]*storage.Statement) error) (int, error) {
+	if s.embeddingClient == nil || len(statements) == 0 {
+		return 0, nil
 	}
 
-	// Assign embeddings to statements
-	for i, emb := range embeddings {
-		statements[i].Embedding = pgvector.NewVector(emb)
+	texts, index := embeddingTexts(statements, pipeline)
+	byText := make([][]*storage.Statement, len(texts))
+	for i, stmt := range statements {
+		byText[index[i]] = append(byText[index[i]], stmt)
 	}
 
-	return nil
+	model := s.embeddingClient.Model()
+	total := 0
+	err := s.embeddingClient.EmbedTextsStream(ctx, texts, func(start int, vectors [][]float32, tokens int) error {
+		total += tokens
+		var batch []*storage.Statement
+		for i, vector := range vectors {
+			if len(vector) == 0 {
+				continue
+			}
+			for _, stmt := range byText[start+i] {
+				stmt.Embedding = pgvector.NewVector(vector)
+				stmt.EmbeddingModel = model
+				batch = append(batch, stmt)
+			}
+		}
+		return persist(batch)
+	})
+	return total, err
+}
+
+// embeddingTexts returns the distinct texts to embed for statements, as
+// produced by the preprocessing pipeline, and for each statement the index
+// of its text. Repeated boilerplate is embedded once and shares a vector.
+func embeddingTexts(statements []*storage.Statement, pipeline preprocess.Pipeline) ([]string, []int) {
+	texts := make([]string, len(statements))
+	for i, stmt := range statements {
+		texts[i] = pipeline.Apply(stmt.Text)
+	}
+	texts, index := dedupeTexts(texts)
+	if len(texts) < len(statements) {
+		log.Printf("[embeddings] %d of %d statements are duplicates, embedding %d unique texts",
+			len(statements)-len(texts), len(statements), len(texts))
+	}
+	return texts, index
+}
+
+// dedupeTexts returns the distinct texts, keyed on a hash of the normalized
+// text, and for each input text the index of its distinct text
+func dedupeTexts(texts []string) ([]string, []int) {
+	seen := make(map[[sha256.Size]byte]int, len(texts))
+	unique := make([]string, 0, len(texts))
+	index := make([]int, len(texts))
+	for i, text := range texts {
+		key := sha256.Sum256([]byte(normalizeStatementText(text)))
+		j, ok := seen[key]
+		if !ok {
+			j = len(unique)
+			seen[key] = j
+			unique = append(unique, text)
+		}
+		index[i] = j
+	}
+	return unique, index
+}
+
+// normalizeStatementText lowercases text and collapses whitespace so that
+// statements differing only in case or spacing are treated as duplicates
+func normalizeStatementText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
 }
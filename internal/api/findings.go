@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/todmy/doc-analyzer/internal/similarity"
+)
+
+// Finding types in the unified findings feed
+const (
+	findingAnomaly       = "anomaly"
+	findingSimilarPair   = "similar_pair"
+	findingContradiction = "contradiction"
+)
+
+const (
+	defaultFindingsPageSize = 50
+	maxFindingsPageSize     = 200
+)
+
+// Finding is an anomaly, similar pair or contradiction in a common shape.
+// Score is the anomaly score, pair similarity or contradiction confidence.
+type Finding struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id,omitempty"`
+	Statements []string `json:"statements"`
+	Files      []string `json:"files"`
+	Score      float64  `json:"score"`
+	Severity   string   `json:"severity"`
+}
+
+// FindingsResponse is a page of the findings feed
+type FindingsResponse struct {
+	Items    []Finding `json:"items"`
+	Type     string    `json:"type,omitempty"`
+	Sort     string    `json:"sort"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Total    int       `json:"total"`
+}
+
+// handleGetFindings returns anomalies, similar pairs and contradictions as
+// one paginated feed for review dashboards. type filters to one kind; sort
+// is score (default) or severity, both descending. Contradictions come from
+// the review worklist, so the feed never triggers LLM calls.
+func (s *Server) handleGetFindings(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	kind := query.Get("type")
+	switch kind {
+	case "", findingAnomaly, findingSimilarPair, findingContradiction:
+	default:
+		respondError(w, http.StatusBadRequest, "type must be anomaly, similar_pair or contradiction")
+		return
+	}
+	order := query.Get("sort")
+	if order == "" {
+		order = "score"
+	}
+	if order != "score" && order != "severity" {
+		respondError(w, http.StatusBadRequest, "sort must be score or severity")
+		return
+	}
+	page, pageSize, ok := parsePage(w, r, defaultFindingsPageSize, maxFindingsPageSize)
+	if !ok {
+		return
+	}
+
+	findings := make([]Finding, 0)
+	if kind != findingContradiction {
+		statements, err := s.statementRepo.GetByProjectID(r.Context(), project.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+			return
+		}
+		statements, ok = s.reconcileStatements(w, statements)
+		if !ok {
+			return
+		}
+
+		if kind != findingSimilarPair {
+			for _, a := range s.computeAnomalies(r.Context(), project, statements, "") {
+				findings = append(findings, Finding{
+					Type:       findingAnomaly,
+					Statements: []string{a.Text},
+					Files:      []string{a.File},
+					Score:      a.Score,
+					Severity:   scoreSeverity(a.Score),
+				})
+			}
+		}
+		if kind != findingAnomaly {
+			for _, p := range s.computeSimilarPairs(statements, similarity.FindOptions{Threshold: s.similarityThreshold(project)}) {
+				findings = append(findings, Finding{
+					Type:       findingSimilarPair,
+					Statements: []string{p.Statement1, p.Statement2},
+					Files:      []string{p.File1, p.File2},
+					Score:      p.Similarity,
+					Severity:   scoreSeverity(p.Similarity),
+				})
+			}
+		}
+	}
+	if kind == "" || kind == findingContradiction {
+		items, err := s.contradictionRepo.GetByProjectID(r.Context(), project.ID, "")
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to fetch contradictions")
+			return
+		}
+		for _, c := range items {
+			findings = append(findings, Finding{
+				Type:       findingContradiction,
+				ID:         c.ID.String(),
+				Statements: []string{c.Text1, c.Text2},
+				Files:      []string{c.File1, c.File2},
+				Score:      c.Confidence,
+				Severity:   c.Severity,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if order == "severity" {
+			si, sj := severityRank(findings[i].Severity), severityRank(findings[j].Severity)
+			if si != sj {
+				return si > sj
+			}
+		}
+		return findings[i].Score > findings[j].Score
+	})
+
+	start, end := pageBounds(page, pageSize, len(findings))
+	respondJSON(w, http.StatusOK, FindingsResponse{
+		Items:    findings[start:end],
+		Type:     kind,
+		Sort:     order,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    len(findings),
+	})
+}
+
+// scoreSeverity grades anomaly scores and similarities, which have no
+// severity of their own, so they can be sorted alongside contradictions
+func scoreSeverity(score float64) string {
+	switch {
+	case score >= 0.9:
+		return "high"
+	case score >= 0.8:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
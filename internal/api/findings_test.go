@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleGetFindings(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "policy.md",
+		"refunds are available for 30 days",
+		"refunds are available for 30 days only",
+		"refunds are never available",
+		"the cafeteria serves lunch at noon",
+		"quarterly reports are due in march",
+		"passwords rotate every ninety days",
+	)
+	contradictions := seedContradictions(t, env, project.ID, doc)
+	contradictions[0].Severity = "low"
+	contradictions[0].Confidence = 0.99
+	env.contradictions.Upsert(t.Context(), contradictions[0])
+
+	get := func(query string) FindingsResponse {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/findings?%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp FindingsResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	resp := get("")
+	types := map[string]int{}
+	for i, f := range resp.Items {
+		types[f.Type]++
+		if i > 0 && f.Score > resp.Items[i-1].Score {
+			t.Errorf("items not sorted by score: %v before %v", resp.Items[i-1].Score, f.Score)
+		}
+	}
+	for _, want := range []string{findingAnomaly, findingSimilarPair, findingContradiction} {
+		if types[want] == 0 {
+			t.Errorf("expected %s findings in mixed feed, got %v", want, types)
+		}
+	}
+	if resp.Total != len(resp.Items) {
+		t.Errorf("expected all %d findings on the first page, got %d", resp.Total, len(resp.Items))
+	}
+
+	resp = get("sort=severity")
+	for i := 1; i < len(resp.Items); i++ {
+		prev, cur := resp.Items[i-1], resp.Items[i]
+		if severityRank(cur.Severity) > severityRank(prev.Severity) ||
+			(cur.Severity == prev.Severity && cur.Score > prev.Score) {
+			t.Errorf("items not sorted by severity then score: %+v before %+v", prev, cur)
+		}
+	}
+	// The high-confidence but low-severity contradiction sorts last among contradictions
+	var last Finding
+	for _, f := range resp.Items {
+		if f.Type == findingContradiction {
+			last = f
+		}
+	}
+	if last.ID != contradictions[0].ID.String() {
+		t.Errorf("expected low severity contradiction last, got %+v", last)
+	}
+
+	resp = get("type=contradiction&page_size=1&page=2")
+	if resp.Total != len(contradictions) || len(resp.Items) != 1 || resp.Items[0].Type != findingContradiction {
+		t.Errorf("expected page 2 of contradictions only, got %+v", resp)
+	}
+	if len(resp.Items[0].Statements) != 2 || resp.Items[0].Files[0] != "policy.md" {
+		t.Errorf("unexpected contradiction finding %+v", resp.Items[0])
+	}
+
+	for _, query := range []string{"type=cluster", "sort=date", "page=0"} {
+		path := fmt.Sprintf("/api/v1/projects/%s/findings?%s", project.ID, query)
+		if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
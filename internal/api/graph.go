@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// maxGraphChains caps the chains listed in a contradiction graph response
+const maxGraphChains = 1000
+
+// GraphNode is a statement involved in at least one contradiction
+type GraphNode struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	File   string `json:"file"`
+	Degree int    `json:"degree"`
+}
+
+// GraphEdge is a stored contradiction between two statements
+type GraphEdge struct {
+	ID         string  `json:"id"`
+	Source     string  `json:"source"`
+	Target     string  `json:"target"`
+	Type       string  `json:"type"`
+	Severity   string  `json:"severity"`
+	Confidence float64 `json:"confidence"`
+	Status     string  `json:"status"`
+}
+
+// GraphComponent is a connected group of contradicting statements
+type GraphComponent struct {
+	Statements     []string `json:"statements"`
+	Contradictions int      `json:"contradictions"`
+	Cyclic         bool     `json:"cyclic"`
+}
+
+// ContradictionGraphResponse describes the contradiction graph of a project.
+// Chains list statement triples A, B, C where B contradicts both A and C but
+// A and C are consistent with each other.
+type ContradictionGraphResponse struct {
+	Nodes           []GraphNode      `json:"nodes"`
+	Edges           []GraphEdge      `json:"edges"`
+	Components      []GraphComponent `json:"components"`
+	Chains          [][3]string      `json:"chains"`
+	ChainsTruncated bool             `json:"chains_truncated,omitempty"`
+}
+
+// handleGetContradictionGraph builds a graph from the project's stored
+// contradictions and reports its connected components and chains. Dismissed
+// contradictions are left out unless requested with ?status=dismissed.
+func (s *Server) handleGetContradictionGraph(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !storage.IsValidReviewStatus(status) {
+		respondError(w, http.StatusBadRequest, "status must be open, accepted, or dismissed")
+		return
+	}
+
+	items, err := s.contradictionRepo.GetByProjectID(r.Context(), project.ID, status)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch contradictions")
+		return
+	}
+
+	response := ContradictionGraphResponse{
+		Nodes:      []GraphNode{},
+		Edges:      []GraphEdge{},
+		Components: []GraphComponent{},
+		Chains:     [][3]string{},
+	}
+
+	pairs := make([][2]string, 0, len(items))
+	nodes := make(map[string]*GraphNode)
+	addNode := func(id, text, file string) {
+		if _, ok := nodes[id]; !ok {
+			nodes[id] = &GraphNode{ID: id, Text: text, File: file}
+		}
+	}
+	for _, c := range items {
+		if status == "" && c.Status == storage.ReviewStatusDismissed {
+			continue
+		}
+		source, target := c.Statement1ID.String(), c.Statement2ID.String()
+		addNode(source, c.Text1, c.File1)
+		addNode(target, c.Text2, c.File2)
+		pairs = append(pairs, [2]string{source, target})
+		response.Edges = append(response.Edges, GraphEdge{
+			ID:         c.ID.String(),
+			Source:     source,
+			Target:     target,
+			Type:       c.Type,
+			Severity:   c.Severity,
+			Confidence: c.Confidence,
+			Status:     c.Status,
+		})
+	}
+
+	graph := contradiction.NewGraph(pairs)
+	for _, component := range graph.Components() {
+		response.Components = append(response.Components, GraphComponent{
+			Statements:     component.Nodes,
+			Contradictions: component.Edges,
+			Cyclic:         component.Cyclic,
+		})
+		// Components list node IDs in sorted order, so nodes follow suit
+		for _, id := range component.Nodes {
+			node := nodes[id]
+			node.Degree = graph.Degree(id)
+			response.Nodes = append(response.Nodes, *node)
+		}
+	}
+
+	chains := graph.Chains(maxGraphChains + 1)
+	if len(chains) > maxGraphChains {
+		chains = chains[:maxGraphChains]
+		response.ChainsTruncated = true
+	}
+	for _, chain := range chains {
+		response.Chains = append(response.Chains, [3]string(chain))
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+func TestHandleGetContradictionGraph_Chain(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "policy.md",
+		"refunds are available for 30 days",
+		"refunds are never available",
+		"refunds are available for 60 days",
+	)
+	// Two contradictions joining three statements: A - B - C
+	items := seedContradictions(t, env, project.ID, doc)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 seeded contradictions, got %d", len(items))
+	}
+	// Pairs are stored in canonical order, so find the shared statement
+	middle := items[0].Statement1ID.String()
+	if items[0].Statement2ID == items[1].Statement1ID || items[0].Statement2ID == items[1].Statement2ID {
+		middle = items[0].Statement2ID.String()
+	}
+
+	path := fmt.Sprintf("/api/v1/projects/%s/contradictions/graph", project.ID)
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ContradictionGraphResponse
+	decodeJSON(t, rec, &resp)
+	if len(resp.Nodes) != 3 || len(resp.Edges) != 2 {
+		t.Fatalf("expected 3 nodes and 2 edges, got %d and %d", len(resp.Nodes), len(resp.Edges))
+	}
+	if len(resp.Components) != 1 {
+		t.Fatalf("expected one connected component, got %+v", resp.Components)
+	}
+	component := resp.Components[0]
+	if len(component.Statements) != 3 || component.Contradictions != 2 || component.Cyclic {
+		t.Errorf("expected an acyclic component of 3 statements and 2 contradictions, got %+v", component)
+	}
+	if len(resp.Chains) != 1 || resp.Chains[0][1] != middle {
+		t.Fatalf("expected one chain through %s, got %v", middle, resp.Chains)
+	}
+	for _, node := range resp.Nodes {
+		want := 1
+		if node.ID == middle {
+			want = 2
+		}
+		if node.Degree != want || node.Text == "" || node.File != "policy.md" {
+			t.Errorf("unexpected node %+v", node)
+		}
+	}
+
+	// Closing the triangle turns the chain into a cycle
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+	var ends []uuid.UUID
+	for _, stmt := range stmts {
+		if stmt.ID.String() != middle {
+			ends = append(ends, stmt.ID)
+		}
+	}
+	closing := &storage.Contradiction{ProjectID: project.ID, Statement1ID: ends[0], Statement2ID: ends[1], Type: "numerical", Severity: "medium"}
+	if err := env.contradictions.Upsert(context.Background(), closing); err != nil {
+		t.Fatalf("failed to seed contradiction: %v", err)
+	}
+
+	rec = env.do(t, http.MethodGet, path, userID.String(), nil)
+	resp = ContradictionGraphResponse{}
+	decodeJSON(t, rec, &resp)
+	if len(resp.Components) != 1 || !resp.Components[0].Cyclic || len(resp.Chains) != 0 {
+		t.Errorf("expected one cyclic component without chains, got %+v and %v", resp.Components, resp.Chains)
+	}
+
+	// Dismissed contradictions are left out
+	statusPath := fmt.Sprintf("/api/v1/projects/%s/contradictions/%s/status", project.ID, closing.ID)
+	env.do(t, http.MethodPut, statusPath, userID.String(), ContradictionStatusRequest{Status: "dismissed"})
+	rec = env.do(t, http.MethodGet, path, userID.String(), nil)
+	resp = ContradictionGraphResponse{}
+	decodeJSON(t, rec, &resp)
+	if len(resp.Edges) != 2 || len(resp.Chains) != 1 {
+		t.Errorf("expected dismissed contradiction to be excluded, got %d edges and %v", len(resp.Edges), resp.Chains)
+	}
+}
@@ -2,16 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/todmy/doc-analyzer/internal/auth"
 )
 
-// Health check
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
 // Auth handlers
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -36,7 +33,7 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 
 	user, err := s.authService.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
-		if err == auth.ErrUserExists {
+		if errors.Is(err, auth.ErrUserExists) {
 			respondError(w, http.StatusConflict, "user already exists")
 			return
 		}
@@ -66,12 +63,135 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := s.authService.Login(r.Context(), req.Email, req.Password)
+	tokens, err := s.authService.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			respondError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to log in")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// handleForgotPassword sends a password reset token. The response is the
+// same whether or not the email belongs to an account.
+func (s *Server) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		respondError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	// Any other failure is only logged: the answer must be the same whether
+	// or not the email belongs to an account
+	if err := s.authService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		if errors.Is(err, auth.ErrPasswordResetDisabled) {
+			respondError(w, http.StatusNotImplemented, "password reset is not configured")
+			return
+		}
+		log.Printf("[auth] password reset request failed: %v", err)
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"status": "if the email belongs to an account, a reset token has been sent",
+	})
+}
+
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		respondError(w, http.StatusBadRequest, "token and password are required")
+		return
+	}
+
+	if len(req.Password) < 8 {
+		respondError(w, http.StatusBadRequest, "password must be at least 8 characters")
+		return
+	}
+
+	if err := s.authService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken):
+			respondError(w, http.StatusBadRequest, "invalid or expired reset token")
+		case errors.Is(err, auth.ErrPasswordResetDisabled):
+			respondError(w, http.StatusNotImplemented, "password reset is not configured")
+		default:
+			respondError(w, http.StatusInternalServerError, "failed to reset password")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "password updated"})
+}
+
+// decodeRefreshToken reads the refresh_token field from a request body
+func decodeRefreshToken(r *http.Request) (string, bool) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		return "", false
+	}
+	return req.RefreshToken, true
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	refresh, ok := decodeRefreshToken(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	token, err := s.authService.RefreshToken(r.Context(), refresh)
 	if err != nil {
-		respondError(w, http.StatusUnauthorized, "invalid credentials")
+		if errors.Is(err, auth.ErrInvalidToken) {
+			respondError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to refresh token")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"token": token})
 }
 
+// handleLogout revokes the caller's access token and, if one is supplied in
+// the body, their refresh token
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if refresh, ok := decodeRefreshToken(r); ok {
+		err := s.authService.RevokeRefreshToken(r.Context(), refresh)
+		if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+			respondError(w, http.StatusInternalServerError, "failed to log out")
+			return
+		}
+	}
+
+	if err := s.authService.RevokeToken(r.Context(), claims); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to log out")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "logged out"})
+}
+
@@ -0,0 +1,573 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/auth"
+)
+
+// stubAuthService returns fixed errors from Register and Login
+type stubAuthService struct {
+	fakeAuthService
+	registerErr error
+	loginErr    error
+}
+
+func (s stubAuthService) Register(ctx context.Context, email, password string) (*auth.User, error) {
+	if s.registerErr != nil {
+		return nil, s.registerErr
+	}
+	return s.fakeAuthService.Register(ctx, email, password)
+}
+
+func (s stubAuthService) Login(ctx context.Context, email, password string) (*auth.TokenPair, error) {
+	if s.loginErr != nil {
+		return nil, s.loginErr
+	}
+	return &auth.TokenPair{AccessToken: "signed-token"}, nil
+}
+
+func TestAuthHandlers(t *testing.T) {
+	creds := map[string]string{"email": "user@example.com", "password": "correct-horse"}
+
+	tests := []struct {
+		name string
+		path string
+		stub stubAuthService
+		body interface{}
+		want int
+	}{
+		{"register", "/api/v1/auth/register", stubAuthService{}, creds, http.StatusCreated},
+		{"register existing user", "/api/v1/auth/register", stubAuthService{registerErr: auth.ErrUserExists}, creds, http.StatusConflict},
+		{"register short password", "/api/v1/auth/register", stubAuthService{}, map[string]string{"email": "a@b.c", "password": "short"}, http.StatusBadRequest},
+		{"login", "/api/v1/auth/login", stubAuthService{}, creds, http.StatusOK},
+		{"login bad credentials", "/api/v1/auth/login", stubAuthService{loginErr: auth.ErrInvalidCredentials}, creds, http.StatusUnauthorized},
+		{"login storage failure", "/api/v1/auth/login", stubAuthService{loginErr: errors.New("connection refused")}, creds, http.StatusInternalServerError},
+		{"login missing fields", "/api/v1/auth/login", stubAuthService{}, map[string]string{"email": "a@b.c"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, "")
+			env.server.authService = tt.stub
+
+			rec := env.do(t, http.MethodPost, tt.path, "", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+
+			if tt.want == http.StatusOK {
+				var resp map[string]string
+				decodeJSON(t, rec, &resp)
+				if resp["token"] != "signed-token" {
+					t.Errorf("expected token in response, got %v", resp)
+				}
+			}
+		})
+	}
+}
+
+// memUserRepo is an in-memory auth.UserRepository
+type memUserRepo struct {
+	mu    sync.Mutex
+	users map[string]*auth.User
+}
+
+func (r *memUserRepo) Create(ctx context.Context, user *auth.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.ID = uuid.NewString()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memUserRepo) GetByID(ctx context.Context, id string) (*auth.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[id], nil
+}
+
+func (r *memUserRepo) GetByEmail(ctx context.Context, email string) (*auth.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// failingUserRepo fails every lookup, like a database that is down
+type failingUserRepo struct {
+	memUserRepo
+	err error
+}
+
+func (r *failingUserRepo) GetByEmail(ctx context.Context, email string) (*auth.User, error) {
+	return nil, r.err
+}
+
+func TestAuthFlow_LoginStorageFailure(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	env.server.authService = auth.NewJWTService(config, &failingUserRepo{err: errors.New("connection refused")})
+
+	creds := map[string]string{"email": "frank@example.com", "password": "correct-horse"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 when the user store fails, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	env.server.authService = auth.NewJWTService(config, &failingUserRepo{err: auth.ErrUserNotFound})
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for an unknown user, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// memRefreshTokenRepo is an in-memory auth.RefreshTokenRepository
+type memRefreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*auth.RefreshToken
+}
+
+func (r *memRefreshTokenRepo) Create(ctx context.Context, token *auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uuid.NewString()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memRefreshTokenRepo) GetByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	return token, nil
+}
+
+func (r *memRefreshTokenRepo) Revoke(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			now := time.Now()
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// memDenylist is an in-memory auth.TokenDenylist
+type memDenylist struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func (d *memDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.revoked[jti] = expiresAt
+	return nil
+}
+
+func (d *memDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.revoked[jti]
+	return ok, nil
+}
+
+func TestAuthFlow_RefreshAndLogout(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	env.server.authService = auth.NewJWTService(config, &memUserRepo{users: map[string]*auth.User{}},
+		auth.WithRefreshTokens(&memRefreshTokenRepo{tokens: map[string]*auth.RefreshToken{}}),
+		auth.WithTokenDenylist(&memDenylist{revoked: map[string]time.Time{}}))
+	env.server.router = chi.NewRouter()
+	env.server.setupRoutes()
+
+	creds := map[string]string{"email": "carol@example.com", "password": "correct-horse"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/register", "", creds); rec.Code != http.StatusCreated {
+		t.Fatalf("register: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var tokens auth.TokenPair
+	decodeJSON(t, rec, &tokens)
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("expected access and refresh tokens, got %+v", tokens)
+	}
+
+	body := map[string]string{"refresh_token": tokens.RefreshToken}
+	rec = env.do(t, http.MethodPost, "/api/v1/auth/refresh", "", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var refreshed map[string]string
+	decodeJSON(t, rec, &refreshed)
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", refreshed["token"], nil); rec.Code != http.StatusOK {
+		t.Errorf("refreshed access token rejected: status %d", rec.Code)
+	}
+
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": "bogus"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown refresh token: expected status 401, got %d", rec.Code)
+	}
+
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/logout", "", body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("logout without access token: expected status 401, got %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/logout", tokens.AccessToken, body); rec.Code != http.StatusOK {
+		t.Fatalf("logout: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", tokens.AccessToken, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked access token: expected status 401, got %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", refreshed["token"], nil); rec.Code != http.StatusOK {
+		t.Errorf("other access token should remain valid after logout: status %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/refresh", "", body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked refresh token: expected status 401, got %d", rec.Code)
+	}
+}
+
+func (r *memUserRepo) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.TokenVersion++
+	return nil
+}
+
+// memResetTokenRepo is an in-memory auth.ResetTokenRepository
+type memResetTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*auth.PasswordResetToken
+}
+
+func (r *memResetTokenRepo) Create(ctx context.Context, token *auth.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uuid.NewString()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memResetTokenRepo) GetByHash(ctx context.Context, hash string) (*auth.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	return token, nil
+}
+
+func (r *memResetTokenRepo) MarkUsed(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			if token.UsedAt != nil {
+				return auth.ErrInvalidToken
+			}
+			now := time.Now()
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// captureResetSender records the reset tokens sent and announces each
+// delivery on sent, since tokens are sent in the background
+type captureResetSender struct {
+	mu     sync.Mutex
+	tokens map[string]string
+	sent   chan string
+	err    error
+}
+
+func newCaptureResetSender() *captureResetSender {
+	return &captureResetSender{tokens: map[string]string{}, sent: make(chan string, 10)}
+}
+
+func (s *captureResetSender) SendPasswordReset(ctx context.Context, email, token string) error {
+	s.mu.Lock()
+	s.tokens[email] = token
+	s.mu.Unlock()
+	s.sent <- email
+	return s.err
+}
+
+// wait returns the next email a token was sent to
+func (s *captureResetSender) wait(t *testing.T) string {
+	t.Helper()
+	select {
+	case email := <-s.sent:
+		return email
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a reset token to be sent")
+		return ""
+	}
+}
+
+func (s *captureResetSender) token(email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[email]
+}
+
+func TestAuthFlow_PasswordReset(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	sender := newCaptureResetSender()
+	env.server.authService = auth.NewJWTService(config, &memUserRepo{users: map[string]*auth.User{}},
+		auth.WithRefreshTokens(&memRefreshTokenRepo{tokens: map[string]*auth.RefreshToken{}}),
+		auth.WithPasswordReset(&memResetTokenRepo{tokens: map[string]*auth.PasswordResetToken{}}, sender))
+	env.server.router = chi.NewRouter()
+	env.server.setupRoutes()
+
+	creds := map[string]string{"email": "dave@example.com", "password": "correct-horse"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/register", "", creds); rec.Code != http.StatusCreated {
+		t.Fatalf("register: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var session auth.TokenPair
+	decodeJSON(t, rec, &session)
+
+	known := env.do(t, http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "dave@example.com"})
+	unknown := env.do(t, http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "nobody@example.com"})
+	if known.Code != http.StatusAccepted || unknown.Code != known.Code || unknown.Body.String() != known.Body.String() {
+		t.Errorf("forgot-password must not reveal accounts: got %d %q and %d %q",
+			known.Code, known.Body.String(), unknown.Code, unknown.Body.String())
+	}
+	if email := sender.wait(t); email != "dave@example.com" {
+		t.Fatalf("expected a token sent to dave@example.com, got %s", email)
+	}
+	if sender.token("nobody@example.com") != "" {
+		t.Error("expected no token to be sent for an unknown email")
+	}
+	token := sender.token("dave@example.com")
+	if token == "" {
+		t.Fatal("expected a reset token to be sent")
+	}
+
+	reset := map[string]string{"token": token, "password": "battery-staple"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/reset-password", "", reset); rec.Code != http.StatusOK {
+		t.Fatalf("reset: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/reset-password", "", reset); rec.Code != http.StatusBadRequest {
+		t.Errorf("reused token: expected status 400, got %d", rec.Code)
+	}
+
+	// Sessions opened before the reset are signed out
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", session.AccessToken, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token after reset: expected status 401, got %d", rec.Code)
+	}
+	refresh := map[string]string{"refresh_token": session.RefreshToken}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/refresh", "", refresh); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after reset: expected status 401, got %d", rec.Code)
+	}
+
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds); rec.Code != http.StatusUnauthorized {
+		t.Errorf("old password: expected status 401, got %d", rec.Code)
+	}
+	newCreds := map[string]string{"email": "dave@example.com", "password": "battery-staple"}
+	rec = env.do(t, http.MethodPost, "/api/v1/auth/login", "", newCreds)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("new password: expected status 200, got %d", rec.Code)
+	}
+	decodeJSON(t, rec, &session)
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", session.AccessToken, nil); rec.Code != http.StatusOK {
+		t.Errorf("access token after logging in again: expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestAuthFlow_PasswordResetDeliveryFailure(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	sender := newCaptureResetSender()
+	sender.err = errors.New("mailer unavailable")
+	env.server.authService = auth.NewJWTService(config, &memUserRepo{users: map[string]*auth.User{}},
+		auth.WithPasswordReset(&memResetTokenRepo{tokens: map[string]*auth.PasswordResetToken{}}, sender))
+
+	creds := map[string]string{"email": "erin@example.com", "password": "correct-horse"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/register", "", creds); rec.Code != http.StatusCreated {
+		t.Fatalf("register: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A failed delivery for a real account looks like any other request
+	known := env.do(t, http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "erin@example.com"})
+	unknown := env.do(t, http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "nobody@example.com"})
+	if known.Code != http.StatusAccepted || unknown.Code != known.Code || unknown.Body.String() != known.Body.String() {
+		t.Errorf("forgot-password must not reveal delivery failures: got %d %q and %d %q",
+			known.Code, known.Body.String(), unknown.Code, unknown.Body.String())
+	}
+	sender.wait(t)
+}
+
+func TestAuthFlow_PasswordResetNotConfigured(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	env.server.authService = auth.NewJWTService(config, &memUserRepo{users: map[string]*auth.User{}})
+
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]string{"email": "dave@example.com"}); rec.Code != http.StatusNotImplemented {
+		t.Errorf("forgot-password: expected status 501, got %d", rec.Code)
+	}
+	reset := map[string]string{"token": "token", "password": "battery-staple"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/reset-password", "", reset); rec.Code != http.StatusNotImplemented {
+		t.Errorf("reset-password: expected status 501, got %d", rec.Code)
+	}
+}
+
+// TestAuthFlow_Projects exercises the full request path with real JWTs:
+// register, log in, then create and list projects through the auth middleware
+func TestAuthFlow_Projects(t *testing.T) {
+	env := newTestEnv(t, "")
+	config := auth.DefaultConfig()
+	config.SecretKey = "test-secret"
+	env.server.authService = auth.NewJWTService(config, &memUserRepo{users: map[string]*auth.User{}})
+	// The auth middleware captures the service when routes are set up
+	env.server.router = chi.NewRouter()
+	env.server.setupRoutes()
+
+	login := func(email string) string {
+		t.Helper()
+		creds := map[string]string{"email": email, "password": "correct-horse"}
+		if rec := env.do(t, http.MethodPost, "/api/v1/auth/register", "", creds); rec.Code != http.StatusCreated {
+			t.Fatalf("register: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", creds)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("login: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp map[string]string
+		decodeJSON(t, rec, &resp)
+		return resp["token"]
+	}
+
+	alice := login("alice@example.com")
+	bob := login("bob@example.com")
+
+	rec := env.do(t, http.MethodPost, "/api/v1/projects", alice, ProjectRequest{Name: "policies"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create project: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created ProjectResponse
+	decodeJSON(t, rec, &created)
+
+	rec = env.do(t, http.MethodGet, "/api/v1/projects", alice, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list projects: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list []ProjectResponse
+	decodeJSON(t, rec, &list)
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("expected alice to see her project, got %+v", list)
+	}
+
+	rec = env.do(t, http.MethodGet, "/api/v1/projects", bob, nil)
+	decodeJSON(t, rec, &list)
+	if len(list) != 0 {
+		t.Errorf("expected bob to see no projects, got %+v", list)
+	}
+
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects/"+created.ID, bob, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for another user's project, got %d", rec.Code)
+	}
+
+	rename := ProjectRequest{Name: "policies 2024"}
+	if rec := env.do(t, http.MethodPut, "/api/v1/projects/"+created.ID, bob, rename); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 renaming another user's project, got %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodPut, "/api/v1/projects/"+created.ID, alice, ProjectRequest{Name: "  "}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a blank name, got %d", rec.Code)
+	}
+	rec = env.do(t, http.MethodPut, "/api/v1/projects/"+created.ID, alice, rename)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rename project: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var renamed ProjectResponse
+	decodeJSON(t, rec, &renamed)
+	if renamed.ID != created.ID || renamed.Name != "policies 2024" {
+		t.Errorf("expected the project renamed, got %+v", renamed)
+	}
+	rec = env.do(t, http.MethodGet, "/api/v1/projects/"+created.ID, alice, nil)
+	decodeJSON(t, rec, &renamed)
+	if renamed.Name != "policies 2024" {
+		t.Errorf("expected the new name to be stored, got %q", renamed.Name)
+	}
+
+	unknown := map[string]string{"email": "nobody@example.com", "password": "correct-horse"}
+	if rec := env.do(t, http.MethodPost, "/api/v1/auth/login", "", unknown); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for unknown email, got %d", rec.Code)
+	}
+
+	if rec := env.do(t, http.MethodGet, "/api/v1/projects", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a token, got %d", rec.Code)
+	}
+}
+
+func TestAuthMe(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.NewString()
+
+	rec := env.do(t, http.MethodGet, "/api/v1/auth/me", userID, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var me map[string]string
+	decodeJSON(t, rec, &me)
+	if me["id"] != userID || me["email"] != userID+"@example.com" {
+		t.Errorf("expected id and email from claims, got %v", me)
+	}
+
+	for name, token := range map[string]string{"missing token": "", "invalid token": "not-a-token"} {
+		rec := env.do(t, http.MethodGet, "/api/v1/auth/me", token, nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", name, rec.Code)
+			continue
+		}
+		var resp map[string]string
+		decodeJSON(t, rec, &resp)
+		if resp["error"] == "" {
+			t.Errorf("%s: expected JSON error body, got %q", name, rec.Body.String())
+		}
+	}
+}
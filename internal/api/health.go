@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds the database ping of a readiness check
+const healthCheckTimeout = 2 * time.Second
+
+// Component statuses of a readiness check
+const (
+	componentOK            = "ok"
+	componentUnavailable   = "unavailable"
+	componentNotConfigured = "not_configured"
+)
+
+// ComponentHealth is the status of one dependency. Error is only set when
+// the component is unavailable.
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthResponse is the result of a readiness check. Status is "ok" or
+// "unavailable"; optional services that are not configured do not make the
+// server unavailable.
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// handleHealthLive reports that the process is up, without touching any
+// dependency
+func (s *Server) handleHealthLive(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleHealthReady pings the database and reports whether the embedding
+// and contradiction services are configured. It responds 503 when a
+// required dependency is unavailable.
+func (s *Server) handleHealthReady(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{
+		Status: componentOK,
+		Components: map[string]ComponentHealth{
+			"database":       s.databaseHealth(r.Context()),
+			"embeddings":     configuredHealth(s.embeddingClient != nil),
+			"contradictions": configuredHealth(s.contradictionService != nil),
+		},
+	}
+	for _, c := range response.Components {
+		if c.Status == componentUnavailable {
+			response.Status = componentUnavailable
+		}
+	}
+
+	status := http.StatusOK
+	if response.Status != componentOK {
+		status = http.StatusServiceUnavailable
+	}
+	respondJSON(w, status, response)
+}
+
+// databaseHealth pings the database
+func (s *Server) databaseHealth(ctx context.Context) ComponentHealth {
+	if s.db == nil {
+		return ComponentHealth{Status: componentUnavailable, Error: "no database connection"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if err := s.db.PingContext(ctx); err != nil {
+		log.Printf("[health] database ping failed: %v", err)
+		return ComponentHealth{Status: componentUnavailable, Error: "ping failed"}
+	}
+	return ComponentHealth{Status: componentOK}
+}
+
+// configuredHealth reports an optional service as ok or not configured
+func configuredHealth(configured bool) ComponentHealth {
+	if configured {
+		return ComponentHealth{Status: componentOK}
+	}
+	return ComponentHealth{Status: componentNotConfigured}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHealth(t *testing.T) {
+	env := newTestEnv(t, "")
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	env.server.db = db
+
+	mock.ExpectPing()
+	rec := env.do(t, http.MethodGet, "/health/ready", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ready HealthResponse
+	decodeJSON(t, rec, &ready)
+	if ready.Status != "ok" || ready.Components["database"].Status != "ok" {
+		t.Errorf("expected a healthy database, got %+v", ready)
+	}
+	if ready.Components["embeddings"].Status != "not_configured" || ready.Components["contradictions"].Status != "not_configured" {
+		t.Errorf("expected unconfigured optional services, got %+v", ready.Components)
+	}
+
+	// A failing ping makes /health and /health/ready unavailable, but not
+	// the liveness check
+	for _, path := range []string{"/health", "/health/ready"} {
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		rec = env.do(t, http.MethodGet, path, "", nil)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected status 503, got %d", path, rec.Code)
+		}
+		var down HealthResponse
+		decodeJSON(t, rec, &down)
+		if down.Status != "unavailable" || down.Components["database"].Status != "unavailable" {
+			t.Errorf("%s: expected an unavailable database, got %+v", path, down)
+		}
+	}
+
+	rec = env.do(t, http.MethodGet, "/health/live", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness to stay 200, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// multipartTempPattern matches the files mime/multipart spills large uploads
+// to in os.TempDir. They are removed when a request ends, but a crash leaves
+// them behind.
+const multipartTempPattern = "multipart-*"
+
+// janitor periodically removes multipart temp files older than tempFileAge
+// from tempDir and forgets upload jobs that finished more than jobRetention
+// ago. tempDir must belong to the server alone, since other processes spill
+// to the same file names. An empty tempDir or a zero age or retention
+// disables that part of the sweep.
+type janitor struct {
+	interval     time.Duration
+	tempDir      string
+	tempFileAge  time.Duration
+	jobRetention time.Duration
+	jobs         *jobStore
+	now          func() time.Time
+}
+
+// newJanitor returns a janitor sweeping every interval, or nil (no cleanup)
+// if interval <= 0
+func newJanitor(interval time.Duration, tempDir string, tempFileAge, jobRetention time.Duration, jobs *jobStore) *janitor {
+	if interval <= 0 {
+		return nil
+	}
+	return &janitor{
+		interval:     interval,
+		tempDir:      tempDir,
+		tempFileAge:  tempFileAge,
+		jobRetention: jobRetention,
+		jobs:         jobs,
+		now:          time.Now,
+	}
+}
+
+// run sweeps every interval until ctx is done. A nil janitor returns at once.
+func (j *janitor) run(ctx context.Context) {
+	if j == nil {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			files, jobs := j.sweep()
+			if files > 0 || jobs > 0 {
+				log.Printf("[janitor] removed %d temp files and %d finished jobs", files, jobs)
+			}
+		}
+	}
+}
+
+// sweep performs one cleanup pass and returns the number of temp files and
+// jobs removed
+func (j *janitor) sweep() (int, int) {
+	now := j.now()
+
+	files := 0
+	if j.tempDir != "" && j.tempFileAge > 0 {
+		files = removeOldFiles(filepath.Join(j.tempDir, multipartTempPattern), now.Add(-j.tempFileAge))
+	}
+
+	jobs := 0
+	if j.jobRetention > 0 && j.jobs != nil {
+		jobs = j.jobs.prune(now.Add(-j.jobRetention))
+	}
+
+	return files, jobs
+}
+
+// removeOldFiles deletes the regular files matching pattern last modified
+// before cutoff. Files that vanish or cannot be removed are skipped.
+func removeOldFiles(pattern string, cutoff time.Time) int {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("[janitor] failed to remove %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
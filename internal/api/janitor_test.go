@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestJanitor_PrunesOldJobsAndTempFiles(t *testing.T) {
+	jobs := newJobStore()
+	finish := func(age time.Duration, stage string) string {
+		job := jobs.create(uuid.New(), UploadResponse{Filename: "a.md"}, 1)
+		jobs.update(job.ID, func(j *JobStatus) { j.Stage = stage })
+		jobs.jobs[job.ID].status.UpdatedAt = time.Now().Add(-age)
+		return job.ID
+	}
+	old := finish(48*time.Hour, jobStageCompleted)
+	recent := finish(time.Minute, jobStageFailed)
+	running := finish(48*time.Hour, jobStageEmbedding)
+
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "multipart-old")
+	newFile := filepath.Join(dir, "multipart-new")
+	other := filepath.Join(dir, "unrelated")
+	for _, path := range []string{oldFile, newFile, other} {
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	for _, path := range []string{oldFile, other} {
+		if err := os.Chtimes(path, stale, stale); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := newJanitor(time.Minute, dir, time.Hour, 24*time.Hour, jobs)
+	files, pruned := j.sweep()
+	if files != 1 || pruned != 1 {
+		t.Errorf("expected 1 temp file and 1 job removed, got %d and %d", files, pruned)
+	}
+
+	if _, ok := jobs.get(old); ok {
+		t.Error("expected the old finished job to be pruned")
+	}
+	if _, ok := jobs.get(recent); !ok {
+		t.Error("expected the recently finished job to be kept")
+	}
+	if _, ok := jobs.get(running); !ok {
+		t.Error("expected the running job to be kept")
+	}
+	for path, want := range map[string]bool{oldFile: false, newFile: true, other: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s: expected exists=%v, got err %v", filepath.Base(path), want, err)
+		}
+	}
+
+	// Nothing left to do is not an error
+	if files, pruned := j.sweep(); files != 0 || pruned != 0 {
+		t.Errorf("second sweep: expected nothing removed, got %d and %d", files, pruned)
+	}
+
+	// Without a temp dir of its own the janitor leaves temp files alone, even
+	// those in os.TempDir
+	t.Setenv("TMPDIR", dir)
+	if err := os.Chtimes(newFile, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := newJanitor(time.Minute, "", time.Hour, 0, nil).sweep(); files != 0 {
+		t.Errorf("expected no temp files removed without a temp dir, got %d", files)
+	}
+	if newJanitor(0, dir, time.Hour, time.Hour, jobs) != nil {
+		t.Error("expected a zero interval to disable the janitor")
+	}
+}
+
+func TestServerRun_StopsJanitorWithContext(t *testing.T) {
+	s, err := NewServer(ServerConfig{JWTSecret: "secret", JanitorInterval: 5 * time.Millisecond, JobRetention: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx, "127.0.0.1:0") }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Run to return once its context is done")
+	}
+
+	// The janitor stopped with the server, so an expired job is kept
+	job := s.jobs.create(uuid.New(), UploadResponse{Filename: "a.md"}, 1)
+	s.jobs.update(job.ID, func(j *JobStatus) { j.Stage = jobStageCompleted })
+	s.jobs.mu.Lock()
+	s.jobs.jobs[job.ID].status.UpdatedAt = time.Now().Add(-48 * time.Hour)
+	s.jobs.mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := s.jobs.get(job.ID); !ok {
+		t.Error("expected the janitor to stop when Run returned")
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// Upload job stages, in order. A job ends in completed or failed.
+const (
+	jobStageEmbedding = "embedding"
+	jobStageSaving    = "saving"
+	jobStageCompleted = "completed"
+	jobStageFailed    = "failed"
+)
+
+// JobStatus reports the progress of an asynchronous document upload
+type JobStatus struct {
+	ID         string    `json:"id"`
+	ProjectID  string    `json:"project_id"`
+	DocumentID string    `json:"document_id"`
+	Filename   string    `json:"filename"`
+	Stage      string    `json:"stage"`
+	Embedded   int       `json:"embedded"`
+	Total      int       `json:"total"`
+	Warning    string    `json:"warning,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Finished reports whether the job has completed or failed
+func (j JobStatus) Finished() bool {
+	return j.Stage == jobStageCompleted || j.Stage == jobStageFailed
+}
+
+// jobStore tracks upload jobs in memory and fans status updates out to
+// subscribers. Jobs are lost on restart.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*jobEntry
+}
+
+type jobEntry struct {
+	status      JobStatus
+	subscribers map[chan JobStatus]struct{}
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{jobs: make(map[string]*jobEntry)}
+}
+
+// create registers a job for an extracted document about to be embedded
+func (s *jobStore) create(projectID uuid.UUID, upload UploadResponse, total int) JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := JobStatus{
+		ID:         uuid.NewString(),
+		ProjectID:  projectID.String(),
+		DocumentID: upload.DocumentID,
+		Filename:   upload.Filename,
+		Stage:      jobStageEmbedding,
+		Total:      total,
+		UpdatedAt:  time.Now(),
+	}
+	s.jobs[status.ID] = &jobEntry{status: status, subscribers: make(map[chan JobStatus]struct{})}
+	return status
+}
+
+// get returns the current status of a job
+func (s *jobStore) get(id string) (JobStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.jobs[id]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return entry.status, true
+}
+
+// update applies fn to a job's status and notifies subscribers. Slow
+// subscribers only see the latest status.
+func (s *jobStore) update(id string, fn func(*JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	fn(&entry.status)
+	entry.status.UpdatedAt = time.Now()
+
+	for ch := range entry.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- entry.status
+	}
+}
+
+// prune forgets finished jobs last updated before cutoff and returns how
+// many were removed. Jobs still running are kept however old they are.
+func (s *jobStore) prune(cutoff time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, entry := range s.jobs {
+		if entry.status.Finished() && entry.status.UpdatedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// subscribe returns a channel receiving status updates of a job along with
+// its current status. The returned cancel func must be called when done.
+func (s *jobStore) subscribe(id string) (<-chan JobStatus, JobStatus, func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.jobs[id]
+	if !ok {
+		return nil, JobStatus{}, nil, false
+	}
+
+	ch := make(chan JobStatus, 1)
+	entry.subscribers[ch] = struct{}{}
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(entry.subscribers, ch)
+	}
+	return ch, entry.status, cancel, true
+}
+
+// startUploadJob embeds and saves a prepared document and its statements in
+// the background, recording progress in the job store. The document is only
+// listed once the job completes.
+func (s *Server) startUploadJob(project *storage.Project, upload UploadResponse, doc *storage.Document, statements []*storage.Statement) JobStatus {
+	job := s.jobs.create(project.ID, upload, len(statements))
+
+	go func() {
+		// The request context ends with the response, so the job gets its own
+		ctx := context.Background()
+		progress := func(embedded, total int) {
+			s.jobs.update(job.ID, func(j *JobStatus) {
+				j.Embedded = embedded
+				if embedded == total {
+					j.Stage = jobStageSaving
+				}
+			})
+		}
+
+		warning, err := s.storeDocument(ctx, project, doc, statements, progress)
+		s.jobs.update(job.ID, func(j *JobStatus) {
+			j.Warning = warning
+			if err != nil {
+				j.Stage = jobStageFailed
+				j.Error = err.Error()
+				return
+			}
+			j.Stage = jobStageCompleted
+		})
+		if err != nil {
+			log.Printf("[upload] job %s failed: %v", job.ID, err)
+		}
+	}()
+
+	return job
+}
+
+// authorizeJob resolves the jobID URL parameter to a job of the authorized
+// project, writing a 404 if there is none
+func (s *Server) authorizeJob(w http.ResponseWriter, r *http.Request) (JobStatus, bool) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return JobStatus{}, false
+	}
+
+	job, ok := s.jobs.get(chi.URLParam(r, "jobID"))
+	if !ok || job.ProjectID != project.ID.String() {
+		respondError(w, http.StatusNotFound, "job not found")
+		return JobStatus{}, false
+	}
+	return job, true
+}
+
+// handleGetJob returns the current status of an upload job
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.authorizeJob(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// handleJobEvents streams an upload job's progress as Server-Sent Events.
+// Each update is a "progress" event carrying the JobStatus JSON; the stream
+// ends with a "completed" or "failed" event.
+func (s *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.authorizeJob(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	updates, current, cancel, ok := s.jobs.subscribe(job.ID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		event := "progress"
+		if current.Finished() {
+			event = current.Stage
+		}
+		data, _ := json.Marshal(current)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+
+		if current.Finished() {
+			return
+		}
+
+		select {
+		case current = <-updates:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+)
+
+func TestAsyncUpload_StreamsEmbeddingProgress(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	env.server.embeddingClient = embeddings.NewClient("test-key",
+		embeddings.WithBaseURL(srv.URL), embeddings.WithBatchSize(2), embeddings.WithMaxConcurrent(1))
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	paragraphs := make([]string, 5)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Statement number %d describes a policy that is long enough to be extracted.", i)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "policy.md")
+	fw.Write([]byte(strings.Join(paragraphs, "\n\n")))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+project.ID.String()+"/documents?async=true", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID.String())
+	rec := httptest.NewRecorder()
+	env.server.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp AsyncUploadResponse
+	decodeJSON(t, rec, &resp)
+	if resp.JobID == "" || resp.Status != "processing" {
+		t.Fatalf("expected a processing job, got %+v", resp)
+	}
+
+	// The stream stays open until the job finishes
+	rec = env.do(t, http.MethodGet, resp.EventsURL, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+
+	var last JobStatus
+	var lastEvent string
+	for _, block := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		lines := strings.SplitN(block, "\n", 2)
+		lastEvent = strings.TrimPrefix(lines[0], "event: ")
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &last); err != nil {
+			t.Fatalf("invalid event data %q: %v", lines[1], err)
+		}
+	}
+	if lastEvent != jobStageCompleted || last.Stage != jobStageCompleted {
+		t.Fatalf("expected stream to end with completed, got event %q status %+v", lastEvent, last)
+	}
+	if last.Total != len(paragraphs) || last.Embedded != last.Total {
+		t.Errorf("expected %d of %d statements embedded, got %d of %d", len(paragraphs), len(paragraphs), last.Embedded, last.Total)
+	}
+
+	docID := uuid.MustParse(resp.DocumentID)
+	stmts, _ := env.statements.GetByDocumentID(t.Context(), docID)
+	if len(stmts) != len(paragraphs) {
+		t.Fatalf("expected %d statements saved, got %d", len(paragraphs), len(stmts))
+	}
+	for _, stmt := range stmts {
+		if len(stmt.Embedding.Slice()) == 0 {
+			t.Errorf("statement %q saved without embedding", stmt.Text)
+		}
+	}
+
+	// The job is only visible within its project
+	other := env.seedProject(t, userID)
+	path := fmt.Sprintf("/api/v1/projects/%s/jobs/%s", other.ID, resp.JobID)
+	if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for job of another project, got %d", rec.Code)
+	}
+	path = fmt.Sprintf("/api/v1/projects/%s/jobs/%s", project.ID, resp.JobID)
+	if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 for job status, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/clustering"
+)
+
+const (
+	// maxKeywordPreviewTexts caps the sample size for keyword previews
+	maxKeywordPreviewTexts = 1000
+	defaultPreviewTopK     = 20
+	maxPreviewTopK         = 200
+)
+
+// KeywordPreviewRequest holds sample texts and extractor options to try
+type KeywordPreviewRequest struct {
+	Texts     []string `json:"texts"`
+	TopK      int      `json:"top_k,omitempty"`
+	NGrams    int      `json:"ngrams,omitempty"`
+	Stem      bool     `json:"stem,omitempty"`
+	StopWords []string `json:"stop_words,omitempty"`
+	MinLength int      `json:"min_length,omitempty"`
+	// Languages selects stop word lists; detected from the texts if empty
+	Languages []string `json:"languages,omitempty"`
+}
+
+// KeywordResponse represents a keyword with its TF-IDF score
+type KeywordResponse struct {
+	Word  string  `json:"word"`
+	Score float64 `json:"score"`
+}
+
+// KeywordPreviewResponse lists the keywords extracted with the given options
+type KeywordPreviewResponse struct {
+	Keywords []KeywordResponse `json:"keywords"`
+}
+
+// handleKeywordPreview extracts keywords from sample texts so extractor
+// settings can be tuned before use. Nothing is persisted.
+func (s *Server) handleKeywordPreview(w http.ResponseWriter, r *http.Request) {
+	var req KeywordPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	texts := make([]string, 0, len(req.Texts))
+	for _, t := range req.Texts {
+		if t = strings.TrimSpace(t); t != "" {
+			texts = append(texts, t)
+		}
+	}
+
+	if len(texts) == 0 {
+		respondError(w, http.StatusBadRequest, "texts are required")
+		return
+	}
+
+	if len(texts) > maxKeywordPreviewTexts {
+		respondError(w, http.StatusBadRequest, "too many texts (max 1000)")
+		return
+	}
+
+	if msg := validateKeywordOptions(req.TopK, req.NGrams, req.Languages); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	topK := req.TopK
+	if topK == 0 {
+		topK = defaultPreviewTopK
+	}
+
+	extractor := clustering.NewKeywordExtractorWithOptions(clustering.KeywordOptions{
+		Languages:      req.Languages,
+		ExtraStopWords: req.StopWords,
+		MinLength:      req.MinLength,
+		NGrams:         req.NGrams,
+		Stem:           req.Stem,
+	})
+
+	keywords := extractor.ExtractKeywords(texts, topK)
+	response := KeywordPreviewResponse{Keywords: make([]KeywordResponse, len(keywords))}
+	for i, kw := range keywords {
+		response.Keywords[i] = KeywordResponse{Word: kw.Word, Score: kw.Score}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// validateKeywordOptions checks the extractor options shared by keyword
+// endpoints and returns an error message, or "" if they are valid
+func validateKeywordOptions(topK, nGrams int, languages []string) string {
+	if nGrams < 0 || nGrams > 2 {
+		return "ngrams must be 1 or 2"
+	}
+	if topK < 0 || topK > maxPreviewTopK {
+		return "top_k must be between 1 and 200"
+	}
+	for _, lang := range languages {
+		if !clustering.IsStopWordLanguage(lang) {
+			return "unsupported language " + lang + " (expected one of " + strings.Join(clustering.StopWordLanguages(), ", ") + ")"
+		}
+	}
+	return ""
+}
+
+// ClusterKeywordsRequest holds existing cluster assignments, keyed by
+// statement ID (e.g. the point IDs and clusters of a visualization), and the
+// extractor options to recompute their keywords with
+type ClusterKeywordsRequest struct {
+	Assignments map[string]int `json:"assignments"`
+	TopK        int            `json:"top_k,omitempty"`
+	NGrams      int            `json:"ngrams,omitempty"`
+	Stem        bool           `json:"stem,omitempty"`
+	StopWords   []string       `json:"stop_words,omitempty"`
+	MinLength   int            `json:"min_length,omitempty"`
+	Languages   []string       `json:"languages,omitempty"`
+}
+
+// ClusterKeywords is a cluster's recomputed keywords
+type ClusterKeywords struct {
+	ID       int               `json:"id"`
+	Size     int               `json:"size"`
+	Keywords []KeywordResponse `json:"keywords"`
+}
+
+// ClusterKeywordsResponse lists the recomputed keywords of every cluster
+type ClusterKeywordsResponse struct {
+	Clusters []ClusterKeywords `json:"clusters"`
+}
+
+// handleRecomputeClusterKeywords re-extracts cluster keywords from given
+// cluster assignments with new extractor options. Labels are taken as-is, so
+// no clustering is run.
+func (s *Server) handleRecomputeClusterKeywords(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req ClusterKeywordsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.Assignments) == 0 {
+		respondError(w, http.StatusBadRequest, "assignments are required")
+		return
+	}
+
+	if msg := validateKeywordOptions(req.TopK, req.NGrams, req.Languages); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	assignments := make(map[uuid.UUID]int, len(req.Assignments))
+	for id, label := range req.Assignments {
+		sid, err := uuid.Parse(id)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid statement ID "+id)
+			return
+		}
+		if label < 0 {
+			respondError(w, http.StatusBadRequest, "cluster IDs must not be negative")
+			return
+		}
+		assignments[sid] = label
+	}
+
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+
+	// Rebuild the clustering from the assignments
+	result := &clustering.ClusterResult{}
+	var texts []string
+	sizes := make(map[int]int)
+	for _, stmt := range statements {
+		label, ok := assignments[stmt.ID]
+		if !ok {
+			continue
+		}
+		texts = append(texts, stmt.Text)
+		result.Labels = append(result.Labels, label)
+		sizes[label]++
+	}
+	if len(texts) != len(assignments) {
+		respondError(w, http.StatusBadRequest, "assignments reference statements outside this project")
+		return
+	}
+
+	for id, size := range sizes {
+		result.Clusters = append(result.Clusters, clustering.Cluster{ID: id, Size: size})
+	}
+	sort.Slice(result.Clusters, func(i, j int) bool {
+		return result.Clusters[i].ID < result.Clusters[j].ID
+	})
+	result.K = len(result.Clusters)
+
+	extractor := clustering.NewKeywordExtractorWithOptions(clustering.KeywordOptions{
+		Languages:      req.Languages,
+		ExtraStopWords: req.StopWords,
+		MinLength:      req.MinLength,
+		NGrams:         req.NGrams,
+		Stem:           req.Stem,
+	})
+	updated := s.clusteringService.RecomputeKeywords(result, texts, extractor, req.TopK)
+
+	response := ClusterKeywordsResponse{Clusters: make([]ClusterKeywords, len(updated.Clusters))}
+	for i, c := range updated.Clusters {
+		keywords := make([]KeywordResponse, len(c.Keywords))
+		for j, kw := range c.Keywords {
+			keywords[j] = KeywordResponse{Word: kw.Word, Score: kw.Score}
+		}
+		response.Clusters[i] = ClusterKeywords{ID: c.ID, Size: c.Size, Keywords: keywords}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
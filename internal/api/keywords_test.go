@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleKeywordPreview(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.NewString()
+
+	texts := []string{
+		"The refund policy covers annual plans.",
+		"Refund policies differ for monthly plans.",
+		"Support tickets are answered within a day.",
+		"Our refund policy excludes hardware.",
+	}
+
+	preview := func(req KeywordPreviewRequest) map[string]bool {
+		t.Helper()
+		req.Texts = texts
+		req.TopK = 200
+		rec := env.do(t, http.MethodPost, "/api/v1/keywords/preview", userID, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp KeywordPreviewResponse
+		decodeJSON(t, rec, &resp)
+		words := make(map[string]bool, len(resp.Keywords))
+		for _, kw := range resp.Keywords {
+			words[kw.Word] = true
+		}
+		return words
+	}
+
+	plain := preview(KeywordPreviewRequest{})
+	if !plain["policy"] || !plain["policies"] {
+		t.Errorf("expected policy and policies as separate keywords, got %v", plain)
+	}
+	if plain["refund policy"] {
+		t.Error("expected no bigrams by default")
+	}
+
+	bigrams := preview(KeywordPreviewRequest{NGrams: 2})
+	if !bigrams["refund policy"] || !bigrams["annual plans"] {
+		t.Errorf("expected bigrams refund policy and annual plans, got %v", bigrams)
+	}
+	if bigrams["tickets answered"] {
+		t.Error("bigrams must not span removed stop words")
+	}
+
+	stemmed := preview(KeywordPreviewRequest{Stem: true})
+	if !stemmed["polici"] || stemmed["policy"] || stemmed["policies"] {
+		t.Errorf("expected policy and policies to share the stem polici, got %v", stemmed)
+	}
+	if !stemmed["plan"] || stemmed["plans"] {
+		t.Errorf("expected plans to stem to plan, got %v", stemmed)
+	}
+
+	extra := preview(KeywordPreviewRequest{StopWords: []string{"refund"}})
+	if extra["refund"] {
+		t.Error("expected custom stop word to be excluded")
+	}
+}
+
+func TestHandleKeywordPreview_Validation(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.NewString()
+
+	tests := []struct {
+		name string
+		req  KeywordPreviewRequest
+	}{
+		{"no texts", KeywordPreviewRequest{Texts: []string{" "}}},
+		{"bad ngrams", KeywordPreviewRequest{Texts: []string{"a text"}, NGrams: 3}},
+		{"bad top_k", KeywordPreviewRequest{Texts: []string{"a text"}, TopK: -1}},
+	}
+	for _, tt := range tests {
+		rec := env.do(t, http.MethodPost, "/api/v1/keywords/preview", userID, tt.req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.name, rec.Code)
+		}
+	}
+}
+
+func TestHandleRecomputeClusterKeywords(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "a.md",
+		"Refund requests for annual plans are reviewed weekly.",
+		"Refund requests for monthly plans are reviewed daily.",
+		"Support tickets are answered within a day.",
+		"Support tickets are escalated overnight.",
+	)
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+	sort.Slice(stmts, func(i, j int) bool { return stmts[i].Position < stmts[j].Position })
+
+	assignments := map[string]int{
+		stmts[0].ID.String(): 0,
+		stmts[1].ID.String(): 0,
+		stmts[2].ID.String(): 1,
+		stmts[3].ID.String(): 1,
+	}
+	path := fmt.Sprintf("/api/v1/projects/%s/clusters/keywords", project.ID)
+
+	recompute := func(stopWords []string) ClusterKeywordsResponse {
+		t.Helper()
+		req := ClusterKeywordsRequest{Assignments: assignments, TopK: 50, StopWords: stopWords}
+		rec := env.do(t, http.MethodPost, path, userID.String(), req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp ClusterKeywordsResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	before := recompute(nil)
+	after := recompute([]string{"weekly", "daily", "answered", "escalated"})
+
+	if len(after.Clusters) != 2 || after.Clusters[0].Size != 2 || after.Clusters[1].Size != 2 {
+		t.Fatalf("expected the two assigned clusters of size 2, got %+v", after.Clusters)
+	}
+	has := func(c ClusterKeywords, word string) bool {
+		for _, kw := range c.Keywords {
+			if kw.Word == word {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(before.Clusters[0], "weekly") || !has(before.Clusters[1], "escalated") {
+		t.Fatalf("expected default keywords to include weekly and escalated, got %+v", before.Clusters)
+	}
+	if has(after.Clusters[0], "weekly") || has(after.Clusters[1], "escalated") {
+		t.Errorf("expected new stop words to be excluded, got %+v", after.Clusters)
+	}
+
+	// Statements of other projects cannot be assigned
+	other := env.seedProject(t, userID)
+	foreignDoc := env.seedDocument(t, other.ID, "b.md", "A statement in another project.")
+	foreign, _ := env.statements.GetByDocumentID(context.Background(), foreignDoc.ID)
+	bad := ClusterKeywordsRequest{Assignments: map[string]int{foreign[0].ID.String(): 0}}
+	if rec := env.do(t, http.MethodPost, path, userID.String(), bad); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for foreign statement, got %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodPost, path, userID.String(), ClusterKeywordsRequest{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without assignments, got %d", rec.Code)
+	}
+}
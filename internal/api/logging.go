@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/todmy/doc-analyzer/internal/auth"
+)
+
+// Request log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// newRequestLogger returns a logger writing request logs to w in format
+// (text when empty)
+func newRequestLogger(w io.Writer, format string) (*slog.Logger, error) {
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		return slog.New(slog.NewTextHandler(w, nil)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, nil)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (expected text or json)", format)
+	}
+}
+
+type requestLogKey struct{}
+
+// requestLogEntry collects what inner handlers learn about a request, such
+// as the authenticated user, for requestLogger to log once it completes
+type requestLogEntry struct {
+	userID string
+	route  *chi.Context
+}
+
+// requestLogger logs every request with its status, size and duration, the
+// request ID, and the user and project it touched when known
+func requestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &requestLogEntry{route: chi.RouteContext(r.Context())}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+					slog.String("remote", r.RemoteAddr),
+				}
+				if id := middleware.GetReqID(r.Context()); id != "" {
+					attrs = append(attrs, slog.String("request_id", id))
+				}
+				if entry.userID != "" {
+					attrs = append(attrs, slog.String("user_id", entry.userID))
+				}
+				if entry.route != nil {
+					if pid := entry.route.URLParam("projectID"); pid != "" {
+						attrs = append(attrs, slog.String("project_id", pid))
+					}
+				}
+				logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+			}()
+
+			ctx := context.WithValue(r.Context(), requestLogKey{}, entry)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		})
+	}
+}
+
+// logRequestUser records the authenticated user in the request log. It runs
+// after auth.Middleware, whose claims are not visible to requestLogger.
+func logRequestUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(requestLogKey{}).(*requestLogEntry); ok {
+			if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+				entry.userID = claims.UserID
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/auth"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newRequestLogger(&buf, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(requestLogger(logger))
+	r.Get("/public", func(w http.ResponseWriter, r *http.Request) {})
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware(fakeAuthService{}))
+		r.Use(logRequestUser)
+		r.Get("/projects/{projectID}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	})
+
+	userID := uuid.NewString()
+	req := httptest.NewRequest(http.MethodGet, "/projects/p-1", nil)
+	req.Header.Set("Authorization", "Bearer "+userID)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/public", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q", lines[0])
+	}
+	if entry["user_id"] != userID || entry["project_id"] != "p-1" || entry["status"] != float64(http.StatusTeapot) {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Errorf("expected a duration, got %v", entry)
+	}
+
+	entry = nil
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q", lines[1])
+	}
+	if _, ok := entry["user_id"]; ok || entry["status"] != float64(http.StatusOK) {
+		t.Errorf("expected an anonymous 200 entry, got %v", entry)
+	}
+
+	if _, err := newRequestLogger(&buf, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// ContradictionPairRequest names two statements of a project to check
+type ContradictionPairRequest struct {
+	Statement1ID string `json:"statement1_id"`
+	Statement2ID string `json:"statement2_id"`
+}
+
+// ContradictionPairResponse is the analysis of a single statement pair.
+// Type, severity, explanation and confidence are only set for a
+// contradiction.
+type ContradictionPairResponse struct {
+	ID              string  `json:"id,omitempty"`
+	Status          string  `json:"status,omitempty"`
+	Statement1ID    string  `json:"statement1_id"`
+	Statement2ID    string  `json:"statement2_id"`
+	Statement1      string  `json:"statement1"`
+	Statement2      string  `json:"statement2"`
+	File1           string  `json:"file1"`
+	File2           string  `json:"file2"`
+	Similarity      float64 `json:"similarity"`
+	IsContradiction bool    `json:"is_contradiction"`
+	Type            string  `json:"type,omitempty"`
+	Severity        string  `json:"severity,omitempty"`
+	Explanation     string  `json:"explanation,omitempty"`
+	Confidence      float64 `json:"confidence,omitempty"`
+}
+
+// handleAnalyzeContradictionPair checks two statements of a project for a
+// contradiction with a single LLM call, whatever their similarity. A found
+// contradiction is recorded in the review worklist.
+func (s *Server) handleAnalyzeContradictionPair(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	if s.contradictionService == nil {
+		respondError(w, http.StatusServiceUnavailable, "contradiction detection not configured - set ANTHROPIC_API_KEY or LLM_PROVIDER")
+		return
+	}
+
+	var req ContradictionPairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	id1, err1 := uuid.Parse(req.Statement1ID)
+	id2, err2 := uuid.Parse(req.Statement2ID)
+	if err1 != nil || err2 != nil {
+		respondError(w, http.StatusBadRequest, "statement1_id and statement2_id must be statement IDs")
+		return
+	}
+	if id1 == id2 {
+		respondError(w, http.StatusBadRequest, "statement1_id and statement2_id must differ")
+		return
+	}
+
+	stmt1, doc1, status, msg := s.projectStatement(r.Context(), project.ID, id1)
+	if status != 0 {
+		respondError(w, status, msg)
+		return
+	}
+	stmt2, doc2, status, msg := s.projectStatement(r.Context(), project.ID, id2)
+	if status != 0 {
+		respondError(w, status, msg)
+		return
+	}
+
+	// Prompt in the project's configured language, else the detected one
+	language := contradiction.Language(project.Language)
+	if language == "" {
+		language = contradiction.DetectLanguage([]string{stmt1.Text, stmt2.Text})
+	}
+
+	pair := contradiction.StatementPair{
+		Statement1:   stmt1.Text,
+		Statement2:   stmt2.Text,
+		Statement1ID: stmt1.ID.String(),
+		Statement2ID: stmt2.ID.String(),
+		File1:        doc1.Filename,
+		File2:        doc2.Filename,
+		Section1:     stmt1.Section,
+		Section2:     stmt2.Section,
+		Language:     language,
+	}
+	if v1, v2 := stmt1.Embedding.Slice(), stmt2.Embedding.Slice(); len(v1) > 0 && len(v1) == len(v2) {
+		pair.Similarity = similarity.CosineSimilarity(v1, v2)
+	}
+
+	result, err := s.contradictionService.AnalyzePair(r.Context(), pair)
+	if errors.Is(err, contradiction.ErrRateLimited) {
+		respondError(w, http.StatusServiceUnavailable, "contradiction analysis unavailable: the LLM provider is rate limiting or failing, try again later")
+		return
+	}
+	if err != nil {
+		log.Printf("[contradictions] failed to analyze pair %s/%s: %v", id1, id2, err)
+		respondError(w, http.StatusInternalServerError, "failed to analyze pair")
+		return
+	}
+
+	response := ContradictionPairResponse{
+		Statement1ID: pair.Statement1ID,
+		Statement2ID: pair.Statement2ID,
+		Statement1:   pair.Statement1,
+		Statement2:   pair.Statement2,
+		File1:        pair.File1,
+		File2:        pair.File2,
+		Similarity:   pair.Similarity,
+	}
+	if result != nil {
+		response.IsContradiction = true
+		response.Type = string(result.Type)
+		response.Severity = string(result.Severity)
+		response.Explanation = result.Explanation
+		response.Confidence = result.Confidence
+		if stored := s.saveContradiction(r.Context(), project.ID, *result); stored != nil {
+			response.ID = stored.ID.String()
+			response.Status = stored.Status
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// projectStatement loads a statement and its document and verifies that it
+// belongs to the project. On failure it returns the error status and
+// message to respond with.
+func (s *Server) projectStatement(ctx context.Context, projectID, id uuid.UUID) (*storage.Statement, *storage.Document, int, string) {
+	stmt, err := s.statementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, "failed to fetch statement"
+	}
+	if stmt == nil {
+		return nil, nil, http.StatusNotFound, "statement " + id.String() + " not found"
+	}
+
+	doc, err := s.documentRepo.GetByID(ctx, stmt.DocumentID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, "failed to fetch document"
+	}
+	if doc == nil || doc.ProjectID != projectID {
+		return nil, nil, http.StatusNotFound, "statement " + id.String() + " not found"
+	}
+
+	return stmt, doc, 0, ""
+}
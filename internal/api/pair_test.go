@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+)
+
+// keywordLLMBackend reports a contradiction when the prompt mentions both
+// "never" and "always"
+type keywordLLMBackend struct{}
+
+func (keywordLLMBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	if strings.Contains(prompt, "never") && strings.Contains(prompt, "always") {
+		return `{"is_contradiction": true, "type": "direct", "severity": "high", "explanation": "never vs always", "confidence": 0.9}`, nil
+	}
+	return `{"is_contradiction": false}`, nil
+}
+
+func TestHandleAnalyzeContradictionPair(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "terms.md",
+		"refunds are never granted after purchase",
+		"refunds are always granted after purchase",
+		"support answers within one business day",
+	)
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+	ids := make(map[string]string, len(stmts))
+	for _, stmt := range stmts {
+		ids[stmt.Text] = stmt.ID.String()
+	}
+	never := ids["refunds are never granted after purchase"]
+	always := ids["refunds are always granted after purchase"]
+	support := ids["support answers within one business day"]
+
+	path := fmt.Sprintf("/api/v1/projects/%s/contradictions/pair", project.ID)
+	analyze := func(id1, id2 string) ContradictionPairResponse {
+		t.Helper()
+		rec := env.do(t, http.MethodPost, path, userID.String(), ContradictionPairRequest{Statement1ID: id1, Statement2ID: id2})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp ContradictionPairResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	// Without a configured LLM the endpoint is unavailable
+	rec := env.do(t, http.MethodPost, path, userID.String(), ContradictionPairRequest{Statement1ID: never, Statement2ID: always})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 without contradiction service, got %d", rec.Code)
+	}
+
+	env.server.contradictionService = contradiction.NewService(
+		contradiction.NewAnalyzerWithBackend(keywordLLMBackend{}), contradiction.DefaultServiceConfig())
+
+	resp := analyze(never, always)
+	if !resp.IsContradiction || resp.Type != "direct" || resp.Severity != "high" {
+		t.Errorf("expected a direct contradiction, got %+v", resp)
+	}
+	if resp.Statement1 != "refunds are never granted after purchase" || resp.File1 != "terms.md" {
+		t.Errorf("expected the statement text and file, got %+v", resp)
+	}
+	if resp.ID == "" {
+		t.Errorf("expected the contradiction to be recorded for review")
+	}
+
+	// A consistent pair is analyzed and reported as no contradiction
+	resp = analyze(never, support)
+	if resp.IsContradiction || resp.Type != "" || resp.ID != "" {
+		t.Errorf("expected no contradiction, got %+v", resp)
+	}
+
+	// Statements outside the project are not found
+	other := env.seedProject(t, userID)
+	otherDoc := env.seedDocument(t, other.ID, "other.md", "refunds are always granted after purchase")
+	otherStmts, _ := env.statements.GetByDocumentID(context.Background(), otherDoc.ID)
+	rec = env.do(t, http.MethodPost, path, userID.String(), ContradictionPairRequest{Statement1ID: never, Statement2ID: otherStmts[0].ID.String()})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a statement of another project, got %d", rec.Code)
+	}
+
+	rec = env.do(t, http.MethodPost, path, userID.String(), ContradictionPairRequest{Statement1ID: never, Statement2ID: never})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for the same statement twice, got %d", rec.Code)
+	}
+}
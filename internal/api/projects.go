@@ -3,25 +3,72 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/preprocess"
 	"github.com/todmy/doc-analyzer/internal/storage"
 )
 
 // ProjectRequest represents a project creation request
 type ProjectRequest struct {
 	Name string `json:"name"`
+	// Language optionally fixes the contradiction prompt language (en, de)
+	Language string `json:"language,omitempty"`
+	// Preprocessing lists the steps applied to statement text before
+	// embedding, in order (lowercase, strip_punctuation, mask_numbers,
+	// collapse_whitespace)
+	Preprocessing []string `json:"preprocessing,omitempty"`
 }
 
 // ProjectResponse represents a project in API responses
 type ProjectResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Language      string   `json:"language,omitempty"`
+	Preprocessing []string `json:"preprocessing,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+// authorizeProject loads the project named by the projectID URL parameter
+// and verifies the authenticated user owns it. On failure it writes the
+// error response (400 bad id, 404 missing, 403 not owner) and returns false.
+func (s *Server) authorizeProject(w http.ResponseWriter, r *http.Request) (*storage.Project, bool) {
+	projectID := chi.URLParam(r, "projectID")
+	if projectID == "" {
+		respondError(w, http.StatusBadRequest, "project id is required")
+		return nil, false
+	}
+
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid project id")
+		return nil, false
+	}
+
+	project, err := s.projectRepo.GetByID(r.Context(), pid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+		return nil, false
+	}
+
+	if project == nil {
+		respondError(w, http.StatusNotFound, "project not found")
+		return nil, false
+	}
+
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok || project.UserID.String() != claims.UserID {
+		respondError(w, http.StatusForbidden, "access denied")
+		return nil, false
+	}
+
+	return project, true
 }
 
 // handleListProjects returns all projects for the authenticated user
@@ -47,10 +94,12 @@ func (s *Server) handleListProjectsImpl(w http.ResponseWriter, r *http.Request)
 	response := make([]ProjectResponse, 0, len(projects))
 	for _, p := range projects {
 		response = append(response, ProjectResponse{
-			ID:        p.ID.String(),
-			Name:      p.Name,
-			CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ID:            p.ID.String(),
+			Name:          p.Name,
+			Language:      p.Language,
+			Preprocessing: p.Preprocessing,
+			CreatedAt:     p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:     p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 	}
 
@@ -82,9 +131,23 @@ func (s *Server) handleCreateProjectImpl(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	language, err := contradiction.ParseLanguage(req.Language)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pipeline, err := preprocess.Parse(req.Preprocessing)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	project := &storage.Project{
-		UserID: uid,
-		Name:   req.Name,
+		UserID:        uid,
+		Name:          req.Name,
+		Language:      string(language),
+		Preprocessing: pipeline.Names(),
 	}
 
 	if err := s.projectRepo.Create(r.Context(), project); err != nil {
@@ -93,84 +156,94 @@ func (s *Server) handleCreateProjectImpl(w http.ResponseWriter, r *http.Request)
 	}
 
 	respondJSON(w, http.StatusCreated, ProjectResponse{
-		ID:        project.ID.String(),
-		Name:      project.Name,
-		CreatedAt: project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:            project.ID.String(),
+		Name:          project.Name,
+		Language:      project.Language,
+		Preprocessing: project.Preprocessing,
+		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
 }
 
 // handleGetProject returns a specific project
 func (s *Server) handleGetProjectImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
-	}
+	respondJSON(w, http.StatusOK, ProjectResponse{
+		ID:            project.ID.String(),
+		Name:          project.Name,
+		Language:      project.Language,
+		Preprocessing: project.Preprocessing,
+		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
 
-	project, err := s.projectRepo.GetByID(r.Context(), pid)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+// handleUpdateProject renames a project. Language and preprocessing are
+// replaced when given and kept otherwise; a changed preprocessing pipeline
+// applies to statements embedded from then on, so existing documents should
+// be re-embedded.
+func (s *Server) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	if project == nil {
-		respondError(w, http.StatusNotFound, "project not found")
+	var req ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	// Verify ownership
-	claims, ok := auth.GetUserFromContext(r.Context())
-	if !ok || project.UserID.String() != claims.UserID {
-		respondError(w, http.StatusForbidden, "access denied")
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
 		return
 	}
+	project.Name = req.Name
 
-	respondJSON(w, http.StatusOK, ProjectResponse{
-		ID:        project.ID.String(),
-		Name:      project.Name,
-		CreatedAt: project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	})
-}
-
-// handleDeleteProject deletes a project
-func (s *Server) handleDeleteProjectImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
-		return
+	if req.Language != "" {
+		language, err := contradiction.ParseLanguage(req.Language)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		project.Language = string(language)
 	}
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+	if req.Preprocessing != nil {
+		pipeline, err := preprocess.Parse(req.Preprocessing)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		project.Preprocessing = pipeline.Names()
 	}
 
-	// Verify ownership
-	project, err := s.projectRepo.GetByID(r.Context(), pid)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+	if err := s.projectRepo.Update(r.Context(), project); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update project")
 		return
 	}
 
-	if project == nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
-	}
+	respondJSON(w, http.StatusOK, ProjectResponse{
+		ID:            project.ID.String(),
+		Name:          project.Name,
+		Language:      project.Language,
+		Preprocessing: project.Preprocessing,
+		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
 
-	claims, ok := auth.GetUserFromContext(r.Context())
-	if !ok || project.UserID.String() != claims.UserID {
-		respondError(w, http.StatusForbidden, "access denied")
+// handleDeleteProject deletes a project
+func (s *Server) handleDeleteProjectImpl(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
 	if err := s.projectRepo.Delete(r.Context(), pid); err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to delete project")
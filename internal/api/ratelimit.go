@@ -0,0 +1,115 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ipRateLimiter is a set of token buckets keyed by client IP. Unlike the
+// embedding client's limiter it never blocks: requests over the limit are
+// rejected so brute-force attempts fail fast.
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // tokens added per second
+	burst     float64 // bucket capacity
+	buckets   map[string]*ipBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type ipBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newIPRateLimiter allows perMinute requests per client IP on average, with
+// bursts of up to burst requests. It returns nil (no limit) if perMinute <= 0.
+func newIPRateLimiter(perMinute float64, burst int) *ipRateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &ipRateLimiter{
+		rate:    perMinute / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*ipBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token for ip. If none is available it returns false and how
+// long until the next token.
+func (l *ipRateLimiter) Allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &ipBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute,
+// so the map does not grow with every client ever seen
+func (l *ipRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for ip, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// rateLimit rejects requests over the limiter's per-IP rate with 429 and a
+// Retry-After header. A nil limiter lets every request through. Clients are
+// keyed on the connection address, so behind a proxy all clients share one
+// bucket unless the proxy's address is rewritten upstream.
+func rateLimit(l *ipRateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := l.Allow(clientIP(r))
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				respondError(w, http.StatusTooManyRequests, "too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	l := newIPRateLimiter(60, 3)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+	ok, wait := l.Allow("10.0.0.1")
+	if ok {
+		t.Fatal("expected request 4 to be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("expected wait of at most 1s at 60/min, got %v", wait)
+	}
+
+	if ok, _ := l.Allow("10.0.0.2"); !ok {
+		t.Error("expected a different IP to have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("expected a token to be available after refill")
+	}
+
+	if newIPRateLimiter(0, 5) != nil {
+		t.Error("expected a zero rate to disable limiting")
+	}
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	env := newTestEnv(t, "")
+	env.server.authService = stubAuthService{}
+	env.server.authLimiter = newIPRateLimiter(1, 2)
+	env.server.router = chi.NewRouter()
+	env.server.setupRoutes()
+
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(`{"email":"user@example.com","password":"correct-horse"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", body)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		env.server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 1; i <= 2; i++ {
+		if rec := login("203.0.113.7:5000"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status 200, got %d", i, rec.Code)
+		}
+	}
+
+	rec := login("203.0.113.7:5001")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request 3: expected status 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 429")
+	}
+
+	if rec := login("198.51.100.9:5000"); rec.Code != http.StatusOK {
+		t.Errorf("other client: expected status 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/preprocess"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// ReembedResponse summarizes a project re-embedding. Drift compares the new
+// vectors with the ones they replaced.
+type ReembedResponse struct {
+	Model           string                  `json:"model"`
+	Documents       int                     `json:"documents"`
+	Statements      int                     `json:"statements"`
+	EmbeddingTokens int                     `json:"embedding_tokens"`
+	Drift           *embeddings.DriftReport `json:"drift"`
+}
+
+// handleReembedProject regenerates the embeddings of every statement in a
+// project with the current embedding model. All new vectors are generated
+// before any is stored and they are written in one transaction, so a failure
+// leaves the project's existing embeddings untouched. A model of another
+// dimension needs statements.embedding resized first
+// (EMBEDDING_DIMENSION_CHECK=resize), which clears the old vectors.
+func (s *Server) handleReembedProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	if s.embeddingClient == nil {
+		respondError(w, http.StatusServiceUnavailable, "embedding service not configured - set OPENROUTER_API_KEY")
+		return
+	}
+
+	documents, err := s.documentRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch documents")
+		return
+	}
+
+	pipeline, err := preprocess.Parse(project.Preprocessing)
+	if err != nil {
+		log.Printf("[reembed] ignoring invalid preprocessing of project %s: %v", project.ID, err)
+	}
+
+	response := ReembedResponse{Model: s.embeddingClient.Model(), Documents: len(documents)}
+	var statements []*storage.Statement
+	var before [][]float32
+	for _, doc := range documents {
+		stmts, err := s.statementRepo.GetByDocumentID(r.Context(), doc.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+			return
+		}
+		for _, stmt := range stmts {
+			before = append(before, stmt.Embedding.Slice())
+		}
+
+		// Embed per document so token usage is attributed to each one
+		tokens, err := s.generateEmbeddingsWithProgress(r.Context(), stmts, pipeline, nil)
+		if tokens > 0 {
+			response.EmbeddingTokens += tokens
+			if err := s.documentRepo.AddEmbeddingTokens(r.Context(), doc.ID, tokens); err != nil {
+				log.Printf("[reembed] failed to record %d embedding tokens: %v", tokens, err)
+			}
+		}
+		if err != nil {
+			log.Printf("[reembed] embedding %s failed: %v", doc.Filename, err)
+			respondError(w, http.StatusBadGateway, embeddingErrorMessage(err))
+			return
+		}
+		statements = append(statements, stmts...)
+	}
+
+	if err := s.statementRepo.UpdateEmbeddings(r.Context(), statements); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to store embeddings")
+		return
+	}
+
+	after := make([][]float32, len(statements))
+	for i, stmt := range statements {
+		after[i] = stmt.Embedding.Slice()
+	}
+	response.Statements = len(statements)
+	response.Drift, _ = embeddings.Drift(before, after)
+
+	respondJSON(w, http.StatusOK, response)
+}
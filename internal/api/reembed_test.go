@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+)
+
+func TestHandleReembedProject(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 8)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "old.md", texts...)
+	newDoc := env.seedDocument(t, project.ID, "new.md", "alpha beta", "beta gamma")
+
+	// Simulate statements embedded with a larger model
+	for _, stmt := range env.statements.items {
+		if stmt.DocumentID == newDoc.ID {
+			stmt.Embedding = pgvector.NewVector(append(stmt.Embedding.Slice(), fakeEmbedding(stmt.Text+" wide")...))
+			stmt.EmbeddingModel = "text-embedding-3-large"
+		}
+	}
+
+	analysisPath := fmt.Sprintf("/api/v1/projects/%s/anomalies/distribution", project.ID)
+	rec := env.do(t, http.MethodGet, analysisPath, userID.String(), nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("mixed dimensions: expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/reembed", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reembed: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ReembedResponse
+	decodeJSON(t, rec, &resp)
+	model := env.server.embeddingClient.Model()
+	if resp.Documents != 2 || resp.Statements != 10 {
+		t.Errorf("expected 2 documents and 10 statements, got %d and %d", resp.Documents, resp.Statements)
+	}
+	if resp.Model != model || resp.EmbeddingTokens <= 0 {
+		t.Errorf("expected model %q and tokens recorded, got %q and %d", model, resp.Model, resp.EmbeddingTokens)
+	}
+	if resp.Drift == nil || resp.Drift.Compared != 10 || resp.Drift.Projected != 2 {
+		t.Errorf("expected drift over 10 statements with 2 projected, got %+v", resp.Drift)
+	}
+
+	for _, stmt := range env.statements.items {
+		if len(stmt.Embedding.Slice()) != fakeEmbeddingDim || stmt.EmbeddingModel != model {
+			t.Errorf("statement %q: expected a %d-dim %s embedding, got %d-dim %q",
+				stmt.Text, fakeEmbeddingDim, model, len(stmt.Embedding.Slice()), stmt.EmbeddingModel)
+		}
+	}
+
+	rec = env.do(t, http.MethodGet, analysisPath, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("after reembed: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleReembedProject_DimensionChange(t *testing.T) {
+	// The new model produces vectors twice as wide as the stored ones
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			wide := append(fakeEmbedding(text), fakeEmbedding(text+" wide")...)
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: wide})
+			resp.Usage.TotalTokens += 10
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md", "alpha beta", "beta gamma", "gamma delta")
+
+	// Resizing the column cleared every stored embedding
+	for _, stmt := range env.statements.items {
+		stmt.Embedding = pgvector.Vector{}
+		stmt.EmbeddingModel = ""
+	}
+
+	rec := env.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/reembed", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reembed: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp ReembedResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Statements != 3 || resp.Drift == nil || resp.Drift.Skipped != 3 || resp.Drift.Compared != 0 {
+		t.Errorf("expected 3 statements with nothing to compare against, got %d and %+v", resp.Statements, resp.Drift)
+	}
+	for _, stmt := range env.statements.items {
+		if len(stmt.Embedding.Slice()) != 2*fakeEmbeddingDim {
+			t.Errorf("statement %q: expected a %d-dim embedding, got %d", stmt.Text, 2*fakeEmbeddingDim, len(stmt.Embedding.Slice()))
+		}
+	}
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/anomalies/distribution", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("after reembed: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleAnalyze_EmbedsOnlyMissing(t *testing.T) {
+	var mu sync.Mutex
+	var inputs []string
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		inputs = append(inputs, req.Input...)
+		if failing && strings.Contains(strings.Join(req.Input, " "), "renew") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: fakeEmbedding(text)})
+			resp.Usage.TotalTokens += 10
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	env.server.embeddingClient = embeddings.NewClient("test-key", embeddings.WithBaseURL(srv.URL), embeddings.WithBatchSize(1))
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	// One batch fails on upload: the others keep their embeddings
+	rec := env.upload(t, project.ID, userID.String(), "plans.md",
+		"Refunds are available for thirty days after purchase.\n\nAnnual plans renew automatically unless cancelled.\n\nSupport answers every ticket within one business day of submission.")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var upload UploadResponse
+	decodeJSON(t, rec, &upload)
+	if !strings.Contains(upload.Warning, "1 of 3 statements saved without embeddings") {
+		t.Errorf("expected a warning about 1 of 3 statements, got %q", upload.Warning)
+	}
+	docID := uuid.MustParse(upload.DocumentID)
+	if doc, _ := env.documents.GetByID(context.Background(), docID); doc.EmbeddingError == "" {
+		t.Errorf("expected the embedding failure to be recorded")
+	}
+
+	analyze := func() AnalysisStatusResponse {
+		t.Helper()
+		mu.Lock()
+		inputs = nil
+		mu.Unlock()
+		rec := env.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/analyze", project.ID), userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("analyze: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp AnalysisStatusResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	failing = false
+	resp := analyze()
+	if resp.EmbeddedStatements != 1 || resp.EmbeddingTokens != 10 {
+		t.Errorf("expected 1 statement embedded for 10 tokens, got %d and %d", resp.EmbeddedStatements, resp.EmbeddingTokens)
+	}
+	if len(inputs) != 1 || !strings.Contains(inputs[0], "renew") {
+		t.Errorf("expected only the missing statement to be sent, got %q", inputs)
+	}
+	for _, stmt := range env.statements.items {
+		if len(stmt.Embedding.Slice()) == 0 {
+			t.Errorf("statement %q: expected an embedding", stmt.Text)
+		}
+	}
+	if doc, _ := env.documents.GetByID(context.Background(), docID); doc.EmbeddingError != "" {
+		t.Errorf("expected the embedding failure to be cleared, got %q", doc.EmbeddingError)
+	}
+
+	// A second run has nothing left to embed
+	resp = analyze()
+	if resp.EmbeddedStatements != 0 || len(inputs) != 0 {
+		t.Errorf("expected nothing to be embedded again, got %d statements and %q", resp.EmbeddedStatements, inputs)
+	}
+}
+
+func TestHandleAnalyze_StoresBatchesIncrementally(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(strings.Join(req.Input, " "), "renew") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: fakeEmbedding(text)})
+			resp.Usage.TotalTokens += 10
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "plans.md",
+		"Refunds are available for thirty days after purchase.",
+		"Annual plans renew automatically unless cancelled.",
+		"Support answers every ticket within one business day of submission.",
+	)
+	for _, stmt := range env.statements.items {
+		stmt.Embedding = pgvector.Vector{}
+		stmt.EmbeddingModel = ""
+	}
+
+	// One batch at a time: the batch before the failure is stored, the
+	// failing one and the one after are not
+	env.server.embeddingClient = embeddings.NewClient("test-key", embeddings.WithBaseURL(srv.URL),
+		embeddings.WithBatchSize(1), embeddings.WithMaxConcurrent(1))
+	writes := env.statements.writes
+	rec := env.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/analyze", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if env.statements.writes != writes+1 {
+		t.Errorf("expected the first batch to be stored on its own, got %d writes", env.statements.writes-writes)
+	}
+	for _, stmt := range env.statements.items {
+		embedded := len(stmt.Embedding.Slice()) > 0
+		if want := stmt.Position == 0; embedded != want {
+			t.Errorf("statement %q: expected embedded=%v, got %v", stmt.Text, want, embedded)
+		}
+	}
+	if stored, _ := env.documents.GetByID(context.Background(), doc.ID); stored.EmbeddingTokens != 10 {
+		t.Errorf("expected the tokens of the stored batch to be recorded, got %d", stored.EmbeddingTokens)
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/contradiction"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// ContradictionStatusRequest represents a review status update
+type ContradictionStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// saveContradiction records a detected contradiction in the review worklist,
+// preserving any review status it already has. Failures are logged and the
+// caller still returns the detection result.
+func (s *Server) saveContradiction(ctx context.Context, projectID uuid.UUID, c contradiction.ContradictionResult) *storage.Contradiction {
+	if s.contradictionRepo == nil {
+		return nil
+	}
+
+	id1, err1 := uuid.Parse(c.Statement1ID)
+	id2, err2 := uuid.Parse(c.Statement2ID)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+
+	stored := &storage.Contradiction{
+		ProjectID:    projectID,
+		Statement1ID: id1,
+		Statement2ID: id2,
+		Type:         string(c.Type),
+		Severity:     string(c.Severity),
+		Explanation:  c.Explanation,
+		Confidence:   c.Confidence,
+	}
+	if err := s.contradictionRepo.Upsert(ctx, stored); err != nil {
+		log.Printf("[contradictions] failed to save contradiction: %v", err)
+		return nil
+	}
+	return stored
+}
+
+// handleListContradictionReview lists stored contradictions with their review status
+func (s *Server) handleListContradictionReview(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+	pid := project.ID
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !storage.IsValidReviewStatus(status) {
+		respondError(w, http.StatusBadRequest, "status must be open, accepted, or dismissed")
+		return
+	}
+
+	items, err := s.contradictionRepo.GetByProjectID(r.Context(), pid, status)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch contradictions")
+		return
+	}
+
+	response := make([]ContradictionResponse, len(items))
+	for i, c := range items {
+		response[i] = contradictionToResponse(c)
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleUpdateContradictionStatus sets the review status of a contradiction
+func (s *Server) handleUpdateContradictionStatus(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+	pid := project.ID
+
+	cid, err := uuid.Parse(chi.URLParam(r, "contradictionID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid contradiction id")
+		return
+	}
+
+	var req ContradictionStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if !storage.IsValidReviewStatus(req.Status) {
+		respondError(w, http.StatusBadRequest, "status must be open, accepted, or dismissed")
+		return
+	}
+
+	existing, err := s.contradictionRepo.GetByID(r.Context(), cid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch contradiction")
+		return
+	}
+
+	if existing == nil || existing.ProjectID != pid {
+		respondError(w, http.StatusNotFound, "contradiction not found")
+		return
+	}
+
+	if err := s.contradictionRepo.UpdateStatus(r.Context(), cid, req.Status); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update contradiction")
+		return
+	}
+	existing.Status = req.Status
+
+	respondJSON(w, http.StatusOK, contradictionToResponse(existing))
+}
+
+// contradictionToResponse converts a stored contradiction to its API form
+func contradictionToResponse(c *storage.Contradiction) ContradictionResponse {
+	return ContradictionResponse{
+		ID:          c.ID.String(),
+		Status:      c.Status,
+		Statement1:  c.Text1,
+		Statement2:  c.Text2,
+		File1:       c.File1,
+		File2:       c.File2,
+		Type:        c.Type,
+		Severity:    c.Severity,
+		Explanation: c.Explanation,
+		Confidence:  c.Confidence,
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// seedContradictions stores a contradiction for each consecutive pair of the
+// document's statements
+func seedContradictions(t *testing.T, env *testEnv, projectID uuid.UUID, doc *storage.Document) []*storage.Contradiction {
+	t.Helper()
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+	var result []*storage.Contradiction
+	for i := 0; i+1 < len(stmts); i++ {
+		c := &storage.Contradiction{
+			ProjectID:    projectID,
+			Statement1ID: stmts[i].ID,
+			Statement2ID: stmts[i+1].ID,
+			Type:         "direct",
+			Severity:     "high",
+			Confidence:   0.9,
+		}
+		if err := env.contradictions.Upsert(context.Background(), c); err != nil {
+			t.Fatalf("failed to seed contradiction: %v", err)
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+func TestContradictionReview_SetAndFilterStatus(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "policy.md",
+		"refunds are available for 30 days",
+		"refunds are never available",
+		"refunds are available for 60 days",
+	)
+	items := seedContradictions(t, env, project.ID, doc)
+
+	statusPath := fmt.Sprintf("/api/v1/projects/%s/contradictions/%s/status", project.ID, items[0].ID)
+	rec := env.do(t, http.MethodPut, statusPath, userID.String(), ContradictionStatusRequest{Status: "accepted"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var updated ContradictionResponse
+	decodeJSON(t, rec, &updated)
+	if updated.Status != "accepted" {
+		t.Errorf("expected status accepted, got %q", updated.Status)
+	}
+
+	tests := []struct {
+		status string
+		want   int
+	}{
+		{"", 2},
+		{"accepted", 1},
+		{"open", 1},
+		{"dismissed", 0},
+	}
+	for _, tt := range tests {
+		path := fmt.Sprintf("/api/v1/projects/%s/contradictions/review?status=%s", project.ID, tt.status)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status=%q: expected 200, got %d", tt.status, rec.Code)
+		}
+		var list []ContradictionResponse
+		decodeJSON(t, rec, &list)
+		if len(list) != tt.want {
+			t.Errorf("status=%q: expected %d items, got %d", tt.status, tt.want, len(list))
+		}
+		for _, item := range list {
+			if tt.status != "" && item.Status != tt.status {
+				t.Errorf("status=%q: got item with status %q", tt.status, item.Status)
+			}
+		}
+	}
+
+	// Re-detecting the same pair keeps the review status
+	again := &storage.Contradiction{
+		ProjectID:    project.ID,
+		Statement1ID: items[0].Statement2ID,
+		Statement2ID: items[0].Statement1ID,
+		Type:         "direct",
+		Severity:     "medium",
+	}
+	env.contradictions.Upsert(context.Background(), again)
+	if again.Status != "accepted" || again.ID != items[0].ID {
+		t.Errorf("expected upsert to keep status accepted on %s, got %q on %s", items[0].ID, again.Status, again.ID)
+	}
+}
+
+func TestContradictionReview_Validation(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	doc := env.seedDocument(t, project.ID, "a.md", "first statement", "second statement")
+	items := seedContradictions(t, env, project.ID, doc)
+
+	statusPath := fmt.Sprintf("/api/v1/projects/%s/contradictions/%s/status", project.ID, items[0].ID)
+
+	rec := env.do(t, http.MethodPut, statusPath, userID.String(), ContradictionStatusRequest{Status: "maybe"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid status, got %d", rec.Code)
+	}
+
+	rec = env.do(t, http.MethodPut, statusPath, uuid.NewString(), ContradictionStatusRequest{Status: "dismissed"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user, got %d", rec.Code)
+	}
+
+	other := env.seedProject(t, userID)
+	otherPath := fmt.Sprintf("/api/v1/projects/%s/contradictions/%s/status", other.ID, items[0].ID)
+	rec = env.do(t, http.MethodPut, otherPath, userID.String(), ContradictionStatusRequest{Status: "dismissed"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for contradiction in another project, got %d", rec.Code)
+	}
+}
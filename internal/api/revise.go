@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+// DocumentUpdateResponse reports a document revision: how its statements
+// changed compared with the previous content
+type DocumentUpdateResponse struct {
+	UploadResponse
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// handleUpdateDocument replaces the content of a document with a revised
+// file, uploaded as the "file" form field with the same extraction options
+// as an upload. The document keeps its ID while its statements are
+// re-extracted and re-embedded. Content identical to the current content is
+// reported with status "unchanged"; content matching another document of
+// the project is rejected with 409.
+func (s *Server) handleUpdateDocument(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	did, err := uuid.Parse(chi.URLParam(r, "documentID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid document id")
+		return
+	}
+
+	current, err := s.documentRepo.GetByID(r.Context(), did)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch document")
+		return
+	}
+	if current == nil || current.ProjectID != project.ID {
+		respondError(w, http.StatusNotFound, "document not found")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.uploadLimit())
+	if err := r.ParseMultipartForm(s.uploadLimit()); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d MB upload limit", s.uploadLimit()>>20))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "file too large or invalid form")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "no file provided")
+		return
+	}
+	defer file.Close()
+
+	opts, err := parseExtractOptions(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, doc, statements, err := s.prepareDocument(r.Context(), project.ID, header.Filename, file, opts)
+	if err != nil {
+		var ue *uploadError
+		if errors.As(err, &ue) {
+			respondError(w, ue.status, ue.message)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to process file")
+		return
+	}
+	if resp.Status == "exists" {
+		if resp.DocumentID != current.ID.String() {
+			respondError(w, http.StatusConflict, fmt.Sprintf("content is identical to document %s (%s)", resp.DocumentID, resp.Filename))
+			return
+		}
+		resp.Status = "unchanged"
+		respondJSON(w, http.StatusOK, DocumentUpdateResponse{UploadResponse: resp})
+		return
+	}
+
+	previous, err := s.statementRepo.GetByDocumentID(r.Context(), current.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+
+	// The revision replaces the current document rather than adding one
+	doc.ID = current.ID
+	doc.CreatedAt = current.CreatedAt
+	doc.EmbeddingTokens = current.EmbeddingTokens
+	for _, stmt := range statements {
+		stmt.DocumentID = current.ID
+	}
+
+	// Drop cached contradiction analyses for the replaced statements
+	if s.contradictionService != nil {
+		if err := s.contradictionService.InvalidateDocument(r.Context(), current.ID.String()); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to invalidate contradiction cache")
+			return
+		}
+	}
+
+	resp.DocumentID = current.ID.String()
+	resp.Status = "updated"
+	warning := s.embedDocument(r.Context(), project, doc, statements, nil)
+	resp.Warning, err = s.replaceDocument(r.Context(), doc, statements, warning)
+	if err != nil {
+		var ue *uploadError
+		if errors.As(err, &ue) {
+			respondError(w, ue.status, ue.message)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to save document")
+		return
+	}
+
+	response := DocumentUpdateResponse{UploadResponse: resp}
+	response.Added, response.Removed, response.Unchanged = diffStatements(previous, statements)
+
+	log.Printf("[upload] updated document %s in %v: %d added, %d removed, %d unchanged",
+		current.ID, time.Since(startTime), response.Added, response.Removed, response.Unchanged)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// diffStatements counts the statement texts only in after (added), only in
+// before (removed) and in both (unchanged). Repeated texts are counted as
+// often as they occur.
+func diffStatements(before, after []*storage.Statement) (added, removed, unchanged int) {
+	remaining := make(map[string]int, len(before))
+	for _, stmt := range before {
+		remaining[stmt.Text]++
+	}
+	for _, stmt := range after {
+		if remaining[stmt.Text] > 0 {
+			remaining[stmt.Text]--
+			unchanged++
+		} else {
+			added++
+		}
+	}
+	return added, len(before) - unchanged, unchanged
+}
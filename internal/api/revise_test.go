@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// updateDocument sends a revised file for a document
+func (e *testEnv) updateDocument(t *testing.T, projectID uuid.UUID, documentID, userID, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/projects/"+projectID.String()+"/documents/"+documentID, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID)
+
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleUpdateDocument(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	refunds := "Refunds are available for thirty days after the purchase date."
+	renewals := "Annual plans renew automatically unless they are cancelled first."
+	support := "Support requests are answered within two business days of receipt."
+	pricing := "Prices shown on the website exclude tax and may change without notice."
+
+	original := strings.Join([]string{refunds, renewals, support}, "\n\n")
+	rec := env.upload(t, project.ID, userID.String(), "terms.md", original)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var uploaded UploadResponse
+	decodeJSON(t, rec, &uploaded)
+	other := "Our office is closed on public holidays in every country we serve."
+	if rec := env.upload(t, project.ID, userID.String(), "office.md", other); rec.Code != http.StatusCreated {
+		t.Fatalf("upload: expected status 201, got %d", rec.Code)
+	}
+
+	revised := strings.Join([]string{refunds, renewals, pricing}, "\n\n")
+	rec = env.updateDocument(t, project.ID, uploaded.DocumentID, userID.String(), "terms-v2.md", revised)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp DocumentUpdateResponse
+	decodeJSON(t, rec, &resp)
+	if resp.DocumentID != uploaded.DocumentID || resp.Status != "updated" || resp.Filename != "terms-v2.md" {
+		t.Errorf("expected the document updated in place, got %+v", resp)
+	}
+	if resp.Added != 1 || resp.Removed != 1 || resp.Unchanged != 2 {
+		t.Errorf("expected 1 added, 1 removed and 2 unchanged, got %+v", resp)
+	}
+
+	docID := uuid.MustParse(uploaded.DocumentID)
+	doc, _ := env.documents.GetByID(context.Background(), docID)
+	if doc.Content != revised || doc.ContentHash == uploaded.Hash {
+		t.Errorf("expected the revised content and hash to be stored")
+	}
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), docID)
+	texts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		texts[i] = stmt.Text
+		if len(stmt.Embedding.Slice()) == 0 {
+			t.Errorf("expected statement %q to be embedded", stmt.Text)
+		}
+	}
+	sort.Strings(texts)
+	if want := []string{renewals, pricing, refunds}; strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected the revised statements, got %q", texts)
+	}
+
+	// Sending the current content again changes nothing
+	rec = env.updateDocument(t, project.ID, uploaded.DocumentID, userID.String(), "terms-v2.md", revised)
+	decodeJSON(t, rec, &resp)
+	if rec.Code != http.StatusOK || resp.Status != "unchanged" {
+		t.Errorf("expected status 200 unchanged, got %d: %+v", rec.Code, resp)
+	}
+
+	// Content of another document of the project is a conflict
+	rec = env.updateDocument(t, project.ID, uploaded.DocumentID, userID.String(), "terms.md", other)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := env.updateDocument(t, project.ID, uuid.NewString(), userID.String(), "terms.md", original); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown document, got %d", rec.Code)
+	}
+	if rec := env.updateDocument(t, project.ID, uploaded.DocumentID, uuid.NewString(), "terms.md", original); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for another user, got %d", rec.Code)
+	}
+}
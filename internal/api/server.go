@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -16,6 +20,7 @@ import (
 
 	"github.com/todmy/doc-analyzer/internal/anomaly"
 	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/charset"
 	"github.com/todmy/doc-analyzer/internal/clustering"
 	"github.com/todmy/doc-analyzer/internal/contradiction"
 	"github.com/todmy/doc-analyzer/internal/embeddings"
@@ -28,17 +33,44 @@ type Server struct {
 	router        *chi.Mux
 	db            *sql.DB
 	authService   auth.Service
+	authLimiter   *ipRateLimiter
 	projectRepo   storage.ProjectRepository
 	documentRepo  storage.DocumentRepository
 	statementRepo storage.StatementRepository
 
+	// skipFailedStatements commits the rows of a statement batch that can be
+	// inserted instead of rolling back the whole batch
+	skipFailedStatements bool
+
+	// uploadEncodingFallback decodes uploads that are neither UTF-8 nor UTF-16
+	uploadEncodingFallback charset.Fallback
+	// maxUploadSize caps a single uploaded document (0 = default)
+	maxUploadSize int64
+	// maxArchiveSize caps batch uploads and extracted archives (0 = default)
+	maxArchiveSize int64
+
+	contradictionRepo storage.ContradictionRepository
+	clusterModelRepo  storage.ClusterModelRepository
+	clusterRepo       storage.ClusterRepository
+
 	// Analysis services
 	embeddingClient      *embeddings.Client
+	embeddingModel       string // configured model, known even without an API key
+	embeddingReconcile   embeddings.ReconcileMode
 	clusteringService    *clustering.Service
 	similarityService    *similarity.Service
 	anomalyService       *anomaly.Service
 	contradictionService *contradiction.Service
 	visualizationService *visualization.Service
+
+	// analysisCache holds computed analysis responses; nil disables caching
+	analysisCache *analysisCache
+
+	// jobs tracks asynchronous uploads
+	jobs *jobStore
+
+	// janitor cleans up temp files and finished jobs; nil disables it
+	janitor *janitor
 }
 
 type ServerConfig struct {
@@ -46,20 +78,144 @@ type ServerConfig struct {
 	JWTSecret       string
 	OpenRouterKey   string
 	AnthropicAPIKey string
+
+	// AuthRatePerMinute limits requests to the public auth endpoints per
+	// client IP, allowing bursts of AuthRateBurst (0 disables the limit)
+	AuthRatePerMinute float64
+	AuthRateBurst     int
+
+	// ResetSender delivers password reset tokens. Without one, the
+	// forgot-password and reset-password endpoints answer 501.
+	ResetSender auth.ResetSender
+
+	// EmbeddingModel overrides the default embedding model
+	EmbeddingModel string
+
+	// EmbeddingRPS caps outgoing embedding requests per second (0 = unlimited)
+	EmbeddingRPS   float64
+	EmbeddingBurst int
+
+	// EmbeddingReconcile controls how mixed-dimension embeddings are handled
+	// during analysis (none, exclude or project)
+	EmbeddingReconcile embeddings.ReconcileMode
+
+	// VectorEFSearch and VectorProbes tune the embedding index scans of
+	// similarity search (hnsw.ef_search, ivfflat.probes); 0 keeps the
+	// database setting
+	VectorEFSearch int
+	VectorProbes   int
+
+	// SkipFailedStatements saves the statements of an upload that can be
+	// inserted and reports the rest, instead of failing the whole document
+	SkipFailedStatements bool
+
+	// UploadEncodingFallback handles uploads that are neither UTF-8 nor
+	// UTF-16 (windows-1252 by default, replace or reject)
+	UploadEncodingFallback charset.Fallback
+
+	// MaxUploadSize caps a single uploaded document, in bytes (0 uses the
+	// 10 MB default)
+	MaxUploadSize int64
+
+	// MaxArchiveSize caps a batch upload and the extracted content of its
+	// zip archives, in bytes (0 uses the 50 MB default)
+	MaxArchiveSize int64
+
+	// Analysis defaults; zero values keep each service's DefaultConfig
+	ClusterDefaultK            int
+	SimilarityThreshold        float64
+	SimilarityMetric           similarity.Metric
+	AnomalyDetector            anomaly.DetectorType
+	AnomalyThreshold           float64
+	ContradictionMinSimilarity float64
+
+	// ClusterMergeDistance merges visualization clusters whose centroids are
+	// closer than this in the normalized projection (0 disables merging)
+	ClusterMergeDistance float64
+
+	// ClusterMinPoints bounds the automatically chosen number of
+	// visualization clusters to one per this many points (0 = default 2)
+	ClusterMinPoints int
+
+	// ClusterMaxIter and ClusterTolerance bound each k-means fit: at most
+	// ClusterMaxIter iterations, stopping early once the inertia changes by
+	// less than ClusterTolerance (0 = defaults 100 and 1e-4)
+	ClusterMaxIter   int
+	ClusterTolerance float64
+
+	// AnalysisCacheSize is the number of analysis responses kept in memory
+	// (0 disables caching); entries expire after AnalysisCacheTTL
+	AnalysisCacheSize int
+	AnalysisCacheTTL  time.Duration
+
+	// ClusterPalette overrides the base cluster colors for visualization
+	ClusterPalette []string
+
+	// JanitorInterval is how often multipart temp files older than
+	// TempFileMaxAge and upload jobs finished more than JobRetention ago are
+	// removed (0 disables the janitor, a zero age or retention that part).
+	// Temp files are only swept from TempDir, the server's own directory
+	// that os.TempDir points to; empty leaves temp files alone. mime/multipart
+	// offers no way to pick the spill directory per request, so pointing
+	// os.TempDir there (via TMPDIR) is up to the caller and applies to the
+	// whole process.
+	JanitorInterval time.Duration
+	TempDir         string
+	TempFileMaxAge  time.Duration
+	JobRetention    time.Duration
+
+	// LLM backend for contradiction detection. With the default anthropic
+	// provider AnthropicAPIKey is used; with openai, OpenAIAPIKey (which may
+	// be empty for a local OpenAI-compatible server set via LLMBaseURL).
+	LLMProvider  contradiction.Provider
+	OpenAIAPIKey string
+	LLMBaseURL   string
+	LLMModel     string
+
+	// ContradictionPromptTemplate replaces the built-in contradiction
+	// prompt (see contradiction.Config.PromptTemplate) and
+	// ContradictionTypes adds contradiction types to the built-in ones
+	ContradictionPromptTemplate string
+	ContradictionTypes          []contradiction.ContradictionType
+	// ContradictionBatchSize is the number of pairs analyzed per LLM
+	// request (see contradiction.ServiceConfig.BatchSize)
+	ContradictionBatchSize int
+	// ContradictionMinConfidence is the confidence a contradiction needs to
+	// be reported (0 uses the service default)
+	ContradictionMinConfidence float64
+
+	// LogFormat is the request log format, text (default) or json
+	LogFormat string
+
+	// CORSOrigins are the browser origins allowed to call the API, e.g.
+	// https://app.example.com; a * may stand for a port or subdomain.
+	// Empty allows http://localhost on any port.
+	CORSOrigins []string
 }
 
-func NewServer(config ServerConfig) *Server {
+// NewServer builds the server and its services from config. An invalid
+// contradiction prompt template or type is an error.
+func NewServer(config ServerConfig) (*Server, error) {
+	requestLog, err := newRequestLogger(os.Stderr, config.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	r.Use(requestLogger(requestLog))
+	r.Use(middleware.Recoverer)
+	origins, err := corsOrigins(config.CORSOrigins)
+	if err != nil {
+		return nil, err
+	}
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:*", "https://*"},
+		AllowedOrigins:   origins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Embeddings-Excluded", "X-Embeddings-Projected", "X-Analysis-Cache", "X-Max-Pairs", "X-Candidate-Threshold", "X-Cluster-K", "X-Clustering"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -72,87 +228,307 @@ func NewServer(config ServerConfig) *Server {
 	}
 	authConfig := auth.DefaultConfig()
 	authConfig.SecretKey = jwtSecret
-	authService := auth.NewJWTService(authConfig, userRepo)
+	authOpts := []auth.ServiceOption{
+		auth.WithRefreshTokens(auth.NewPostgresRefreshTokenRepository(config.DB)),
+		auth.WithTokenDenylist(auth.NewPostgresTokenDenylist(config.DB)),
+	}
+	if config.ResetSender != nil {
+		authOpts = append(authOpts, auth.WithPasswordReset(auth.NewPostgresResetTokenRepository(config.DB), config.ResetSender))
+	}
+	authService := auth.NewJWTService(authConfig, userRepo, authOpts...)
 
 	// Initialize embedding client (optional - can work without it)
 	var embClient *embeddings.Client
 	if config.OpenRouterKey != "" {
-		embClient = embeddings.NewClient(config.OpenRouterKey)
+		opts := []embeddings.ClientOption{
+			embeddings.WithRateLimit(config.EmbeddingRPS, config.EmbeddingBurst),
+		}
+		if config.EmbeddingModel != "" {
+			opts = append(opts, embeddings.WithModel(config.EmbeddingModel))
+		}
+		embClient = embeddings.NewClient(config.OpenRouterKey, opts...)
 	}
 
 	// Initialize analysis services
-	clusteringSvc := clustering.NewService(clustering.DefaultConfig())
-	similaritySvc := similarity.NewService(0.75)
-	anomalySvc := anomaly.NewService(anomaly.DefaultConfig())
+	clusteringConfig := clustering.DefaultConfig()
+	clusteringConfig.MinClusterDistance = config.ClusterMergeDistance
+	if config.ClusterMinPoints > 0 {
+		clusteringConfig.MinPointsPerCluster = config.ClusterMinPoints
+	}
+	if config.ClusterDefaultK > 0 {
+		clusteringConfig.DefaultK = config.ClusterDefaultK
+	}
+	if config.ClusterMaxIter > 0 {
+		clusteringConfig.MaxIter = config.ClusterMaxIter
+	}
+	if config.ClusterTolerance > 0 {
+		clusteringConfig.Tolerance = config.ClusterTolerance
+	}
+	clusteringSvc := clustering.NewService(clusteringConfig)
+	similaritySvc := similarity.NewService(config.SimilarityThreshold)
+	similaritySvc.SetMetric(config.SimilarityMetric)
+	anomalyConfig := anomaly.DefaultConfig()
+	if config.AnomalyDetector != "" {
+		anomalyConfig.Detector = config.AnomalyDetector
+	}
+	if config.AnomalyThreshold > 0 {
+		anomalyConfig.Threshold = config.AnomalyThreshold
+	}
+	anomalySvc := anomaly.NewService(anomalyConfig)
 
 	// Initialize contradiction service (optional - needs API key)
 	var contradictionSvc *contradiction.Service
-	if config.AnthropicAPIKey != "" {
-		analyzer := contradiction.NewAnalyzer(contradiction.Config{
-			APIKey: config.AnthropicAPIKey,
-		})
-		contradictionSvc = contradiction.NewService(analyzer, contradiction.DefaultServiceConfig())
+	llmConfig := contradiction.Config{
+		Provider:       config.LLMProvider,
+		BaseURL:        config.LLMBaseURL,
+		Model:          config.LLMModel,
+		PromptTemplate: config.ContradictionPromptTemplate,
+		Types:          config.ContradictionTypes,
+	}
+	llmConfigured := false
+	switch config.LLMProvider {
+	case contradiction.ProviderOpenAI:
+		llmConfig.APIKey = config.OpenAIAPIKey
+		llmConfigured = config.OpenAIAPIKey != "" || config.LLMBaseURL != ""
+	default:
+		llmConfig.APIKey = config.AnthropicAPIKey
+		llmConfigured = config.AnthropicAPIKey != ""
+	}
+	if llmConfigured {
+		analyzer, err := contradiction.NewAnalyzer(llmConfig)
+		if err != nil {
+			return nil, err
+		}
+		serviceConfig := contradiction.DefaultServiceConfig()
+		serviceConfig.Cache = contradiction.NewPostgresCache(config.DB)
+		if config.ContradictionMinSimilarity > 0 {
+			serviceConfig.MinSimilarity = config.ContradictionMinSimilarity
+		}
+		if config.ContradictionMinConfidence > 0 {
+			serviceConfig.MinConfidence = config.ContradictionMinConfidence
+		}
+		if config.ContradictionBatchSize > 0 {
+			serviceConfig.BatchSize = config.ContradictionBatchSize
+		}
+		contradictionSvc = contradiction.NewService(analyzer, serviceConfig)
+	}
+
+	log.Printf("[config] analysis defaults: cluster k=%d, similarity threshold=%.2f metric=%s, anomaly detector=%s threshold=%.2f",
+		clusteringSvc.DefaultK(), similaritySvc.GetThreshold(), similaritySvc.GetMetric(), anomalySvc.GetDetector(), anomalySvc.GetThreshold())
+	if contradictionSvc != nil {
+		log.Printf("[config] contradiction min similarity=%.2f, min confidence=%.2f",
+			contradictionSvc.MinSimilarity(), contradictionSvc.MinConfidence(contradiction.DetectOptions{}))
 	}
 
 	// Initialize visualization service
-	visualizationSvc := visualization.NewService(visualization.DefaultConfig(), embClient)
+	visConfig := visualization.DefaultConfig()
+	if len(config.ClusterPalette) > 0 {
+		visConfig.Palette = config.ClusterPalette
+	}
+	visualizationSvc := visualization.NewService(visConfig, embClient)
+
+	statementRepo := storage.NewPostgresStatementRepository(config.DB,
+		storage.WithEFSearch(config.VectorEFSearch),
+		storage.WithProbes(config.VectorProbes),
+	)
 
 	s := &Server{
 		router:        r,
 		db:            config.DB,
 		authService:   authService,
+		authLimiter:   newIPRateLimiter(config.AuthRatePerMinute, config.AuthRateBurst),
 		projectRepo:   storage.NewPostgresProjectRepository(config.DB),
 		documentRepo:  storage.NewPostgresDocumentRepository(config.DB),
-		statementRepo: storage.NewPostgresStatementRepository(config.DB),
+		statementRepo: statementRepo,
+
+		skipFailedStatements:   config.SkipFailedStatements,
+		uploadEncodingFallback: config.UploadEncodingFallback,
+		maxUploadSize:          config.MaxUploadSize,
+		maxArchiveSize:         config.MaxArchiveSize,
+
+		contradictionRepo: storage.NewPostgresContradictionRepository(config.DB),
+		clusterModelRepo:  storage.NewPostgresClusterModelRepository(config.DB),
+		clusterRepo:       storage.NewPostgresClusterRepository(config.DB),
 
 		embeddingClient:      embClient,
+		embeddingModel:       config.EmbeddingModel,
+		embeddingReconcile:   config.EmbeddingReconcile,
 		clusteringService:    clusteringSvc,
 		similarityService:    similaritySvc,
 		anomalyService:       anomalySvc,
 		contradictionService: contradictionSvc,
 		visualizationService: visualizationSvc,
+
+		analysisCache: newAnalysisCache(config.AnalysisCacheSize, config.AnalysisCacheTTL),
+		jobs:          newJobStore(),
 	}
+	s.janitor = newJanitor(config.JanitorInterval, config.TempDir, config.TempFileMaxAge, config.JobRetention, s.jobs)
 	s.setupRoutes()
 
-	return s
+	return s, nil
+}
+
+// defaultCORSOrigins allow a local frontend on any port
+var defaultCORSOrigins = []string{"http://localhost:*"}
+
+// corsOrigins validates the allowed CORS origins, defaulting to
+// defaultCORSOrigins. Credentials are allowed, so an origin matching any
+// host ("*" or "https://*") is rejected: it would let every site make
+// authenticated requests. In the host a wildcard is only accepted as a
+// leading "*." followed by a domain of at least two labels, e.g.
+// "https://*.example.com"; the port may be a wildcard too.
+func corsOrigins(origins []string) ([]string, error) {
+	if len(origins) == 0 {
+		return defaultCORSOrigins, nil
+	}
+
+	valid := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			return nil, fmt.Errorf("invalid CORS origin %q: a wildcard host with credentials allows any site", origin)
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" {
+			return nil, fmt.Errorf("invalid CORS origin %q: expected http:// or https:// and a host", origin)
+		}
+		if host == "*" || strings.HasPrefix(host, "*:") {
+			return nil, fmt.Errorf("invalid CORS origin %q: a wildcard host with credentials allows any site", origin)
+		}
+		if !validWildcardHost(host) {
+			return nil, fmt.Errorf("invalid CORS origin %q: a wildcard must be a leading \"*.\" followed by a domain such as example.com", origin)
+		}
+		valid = append(valid, origin)
+	}
+	if len(valid) == 0 {
+		return defaultCORSOrigins, nil
+	}
+	return valid, nil
+}
+
+// validWildcardHost reports whether the wildcards of host, with an optional
+// port, are allowed: a port of "*", and a leading "*." followed by a
+// wildcard-free domain of at least two non-empty labels, so "*.example.com"
+// matches one site's subdomains but "*.com" is rejected
+func validWildcardHost(host string) bool {
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		if port := host[i+1:]; port != "*" && strings.Contains(port, "*") {
+			return false
+		}
+		host = host[:i]
+	}
+	if !strings.Contains(host, "*") {
+		return true
+	}
+	domain, ok := strings.CutPrefix(host, "*.")
+	if !ok || strings.Contains(domain, "*") {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" {
+			return false
+		}
+	}
+	return true
 }
 
 func (s *Server) setupRoutes() {
-	// Health check
-	s.router.Get("/health", s.handleHealth)
+	// Health checks: liveness is the process answering, readiness also
+	// needs the database
+	s.router.Get("/health", s.handleHealthReady)
+	s.router.Get("/health/ready", s.handleHealthReady)
+	s.router.Get("/health/live", s.handleHealthLive)
 
 	// API v1
 	s.router.Route("/api/v1", func(r chi.Router) {
-		// Auth routes (public)
-		r.Post("/auth/register", s.handleRegister)
-		r.Post("/auth/login", s.handleLogin)
+		// Auth routes (public, throttled per client IP)
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit(s.authLimiter))
+
+			r.Post("/auth/register", s.handleRegister)
+			r.Post("/auth/login", s.handleLogin)
+			r.Post("/auth/refresh", s.handleRefresh)
+			r.Post("/auth/forgot-password", s.handleForgotPassword)
+			r.Post("/auth/reset-password", s.handleResetPassword)
+		})
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(auth.Middleware(s.authService))
+			r.Use(logRequestUser)
+
+			r.Get("/auth/me", auth.NewHandlers(s.authService).Me)
+			r.Post("/auth/logout", s.handleLogout)
+
+			// Stateless analysis (nothing is persisted)
+			r.Post("/analyze/adhoc", s.handleAdhocAnalyze)
+			r.Post("/keywords/preview", s.handleKeywordPreview)
+			r.Post("/embeddings/estimate", s.handleEstimateEmbeddings)
+
+			r.Get("/documents/duplicates", s.handleListDuplicateDocuments)
+
+			r.Get("/statements/{statementID}", s.handleGetStatement)
+			r.Get("/statements/{statementID}/similar-global", s.handleGetGlobalSimilar)
 
 			// Projects
 			r.Route("/projects", func(r chi.Router) {
 				r.Get("/", s.handleListProjectsImpl)
 				r.Post("/", s.handleCreateProjectImpl)
+				r.Post("/bulk", s.handleBulkCreateProject)
 				r.Get("/{projectID}", s.handleGetProjectImpl)
+				r.Put("/{projectID}", s.handleUpdateProject)
 				r.Delete("/{projectID}", s.handleDeleteProjectImpl)
+				r.Get("/{projectID}/usage", s.handleGetProjectUsage)
+				r.Get("/{projectID}/config", s.handleGetProjectConfig)
+				r.Put("/{projectID}/config", s.handleUpdateProjectConfig)
 
 				// Documents
 				r.Post("/{projectID}/documents", s.handleUpload)
 				r.Get("/{projectID}/documents", s.handleListDocuments)
+				r.Put("/{projectID}/documents/{documentID}", s.handleUpdateDocument)
 				r.Delete("/{projectID}/documents/{documentID}", s.handleDeleteDocument)
 
+				// Asynchronous upload jobs
+				r.Get("/{projectID}/jobs/{jobID}", s.handleGetJob)
+				r.Get("/{projectID}/jobs/{jobID}/events", s.handleJobEvents)
+
+				// Statements
+				r.Get("/{projectID}/statements", s.handleListStatements)
+				r.Get("/{projectID}/statements/search", s.handleSearchStatements)
+				r.Post("/{projectID}/statements/tag-by-similarity", s.handleTagBySimilarity)
+
 				// Analysis
 				r.Post("/{projectID}/analyze", s.handleAnalyzeImpl)
+				r.Get("/{projectID}/analyze/estimate", s.handleGetAnalyzeEstimate)
+				r.Post("/{projectID}/reembed", s.handleReembedProject)
 				r.Get("/{projectID}/visualization", s.handleGetVisualizationImpl)
 				r.Post("/{projectID}/visualization/axes", s.handleSetAxesImpl)
 
 				// Results
 				r.Get("/{projectID}/clusters", s.handleGetClustersImpl)
+				r.Get("/{projectID}/clusters/metrics", s.handleGetClusterMetrics)
+				r.Get("/{projectID}/clusters/elbow", s.handleGetClusterElbow)
+				r.Post("/{projectID}/clusters/keywords", s.handleRecomputeClusterKeywords)
 				r.Get("/{projectID}/similar-pairs", s.handleGetSimilarPairsImpl)
 				r.Get("/{projectID}/anomalies", s.handleGetAnomaliesImpl)
+				r.Get("/{projectID}/anomalies/distribution", s.handleGetAnomalyDistribution)
+				r.Get("/{projectID}/anomalies/range", s.handleGetAnomalyRange)
 				r.Get("/{projectID}/contradictions", s.handleGetContradictionsImpl)
+				r.Get("/{projectID}/contradictions/graph", s.handleGetContradictionGraph)
+				r.Post("/{projectID}/contradictions/pair", s.handleAnalyzeContradictionPair)
+				r.Get("/{projectID}/findings", s.handleGetFindings)
+				r.Get("/{projectID}/export", s.handleExport)
+
+				// Contradiction review worklist
+				r.Get("/{projectID}/contradictions/review", s.handleListContradictionReview)
+				r.Put("/{projectID}/contradictions/{contradictionID}/status", s.handleUpdateContradictionStatus)
 			})
 		})
 	})
@@ -161,8 +537,30 @@ func (s *Server) setupRoutes() {
 	s.router.Get("/*", s.serveSPA)
 }
 
-func (s *Server) Run(addr string) error {
-	return http.ListenAndServe(addr, s.router)
+// shutdownTimeout bounds how long Run waits for in-flight requests once its
+// context is done
+const shutdownTimeout = 30 * time.Second
+
+// Run serves on addr until ctx is done, then shuts down gracefully. The
+// janitor runs for as long as the server does and stops when Run returns.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.janitor.run(ctx)
+
+	srv := &http.Server{Addr: addr, Handler: s.router}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	return srv.Shutdown(shutdownCtx)
 }
 
 // Helper to send JSON responses
@@ -0,0 +1,997 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/todmy/doc-analyzer/internal/anomaly"
+	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/clustering"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/similarity"
+	"github.com/todmy/doc-analyzer/internal/storage"
+	"github.com/todmy/doc-analyzer/internal/visualization"
+)
+
+// testEnv bundles a Server wired to in-memory repositories
+type testEnv struct {
+	server     *Server
+	projects   *memProjectRepo
+	documents  *memDocumentRepo
+	statements *memStatementRepo
+
+	contradictions *memContradictionRepo
+	clusterModels  *memClusterModelRepo
+	clusters       *memClusterRepo
+}
+
+// newTestEnv creates a server backed by in-memory repositories. If
+// embeddingURL is non-empty an embedding client pointing at it is configured.
+func newTestEnv(t *testing.T, embeddingURL string) *testEnv {
+	t.Helper()
+
+	projects := &memProjectRepo{items: map[uuid.UUID]*storage.Project{}}
+	documents := &memDocumentRepo{items: map[uuid.UUID]*storage.Document{}}
+	statements := &memStatementRepo{items: map[uuid.UUID]*storage.Statement{}, documents: documents, projects: projects}
+	documents.statements = statements
+	documents.projects = projects
+	contradictions := &memContradictionRepo{items: map[uuid.UUID]*storage.Contradiction{}, statements: statements}
+	clusterModels := &memClusterModelRepo{items: map[uuid.UUID]*storage.ClusterModel{}}
+	clusters := &memClusterRepo{items: map[uuid.UUID]*storage.Clustering{}}
+
+	var embClient *embeddings.Client
+	if embeddingURL != "" {
+		embClient = embeddings.NewClient("test-key", embeddings.WithBaseURL(embeddingURL))
+	}
+
+	var embProvider visualization.EmbeddingProvider
+	if embClient != nil {
+		embProvider = embClient
+	}
+
+	s := &Server{
+		router:        chi.NewRouter(),
+		authService:   fakeAuthService{},
+		projectRepo:   projects,
+		documentRepo:  documents,
+		statementRepo: statements,
+
+		contradictionRepo: contradictions,
+		clusterModelRepo:  clusterModels,
+		clusterRepo:       clusters,
+
+		embeddingClient:      embClient,
+		clusteringService:    clustering.NewService(clustering.DefaultConfig()),
+		similarityService:    similarity.NewService(0.75),
+		anomalyService:       anomaly.NewService(anomaly.DefaultConfig()),
+		visualizationService: visualization.NewService(visualization.DefaultConfig(), embProvider),
+		jobs:                 newJobStore(),
+	}
+	s.setupRoutes()
+
+	return &testEnv{
+		server:     s,
+		projects:   projects,
+		documents:  documents,
+		statements: statements,
+
+		contradictions: contradictions,
+		clusterModels:  clusterModels,
+		clusters:       clusters,
+	}
+}
+
+// do performs a request as the given user (empty userID sends no token)
+func (e *testEnv) do(t *testing.T, method, path, userID string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("Authorization", "Bearer "+userID)
+	}
+
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// upload posts a file to the project's document upload endpoint
+func (e *testEnv) upload(t *testing.T, projectID uuid.UUID, userID, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+projectID.String()+"/documents", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID)
+
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// seedProject creates a project owned by userID
+func (e *testEnv) seedProject(t *testing.T, userID uuid.UUID) *storage.Project {
+	t.Helper()
+	p := &storage.Project{UserID: userID, Name: "test"}
+	if err := e.projects.Create(context.Background(), p); err != nil {
+		t.Fatalf("failed to seed project: %v", err)
+	}
+	return p
+}
+
+// seedDocument creates a document with statements whose embeddings are
+// produced by fakeEmbedding
+func (e *testEnv) seedDocument(t *testing.T, projectID uuid.UUID, filename string, texts ...string) *storage.Document {
+	t.Helper()
+	doc := &storage.Document{ProjectID: projectID, Filename: filename, Content: strings.Join(texts, "\n\n"), ContentHash: uuid.NewString()}
+	if err := e.documents.Create(context.Background(), doc); err != nil {
+		t.Fatalf("failed to seed document: %v", err)
+	}
+	stmts := make([]*storage.Statement, len(texts))
+	for i, text := range texts {
+		stmts[i] = &storage.Statement{
+			DocumentID: doc.ID,
+			Text:       text,
+			Position:   i,
+			Line:       i + 1,
+			Embedding:  pgvector.NewVector(fakeEmbedding(text)),
+		}
+	}
+	if err := e.statements.CreateBatch(context.Background(), stmts); err != nil {
+		t.Fatalf("failed to seed statements: %v", err)
+	}
+	return doc
+}
+
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// fakeAuthService accepts any bearer token and treats it as the user ID
+type fakeAuthService struct{}
+
+func (fakeAuthService) Register(ctx context.Context, email, password string) (*auth.User, error) {
+	return &auth.User{ID: uuid.NewString(), Email: email}, nil
+}
+
+func (fakeAuthService) Login(ctx context.Context, email, password string) (*auth.TokenPair, error) {
+	return nil, auth.ErrInvalidCredentials
+}
+
+func (fakeAuthService) RefreshToken(ctx context.Context, refresh string) (string, error) {
+	return "", auth.ErrInvalidToken
+}
+
+func (fakeAuthService) RevokeRefreshToken(ctx context.Context, refresh string) error {
+	return auth.ErrInvalidToken
+}
+
+func (fakeAuthService) RevokeToken(ctx context.Context, claims *auth.Claims) error {
+	return nil
+}
+
+func (fakeAuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	return auth.ErrPasswordResetDisabled
+}
+
+func (fakeAuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return auth.ErrPasswordResetDisabled
+}
+
+func (fakeAuthService) ValidateToken(ctx context.Context, token string) (*auth.Claims, error) {
+	if _, err := uuid.Parse(token); err != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	return &auth.Claims{UserID: token, Email: token + "@example.com"}, nil
+}
+
+// fakeEmbeddingDim is the dimension of vectors returned by fakeEmbedding
+const fakeEmbeddingDim = 32
+
+// fakeEmbedding maps text to a normalized bag-of-words vector so texts that
+// share words are similar and texts with disjoint words are orthogonal-ish
+func fakeEmbedding(text string) []float32 {
+	vec := make([]float32, fakeEmbeddingDim)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.Trim(word, ".,;:!?")))
+		vec[h.Sum32()%fakeEmbeddingDim]++
+	}
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vec {
+			vec[i] = float32(float64(vec[i]) / norm)
+		}
+	}
+	return vec
+}
+
+// newFakeEmbeddingServer serves /embeddings using fakeEmbedding
+func newFakeEmbeddingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: fakeEmbedding(text)})
+			resp.Usage.TotalTokens += len(text) / 4
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// memProjectRepo is an in-memory storage.ProjectRepository
+type memProjectRepo struct {
+	mu     sync.Mutex
+	items  map[uuid.UUID]*storage.Project
+	writes int
+}
+
+func (r *memProjectRepo) Create(ctx context.Context, p *storage.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	cp := *p
+	r.items[p.ID] = &cp
+	r.writes++
+	return nil
+}
+
+func (r *memProjectRepo) GetByID(ctx context.Context, id uuid.UUID) (*storage.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.items[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (r *memProjectRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*storage.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*storage.Project
+	for _, p := range r.items {
+		if p.UserID == userID {
+			cp := *p
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID.String() < result[j].ID.String() })
+	return result, nil
+}
+
+func (r *memProjectRepo) Update(ctx context.Context, p *storage.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *p
+	r.items[p.ID] = &cp
+	r.writes++
+	return nil
+}
+
+func (r *memProjectRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	r.writes++
+	return nil
+}
+
+// memDocumentRepo is an in-memory storage.DocumentRepository
+type memDocumentRepo struct {
+	mu         sync.Mutex
+	items      map[uuid.UUID]*storage.Document
+	writes     int
+	statements *memStatementRepo
+	projects   *memProjectRepo
+	// saveErr, when set, fails CreateWithStatements as a rolled back
+	// transaction would, storing nothing
+	saveErr error
+}
+
+func (r *memDocumentRepo) Create(ctx context.Context, d *storage.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	cp := *d
+	r.items[d.ID] = &cp
+	r.writes++
+	return nil
+}
+
+func (r *memDocumentRepo) CreateWithStatements(ctx context.Context, d *storage.Document, statements []*storage.Statement, partial bool) (*storage.BatchResult, error) {
+	if r.saveErr != nil {
+		return nil, r.saveErr
+	}
+	if err := r.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	if err := r.statements.CreateBatch(ctx, statements); err != nil {
+		return nil, err
+	}
+	return &storage.BatchResult{Inserted: len(statements)}, nil
+}
+
+func (r *memDocumentRepo) UpdateWithStatements(ctx context.Context, d *storage.Document, statements []*storage.Statement, partial bool) (*storage.BatchResult, error) {
+	if r.saveErr != nil {
+		return nil, r.saveErr
+	}
+	if err := r.Update(ctx, d); err != nil {
+		return nil, err
+	}
+	if err := r.statements.DeleteByDocumentID(ctx, d.ID); err != nil {
+		return nil, err
+	}
+	if err := r.statements.CreateBatch(ctx, statements); err != nil {
+		return nil, err
+	}
+	return &storage.BatchResult{Inserted: len(statements)}, nil
+}
+
+func (r *memDocumentRepo) GetByID(ctx context.Context, id uuid.UUID) (*storage.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.items[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (r *memDocumentRepo) GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*storage.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*storage.Document
+	for _, d := range r.items {
+		if d.ProjectID == projectID {
+			cp := *d
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Filename < result[j].Filename })
+	return result, nil
+}
+
+func (r *memDocumentRepo) ListWithStats(ctx context.Context, projectID uuid.UUID, filter storage.DocumentFilter) ([]*storage.DocumentStats, error) {
+	docs, _ := r.GetByProjectID(ctx, projectID)
+	var result []*storage.DocumentStats
+	for _, d := range docs {
+		stmts, _ := r.statements.GetByDocumentID(ctx, d.ID)
+		stats := &storage.DocumentStats{Document: *d, StatementCount: len(stmts)}
+		for _, stmt := range stmts {
+			if len(stmt.Embedding.Slice()) > 0 {
+				stats.EmbeddedCount++
+			}
+		}
+		switch {
+		case stats.EmbeddedCount == stats.StatementCount:
+			stats.EmbeddingStatus = storage.EmbeddingStatusDone
+		case d.EmbeddingError != "":
+			stats.EmbeddingStatus = storage.EmbeddingStatusFailed
+		default:
+			stats.EmbeddingStatus = storage.EmbeddingStatusPending
+		}
+		if stats.StatementCount < filter.MinStatements ||
+			(filter.EmbeddingStatus != "" && stats.EmbeddingStatus != filter.EmbeddingStatus) {
+			continue
+		}
+		stats.Content = ""
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+func (r *memDocumentRepo) GetByHash(ctx context.Context, projectID uuid.UUID, hash string) (*storage.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.items {
+		if d.ProjectID == projectID && d.ContentHash == hash {
+			cp := *d
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memDocumentRepo) ListDuplicatesByUser(ctx context.Context, userID uuid.UUID) ([]*storage.Document, error) {
+	projects, _ := r.projects.GetByUserID(ctx, userID)
+	var docs []*storage.Document
+	counts := map[string]int{}
+	for _, p := range projects {
+		projectDocs, _ := r.GetByProjectID(ctx, p.ID)
+		for _, d := range projectDocs {
+			counts[d.ContentHash]++
+			d.Content = ""
+			docs = append(docs, d)
+		}
+	}
+	var result []*storage.Document
+	for _, d := range docs {
+		if counts[d.ContentHash] > 1 {
+			result = append(result, d)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ContentHash != result[j].ContentHash {
+			return result[i].ContentHash < result[j].ContentHash
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *memDocumentRepo) Update(ctx context.Context, d *storage.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *d
+	r.items[d.ID] = &cp
+	r.writes++
+	return nil
+}
+
+func (r *memDocumentRepo) AddEmbeddingTokens(ctx context.Context, id uuid.UUID, tokens int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d, ok := r.items[id]; ok {
+		d.EmbeddingTokens += tokens
+	}
+	r.writes++
+	return nil
+}
+
+func (r *memDocumentRepo) SetEmbeddingError(ctx context.Context, id uuid.UUID, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d, ok := r.items[id]; ok {
+		d.EmbeddingError = message
+	}
+	r.writes++
+	return nil
+}
+
+func (r *memDocumentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	r.writes++
+	return nil
+}
+
+func (r *memDocumentRepo) DeleteByProjectID(ctx context.Context, projectID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, d := range r.items {
+		if d.ProjectID == projectID {
+			delete(r.items, id)
+		}
+	}
+	r.writes++
+	return nil
+}
+
+// memStatementRepo is an in-memory storage.StatementRepository
+type memStatementRepo struct {
+	mu        sync.Mutex
+	items     map[uuid.UUID]*storage.Statement
+	documents *memDocumentRepo
+	projects  *memProjectRepo
+	writes    int
+	tags      map[uuid.UUID]map[string]bool
+}
+
+func (r *memStatementRepo) Create(ctx context.Context, s *storage.Statement) error {
+	return r.CreateBatch(ctx, []*storage.Statement{s})
+}
+
+func (r *memStatementRepo) CreateBatch(ctx context.Context, statements []*storage.Statement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range statements {
+		if s.ID == uuid.Nil {
+			s.ID = uuid.New()
+		}
+		cp := *s
+		r.items[s.ID] = &cp
+	}
+	r.writes++
+	return nil
+}
+
+func (r *memStatementRepo) CreateBatchPartial(ctx context.Context, statements []*storage.Statement) (*storage.BatchResult, error) {
+	if err := r.CreateBatch(ctx, statements); err != nil {
+		return nil, err
+	}
+	return &storage.BatchResult{Inserted: len(statements)}, nil
+}
+
+func (r *memStatementRepo) GetByID(ctx context.Context, id uuid.UUID) (*storage.Statement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.items[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (r *memStatementRepo) GetByDocumentID(ctx context.Context, documentID uuid.UUID) ([]*storage.Statement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*storage.Statement
+	for _, s := range r.items {
+		if s.DocumentID == documentID {
+			cp := *s
+			result = append(result, &cp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Position < result[j].Position })
+	return result, nil
+}
+
+func (r *memStatementRepo) GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*storage.Statement, error) {
+	docs, _ := r.documents.GetByProjectID(ctx, projectID)
+	var result []*storage.Statement
+	for _, d := range docs {
+		stmts, _ := r.GetByDocumentID(ctx, d.ID)
+		result = append(result, stmts...)
+	}
+	return result, nil
+}
+
+func (r *memStatementRepo) ListByProjectID(ctx context.Context, projectID uuid.UUID, limit, offset int) ([]*storage.Statement, error) {
+	stmts, _ := r.GetByProjectID(ctx, projectID)
+	if offset >= len(stmts) {
+		return nil, nil
+	}
+	stmts = stmts[offset:]
+	if len(stmts) > limit {
+		stmts = stmts[:limit]
+	}
+	for _, s := range stmts {
+		s.Embedding = pgvector.Vector{}
+	}
+	return stmts, nil
+}
+
+func (r *memStatementRepo) CountByProjectID(ctx context.Context, projectID uuid.UUID) (int, error) {
+	stmts, _ := r.GetByProjectID(ctx, projectID)
+	return len(stmts), nil
+}
+
+func (r *memStatementRepo) GetMissingEmbeddings(ctx context.Context, projectID uuid.UUID) ([]*storage.Statement, error) {
+	stmts, _ := r.GetByProjectID(ctx, projectID)
+	var result []*storage.Statement
+	for _, s := range stmts {
+		if len(s.Embedding.Slice()) == 0 {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (r *memStatementRepo) FindSimilar(ctx context.Context, embedding pgvector.Vector, limit int, threshold float64) ([]*storage.StatementWithSimilarity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*storage.StatementWithSimilarity
+	for _, s := range r.items {
+		sim := similarity.CosineSimilarity(embedding.Slice(), s.Embedding.Slice())
+		if sim >= threshold {
+			cp := *s
+			result = append(result, &storage.StatementWithSimilarity{Statement: &cp, Similarity: sim})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Similarity > result[j].Similarity })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindSimilarForUser scans the statements of every project owned by userID
+func (r *memStatementRepo) FindSimilarForUser(ctx context.Context, userID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*storage.ProjectStatementMatch, error) {
+	projects, _ := r.projects.GetByUserID(ctx, userID)
+	var result []*storage.ProjectStatementMatch
+	for _, p := range projects {
+		stmts, _ := r.GetByProjectID(ctx, p.ID)
+		for _, s := range stmts {
+			sim := similarity.CosineSimilarity(embedding.Slice(), s.Embedding.Slice())
+			if sim < threshold {
+				continue
+			}
+			doc, _ := r.documents.GetByID(ctx, s.DocumentID)
+			result = append(result, &storage.ProjectStatementMatch{Statement: s, ProjectID: p.ID, Filename: doc.Filename, Similarity: sim})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Similarity > result[j].Similarity })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindSimilarInProject scans the statements of one project
+func (r *memStatementRepo) FindSimilarInProject(ctx context.Context, projectID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*storage.StatementWithSimilarity, error) {
+	stmts, _ := r.GetByProjectID(ctx, projectID)
+	var result []*storage.StatementWithSimilarity
+	for _, s := range stmts {
+		if sim := similarity.CosineSimilarity(embedding.Slice(), s.Embedding.Slice()); sim >= threshold {
+			result = append(result, &storage.StatementWithSimilarity{Statement: s, Similarity: sim})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Similarity > result[j].Similarity })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// SearchByText matches statements containing every query word, ignoring case
+func (r *memStatementRepo) SearchByText(ctx context.Context, projectID uuid.UUID, query string, limit int) ([]*storage.StatementSearchResult, error) {
+	stmts, _ := r.GetByProjectID(ctx, projectID)
+	words := strings.Fields(strings.ToLower(query))
+	var result []*storage.StatementSearchResult
+	for _, s := range stmts {
+		text := strings.ToLower(s.Text)
+		matched := 0
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				matched++
+			}
+		}
+		if matched == 0 || matched < len(words) {
+			continue
+		}
+		doc, _ := r.documents.GetByID(ctx, s.DocumentID)
+		result = append(result, &storage.StatementSearchResult{Statement: s, Filename: doc.Filename, Rank: 1})
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *memStatementRepo) UpdateEmbeddings(ctx context.Context, statements []*storage.Statement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range statements {
+		if stored, ok := r.items[s.ID]; ok {
+			stored.Embedding = s.Embedding
+			stored.EmbeddingModel = s.EmbeddingModel
+		}
+	}
+	r.writes++
+	return nil
+}
+
+func (r *memStatementRepo) AddTag(ctx context.Context, statementIDs []uuid.UUID, tag string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tags == nil {
+		r.tags = map[uuid.UUID]map[string]bool{}
+	}
+	added := 0
+	for _, id := range statementIDs {
+		if r.tags[id] == nil {
+			r.tags[id] = map[string]bool{}
+		}
+		if !r.tags[id][tag] {
+			r.tags[id][tag] = true
+			added++
+		}
+	}
+	r.writes++
+	return added, nil
+}
+
+func (r *memStatementRepo) GetTags(ctx context.Context, statementID uuid.UUID) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tags := []string{}
+	for tag := range r.tags[statementID] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (r *memStatementRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	r.writes++
+	return nil
+}
+
+func (r *memStatementRepo) DeleteByDocumentID(ctx context.Context, documentID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.items {
+		if s.DocumentID == documentID {
+			delete(r.items, id)
+		}
+	}
+	r.writes++
+	return nil
+}
+
+// memContradictionRepo is an in-memory storage.ContradictionRepository
+type memContradictionRepo struct {
+	mu         sync.Mutex
+	items      map[uuid.UUID]*storage.Contradiction
+	statements *memStatementRepo
+}
+
+func (r *memContradictionRepo) Upsert(ctx context.Context, c *storage.Contradiction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.Statement1ID, c.Statement2ID = storage.CanonicalPair(c.Statement1ID, c.Statement2ID)
+	for _, existing := range r.items {
+		if existing.Statement1ID == c.Statement1ID && existing.Statement2ID == c.Statement2ID {
+			c.ID, c.Status, c.CreatedAt = existing.ID, existing.Status, existing.CreatedAt
+		}
+	}
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if c.Status == "" {
+		c.Status = storage.ReviewStatusOpen
+	}
+	cp := *c
+	r.items[c.ID] = &cp
+	return nil
+}
+
+func (r *memContradictionRepo) populate(c *storage.Contradiction) *storage.Contradiction {
+	cp := *c
+	ctx := context.Background()
+	if s1, _ := r.statements.GetByID(ctx, c.Statement1ID); s1 != nil {
+		cp.Text1 = s1.Text
+		if d, _ := r.statements.documents.GetByID(ctx, s1.DocumentID); d != nil {
+			cp.File1 = d.Filename
+		}
+	}
+	if s2, _ := r.statements.GetByID(ctx, c.Statement2ID); s2 != nil {
+		cp.Text2 = s2.Text
+		if d, _ := r.statements.documents.GetByID(ctx, s2.DocumentID); d != nil {
+			cp.File2 = d.Filename
+		}
+	}
+	return &cp
+}
+
+func (r *memContradictionRepo) GetByID(ctx context.Context, id uuid.UUID) (*storage.Contradiction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.items[id]
+	if !ok {
+		return nil, nil
+	}
+	return r.populate(c), nil
+}
+
+func (r *memContradictionRepo) GetByProjectID(ctx context.Context, projectID uuid.UUID, status string) ([]*storage.Contradiction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*storage.Contradiction
+	for _, c := range r.items {
+		if c.ProjectID == projectID && (status == "" || c.Status == status) {
+			result = append(result, r.populate(c))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID.String() < result[j].ID.String() })
+	return result, nil
+}
+
+func (r *memContradictionRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.items[id]; ok {
+		c.Status = status
+	}
+	return nil
+}
+
+func TestNewServer_AnalysisDefaults(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		JWTSecret:                  "secret",
+		AnthropicAPIKey:            "test-key",
+		ClusterDefaultK:            8,
+		SimilarityThreshold:        0.9,
+		AnomalyDetector:            anomaly.DetectorIsolation,
+		AnomalyThreshold:           0.6,
+		ContradictionMinSimilarity: 0.65,
+	})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if got := s.clusteringService.DefaultK(); got != 8 {
+		t.Errorf("expected default k 8, got %d", got)
+	}
+	if got := s.similarityService.GetThreshold(); got != 0.9 {
+		t.Errorf("expected similarity threshold 0.9, got %v", got)
+	}
+	if got := s.anomalyService.GetDetector(); got != anomaly.DetectorIsolation {
+		t.Errorf("expected isolation detector, got %q", got)
+	}
+	if got := s.anomalyService.GetThreshold(); got != 0.6 {
+		t.Errorf("expected anomaly threshold 0.6, got %v", got)
+	}
+	if got := s.contradictionService.MinSimilarity(); got != 0.65 {
+		t.Errorf("expected contradiction min similarity 0.65, got %v", got)
+	}
+
+	// Zero values keep the built-in defaults
+	s, err = NewServer(ServerConfig{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if got := s.clusteringService.DefaultK(); got != clustering.DefaultConfig().DefaultK {
+		t.Errorf("expected built-in default k, got %d", got)
+	}
+	if got := s.similarityService.GetThreshold(); got != similarity.DefaultThreshold {
+		t.Errorf("expected built-in similarity threshold, got %v", got)
+	}
+	if got := s.anomalyService.GetDetector(); got != anomaly.DefaultConfig().Detector {
+		t.Errorf("expected built-in detector, got %q", got)
+	}
+}
+
+func TestNewServer_CORSOrigins(t *testing.T) {
+	preflight := func(s *Server, origin string) string {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/projects", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	s, err := NewServer(ServerConfig{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if got := preflight(s, "http://localhost:5173"); got != "http://localhost:5173" {
+		t.Errorf("expected localhost to be allowed by default, got %q", got)
+	}
+	if got := preflight(s, "https://evil.example"); got != "" {
+		t.Errorf("expected other origins to be rejected by default, got %q", got)
+	}
+
+	s, err = NewServer(ServerConfig{JWTSecret: "secret", CORSOrigins: []string{" https://app.example.com ", "https://*.example.org"}})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	for origin, want := range map[string]string{
+		"https://app.example.com":   "https://app.example.com",
+		"https://admin.example.org": "https://admin.example.org",
+		"http://localhost:5173":     "",
+	} {
+		if got := preflight(s, origin); got != want {
+			t.Errorf("%s: expected allowed origin %q, got %q", origin, want, got)
+		}
+	}
+
+	if _, err := NewServer(ServerConfig{JWTSecret: "secret", CORSOrigins: []string{"https://*.example.co.uk", "http://*.example.org:8080", "http://localhost:*"}}); err != nil {
+		t.Errorf("expected subdomain and port wildcards to be accepted, got %v", err)
+	}
+
+	for _, origins := range [][]string{
+		{"*"}, {"https://*"}, {"http://*:8080"}, {"app.example.com"}, {"ftp://files.example.com"},
+		{"https://*.com"}, {"https://*example.com"}, {"https://app.*.example.com"}, {"https://*.*.example.com"},
+		{"https://*.example..com"}, {"https://*.example.com*"}, {"http://localhost:80*"},
+	} {
+		if _, err := NewServer(ServerConfig{JWTSecret: "secret", CORSOrigins: origins}); err == nil {
+			t.Errorf("expected origins %v to be rejected", origins)
+		}
+	}
+}
+
+// memClusterModelRepo is an in-memory storage.ClusterModelRepository
+type memClusterModelRepo struct {
+	mu    sync.Mutex
+	items map[uuid.UUID]*storage.ClusterModel
+}
+
+func (r *memClusterModelRepo) GetByProjectID(ctx context.Context, projectID uuid.UUID) (*storage.ClusterModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.items[projectID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *m
+	return &cp, nil
+}
+
+func (r *memClusterModelRepo) Save(ctx context.Context, model *storage.ClusterModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *model
+	r.items[model.ProjectID] = &cp
+	return nil
+}
+
+type memClusterRepo struct {
+	mu    sync.Mutex
+	items map[uuid.UUID]*storage.Clustering
+}
+
+func (r *memClusterRepo) GetByProjectID(ctx context.Context, projectID uuid.UUID) (*storage.Clustering, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.items[projectID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (r *memClusterRepo) Replace(ctx context.Context, clustering *storage.Clustering) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range clustering.Clusters {
+		c.ID = uuid.New()
+		c.ProjectID = clustering.ProjectID
+	}
+	cp := *clustering
+	r.items[clustering.ProjectID] = &cp
+	return nil
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+
+	defaultStatementPageSize = 50
+	maxStatementPageSize     = 200
+
+	defaultGlobalSimilarLimit = 20
+	maxGlobalSimilarLimit     = 100
+)
+
+// StatementResponse is a statement with its full text and source location
+type StatementResponse struct {
+	ID         string   `json:"id"`
+	DocumentID string   `json:"document_id"`
+	File       string   `json:"file"`
+	Text       string   `json:"text"`
+	Position   int      `json:"position"`
+	Line       int      `json:"line"`
+	Section    string   `json:"section,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// StatementListResponse is a page of a project's statements
+type StatementListResponse struct {
+	Items    []StatementResponse `json:"items"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int                 `json:"total"`
+}
+
+// StatementSearchResult is a statement matching a full-text search
+type StatementSearchResult struct {
+	ID         string  `json:"id"`
+	DocumentID string  `json:"document_id"`
+	Text       string  `json:"text"`
+	File       string  `json:"file"`
+	Line       int     `json:"line"`
+	Rank       float64 `json:"rank"`
+}
+
+// StatementSearchResponse lists full-text search matches, best first
+type StatementSearchResponse struct {
+	Query   string                  `json:"query"`
+	Results []StatementSearchResult `json:"results"`
+}
+
+// handleSearchStatements finds statements in a project containing the words
+// of the q parameter
+func (s *Server) handleSearchStatements(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxSearchLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+
+	matches, err := s.statementRepo.SearchByText(r.Context(), project.ID, q, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to search statements")
+		return
+	}
+
+	results := make([]StatementSearchResult, len(matches))
+	for i, m := range matches {
+		results[i] = StatementSearchResult{
+			ID:         m.Statement.ID.String(),
+			DocumentID: m.Statement.DocumentID.String(),
+			Text:       m.Statement.Text,
+			File:       m.Filename,
+			Line:       m.Statement.Line,
+			Rank:       m.Rank,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, StatementSearchResponse{Query: q, Results: results})
+}
+
+// handleListStatements returns a project's statements in document order,
+// paginated with page and page_size
+func (s *Server) handleListStatements(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize, ok := parsePage(w, r, defaultStatementPageSize, maxStatementPageSize)
+	if !ok {
+		return
+	}
+
+	total, err := s.statementRepo.CountByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+
+	// Only the requested page is loaded; pages past the end are empty
+	start, end := pageBounds(page, pageSize, total)
+	var statements []*storage.Statement
+	if start < end {
+		statements, err = s.statementRepo.ListByProjectID(r.Context(), project.ID, end-start, start)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+			return
+		}
+	}
+
+	docs, err := s.documentRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch documents")
+		return
+	}
+	filenames := make(map[uuid.UUID]string, len(docs))
+	for _, doc := range docs {
+		filenames[doc.ID] = doc.Filename
+	}
+
+	items := make([]StatementResponse, 0, len(statements))
+	for _, stmt := range statements {
+		items = append(items, newStatementResponse(stmt, filenames[stmt.DocumentID]))
+	}
+
+	respondJSON(w, http.StatusOK, StatementListResponse{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// authorizeStatement loads the statement named by the statementID URL
+// parameter and verifies the authenticated user owns the project of its
+// document. On failure it writes the error response (400 bad id, 404
+// missing, 403 not owner) and returns false.
+func (s *Server) authorizeStatement(w http.ResponseWriter, r *http.Request) (*storage.Statement, *storage.Document, *storage.Project, bool) {
+	sid, err := uuid.Parse(chi.URLParam(r, "statementID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid statement id")
+		return nil, nil, nil, false
+	}
+
+	stmt, err := s.statementRepo.GetByID(r.Context(), sid)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statement")
+		return nil, nil, nil, false
+	}
+	if stmt == nil {
+		respondError(w, http.StatusNotFound, "statement not found")
+		return nil, nil, nil, false
+	}
+
+	doc, err := s.documentRepo.GetByID(r.Context(), stmt.DocumentID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch document")
+		return nil, nil, nil, false
+	}
+	if doc == nil {
+		respondError(w, http.StatusNotFound, "statement not found")
+		return nil, nil, nil, false
+	}
+
+	project, err := s.projectRepo.GetByID(r.Context(), doc.ProjectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+		return nil, nil, nil, false
+	}
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if project == nil || !ok || project.UserID.String() != claims.UserID {
+		respondError(w, http.StatusForbidden, "access denied")
+		return nil, nil, nil, false
+	}
+
+	return stmt, doc, project, true
+}
+
+// handleGetStatement returns a single statement with its tags
+func (s *Server) handleGetStatement(w http.ResponseWriter, r *http.Request) {
+	stmt, doc, _, ok := s.authorizeStatement(w, r)
+	if !ok {
+		return
+	}
+
+	response := newStatementResponse(stmt, doc.Filename)
+	tags, err := s.statementRepo.GetTags(r.Context(), stmt.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch statement tags")
+		return
+	}
+	response.Tags = tags
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// GlobalSimilarMatch is a similar statement from any of the caller's projects
+type GlobalSimilarMatch struct {
+	StatementResponse
+	ProjectID   string  `json:"project_id"`
+	ProjectName string  `json:"project_name"`
+	Similarity  float64 `json:"similarity"`
+}
+
+// GlobalSimilarResponse lists statements similar to a source statement
+// across all of the caller's projects, most similar first
+type GlobalSimilarResponse struct {
+	Statement StatementResponse    `json:"statement"`
+	Threshold float64              `json:"threshold"`
+	Matches   []GlobalSimilarMatch `json:"matches"`
+}
+
+// handleGetGlobalSimilar finds statements similar to the given one in every
+// project owned by the caller. Optional threshold and limit parameters
+// default to the similarity service threshold and 20 matches.
+func (s *Server) handleGetGlobalSimilar(w http.ResponseWriter, r *http.Request) {
+	stmt, doc, project, ok := s.authorizeStatement(w, r)
+	if !ok {
+		return
+	}
+
+	if len(stmt.Embedding.Slice()) == 0 {
+		respondError(w, http.StatusUnprocessableEntity, "statement has no embedding")
+		return
+	}
+
+	threshold := s.similarityService.GetThreshold()
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "threshold must be in (0, 1]")
+			return
+		}
+		threshold = parsed
+	}
+	limit := defaultGlobalSimilarLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxGlobalSimilarLimit {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxGlobalSimilarLimit))
+			return
+		}
+	}
+
+	// Ask for one extra match since the source statement matches itself
+	matches, err := s.statementRepo.FindSimilarForUser(r.Context(), project.UserID, stmt.Embedding, limit+1, threshold)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to find similar statements")
+		return
+	}
+
+	projects, err := s.projectRepo.GetByUserID(r.Context(), project.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch projects")
+		return
+	}
+	names := make(map[uuid.UUID]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+
+	response := GlobalSimilarResponse{
+		Statement: newStatementResponse(stmt, doc.Filename),
+		Threshold: threshold,
+		Matches:   make([]GlobalSimilarMatch, 0, len(matches)),
+	}
+	for _, m := range matches {
+		if m.Statement.ID == stmt.ID || len(response.Matches) == limit {
+			continue
+		}
+		response.Matches = append(response.Matches, GlobalSimilarMatch{
+			StatementResponse: newStatementResponse(m.Statement, m.Filename),
+			ProjectID:         m.ProjectID.String(),
+			ProjectName:       names[m.ProjectID],
+			Similarity:        m.Similarity,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+func newStatementResponse(stmt *storage.Statement, filename string) StatementResponse {
+	return StatementResponse{
+		ID:         stmt.ID.String(),
+		DocumentID: stmt.DocumentID.String(),
+		File:       filename,
+		Text:       stmt.Text,
+		Position:   stmt.Position,
+		Line:       stmt.Line,
+		Section:    stmt.Section,
+	}
+}
+
+// parsePage reads the page (1-based) and page_size query parameters. On
+// invalid values it writes a 400 response and returns false.
+func parsePage(w http.ResponseWriter, r *http.Request, defaultSize, maxSize int) (int, int, bool) {
+	var err error
+	query := r.URL.Query()
+
+	page := 1
+	if v := query.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			respondError(w, http.StatusBadRequest, "page must be a positive integer")
+			return 0, 0, false
+		}
+	}
+	pageSize := defaultSize
+	if v := query.Get("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxSize {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("page_size must be between 1 and %d", maxSize))
+			return 0, 0, false
+		}
+	}
+	return page, pageSize, true
+}
+
+// pageBounds returns the slice bounds of a page over total items. Pages
+// past the end are empty; the page is compared before multiplying so huge
+// page numbers cannot overflow.
+func pageBounds(page, pageSize, total int) (int, int) {
+	if page < 1 || pageSize < 1 || total <= 0 || page-1 > (total-1)/pageSize {
+		return total, total
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleSearchStatements(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "policy.md",
+		"Refunds are available for thirty days after purchase",
+		"Shipping is free on orders over fifty dollars",
+	)
+	env.seedDocument(t, project.ID, "faq.md", "No refunds are given for digital goods")
+
+	other := env.seedProject(t, userID)
+	env.seedDocument(t, other.ID, "other.md", "Refunds in another project must not appear")
+
+	path := fmt.Sprintf("/api/v1/projects/%s/statements/search?q=refunds", project.ID)
+	rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp StatementSearchResponse
+	decodeJSON(t, rec, &resp)
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", resp.Results)
+	}
+	files := map[string]int{}
+	for _, r := range resp.Results {
+		files[r.File] = r.Line
+	}
+	if line, ok := files["policy.md"]; !ok || line != 1 {
+		t.Errorf("expected policy.md line 1 in results, got %+v", resp.Results)
+	}
+	if _, ok := files["faq.md"]; !ok {
+		t.Errorf("expected faq.md in results, got %+v", resp.Results)
+	}
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/statements/search?q=refunds&limit=1", project.ID), userID.String(), nil)
+	decodeJSON(t, rec, &resp)
+	if len(resp.Results) != 1 {
+		t.Errorf("expected limit to cap results at 1, got %d", len(resp.Results))
+	}
+
+	for _, query := range []string{"", "q=refunds&limit=0", "q=refunds&limit=abc"} {
+		path := fmt.Sprintf("/api/v1/projects/%s/statements/search?%s", project.ID, query)
+		if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHandleListStatements(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md", "first statement", "second statement", "third statement")
+	env.seedDocument(t, project.ID, "b.md", "fourth statement", "fifth statement")
+
+	get := func(query string) StatementListResponse {
+		t.Helper()
+		path := fmt.Sprintf("/api/v1/projects/%s/statements?%s", project.ID, query)
+		rec := env.do(t, http.MethodGet, path, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp StatementListResponse
+		decodeJSON(t, rec, &resp)
+		return resp
+	}
+
+	resp := get("")
+	if resp.Total != 5 || len(resp.Items) != 5 || resp.PageSize != defaultStatementPageSize {
+		t.Fatalf("expected all 5 statements on one default page, got %+v", resp)
+	}
+
+	resp = get("page=2&page_size=2")
+	if resp.Total != 5 || len(resp.Items) != 2 {
+		t.Fatalf("expected 2 of 5 statements, got %+v", resp)
+	}
+	if got := resp.Items[0]; got.Text != "third statement" || got.File != "a.md" || got.Line != 3 || got.Position != 2 {
+		t.Errorf("unexpected first item on page 2: %+v", got)
+	}
+
+	if resp = get("page=9"); len(resp.Items) != 0 || resp.Total != 5 {
+		t.Errorf("expected empty page past the end, got %+v", resp)
+	}
+	// Page numbers whose offset overflows are past the end too
+	if resp = get("page=4611686018427387905&page_size=2"); len(resp.Items) != 0 || resp.Total != 5 {
+		t.Errorf("expected empty page for a huge page number, got %+v", resp)
+	}
+
+	for _, query := range []string{"page=0", "page_size=201", "page_size=x"} {
+		path := fmt.Sprintf("/api/v1/projects/%s/statements?%s", project.ID, query)
+		if rec := env.do(t, http.MethodGet, path, userID.String(), nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page, pageSize, total int
+		start, end            int
+	}{
+		{1, 10, 5, 0, 5},
+		{2, 2, 5, 2, 4},
+		{3, 2, 5, 4, 5},
+		{4, 2, 5, 5, 5},
+		{1, 10, 0, 0, 0},
+		{0, 10, 5, 5, 5},
+		{math.MaxInt/2 + 2, 2, 5, 5, 5},
+		{math.MaxInt, math.MaxInt, 5, 5, 5},
+	}
+	for _, tt := range tests {
+		start, end := pageBounds(tt.page, tt.pageSize, tt.total)
+		if start != tt.start || end != tt.end {
+			t.Errorf("pageBounds(%d, %d, %d) = %d, %d; want %d, %d", tt.page, tt.pageSize, tt.total, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestHandleGetStatement(t *testing.T) {
+	env := newTestEnv(t, "")
+	owner := uuid.New()
+	project := env.seedProject(t, owner)
+	doc := env.seedDocument(t, project.ID, "a.md", "short", "a much longer statement whose full text the frontend wants to show")
+
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), doc.ID)
+	target := stmts[1]
+
+	rec := env.do(t, http.MethodGet, "/api/v1/statements/"+target.ID.String(), owner.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp StatementResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Text != target.Text || resp.File != "a.md" || resp.DocumentID != doc.ID.String() || resp.Line != 2 {
+		t.Errorf("unexpected statement %+v", resp)
+	}
+
+	cases := []struct {
+		name   string
+		id     string
+		user   string
+		status int
+	}{
+		{"other user", target.ID.String(), uuid.NewString(), http.StatusForbidden},
+		{"missing", uuid.NewString(), owner.String(), http.StatusNotFound},
+		{"invalid id", "not-a-uuid", owner.String(), http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		rec := env.do(t, http.MethodGet, "/api/v1/statements/"+tc.id, tc.user, nil)
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, rec.Code)
+		}
+	}
+}
+
+func TestHandleGetGlobalSimilar(t *testing.T) {
+	env := newTestEnv(t, "")
+	owner := uuid.New()
+	first := env.seedProject(t, owner)
+	second := env.seedProject(t, owner)
+	foreign := env.seedProject(t, uuid.New())
+
+	const text = "customers may request a refund within thirty days"
+	source := env.seedDocument(t, first.ID, "a.md", text, "customers may visit the office on weekdays")
+	env.seedDocument(t, second.ID, "b.md", text)
+	env.seedDocument(t, foreign.ID, "c.md", text)
+
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), source.ID)
+	sourceID := stmts[0].ID
+
+	rec := env.do(t, http.MethodGet, "/api/v1/statements/"+sourceID.String()+"/similar-global?threshold=0.9", owner.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp GlobalSimilarResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Statement.ID != sourceID.String() {
+		t.Errorf("expected source statement %s, got %s", sourceID, resp.Statement.ID)
+	}
+	if len(resp.Matches) != 1 {
+		t.Fatalf("expected 1 match outside the source statement, got %+v", resp.Matches)
+	}
+	match := resp.Matches[0]
+	if match.ProjectID != second.ID.String() || match.File != "b.md" || match.ProjectName != second.Name {
+		t.Errorf("expected match in second project's b.md, got %+v", match)
+	}
+	for _, m := range resp.Matches {
+		if m.ProjectID == foreign.ID.String() {
+			t.Errorf("match from another user's project leaked: %+v", m)
+		}
+	}
+
+	// Lower threshold: the loosely related statement in the first project
+	// joins, so matches span both owned projects
+	rec = env.do(t, http.MethodGet, "/api/v1/statements/"+sourceID.String()+"/similar-global?threshold=0.1", owner.String(), nil)
+	decodeJSON(t, rec, &resp)
+	projects := map[string]bool{}
+	for _, m := range resp.Matches {
+		projects[m.ProjectID] = true
+		if m.ID == sourceID.String() {
+			t.Error("source statement must not match itself")
+		}
+	}
+	if !projects[first.ID.String()] || !projects[second.ID.String()] {
+		t.Errorf("expected matches from both owned projects, got %+v", resp.Matches)
+	}
+	if projects[foreign.ID.String()] {
+		t.Error("match from another user's project leaked")
+	}
+
+	if rec := env.do(t, http.MethodGet, "/api/v1/statements/"+sourceID.String()+"/similar-global", uuid.NewString(), nil); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for another user's statement, got %d", rec.Code)
+	}
+	if rec := env.do(t, http.MethodGet, "/api/v1/statements/"+sourceID.String()+"/similar-global?limit=0", owner.String(), nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for limit=0, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/todmy/doc-analyzer/internal/preprocess"
+)
+
+const (
+	maxTagLength = 100
+
+	defaultTagBySimilarityLimit = 500
+	maxTagBySimilarityLimit     = 5000
+)
+
+// TagBySimilarityRequest selects the statements similar to Query and tags
+// them. Threshold defaults to the similarity service threshold and Limit
+// caps the number of statements tagged.
+type TagBySimilarityRequest struct {
+	Query     string  `json:"query"`
+	Tag       string  `json:"tag"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Limit     int     `json:"limit,omitempty"`
+}
+
+// TagBySimilarityResponse reports the statements a tag was applied to.
+// Tagged counts every matching statement, Added only those that did not
+// carry the tag yet.
+type TagBySimilarityResponse struct {
+	Tag          string   `json:"tag"`
+	Threshold    float64  `json:"threshold"`
+	Tagged       int      `json:"tagged"`
+	Added        int      `json:"added"`
+	StatementIDs []string `json:"statement_ids"`
+}
+
+// handleTagBySimilarity embeds a query text and tags every statement of the
+// project whose embedding is at least threshold similar to it
+func (s *Server) handleTagBySimilarity(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req TagBySimilarityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Query = strings.TrimSpace(req.Query)
+	req.Tag = strings.TrimSpace(req.Tag)
+	if req.Query == "" {
+		respondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	if req.Tag == "" || len(req.Tag) > maxTagLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("tag must be between 1 and %d characters", maxTagLength))
+		return
+	}
+	if req.Threshold < 0 || req.Threshold > 1 {
+		respondError(w, http.StatusBadRequest, "threshold must be between 0 and 1")
+		return
+	}
+	if req.Threshold == 0 {
+		req.Threshold = s.similarityService.GetThreshold()
+	}
+	if req.Limit < 0 || req.Limit > maxTagBySimilarityLimit {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxTagBySimilarityLimit))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultTagBySimilarityLimit
+	}
+
+	if s.embeddingClient == nil {
+		respondError(w, http.StatusServiceUnavailable, "embedding service not configured - set OPENROUTER_API_KEY")
+		return
+	}
+
+	// Embed the query the way the project's statements were embedded
+	pipeline, _ := preprocess.Parse(project.Preprocessing)
+	embedding, err := s.embeddingClient.EmbedText(r.Context(), pipeline.Apply(req.Query))
+	if err != nil {
+		respondError(w, http.StatusBadGateway, embeddingErrorMessage(err))
+		return
+	}
+
+	matches, err := s.statementRepo.FindSimilarInProject(r.Context(), project.ID, pgvector.NewVector(embedding), req.Limit, req.Threshold)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to find similar statements")
+		return
+	}
+
+	ids := make([]uuid.UUID, len(matches))
+	response := TagBySimilarityResponse{
+		Tag:          req.Tag,
+		Threshold:    req.Threshold,
+		Tagged:       len(matches),
+		StatementIDs: make([]string, len(matches)),
+	}
+	for i, m := range matches {
+		ids[i] = m.Statement.ID
+		response.StatementIDs[i] = m.Statement.ID.String()
+	}
+
+	response.Added, err = s.statementRepo.AddTag(r.Context(), ids, req.Tag)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to tag statements")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleTagBySimilarity(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	doc := env.seedDocument(t, project.ID, "a.md",
+		"refunds are available for thirty days after purchase",
+		"refunds are available for sixty days after purchase",
+		"support tickets get answered within one business day",
+	)
+	stmts, _ := env.statements.GetByDocumentID(t.Context(), doc.ID)
+
+	path := fmt.Sprintf("/api/v1/projects/%s/statements/tag-by-similarity", project.ID)
+	body := TagBySimilarityRequest{Query: "refunds are available for ninety days after purchase", Tag: "refunds", Threshold: 0.8}
+	rec := env.do(t, http.MethodPost, path, userID.String(), body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp TagBySimilarityResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Tagged != 2 || resp.Added != 2 {
+		t.Errorf("expected 2 statements tagged and added, got %d and %d", resp.Tagged, resp.Added)
+	}
+
+	for i, stmt := range stmts {
+		rec := env.do(t, http.MethodGet, "/api/v1/statements/"+stmt.ID.String(), userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get statement %d: expected status 200, got %d", i, rec.Code)
+		}
+		var got StatementResponse
+		decodeJSON(t, rec, &got)
+
+		tagged := len(got.Tags) == 1 && got.Tags[0] == "refunds"
+		if want := i < 2; tagged != want {
+			t.Errorf("statement %d: expected tagged=%v, got tags %v", i, want, got.Tags)
+		}
+	}
+
+	// Tagging again matches the same statements without adding duplicates
+	rec = env.do(t, http.MethodPost, path, userID.String(), body)
+	decodeJSON(t, rec, &resp)
+	if resp.Tagged != 2 || resp.Added != 0 {
+		t.Errorf("repeat: expected 2 tagged and 0 added, got %d and %d", resp.Tagged, resp.Added)
+	}
+
+	rec = env.do(t, http.MethodPost, path, userID.String(), TagBySimilarityRequest{Query: "refunds"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing tag: expected status 400, got %d", rec.Code)
+	}
+}
@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,10 +18,16 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/todmy/doc-analyzer/internal/auth"
+	"github.com/todmy/doc-analyzer/internal/charset"
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/preprocess"
 	"github.com/todmy/doc-analyzer/internal/storage"
 )
 
-const maxUploadSize = 10 << 20 // 10 MB
+const (
+	defaultMaxUploadSize = 10 << 20 // 10 MB per document
+	maxBulkUploadSize    = 50 << 20 // 50 MB across all files
+)
 
 // UploadResponse represents the response after file upload
 type UploadResponse struct {
@@ -25,179 +35,505 @@ type UploadResponse struct {
 	Filename   string `json:"filename"`
 	Hash       string `json:"hash"`
 	Status     string `json:"status"`
+	// Warning explains why statements were stored without embeddings or
+	// which statements could not be saved
+	Warning string `json:"warning,omitempty"`
 }
 
-// handleUpload handles document file uploads
+// handleUpload handles document file uploads. For CSV files the optional
+// text_columns form field names the columns holding the statement text
+// ("auto" picks the column with the most text); without it all fields of a
+// row are combined. For JSON files the optional json_path field (e.g.
+// "$.items[*].description") selects the values statements come from;
+// without it every string is extracted. For XML files the optional
+// xml_elements field (e.g. "para,note") names the elements whose text
+// becomes a statement; without it the text of every leaf element does.
 func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	projectID := chi.URLParam(r, "projectID")
-	log.Printf("[upload] starting upload for project %s", projectID)
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	log.Printf("[upload] starting upload for project %s", chi.URLParam(r, "projectID"))
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	// Limit upload size; several files or a zip archive share the archive
+	// limit, a single document is still capped at the upload limit
+	limit := max(s.archiveLimit(), s.uploadLimit())
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	// Parse multipart form
+	if err := r.ParseMultipartForm(s.uploadLimit()); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.respondUploadTooLarge(w)
+			return
+		}
+		respondError(w, http.StatusBadRequest, "file too large or invalid form")
+		return
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		respondError(w, http.StatusBadRequest, "no file provided")
 		return
 	}
 
-	// Verify project exists and user has access
-	project, err := s.projectRepo.GetByID(r.Context(), pid)
+	opts, err := parseExtractOptions(r)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to fetch project")
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if project == nil {
-		respondError(w, http.StatusNotFound, "project not found")
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	if len(headers) > 1 || isArchive(headers[0].Filename) {
+		if async {
+			respondError(w, http.StatusBadRequest, "async upload takes a single document, not a batch")
+			return
+		}
+		s.handleBatchUpload(w, r, project, headers, opts)
 		return
 	}
 
-	claims, ok := auth.GetUserFromContext(r.Context())
-	if !ok || project.UserID.String() != claims.UserID {
-		respondError(w, http.StatusForbidden, "access denied")
+	header := headers[0]
+	if header.Size > s.uploadLimit() {
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d MB upload limit", s.uploadLimit()>>20))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "failed to read file")
 		return
 	}
+	defer file.Close()
 
-	// Limit upload size
-	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	// With ?async=true only extraction happens in the request; embedding
+	// runs as a job whose progress is streamed from the events endpoint
+	if async {
+		s.handleAsyncUpload(w, r, project, header.Filename, file, opts)
+		return
+	}
 
-	// Parse multipart form
-	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
-		respondError(w, http.StatusBadRequest, "file too large or invalid form")
+	resp, err := s.ingestDocument(r.Context(), project, header.Filename, file, opts)
+	if err != nil {
+		var ue *uploadError
+		if errors.As(err, &ue) {
+			respondError(w, ue.status, ue.message)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to process file")
 		return
 	}
 
-	file, header, err := r.FormFile("file")
+	status := http.StatusCreated
+	if resp.Status == "exists" {
+		status = http.StatusOK
+	}
+	log.Printf("[upload] completed upload of %s in %v", header.Filename, time.Since(startTime))
+	respondJSON(w, status, resp)
+}
+
+// AsyncUploadResponse is returned when embedding continues in the background
+type AsyncUploadResponse struct {
+	UploadResponse
+	JobID     string `json:"job_id,omitempty"`
+	EventsURL string `json:"events_url,omitempty"`
+}
+
+// handleAsyncUpload stores and extracts a document, then returns 202 with a
+// job whose events stream reports embedding progress. Duplicate content is
+// reported as on a synchronous upload, without a job.
+func (s *Server) handleAsyncUpload(w http.ResponseWriter, r *http.Request, project *storage.Project, filename string, file io.Reader, opts extractOptions) {
+	resp, doc, statements, err := s.prepareDocument(r.Context(), project.ID, filename, file, opts)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "no file provided")
+		var ue *uploadError
+		if errors.As(err, &ue) {
+			respondError(w, ue.status, ue.message)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to process file")
+		return
+	}
+	if resp.Status == "exists" {
+		respondJSON(w, http.StatusOK, AsyncUploadResponse{UploadResponse: resp})
 		return
 	}
-	defer file.Close()
 
-	// Validate file extension
-	ext := filepath.Ext(header.Filename)
-	allowedExts := map[string]bool{".md": true, ".txt": true, ".json": true, ".csv": true}
-	if !allowedExts[ext] {
-		respondError(w, http.StatusBadRequest, "only .md, .txt, .json, and .csv files are allowed")
+	job := s.startUploadJob(project, resp, doc, statements)
+	resp.Status = "processing"
+	respondJSON(w, http.StatusAccepted, AsyncUploadResponse{
+		UploadResponse: resp,
+		JobID:          job.ID,
+		EventsURL:      fmt.Sprintf("/api/v1/projects/%s/jobs/%s/events", project.ID, job.ID),
+	})
+}
+
+// respondUploadTooLarge rejects an upload whose body exceeds the combined
+// limit. The body is cut off before its files are known, so when a batch may
+// be larger than a single document both limits are named.
+func (s *Server) respondUploadTooLarge(w http.ResponseWriter) {
+	if s.archiveLimit() <= s.uploadLimit() {
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d MB upload limit", s.uploadLimit()>>20))
 		return
 	}
+	respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds the %d MB limit for several files or an archive, and a single document may not exceed %d MB",
+		s.archiveLimit()>>20, s.uploadLimit()>>20))
+}
+
+// uploadLimit returns the maximum size of a single uploaded document
+func (s *Server) uploadLimit() int64 {
+	if s.maxUploadSize > 0 {
+		return s.maxUploadSize
+	}
+	return defaultMaxUploadSize
+}
+
+// uploadError is an ingestion failure with the HTTP status to report
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string {
+	return e.message
+}
+
+// allowedUploadExts lists the document types that can be ingested
+var allowedUploadExts = map[string]bool{".md": true, ".txt": true, ".json": true, ".csv": true, ".xml": true}
+
+// ingestDocument stores a file as a document of the project, extracts its
+// statements and embeds them. A file whose content already exists in the
+// project is not stored again and is reported with status "exists".
+// Failures are returned as *uploadError.
+func (s *Server) ingestDocument(ctx context.Context, project *storage.Project, filename string, file io.Reader, opts extractOptions) (UploadResponse, error) {
+	resp, doc, statements, err := s.prepareDocument(ctx, project.ID, filename, file, opts)
+	if err != nil || resp.Status == "exists" {
+		return resp, err
+	}
+
+	resp.Warning, err = s.storeDocument(ctx, project, doc, statements, nil)
+	return resp, err
+}
+
+// prepareDocument validates a file as a document of the project and
+// extracts its statements. The document and statements are returned
+// unsaved and unembedded; see storeDocument. An existing document with the
+// same content is reported with status "exists" and no document.
+func (s *Server) prepareDocument(ctx context.Context, pid uuid.UUID, filename string, file io.Reader, opts extractOptions) (UploadResponse, *storage.Document, []*storage.Statement, error) {
+	// Validate file extension
+	ext := filepath.Ext(filename)
+	if !allowedUploadExts[ext] {
+		return UploadResponse{}, nil, nil, &uploadError{http.StatusBadRequest, "only .md, .txt, .json, .csv and .xml files are allowed"}
+	}
 
 	// Read file content
 	content, err := io.ReadAll(file)
 	if err != nil {
 		log.Printf("[upload] failed to read file: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to read file")
-		return
+		return UploadResponse{}, nil, nil, &uploadError{http.StatusInternalServerError, "failed to read file"}
 	}
-	log.Printf("[upload] read file %s (%.2f KB)", header.Filename, float64(len(content))/1024)
+	log.Printf("[upload] read file %s (%.2f KB)", filename, float64(len(content))/1024)
 
 	// Calculate content hash
 	hash := sha256.Sum256(content)
 	hashStr := hex.EncodeToString(hash[:])
 
 	// Check if document with same hash already exists
-	existingDoc, err := s.documentRepo.GetByHash(r.Context(), pid, hashStr)
+	existingDoc, err := s.documentRepo.GetByHash(ctx, pid, hashStr)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to check existing documents")
-		return
+		return UploadResponse{}, nil, nil, &uploadError{http.StatusInternalServerError, "failed to check existing documents"}
 	}
 
 	if existingDoc != nil {
-		respondJSON(w, http.StatusOK, UploadResponse{
+		return UploadResponse{
 			DocumentID: existingDoc.ID.String(),
 			Filename:   existingDoc.Filename,
 			Hash:       hashStr,
 			Status:     "exists",
-		})
-		return
+		}, nil, nil, nil
 	}
 
-	// Sanitize content to valid UTF-8 (replaces invalid sequences with replacement char)
-	sanitizedContent := strings.ToValidUTF8(string(content), "�")
+	// Transcode content to UTF-8, rejecting binary files
+	text, encoding, err := charset.Decode(content, s.uploadEncodingFallback)
+	if err != nil {
+		log.Printf("[upload] rejected %s: %v", filename, err)
+		if errors.Is(err, charset.ErrBinary) {
+			return UploadResponse{}, nil, nil, &uploadError{http.StatusBadRequest, "file content appears to be binary, not text"}
+		}
+		return UploadResponse{}, nil, nil, &uploadError{http.StatusBadRequest, "file content is not valid UTF-8 or UTF-16 text"}
+	}
+	if encoding != charset.UTF8 {
+		log.Printf("[upload] transcoded %s from %s", filename, encoding)
+	}
 
-	// Create new document
+	// The document is saved together with its statements by storeDocument
 	doc := &storage.Document{
+		ID:          uuid.New(),
 		ProjectID:   pid,
-		Filename:    header.Filename,
-		Content:     sanitizedContent,
+		Filename:    filename,
+		Content:     text,
 		ContentHash: hashStr,
 	}
 
-	if err := s.documentRepo.Create(r.Context(), doc); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to save document")
-		return
-	}
-
 	// Extract statements from document
 	extractStart := time.Now()
-	statements := extractStatements(doc.Content, doc.ID, ext)
+	statements, err := extractStatements(doc.Content, doc.ID, ext, opts)
+	if err != nil {
+		return UploadResponse{}, nil, nil, &uploadError{http.StatusBadRequest, err.Error()}
+	}
 	log.Printf("[upload] extracted %d statements in %v", len(statements), time.Since(extractStart))
 
-	if len(statements) > 0 {
-		// Generate embeddings for statements
-		embeddingStart := time.Now()
-		log.Printf("[upload] starting embedding generation for %d statements...", len(statements))
-		if err := s.generateEmbeddingsForStatements(r.Context(), statements); err != nil {
-			log.Printf("[upload] embedding generation failed after %v: %v", time.Since(embeddingStart), err)
-			// Continue - statements will be stored without embeddings
-		} else {
-			log.Printf("[upload] embedding generation completed in %v", time.Since(embeddingStart))
+	return UploadResponse{
+		DocumentID: doc.ID.String(),
+		Filename:   doc.Filename,
+		Hash:       hashStr,
+		Status:     "created",
+	}, doc, statements, nil
+}
+
+// storeDocument embeds the extracted statements of a prepared document of
+// project, reporting embedding progress to progress (may be nil), and saves
+// the document with its statements in one transaction. If embedding fails
+// the statements are saved without embeddings and the returned warning
+// explains why. A save failure stores nothing and is returned as
+// *uploadError.
+func (s *Server) storeDocument(ctx context.Context, project *storage.Project, doc *storage.Document, statements []*storage.Statement, progress embeddings.ProgressFunc) (string, error) {
+	warning := s.embedDocument(ctx, project, doc, statements, progress)
+	return s.saveDocument(ctx, doc, statements, warning)
+}
+
+// embedDocument embeds the statements of a document of project, adding the
+// tokens spent to the document and recording why embedding failed. It
+// returns a warning when statements are left without embeddings.
+func (s *Server) embedDocument(ctx context.Context, project *storage.Project, doc *storage.Document, statements []*storage.Statement, progress embeddings.ProgressFunc) string {
+	doc.EmbeddingError = ""
+	if len(statements) == 0 {
+		return ""
+	}
+
+	// Generate embeddings for statements
+	var warning string
+	embeddingStart := time.Now()
+	log.Printf("[upload] starting embedding generation for %d statements...", len(statements))
+	pipeline, err := preprocess.Parse(project.Preprocessing)
+	if err != nil {
+		log.Printf("[upload] ignoring invalid preprocessing of project %s: %v", project.ID, err)
+	}
+	tokens, err := s.generateEmbeddingsWithProgress(ctx, statements, pipeline, progress)
+	if err != nil {
+		log.Printf("[upload] embedding generation failed after %v: %v", time.Since(embeddingStart), err)
+		// Continue - statements will be stored without embeddings
+		doc.EmbeddingError = embeddingErrorMessage(err)
+		warning = fmt.Sprintf("%d of %d statements saved without embeddings: %s",
+			len(withoutEmbedding(statements)), len(statements), doc.EmbeddingError)
+	} else {
+		log.Printf("[upload] embedding generation completed in %v", time.Since(embeddingStart))
+	}
+
+	// Record the tokens spent, including those of batches before a failure
+	doc.EmbeddingTokens += tokens
+
+	return warning
+}
+
+// saveDocument stores a document with its statements in one transaction.
+// With skipFailedStatements, statements that cannot be inserted are skipped
+// and added to the warning; the document is only stored if at least one
+// statement was. It returns the warning and a failure as *uploadError.
+func (s *Server) saveDocument(ctx context.Context, doc *storage.Document, statements []*storage.Statement, warning string) (string, error) {
+	saveStart := time.Now()
+	result, err := s.documentRepo.CreateWithStatements(ctx, doc, statements, s.skipFailedStatements)
+	if err != nil {
+		log.Printf("[upload] failed to save document %s: %v", doc.Filename, err)
+		return warning, &uploadError{http.StatusInternalServerError, "failed to save document"}
+	}
+	return saveWarning(doc, statements, result, warning, saveStart)
+}
+
+// replaceDocument stores a revised document in place of the current one,
+// replacing all of its statements in one transaction. Failures are handled
+// as in saveDocument.
+func (s *Server) replaceDocument(ctx context.Context, doc *storage.Document, statements []*storage.Statement, warning string) (string, error) {
+	saveStart := time.Now()
+	result, err := s.documentRepo.UpdateWithStatements(ctx, doc, statements, s.skipFailedStatements)
+	if err != nil {
+		log.Printf("[upload] failed to update document %s: %v", doc.Filename, err)
+		return warning, &uploadError{http.StatusInternalServerError, "failed to save document"}
+	}
+	return saveWarning(doc, statements, result, warning, saveStart)
+}
+
+// saveWarning reports the outcome of saving a document's statements: it
+// fails when none of them could be saved and otherwise adds the skipped
+// statements to the warning
+func saveWarning(doc *storage.Document, statements []*storage.Statement, result *storage.BatchResult, warning string, saveStart time.Time) (string, error) {
+	for _, f := range result.Failures {
+		log.Printf("[upload] failed to save statement at position %d: %v", f.Position, f.Err)
+	}
+	if len(statements) > 0 && result.Inserted == 0 {
+		return warning, &uploadError{http.StatusInternalServerError, "failed to save statements"}
+	}
+	log.Printf("[upload] saved document %s with %d of %d statements in %v", doc.Filename, result.Inserted, len(statements), time.Since(saveStart))
+
+	if len(result.Failures) > 0 {
+		positions := make([]string, len(result.Failures))
+		for i, f := range result.Failures {
+			positions[i] = strconv.Itoa(f.Position)
 		}
+		failed := fmt.Sprintf("%d of %d statements could not be saved (positions %s)",
+			len(result.Failures), len(statements), strings.Join(positions, ", "))
+		if warning != "" {
+			warning += "; "
+		}
+		warning += failed
+	}
 
-		// Save statements
-		saveStart := time.Now()
-		if err := s.statementRepo.CreateBatch(r.Context(), statements); err != nil {
-			log.Printf("[upload] failed to save statements: %v", err)
-			respondError(w, http.StatusInternalServerError, "failed to save statements")
+	return warning, nil
+}
+
+// BulkFileResult is the outcome of ingesting one file of a bulk upload
+type BulkFileResult struct {
+	UploadResponse
+	Error string `json:"error,omitempty"`
+}
+
+// BulkProjectResponse represents the result of creating a project with documents
+type BulkProjectResponse struct {
+	ProjectID string           `json:"project_id,omitempty"`
+	Name      string           `json:"name"`
+	Files     []BulkFileResult `json:"files"`
+}
+
+// handleBulkCreateProject creates a project and ingests all uploaded files in
+// one request. The multipart form carries the project "name" and one or more
+// "files". If no file can be ingested the project is deleted again.
+func (s *Server) handleBulkCreateProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	uid, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.archiveLimit())
+	if err := r.ParseMultipartForm(s.uploadLimit()); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds the %d MB limit", s.archiveLimit()>>20))
 			return
 		}
-		log.Printf("[upload] saved %d statements in %v", len(statements), time.Since(saveStart))
+		respondError(w, http.StatusBadRequest, "invalid form")
+		return
 	}
 
-	log.Printf("[upload] completed upload of %s in %v", header.Filename, time.Since(startTime))
-	respondJSON(w, http.StatusCreated, UploadResponse{
-		DocumentID: doc.ID.String(),
-		Filename:   doc.Filename,
-		Hash:       hashStr,
-		Status:     "created",
-	})
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	headers := r.MultipartForm.File["files"]
+	if len(headers) == 0 {
+		respondError(w, http.StatusBadRequest, "no files provided")
+		return
+	}
+	opts, err := parseExtractOptions(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project := &storage.Project{UserID: uid, Name: name}
+	if err := s.projectRepo.Create(r.Context(), project); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to create project")
+		return
+	}
+
+	response := BulkProjectResponse{
+		ProjectID: project.ID.String(),
+		Name:      project.Name,
+		Files:     make([]BulkFileResult, len(headers)),
+	}
+
+	succeeded := 0
+	for i, header := range headers {
+		result := s.ingestFileHeader(r.Context(), project, header, opts)
+		if result.Error == "" {
+			succeeded++
+		}
+		response.Files[i] = result
+	}
+
+	// Roll back the project if nothing could be ingested
+	if succeeded == 0 {
+		if err := s.projectRepo.Delete(r.Context(), project.ID); err != nil {
+			log.Printf("[upload] failed to roll back project %s: %v", project.ID, err)
+		}
+		response.ProjectID = ""
+		respondJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, response)
 }
 
-// handleListDocuments lists all documents in a project
+// DocumentResponse describes a document of a project listing
+type DocumentResponse struct {
+	ID              string `json:"id"`
+	Filename        string `json:"filename"`
+	Hash            string `json:"hash"`
+	StatementCount  int    `json:"statement_count"`
+	EmbeddedCount   int    `json:"embedded_count"`
+	EmbeddingStatus string `json:"embedding_status"`
+	EmbeddingError  string `json:"embedding_error,omitempty"`
+}
+
+// handleListDocuments lists the documents in a project with their statement
+// counts and embedding status. ?embedding_status=done|pending|failed and
+// ?min_statements=N narrow the listing.
 func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	var filter storage.DocumentFilter
+	switch status := r.URL.Query().Get("embedding_status"); status {
+	case "", storage.EmbeddingStatusDone, storage.EmbeddingStatusPending, storage.EmbeddingStatusFailed:
+		filter.EmbeddingStatus = status
+	default:
+		respondError(w, http.StatusBadRequest, "embedding_status must be done, pending or failed")
 		return
 	}
+	if v := r.URL.Query().Get("min_statements"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "min_statements must be a non-negative integer")
+			return
+		}
+		filter.MinStatements = n
+	}
 
-	docs, err := s.documentRepo.GetByProjectID(r.Context(), pid)
+	docs, err := s.documentRepo.ListWithStats(r.Context(), project.ID, filter)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to fetch documents")
 		return
 	}
 
-	type DocumentResponse struct {
-		ID       string `json:"id"`
-		Filename string `json:"filename"`
-		Hash     string `json:"hash"`
-	}
-
 	response := make([]DocumentResponse, 0, len(docs))
 	for _, doc := range docs {
 		response = append(response, DocumentResponse{
-			ID:       doc.ID.String(),
-			Filename: doc.Filename,
-			Hash:     doc.ContentHash,
+			ID:              doc.ID.String(),
+			Filename:        doc.Filename,
+			Hash:            doc.ContentHash,
+			StatementCount:  doc.StatementCount,
+			EmbeddedCount:   doc.EmbeddedCount,
+			EmbeddingStatus: doc.EmbeddingStatus,
+			EmbeddingError:  doc.EmbeddingError,
 		})
 	}
 
@@ -206,26 +542,37 @@ func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
 
 // handleDeleteDocument deletes a document from a project
 func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	documentID := chi.URLParam(r, "documentID")
-
-	if projectID == "" || documentID == "" {
-		respondError(w, http.StatusBadRequest, "project id and document id are required")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
 
-	did, err := uuid.Parse(documentID)
+	did, err := uuid.Parse(chi.URLParam(r, "documentID"))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "invalid document id")
 		return
 	}
 
-	// Delete statements first, then document
-	if err := s.statementRepo.DeleteByDocumentID(r.Context(), did); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to delete statements")
+	doc, err := s.documentRepo.GetByID(r.Context(), did)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch document")
+		return
+	}
+
+	if doc == nil || doc.ProjectID != project.ID {
+		respondError(w, http.StatusNotFound, "document not found")
 		return
 	}
 
+	// Drop cached contradiction analyses for the document's statements
+	if s.contradictionService != nil {
+		if err := s.contradictionService.InvalidateDocument(r.Context(), did.String()); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to invalidate contradiction cache")
+			return
+		}
+	}
+
+	// Statements are removed with the document by ON DELETE CASCADE
 	if err := s.documentRepo.Delete(r.Context(), did); err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to delete document")
 		return
@@ -0,0 +1,908 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+func TestHandleUpload_EmbeddingErrorMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"error":{"message":"Insufficient credits. Add more using https://openrouter.ai/credits","code":402}}`))
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	rec := env.upload(t, project.ID, userID.String(), "notes.md", "Refunds are available for thirty days after purchase.\n\nAnnual plans renew automatically unless cancelled.")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp UploadResponse
+	decodeJSON(t, rec, &resp)
+	if !strings.Contains(resp.Warning, "insufficient credits") {
+		t.Errorf("expected actionable insufficient credits warning, got %q", resp.Warning)
+	}
+	if strings.Contains(resp.Warning, "{") {
+		t.Errorf("expected no raw provider body in warning, got %q", resp.Warning)
+	}
+}
+
+func TestHandleUpload_DeduplicatesEmbeddingTexts(t *testing.T) {
+	var inputs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		inputs = append(inputs, req.Input...)
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: fakeEmbedding(text)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	disclaimer := "This document is confidential and intended for internal use only."
+	content := strings.Join([]string{
+		disclaimer,
+		"Refunds are available for thirty days after purchase.",
+		disclaimer,
+		"Annual plans renew automatically unless cancelled.",
+		"THIS DOCUMENT IS  CONFIDENTIAL and intended for internal use only.",
+	}, "\n\n")
+
+	rec := env.upload(t, project.ID, userID.String(), "terms.md", content)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	decodeJSON(t, rec, &resp)
+
+	if len(inputs) != 3 {
+		t.Errorf("expected 3 unique texts embedded, got %d: %q", len(inputs), inputs)
+	}
+
+	// Every statement row is kept and duplicates share the embedding
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), uuid.MustParse(resp.DocumentID))
+	if len(stmts) != 5 {
+		t.Fatalf("expected 5 statements saved, got %d", len(stmts))
+	}
+	want := fakeEmbedding(disclaimer)
+	for _, stmt := range stmts {
+		if normalizeStatementText(stmt.Text) != normalizeStatementText(disclaimer) {
+			continue
+		}
+		got := stmt.Embedding.Slice()
+		if len(got) != len(want) {
+			t.Fatalf("statement %q: expected %d dimensions, got %d", stmt.Text, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("statement %q: expected shared disclaimer embedding", stmt.Text)
+				break
+			}
+		}
+	}
+}
+
+func TestHandleUpload_PreprocessesEmbeddingText(t *testing.T) {
+	var inputs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddings.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		inputs = append(inputs, req.Input...)
+		resp := embeddings.EmbeddingResponse{Model: req.Model}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, embeddings.EmbeddingData{Index: i, Embedding: fakeEmbedding(text)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+
+	rec := env.do(t, http.MethodPost, "/api/v1/projects", userID.String(), ProjectRequest{
+		Name:          "noisy",
+		Preprocessing: []string{"lowercase", "mask_numbers", "strip_punctuation", "collapse_whitespace"},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var project ProjectResponse
+	decodeJSON(t, rec, &project)
+	if len(project.Preprocessing) != 4 {
+		t.Fatalf("expected 4 preprocessing steps, got %v", project.Preprocessing)
+	}
+
+	original := "Refunds of $1,200 are PAID within 30 days of the purchase date!"
+	rec = env.upload(t, uuid.MustParse(project.ID), userID.String(), "terms.md", original)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var upload UploadResponse
+	decodeJSON(t, rec, &upload)
+
+	if len(inputs) != 1 || inputs[0] != "refunds of NUM are paid within NUM days of the purchase date" {
+		t.Errorf("expected preprocessed embedding input, got %q", inputs)
+	}
+
+	// The stored and displayed text stays original
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), uuid.MustParse(upload.DocumentID))
+	if len(stmts) != 1 || stmts[0].Text != original {
+		t.Fatalf("expected the original statement text to be stored, got %+v", stmts)
+	}
+	rec = env.do(t, http.MethodGet, "/api/v1/statements/"+stmts[0].ID.String(), userID.String(), nil)
+	var stmt StatementResponse
+	decodeJSON(t, rec, &stmt)
+	if stmt.Text != original {
+		t.Errorf("expected displayed text %q, got %q", original, stmt.Text)
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/v1/projects", userID.String(), ProjectRequest{
+		Name:          "invalid",
+		Preprocessing: []string{"stem"},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown step, got %d", rec.Code)
+	}
+}
+
+func TestHandleUpload_SaveFailureStoresNothing(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.documents.saveErr = errors.New("statement insert failed")
+
+	rec := env.upload(t, project.ID, userID.String(), "terms.md", "Refunds are available for thirty days after purchase.")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The document is not left behind without its statements
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 0 || len(stmts) != 0 {
+		t.Errorf("expected nothing stored, got %d documents and %d statements", len(docs), len(stmts))
+	}
+
+	// Retrying the same content is not mistaken for a duplicate
+	env.documents.saveErr = nil
+	rec = env.upload(t, project.ID, userID.String(), "terms.md", "Refunds are available for thirty days after purchase.")
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected status 201 on retry, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleUpload_TranscodesUTF16(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	first := "Les remboursements sont accordés pendant trente jours après l'achat."
+	second := "Die Jahrespläne verlängern sich automatisch, außer sie werden gekündigt."
+
+	// UTF-16LE with a byte order mark, as saved by many Windows editors
+	content := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(first + "\r\n\r\n" + second)) {
+		content = binary.LittleEndian.AppendUint16(content, unit)
+	}
+
+	rec := env.upload(t, project.ID, userID.String(), "terms.txt", string(content))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	decodeJSON(t, rec, &resp)
+
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), uuid.MustParse(resp.DocumentID))
+	texts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		texts[i] = stmt.Text
+	}
+	sort.Strings(texts)
+	want := []string{second, first}
+	if len(texts) != len(want) {
+		t.Fatalf("expected %d statements, got %q", len(want), texts)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Errorf("expected statement %q, got %q", want[i], texts[i])
+		}
+	}
+
+	doc, _ := env.documents.GetByID(context.Background(), uuid.MustParse(resp.DocumentID))
+	if doc == nil || strings.ContainsRune(doc.Content, 0) || strings.HasPrefix(doc.Content, "\uFEFF") {
+		t.Errorf("expected document content decoded without NULs or BOM")
+	}
+}
+
+func TestHandleUpload_RejectsBinary(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	content := string([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00, 0x00, 0x0D, 'I', 'H', 'D', 'R', 0x00, 0x01})
+	rec := env.upload(t, project.ID, userID.String(), "image.txt", content)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for binary content, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleUpload_InvalidByteSequences(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	// Latin-1 accents are not valid UTF-8 and are transcoded
+	rec := env.upload(t, project.ID, userID.String(), "menu.txt", "The caf\xe9 on the corner serves cr\xe8me br\xfbl\xe9e every single day.")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	decodeJSON(t, rec, &resp)
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), uuid.MustParse(resp.DocumentID))
+	if len(stmts) != 1 || stmts[0].Text != "The café on the corner serves crème brûlée every single day." {
+		t.Errorf("expected the transcoded statement, got %+v", stmts)
+	}
+
+	// Mostly invalid bytes would only produce nonsense statements
+	garbage := string([]byte{0xc3, 0x28, 0xa0, 0xa1, 0xe2, 0x28, 0xa1, 0xf0, 0x28, 0x8c, 0xbc, 0xfe, 0xff, 0xfd, 0x9b, 0xd4})
+	rec = env.upload(t, project.ID, userID.String(), "notes.txt", garbage)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid bytes, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 1 {
+		t.Errorf("expected only the transcoded document, got %d", len(docs))
+	}
+}
+
+func TestHandleUpload_ConfiguredSizeLimit(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	env.server.maxUploadSize = 1 << 20
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	content := strings.Repeat("Refunds are available for thirty days. ", (2<<20)/39)
+	rec := env.upload(t, project.ID, userID.String(), "big.txt", content)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "1 MB") {
+		t.Errorf("expected the configured limit in the message, got %s", rec.Body.String())
+	}
+
+	// A request body beyond every limit is cut off while reading, naming the
+	// upload limit that a single document exceeds
+	env.server.maxArchiveSize = 1 << 20
+	rec = env.upload(t, project.ID, userID.String(), "big.txt", content)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413 for the request body, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "1 MB upload limit") {
+		t.Errorf("expected the upload limit in the message, got %s", rec.Body.String())
+	}
+
+	// With a larger archive limit both limits are named
+	env.server.maxArchiveSize = 2 << 20
+	rec = env.upload(t, project.ID, userID.String(), "big.txt", strings.Repeat(content, 2))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413 for the request body, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "2 MB limit for several files") || !strings.Contains(rec.Body.String(), "single document may not exceed 1 MB") {
+		t.Errorf("expected both limits in the message, got %s", rec.Body.String())
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 0 {
+		t.Errorf("expected no documents, got %d", len(docs))
+	}
+}
+
+// uploadWithFields uploads a file along with extra form fields; empty
+// fields are left out
+func (e *testEnv) uploadWithFields(t *testing.T, projectID uuid.UUID, userID, filename, content string, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if value != "" {
+			mw.WriteField(name, value)
+		}
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+projectID.String()+"/documents", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID)
+
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleUpload_CSVTextColumns(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+
+	content := "id,created_at,title,body\n" +
+		"1,2024-01-02T10:00:00Z,Refunds,Refunds are available for thirty days after the purchase date.\n" +
+		"2,2024-01-03T11:30:00Z,Renewals,Annual plans renew automatically unless they are cancelled first.\n"
+
+	upload := func(textColumns string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		project := env.seedProject(t, userID)
+		rec := env.uploadWithFields(t, project.ID, userID.String(), "tickets.csv", content, map[string]string{"text_columns": textColumns})
+
+		stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+		sort.Slice(stmts, func(i, j int) bool { return stmts[i].Position < stmts[j].Position })
+		texts := make([]string, len(stmts))
+		for i, stmt := range stmts {
+			texts[i] = stmt.Text
+		}
+		return rec, texts
+	}
+
+	bodies := []string{
+		"Refunds are available for thirty days after the purchase date.",
+		"Annual plans renew automatically unless they are cancelled first.",
+	}
+	for _, textColumns := range []string{"body", "BODY", "auto"} {
+		rec, texts := upload(textColumns)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s: expected status 201, got %d: %s", textColumns, rec.Code, rec.Body.String())
+		}
+		if strings.Join(texts, "|") != strings.Join(bodies, "|") {
+			t.Errorf("%s: expected only the body column, got %q", textColumns, texts)
+		}
+	}
+
+	rec, texts := upload("title, body")
+	if rec.Code != http.StatusCreated || len(texts) != 2 || texts[0] != "Refunds "+bodies[0] {
+		t.Errorf("title,body: expected the joined columns, got %d %q", rec.Code, texts)
+	}
+
+	// Without columns every field of a row is joined
+	rec, texts = upload("")
+	if rec.Code != http.StatusCreated || len(texts) != 2 || !strings.HasPrefix(texts[0], "1 2024-01-02T10:00:00Z Refunds") {
+		t.Errorf("expected the joined rows, got %d %q", rec.Code, texts)
+	}
+
+	if rec, _ := upload("summary"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown column, got %d", rec.Code)
+	}
+}
+
+func TestHandleUpload_JSONPath(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+
+	content := `{
+		"next": "https://api.example.com/v1/tickets?cursor=c2VjcmV0LWN1cnNvci12YWx1ZS0xMjM0NTY3ODkw",
+		"items": [
+			{"id": "a7f3c2d1-8e4b-4c6a-9f2e-1b3d5a7c9e0f", "description": "Refunds are available for thirty days after the purchase date."},
+			{"id": "b8e4d3c2-9f5c-4d7b-a03f-2c4e6b8d0f1a", "description": "Annual plans renew automatically unless they are cancelled first."}
+		]
+	}`
+
+	extract := func(jsonPath string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		project := env.seedProject(t, userID)
+		rec := env.uploadWithFields(t, project.ID, userID.String(), "tickets.json", content, map[string]string{"json_path": jsonPath})
+		stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+		texts := make([]string, len(stmts))
+		for i, stmt := range stmts {
+			texts[i] = stmt.Text
+		}
+		sort.Strings(texts)
+		return rec, texts
+	}
+
+	rec, texts := extract("$.items[*].description")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	want := []string{
+		"Annual plans renew automatically unless they are cancelled first.",
+		"Refunds are available for thirty days after the purchase date.",
+	}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected only the descriptions, got %q", texts)
+	}
+
+	// Without a selector every long string is extracted, the URL included
+	if _, texts := extract(""); len(texts) != 3 {
+		t.Errorf("expected 3 statements without a selector, got %q", texts)
+	}
+
+	for _, jsonPath := range []string{"items[*]", "$.items[", "$.items[x]"} {
+		if rec, _ := extract(jsonPath); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", jsonPath, rec.Code)
+		}
+	}
+}
+
+func TestHandleUpload_XML(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+
+	content := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<!-- Terms of service -->
+<chapter id="terms-of-service-and-conditions-of-use-for-all-customers">
+	<title>Terms</title>
+	<para>Refunds are available for thirty days after the purchase date.</para>
+	<para>Annual plans <emphasis>renew automatically</emphasis> unless they are cancelled first.</para>
+	<note><![CDATA[Prices shown <excluding> tax may change at any time without notice.]]></note>
+</chapter>`
+
+	extract := func(elements string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		project := env.seedProject(t, userID)
+		rec := env.uploadWithFields(t, project.ID, userID.String(), "terms.xml", content, map[string]string{"xml_elements": elements})
+		stmts, _ := env.statements.GetByProjectID(context.Background(), project.ID)
+		texts := make([]string, len(stmts))
+		for i, stmt := range stmts {
+			texts[i] = stmt.Text
+		}
+		sort.Strings(texts)
+		return rec, texts
+	}
+
+	// Leaf elements only: the nested emphasis is too short on its own and
+	// the attribute is never extracted
+	rec, texts := extract("")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	want := []string{
+		"Prices shown <excluding> tax may change at any time without notice.",
+		"Refunds are available for thirty days after the purchase date.",
+	}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected the leaf texts, got %q", texts)
+	}
+
+	// Selected elements include the text of nested markup
+	_, texts = extract("para")
+	want = []string{
+		"Annual plans renew automatically unless they are cancelled first.",
+		"Refunds are available for thirty days after the purchase date.",
+	}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected the para texts, got %q", texts)
+	}
+}
+
+func TestHandleUpload_MarkdownSections(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	content := "Our terms apply to every customer account without exception.\n\n" +
+		"# Billing\n\n" +
+		"## Refund Policy ##\n" +
+		"Refunds are available for thirty days after the purchase date.\n\n" +
+		"### Exceptions\n\n" +
+		"Refunds are not available for plans bought during a promotion.\n\n" +
+		"## Renewals\n\n" +
+		"Annual plans renew automatically unless they are cancelled first.\n\n" +
+		"# Support\n\n" +
+		"Support tickets are answered within one business day of receipt.\n"
+
+	rec := env.upload(t, project.ID, userID.String(), "terms.md", content)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	decodeJSON(t, rec, &resp)
+
+	stmts, _ := env.statements.GetByDocumentID(context.Background(), uuid.MustParse(resp.DocumentID))
+	sort.Slice(stmts, func(i, j int) bool { return stmts[i].Position < stmts[j].Position })
+	want := []string{
+		"",
+		"Billing > Refund Policy",
+		"Billing > Refund Policy > Exceptions",
+		"Billing > Renewals",
+		"Support",
+	}
+	if len(stmts) != len(want) {
+		t.Fatalf("expected %d statements, got %d", len(want), len(stmts))
+	}
+	for i, stmt := range stmts {
+		if stmt.Section != want[i] {
+			t.Errorf("%q: expected section %q, got %q", stmt.Text, want[i], stmt.Section)
+		}
+		if strings.HasPrefix(stmt.Text, "#") {
+			t.Errorf("expected headers not to become statements, got %q", stmt.Text)
+		}
+	}
+}
+
+// bulkRequest builds a bulk project creation request with the given files
+func bulkRequest(t *testing.T, userID, name string, files map[string]string) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", name)
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		fw, err := mw.CreateFormFile("files", filename)
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		fw.Write([]byte(files[filename]))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/bulk", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID)
+	return req
+}
+
+func TestHandleBulkCreateProject(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+
+	files := map[string]string{
+		"a.md":  "Refunds are available for thirty days after purchase.",
+		"b.txt": "Annual plans renew automatically unless they are cancelled.",
+		"c.csv": "text\nSupport tickets are answered within one business day.",
+	}
+
+	rec := httptest.NewRecorder()
+	env.server.router.ServeHTTP(rec, bulkRequest(t, userID.String(), "onboarding", files))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp BulkProjectResponse
+	decodeJSON(t, rec, &resp)
+
+	pid, err := uuid.Parse(resp.ProjectID)
+	if err != nil {
+		t.Fatalf("expected project id, got %q", resp.ProjectID)
+	}
+	project, _ := env.projects.GetByID(context.Background(), pid)
+	if project == nil || project.Name != "onboarding" || project.UserID != userID {
+		t.Fatalf("expected project onboarding owned by user, got %+v", project)
+	}
+
+	if len(resp.Files) != 3 {
+		t.Fatalf("expected 3 file results, got %d", len(resp.Files))
+	}
+	for _, f := range resp.Files {
+		if f.Status != "created" || f.Error != "" {
+			t.Errorf("expected %s to be created, got status %q error %q", f.Filename, f.Status, f.Error)
+		}
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), pid)
+	if len(docs) != 3 {
+		t.Errorf("expected 3 documents, got %d", len(docs))
+	}
+	stmts, _ := env.statements.GetByProjectID(context.Background(), pid)
+	if len(stmts) != 3 {
+		t.Errorf("expected 3 statements, got %d", len(stmts))
+	}
+}
+
+func TestHandleBulkCreateProject_ArchiveLimit(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	env.server.maxArchiveSize = 1 << 20
+	userID := uuid.New()
+
+	files := map[string]string{
+		"a.txt": strings.Repeat("Refunds are available for thirty days. ", (1<<20)/39),
+		"b.txt": strings.Repeat("Annual plans renew automatically. ", (1<<20)/34),
+	}
+	rec := httptest.NewRecorder()
+	env.server.router.ServeHTTP(rec, bulkRequest(t, userID.String(), "onboarding", files))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "1 MB") {
+		t.Errorf("expected the configured archive limit in the message, got %s", rec.Body.String())
+	}
+}
+
+func TestHandleBulkCreateProject_RollsBack(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+
+	files := map[string]string{
+		"a.exe": "binary",
+		"b.pdf": "binary",
+	}
+
+	rec := httptest.NewRecorder()
+	env.server.router.ServeHTTP(rec, bulkRequest(t, userID.String(), "broken", files))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp BulkProjectResponse
+	decodeJSON(t, rec, &resp)
+	for _, f := range resp.Files {
+		if f.Error == "" {
+			t.Errorf("expected an error for %s", f.Filename)
+		}
+	}
+
+	projects, _ := env.projects.GetByUserID(context.Background(), userID)
+	if len(projects) != 0 {
+		t.Errorf("expected project to be rolled back, got %d projects", len(projects))
+	}
+}
+
+// batchUpload posts the given files as repeated "file" parts of one upload
+func (e *testEnv) batchUpload(t *testing.T, projectID uuid.UUID, userID string, names []string, contents [][]byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for i, name := range names {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		fw.Write(contents[i])
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+projectID.String()+"/documents", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+userID)
+
+	rec := httptest.NewRecorder()
+	e.server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// zipArchive builds a zip archive of the given entries, in order
+func zipArchive(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		fw, err := zw.Create(entry[0])
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		fw.Write([]byte(entry[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleUpload_MultipleFiles(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	rec := env.batchUpload(t, project.ID, userID.String(),
+		[]string{"a.md", "b.txt", "c.pdf"},
+		[][]byte{
+			[]byte("Refunds are available for thirty days after purchase."),
+			[]byte("Annual plans renew automatically unless they are cancelled."),
+			[]byte("%PDF"),
+		})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp BatchUploadResponse
+	decodeJSON(t, rec, &resp)
+	if len(resp.Files) != 3 {
+		t.Fatalf("expected 3 file results, got %+v", resp.Files)
+	}
+	for _, f := range resp.Files[:2] {
+		if f.Status != "created" || f.Error != "" {
+			t.Errorf("expected %s to be created, got status %q error %q", f.Filename, f.Status, f.Error)
+		}
+	}
+	if resp.Files[2].Error == "" {
+		t.Errorf("expected an error for c.pdf, got %+v", resp.Files[2])
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 2 {
+		t.Errorf("expected 2 documents, got %d", len(docs))
+	}
+}
+
+func TestHandleUpload_ZipArchive(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	archive := zipArchive(t,
+		[2]string{"docs/", ""},
+		[2]string{"docs/a.md", "Refunds are available for thirty days after purchase."},
+		[2]string{"docs/copy.md", "Refunds are available for thirty days after purchase."},
+		[2]string{"docs/b.txt", "Annual plans renew automatically unless they are cancelled."},
+		[2]string{"docs/logo.png", "not a document"},
+		[2]string{"__MACOSX/docs/._a.md", "metadata"},
+	)
+
+	rec := env.batchUpload(t, project.ID, userID.String(), []string{"docs.zip"}, [][]byte{archive})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp BatchUploadResponse
+	decodeJSON(t, rec, &resp)
+
+	want := []struct{ filename, status string }{
+		{"docs/a.md", "created"},
+		{"docs/copy.md", "exists"},
+		{"docs/b.txt", "created"},
+		{"docs/logo.png", "skipped"},
+	}
+	if len(resp.Files) != len(want) {
+		t.Fatalf("expected %d file results, got %+v", len(want), resp.Files)
+	}
+	for i, w := range want {
+		f := resp.Files[i]
+		if f.Filename != w.filename || f.Status != w.status || f.Error != "" {
+			t.Errorf("result %d: expected %s %s, got %+v", i, w.filename, w.status, f)
+		}
+	}
+	if resp.Files[3].Warning == "" {
+		t.Error("expected a warning for the skipped file")
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 2 {
+		t.Errorf("expected 2 documents, got %d", len(docs))
+	}
+}
+
+func TestHandleUpload_RejectsOversizedArchive(t *testing.T) {
+	env := newTestEnv(t, newFakeEmbeddingServer(t).URL)
+	env.server.maxArchiveSize = 1 << 10
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	// Compresses far below the limit but extracts beyond it
+	archive := zipArchive(t,
+		[2]string{"a.md", "Refunds are available for thirty days after purchase."},
+		[2]string{"big.txt", strings.Repeat("Plans renew automatically. ", 100)},
+	)
+	if len(archive) >= 1<<10 {
+		t.Fatalf("test archive too large: %d bytes", len(archive))
+	}
+
+	rec := env.batchUpload(t, project.ID, userID.String(), []string{"docs.zip"}, [][]byte{archive})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	docs, _ := env.documents.GetByProjectID(context.Background(), project.ID)
+	if len(docs) != 0 {
+		t.Errorf("expected no documents, got %d", len(docs))
+	}
+}
+
+func TestHandleListDocuments_Filters(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	env.seedDocument(t, project.ID, "a.md", "Refunds take thirty days.", "Plans renew yearly.", "Support replies within a day.")
+	env.seedDocument(t, project.ID, "b.md", "Invoices are sent monthly.")
+
+	// Statements stored without embeddings, with and without a failure
+	unembedded := func(filename, embeddingError string, texts ...string) {
+		doc := &storage.Document{ID: uuid.New(), ProjectID: project.ID, Filename: filename, ContentHash: uuid.NewString(), EmbeddingError: embeddingError}
+		stmts := make([]*storage.Statement, len(texts))
+		for i, text := range texts {
+			stmts[i] = &storage.Statement{DocumentID: doc.ID, Text: text, Position: i}
+		}
+		if _, err := env.documents.CreateWithStatements(context.Background(), doc, stmts, false); err != nil {
+			t.Fatalf("failed to seed %s: %v", filename, err)
+		}
+	}
+	unembedded("c.md", "", "Trials last two weeks.", "Trials need no card.")
+	unembedded("d.md", "embedding provider timed out - try again later", "Seats are billed per user.")
+
+	list := func(query string) []DocumentResponse {
+		t.Helper()
+		rec := env.do(t, http.MethodGet, "/api/v1/projects/"+project.ID.String()+"/documents"+query, userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var docs []DocumentResponse
+		decodeJSON(t, rec, &docs)
+		return docs
+	}
+	names := func(docs []DocumentResponse) string {
+		filenames := make([]string, len(docs))
+		for i, d := range docs {
+			filenames[i] = d.Filename
+		}
+		return strings.Join(filenames, ",")
+	}
+
+	all := list("")
+	if names(all) != "a.md,b.md,c.md,d.md" {
+		t.Fatalf("expected every document, got %s", names(all))
+	}
+	want := map[string]struct {
+		count  int
+		status string
+	}{
+		"a.md": {3, "done"},
+		"b.md": {1, "done"},
+		"c.md": {2, "pending"},
+		"d.md": {1, "failed"},
+	}
+	for _, d := range all {
+		if w := want[d.Filename]; d.StatementCount != w.count || d.EmbeddingStatus != w.status {
+			t.Errorf("%s: expected %d statements %s, got %d %s", d.Filename, w.count, w.status, d.StatementCount, d.EmbeddingStatus)
+		}
+	}
+
+	cases := map[string]string{
+		"?embedding_status=done":                  "a.md,b.md",
+		"?embedding_status=pending":               "c.md",
+		"?embedding_status=failed":                "d.md",
+		"?min_statements=2":                       "a.md,c.md",
+		"?embedding_status=done&min_statements=2": "a.md",
+		"?min_statements=4":                       "",
+	}
+	for query, expected := range cases {
+		if got := names(list(query)); got != expected {
+			t.Errorf("%s: expected %q, got %q", query, expected, got)
+		}
+	}
+
+	for _, query := range []string{"?embedding_status=broken", "?min_statements=-1", "?min_statements=x"} {
+		rec := env.do(t, http.MethodGet, "/api/v1/projects/"+project.ID.String()+"/documents"+query, userID.String(), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
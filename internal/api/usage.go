@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/todmy/doc-analyzer/internal/embeddings"
+)
+
+// DocumentUsage is the embedding token usage of one document
+type DocumentUsage struct {
+	DocumentID       string   `json:"document_id"`
+	Filename         string   `json:"filename"`
+	EmbeddingTokens  int      `json:"embedding_tokens"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+}
+
+// ProjectUsageResponse summarizes the embedding tokens consumed by a
+// project. Cost estimates use the list price of the currently configured
+// model and are omitted when its price is unknown.
+type ProjectUsageResponse struct {
+	EmbeddingTokens  int             `json:"embedding_tokens"`
+	Model            string          `json:"model,omitempty"`
+	EstimatedCostUSD *float64        `json:"estimated_cost_usd,omitempty"`
+	Documents        []DocumentUsage `json:"documents"`
+}
+
+// handleGetProjectUsage reports the embedding tokens recorded per document
+func (s *Server) handleGetProjectUsage(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+
+	documents, err := s.documentRepo.GetByProjectID(r.Context(), project.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch documents")
+		return
+	}
+
+	response := ProjectUsageResponse{Documents: make([]DocumentUsage, len(documents))}
+	var price float64
+	var priced bool
+	if s.embeddingClient != nil {
+		response.Model = s.embeddingClient.Model()
+		price, priced = embeddings.PricePerMillionTokens(response.Model)
+	}
+	estimate := func(tokens int) *float64 {
+		if !priced {
+			return nil
+		}
+		cost := float64(tokens) / 1e6 * price
+		return &cost
+	}
+
+	for i, d := range documents {
+		response.EmbeddingTokens += d.EmbeddingTokens
+		response.Documents[i] = DocumentUsage{
+			DocumentID:       d.ID.String(),
+			Filename:         d.Filename,
+			EmbeddingTokens:  d.EmbeddingTokens,
+			EstimatedCostUSD: estimate(d.EmbeddingTokens),
+		}
+	}
+	response.EstimatedCostUSD = estimate(response.EmbeddingTokens)
+
+	respondJSON(w, http.StatusOK, response)
+}
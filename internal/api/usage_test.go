@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleGetProjectUsage(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	files := map[string]string{
+		"a.md": "Refunds are available for thirty days after purchase.\n\nAnnual plans renew automatically unless cancelled.",
+		"b.md": "Support tickets are answered within one business day of submission.",
+	}
+	for name, content := range files {
+		if rec := env.upload(t, project.ID, userID.String(), name, content); rec.Code != http.StatusCreated {
+			t.Fatalf("upload %s: expected status 201, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/usage", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ProjectUsageResponse
+	decodeJSON(t, rec, &resp)
+	if len(resp.Documents) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(resp.Documents))
+	}
+
+	sum := 0
+	for _, d := range resp.Documents {
+		if d.EmbeddingTokens <= 0 {
+			t.Errorf("document %s: expected recorded tokens, got %d", d.Filename, d.EmbeddingTokens)
+		}
+		if d.EstimatedCostUSD == nil {
+			t.Errorf("document %s: expected a cost estimate", d.Filename)
+		}
+		sum += d.EmbeddingTokens
+	}
+	if resp.EmbeddingTokens != sum {
+		t.Errorf("expected total %d to equal the document sum %d", resp.EmbeddingTokens, sum)
+	}
+	if resp.EstimatedCostUSD == nil || *resp.EstimatedCostUSD <= 0 {
+		t.Errorf("expected a positive project cost estimate, got %v", resp.EstimatedCostUSD)
+	}
+}
@@ -1,11 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/clustering"
 	"github.com/todmy/doc-analyzer/internal/storage"
 	"github.com/todmy/doc-analyzer/internal/visualization"
 )
@@ -17,6 +25,11 @@ type VisualizationResponse struct {
 	Dimensions int                  `json:"dimensions"`
 	Method     string               `json:"method"`
 	AxisLabels []string             `json:"axis_labels,omitempty"`
+	// ExplainedVariance is the fraction of the embedding variance shown by
+	// each dimension of a PCA projection
+	ExplainedVariance []float64 `json:"explained_variance,omitempty"`
+	// Warnings name semantic axes that barely separate the statements
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // VisualizationPoint represents a point in the visualization
@@ -40,9 +53,74 @@ type ClusterInfo struct {
 	Density  float64  `json:"density"`
 }
 
-// SemanticAxesRequest represents a request to set semantic axes
+// ClusterMetricsResponse holds the elbow and silhouette curves for choosing
+// k. Arrays are indexed by position in K.
+type ClusterMetricsResponse struct {
+	K            []int     `json:"k"`
+	Inertia      []float64 `json:"inertia"`
+	Silhouette   []float64 `json:"silhouette"`
+	ElbowK       int       `json:"elbow_k"`
+	SilhouetteK  int       `json:"silhouette_k"`
+	RecommendedK int       `json:"recommended_k"`
+	Dimensions   int       `json:"dimensions"`
+}
+
+// SemanticAxesRequest represents a request to set semantic axes, given as
+// single words, word-pair axes or both (words first). Words are trimmed, and
+// lowercased as well with Normalize.
 type SemanticAxesRequest struct {
-	Words []string `json:"words"`
+	Words     []string              `json:"words,omitempty"`
+	Axes      []SemanticAxisRequest `json:"axes,omitempty"`
+	Normalize bool                  `json:"normalize,omitempty"`
+}
+
+// SemanticAxisRequest is an axis running from the negative word to the
+// positive one. Without negative it is a single-word axis.
+type SemanticAxisRequest struct {
+	Positive string `json:"positive"`
+	Negative string `json:"negative,omitempty"`
+}
+
+// poles returns the axes of the request, or an error message if they are
+// invalid
+func (req SemanticAxesRequest) poles() ([]visualization.AxisPoles, string) {
+	poles := visualization.WordPoles(normalizeAxisWords(req.Words, req.Normalize))
+	for _, axis := range req.Axes {
+		poles = append(poles, visualization.AxisPoles{
+			Positive: visualization.NormalizeAxisWord(axis.Positive, req.Normalize),
+			Negative: visualization.NormalizeAxisWord(axis.Negative, req.Normalize),
+		})
+	}
+
+	if len(poles) == 0 || len(poles) > 3 {
+		return nil, "provide 1-3 words or axes for semantic axes"
+	}
+	for _, pole := range poles {
+		if pole.Positive == "" {
+			return nil, "every axis needs a positive word"
+		}
+		if err := visualization.ValidateAxisWord(pole.Positive); err != nil {
+			return nil, err.Error()
+		}
+		if pole.Negative != "" {
+			if err := visualization.ValidateAxisWord(pole.Negative); err != nil {
+				return nil, err.Error()
+			}
+		}
+		if strings.EqualFold(pole.Positive, pole.Negative) {
+			return nil, "the positive and negative words of an axis must differ"
+		}
+	}
+	return poles, ""
+}
+
+// normalizeAxisWords trims each axis word, lowercasing it too with lower
+func normalizeAxisWords(words []string, lower bool) []string {
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = visualization.NormalizeAxisWord(word, lower)
+	}
+	return normalized
 }
 
 // maxVisualizationPoints is the maximum number of points to render for performance
@@ -51,17 +129,11 @@ const maxVisualizationPoints = 1000
 
 // handleGetVisualization returns visualization data for a project
 func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
-		return
-	}
-
-	pid, err := uuid.Parse(projectID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
 
 	// Parse dimensions parameter (default 2)
 	dimensions := 2
@@ -75,8 +147,22 @@ func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Reque
 		method = "pca"
 	}
 
-	// Parse words parameter for semantic method
-	words := r.URL.Query()["words"]
+	// Parse words parameter for semantic and hybrid methods, trimmed and,
+	// with ?normalize=true, lowercased
+	normalize, _ := strconv.ParseBool(r.URL.Query().Get("normalize"))
+	words := normalizeAxisWords(r.URL.Query()["words"], normalize)
+	for _, word := range words {
+		if err := visualization.ValidateAxisWord(word); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Hybrid fills the dimensions not taken by a word axis with PCA
+	if method == "hybrid" && (len(words) == 0 || len(words) > dimensions) {
+		respondError(w, http.StatusBadRequest, "method=hybrid requires between 1 and "+strconv.Itoa(dimensions)+" words")
+		return
+	}
 
 	// Get statements for project
 	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
@@ -85,6 +171,24 @@ func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+	// Identifies the statement set before sampling, like the stored clustering
+	fingerprint := statementFingerprint(statements)
+
+	cacheKey := analysisCacheKey(analysisParams{
+		Kind:       "visualization",
+		K:          project.Analysis.ClusterK,
+		Method:     method,
+		Dimensions: dimensions,
+		Words:      words,
+	}, statements)
+	if len(statements) > 0 && s.respondCached(w, cacheKey) {
+		return
+	}
+
 	// Sample statements if too many for performance
 	if len(statements) > maxVisualizationPoints {
 		statements = sampleStatements(statements, maxVisualizationPoints)
@@ -120,6 +224,11 @@ func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Reque
 	// Get visualization coordinates
 	visResult, err := s.visualizationService.GetVisualization(r.Context(), embeddings, method, dimensions, words)
 	if err != nil {
+		var degenerate *visualization.DegenerateAxisError
+		if errors.As(err, &degenerate) {
+			respondError(w, http.StatusUnprocessableEntity, degenerate.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "failed to generate visualization")
 		return
 	}
@@ -127,10 +236,17 @@ func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Reque
 	// Convert to model statements for anomaly detection
 	modelStatements := s.convertToModelStatements(statements)
 
-	// Run clustering on projected coordinates (much faster than full embeddings)
-	coords := extractCoords(visResult.Points, dimensions)
-	texts := extractTexts(statements)
-	clusterResult := s.clusteringService.AutoClusterCoordinates(coords, texts, 10)
+	// Color points by the stored clustering while it matches the statements,
+	// so they agree with /clusters; otherwise run clustering on projected
+	// coordinates (much faster than full embeddings)
+	clusterLabels, clusters := s.storedVisualizationClusters(r.Context(), project, fingerprint, statements)
+	if clusterLabels == nil {
+		coords := extractCoords(visResult.Points, dimensions)
+		texts := extractTexts(statements)
+		clusterResult := s.clusteringService.AutoClusterCoordinates(coords, texts, 10)
+		clusterLabels = clusterResult.Labels
+		clusters = s.buildClusterInfo(clusterResult)
+	}
 
 	// Get anomaly scores
 	anomalyResults := s.anomalyService.DetectAnomalies(modelStatements)
@@ -156,63 +272,145 @@ func (s *Server) handleGetVisualizationImpl(w http.ResponseWriter, r *http.Reque
 			X:            visResult.Points[i].X,
 			Y:            visResult.Points[i].Y,
 			Z:            visResult.Points[i].Z,
-			ClusterID:    clusterResult.Labels[i],
+			ClusterID:    clusterLabels[i],
 			AnomalyScore: anomalyScores[i],
 			Preview:      preview,
 			SourceFile:   filename,
 		}
 	}
 
-	// Build cluster info
-	clusterColors := []string{"#3498db", "#e74c3c", "#2ecc71", "#f39c12", "#9b59b6", "#1abc9c", "#e91e63", "#00bcd4", "#ff5722", "#607d8b"}
-	clusters := make([]ClusterInfo, len(clusterResult.Clusters))
-	for i, c := range clusterResult.Clusters {
-		keywords := make([]string, len(c.Keywords))
-		for j, kw := range c.Keywords {
-			keywords[j] = kw.Word
-		}
-		color := clusterColors[i%len(clusterColors)]
-		clusters[i] = ClusterInfo{
-			ID:       c.ID,
-			Keywords: keywords,
-			Color:    color,
-			Size:     c.Size,
-			Density:  c.Density,
-		}
-	}
-
-	respondJSON(w, http.StatusOK, VisualizationResponse{
+	response := VisualizationResponse{
 		Points:     points,
 		Clusters:   clusters,
 		Dimensions: dimensions,
 		Method:     method,
-	})
+
+		ExplainedVariance: visResult.ExplainedVariance,
+		Warnings:          visResult.Warnings,
+	}
+	s.analysisCache.Set(cacheKey, response)
+	respondJSON(w, http.StatusOK, response)
 }
 
-// handleSetAxes sets semantic axes for visualization
-func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	if projectID == "" {
-		respondError(w, http.StatusBadRequest, "project id is required")
+// maxMetricsK caps maxK for cluster metrics; silhouette scoring is O(n²)
+// per k
+const maxMetricsK = 20
+
+// handleGetClusterMetrics returns elbow inertias and silhouette scores for
+// k = 1..maxK, computed on the same PCA projection the visualization clusters
+func (s *Server) handleGetClusterMetrics(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
 		return
 	}
+	pid := project.ID
+
+	maxK := 10
+	if v := r.URL.Query().Get("maxK"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > maxMetricsK {
+			respondError(w, http.StatusBadRequest, "maxK must be between 1 and 20")
+			return
+		}
+		maxK = parsed
+	}
 
-	pid, err := uuid.Parse(projectID)
+	dimensions := 2
+	if d := r.URL.Query().Get("dimensions"); d == "3" {
+		dimensions = 3
+	}
+
+	statements, err := s.statementRepo.GetByProjectID(r.Context(), pid)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
+		respondError(w, http.StatusInternalServerError, "failed to fetch statements")
+		return
+	}
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+
+	if len(statements) == 0 {
+		respondJSON(w, http.StatusOK, ClusterMetricsResponse{
+			K:          []int{},
+			Inertia:    []float64{},
+			Silhouette: []float64{},
+			Dimensions: dimensions,
+		})
 		return
 	}
 
+	cacheKey := analysisCacheKey(analysisParams{Kind: "cluster_metrics", K: maxK, Dimensions: dimensions}, statements)
+	if s.respondCached(w, cacheKey) {
+		return
+	}
+
+	if len(statements) > maxVisualizationPoints {
+		statements = sampleStatements(statements, maxVisualizationPoints)
+	}
+
+	embeddings := make([][]float32, len(statements))
+	for i, stmt := range statements {
+		embeddings[i] = stmt.Embedding.Slice()
+	}
+
+	visResult, err := s.visualizationService.GetVisualization(r.Context(), embeddings, "pca", dimensions, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to project embeddings")
+		return
+	}
+
+	coords := extractCoords(visResult.Points, dimensions)
+	points := make([][]float32, len(coords))
+	for i, coord := range coords {
+		points[i] = make([]float32, len(coord))
+		for j, v := range coord {
+			points[i][j] = float32(v)
+		}
+	}
+
+	metrics := clustering.EvaluateK(points, maxK)
+	ks := make([]int, len(metrics.Inertia))
+	for i := range ks {
+		ks[i] = i + 1
+	}
+
+	response := ClusterMetricsResponse{
+		K:            ks,
+		Inertia:      metrics.Inertia,
+		Silhouette:   metrics.Silhouette,
+		ElbowK:       metrics.ElbowK,
+		SilhouetteK:  metrics.SilhouetteK,
+		RecommendedK: metrics.RecommendedK,
+		Dimensions:   dimensions,
+	}
+	s.analysisCache.Set(cacheKey, response)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleSetAxes sets semantic axes for visualization
+func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.authorizeProject(w, r)
+	if !ok {
+		return
+	}
+	pid := project.ID
+
 	var req SemanticAxesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	if len(req.Words) == 0 || len(req.Words) > 3 {
-		respondError(w, http.StatusBadRequest, "provide 1-3 words for semantic axes")
+	poles, msg := req.poles()
+	if msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
 		return
 	}
+	labels := make([]string, len(poles))
+	for i, pole := range poles {
+		labels[i] = pole.Label()
+	}
 
 	// Check if embedding client is configured for semantic axes
 	if s.embeddingClient == nil {
@@ -227,6 +425,12 @@ func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	statements, ok = s.reconcileStatements(w, statements)
+	if !ok {
+		return
+	}
+	fingerprint := statementFingerprint(statements)
+
 	// Sample statements if too many for performance
 	if len(statements) > maxVisualizationPoints {
 		statements = sampleStatements(statements, maxVisualizationPoints)
@@ -247,9 +451,9 @@ func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, VisualizationResponse{
 			Points:     []VisualizationPoint{},
 			Clusters:   []ClusterInfo{},
-			Dimensions: len(req.Words),
+			Dimensions: len(poles),
 			Method:     "semantic",
-			AxisLabels: req.Words,
+			AxisLabels: labels,
 		})
 		return
 	}
@@ -261,8 +465,13 @@ func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get visualization coordinates using semantic axes
-	visResult, err := s.visualizationService.GetVisualization(r.Context(), embeddings, "semantic", len(req.Words), req.Words)
+	visResult, err := s.visualizationService.GetVisualizationWithAxes(r.Context(), embeddings, "semantic", len(poles), poles)
 	if err != nil {
+		var degenerate *visualization.DegenerateAxisError
+		if errors.As(err, &degenerate) {
+			respondError(w, http.StatusUnprocessableEntity, degenerate.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "failed to generate semantic visualization: "+err.Error())
 		return
 	}
@@ -270,10 +479,16 @@ func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
 	// Convert to model statements for anomaly detection
 	modelStatements := s.convertToModelStatements(statements)
 
-	// Run clustering on projected coordinates (semantic mode)
-	coords := extractCoords(visResult.Points, len(req.Words))
-	texts := extractTexts(statements)
-	clusterResult := s.clusteringService.AutoClusterCoordinates(coords, texts, 10)
+	// Color points by the stored clustering while it matches the statements;
+	// otherwise run clustering on projected coordinates (semantic mode)
+	clusterLabels, clusters := s.storedVisualizationClusters(r.Context(), project, fingerprint, statements)
+	if clusterLabels == nil {
+		coords := extractCoords(visResult.Points, len(poles))
+		texts := extractTexts(statements)
+		clusterResult := s.clusteringService.AutoClusterCoordinates(coords, texts, 10)
+		clusterLabels = clusterResult.Labels
+		clusters = s.buildClusterInfo(clusterResult)
+	}
 
 	// Get anomaly scores
 	anomalyResults := s.anomalyService.DetectAnomalies(modelStatements)
@@ -298,38 +513,95 @@ func (s *Server) handleSetAxesImpl(w http.ResponseWriter, r *http.Request) {
 			X:            visResult.Points[i].X,
 			Y:            visResult.Points[i].Y,
 			Z:            visResult.Points[i].Z,
-			ClusterID:    clusterResult.Labels[i],
+			ClusterID:    clusterLabels[i],
 			AnomalyScore: anomalyScores[i],
 			Preview:      preview,
 			SourceFile:   filename,
 		}
 	}
 
-	// Build cluster info
-	clusterColors := []string{"#3498db", "#e74c3c", "#2ecc71", "#f39c12", "#9b59b6", "#1abc9c", "#e91e63", "#00bcd4", "#ff5722", "#607d8b"}
-	clusters := make([]ClusterInfo, len(clusterResult.Clusters))
-	for i, c := range clusterResult.Clusters {
+	respondJSON(w, http.StatusOK, VisualizationResponse{
+		Points:     points,
+		Clusters:   clusters,
+		Dimensions: len(poles),
+		Method:     "semantic",
+		AxisLabels: labels,
+		Warnings:   visResult.Warnings,
+	})
+}
+
+// buildClusterInfo converts a clustering result into legend entries with
+// a distinct color per cluster
+func (s *Server) buildClusterInfo(result *clustering.ClusterResult) []ClusterInfo {
+	colors := s.visualizationService.ClusterColors(len(result.Clusters))
+	clusters := make([]ClusterInfo, len(result.Clusters))
+	for i, c := range result.Clusters {
 		keywords := make([]string, len(c.Keywords))
 		for j, kw := range c.Keywords {
 			keywords[j] = kw.Word
 		}
-		color := clusterColors[i%len(clusterColors)]
 		clusters[i] = ClusterInfo{
 			ID:       c.ID,
 			Keywords: keywords,
-			Color:    color,
+			Color:    colors[i],
 			Size:     c.Size,
 			Density:  c.Density,
 		}
 	}
+	return clusters
+}
 
-	respondJSON(w, http.StatusOK, VisualizationResponse{
-		Points:     points,
-		Clusters:   clusters,
-		Dimensions: len(req.Words),
-		Method:     "semantic",
-		AxisLabels: req.Words,
-	})
+// storedVisualizationClusters returns the cluster label of each of
+// statements and the legend entries from the project's stored clustering,
+// or nil labels when it was not computed for the project's default k from
+// the statements behind fingerprint. statements may be a sample of those;
+// sizes count only the sampled statements.
+func (s *Server) storedVisualizationClusters(ctx context.Context, project *storage.Project, fingerprint string, statements []*storage.Statement) ([]int, []ClusterInfo) {
+	if s.clusterRepo == nil {
+		return nil, nil
+	}
+	stored, err := s.clusterRepo.GetByProjectID(ctx, project.ID)
+	if err != nil {
+		log.Printf("[visualization] failed to load clusters of project %s: %v", project.ID, err)
+		return nil, nil
+	}
+	if stored == nil || stored.RequestedK != project.Analysis.ClusterK || stored.Fingerprint != fingerprint {
+		return nil, nil
+	}
+
+	labelOf := make(map[uuid.UUID]int)
+	for _, c := range stored.Clusters {
+		for _, id := range c.StatementIDs {
+			labelOf[id] = c.Label
+		}
+	}
+	labels := make([]int, len(statements))
+	sizes := make(map[int]int)
+	for i, stmt := range statements {
+		label, ok := labelOf[stmt.ID]
+		if !ok {
+			return nil, nil
+		}
+		labels[i] = label
+		sizes[label]++
+	}
+
+	colors := s.visualizationService.ClusterColors(len(stored.Clusters))
+	clusters := make([]ClusterInfo, len(stored.Clusters))
+	for i, c := range stored.Clusters {
+		keywords := c.Keywords
+		if keywords == nil {
+			keywords = []string{}
+		}
+		clusters[i] = ClusterInfo{
+			ID:       c.Label,
+			Keywords: keywords,
+			Color:    colors[i],
+			Size:     sizes[c.Label],
+			Density:  c.Density,
+		}
+	}
+	return labels, clusters
 }
 
 // extractCoords extracts 2D or 3D coordinates from visualization points
@@ -354,18 +626,80 @@ func extractTexts(statements []*storage.Statement) []string {
 	return texts
 }
 
-// sampleStatements returns a uniformly distributed sample of statements
+// sampleStatements picks maxCount statements stratified by document: every
+// document keeps a share proportional to its size and at least one
+// statement, so small documents are not skipped between large ones. Within a
+// document the picks are evenly spaced. The sample is deterministic and
+// keeps the input order.
 func sampleStatements(statements []*storage.Statement, maxCount int) []*storage.Statement {
 	if len(statements) <= maxCount {
 		return statements
 	}
 
-	// Use deterministic sampling by taking evenly spaced indices
-	step := float64(len(statements)) / float64(maxCount)
-	sampled := make([]*storage.Statement, maxCount)
-	for i := 0; i < maxCount; i++ {
-		idx := int(float64(i) * step)
-		sampled[i] = statements[idx]
+	// Group statement indices by document in order of appearance
+	var groups [][]int
+	groupOf := make(map[uuid.UUID]int)
+	for i, stmt := range statements {
+		g, ok := groupOf[stmt.DocumentID]
+		if !ok {
+			g = len(groups)
+			groupOf[stmt.DocumentID] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	selected := make([]bool, len(statements))
+	for g, quota := range sampleQuotas(groups, len(statements), maxCount) {
+		members := groups[g]
+		step := float64(len(members)) / float64(quota)
+		for i := 0; i < quota; i++ {
+			selected[members[int(float64(i)*step)]] = true
+		}
+	}
+
+	sampled := make([]*storage.Statement, 0, maxCount)
+	for i, stmt := range statements {
+		if selected[i] {
+			sampled = append(sampled, stmt)
+		}
 	}
 	return sampled
 }
+
+// sampleQuotas splits maxCount picks among groups of n statements in total:
+// one per group, the rest proportional to group size by largest remainder.
+// With more groups than picks, evenly spaced groups get one each.
+func sampleQuotas(groups [][]int, n, maxCount int) []int {
+	quotas := make([]int, len(groups))
+	if len(groups) >= maxCount {
+		step := float64(len(groups)) / float64(maxCount)
+		for i := 0; i < maxCount; i++ {
+			quotas[int(float64(i)*step)] = 1
+		}
+		return quotas
+	}
+
+	remaining := maxCount - len(groups)
+	rest := n - len(groups)
+	remainders := make([]float64, len(groups))
+	assigned := 0
+	for g, members := range groups {
+		share := float64(remaining) * float64(len(members)-1) / float64(rest)
+		quotas[g] = 1 + int(share)
+		remainders[g] = share - math.Floor(share)
+		assigned += int(share)
+	}
+
+	order := make([]int, len(groups))
+	for g := range order {
+		order[g] = g
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, g := range order[:remaining-assigned] {
+		quotas[g]++
+	}
+	return quotas
+}
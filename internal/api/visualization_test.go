@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/todmy/doc-analyzer/internal/storage"
+)
+
+func TestHandleSetAxes_WordPairs(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"the hosting plan is cheap",
+		"the hosting plan is expensive",
+		"the hosting plan exists",
+	)
+	path := fmt.Sprintf("/api/v1/projects/%s/visualization/axes", project.ID)
+
+	rec := env.do(t, http.MethodPost, path, userID.String(), map[string]interface{}{
+		"axes": []map[string]string{{"positive": "cheap", "negative": "expensive"}},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response VisualizationResponse
+	decodeJSON(t, rec, &response)
+	if response.Dimensions != 1 || !reflect.DeepEqual(response.AxisLabels, []string{"cheap-expensive"}) {
+		t.Errorf("expected one cheap-expensive axis, got %d dimensions and labels %v", response.Dimensions, response.AxisLabels)
+	}
+	x := make(map[string]float64)
+	for _, p := range response.Points {
+		x[p.Preview] = p.X
+	}
+	cheap, expensive, neutral := x["the hosting plan is cheap"], x["the hosting plan is expensive"], x["the hosting plan exists"]
+	if !(cheap > neutral && neutral > expensive) {
+		t.Errorf("expected cheap > neutral > expensive along the axis, got %v > %v > %v", cheap, neutral, expensive)
+	}
+
+	// Single words still work and combine with pairs
+	rec = env.do(t, http.MethodPost, path, userID.String(), map[string]interface{}{
+		"words": []string{"hosting"},
+		"axes":  []map[string]string{{"positive": "cheap", "negative": "expensive"}},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	decodeJSON(t, rec, &response)
+	if !reflect.DeepEqual(response.AxisLabels, []string{"hosting", "cheap-expensive"}) {
+		t.Errorf("expected words before pairs, got %v", response.AxisLabels)
+	}
+
+	for name, body := range map[string]interface{}{
+		"no axes":       map[string]interface{}{},
+		"no positive":   map[string]interface{}{"axes": []map[string]string{{"negative": "expensive"}}},
+		"same poles":    map[string]interface{}{"axes": []map[string]string{{"positive": "cheap", "negative": "Cheap"}}},
+		"too many axes": map[string]interface{}{"words": []string{"a", "b", "c"}, "axes": []map[string]string{{"positive": "cheap"}}},
+	} {
+		if rec := env.do(t, http.MethodPost, path, userID.String(), body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestHandleSetAxes_NormalizesAndWarns(t *testing.T) {
+	srv := newFakeEmbeddingServer(t)
+	env := newTestEnv(t, srv.URL)
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+	env.seedDocument(t, project.ID, "a.md",
+		"the hosting plan is cheap",
+		"the hosting plan is expensive",
+		"the hosting plan exists",
+	)
+	path := fmt.Sprintf("/api/v1/projects/%s/visualization/axes", project.ID)
+
+	rec := env.do(t, http.MethodPost, path, userID.String(), map[string]interface{}{
+		"words":     []string{"  Hosting  Plan "},
+		"normalize": true,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response VisualizationResponse
+	decodeJSON(t, rec, &response)
+	if !reflect.DeepEqual(response.AxisLabels, []string{"hosting plan"}) {
+		t.Errorf("expected the normalized label, got %v", response.AxisLabels)
+	}
+	if len(response.Warnings) != 0 {
+		t.Errorf("expected no warnings for a word of the corpus, got %v", response.Warnings)
+	}
+
+	// A word that shares nothing with the statements makes a weak axis
+	rec = env.do(t, http.MethodPost, path, userID.String(), map[string]interface{}{
+		"words": []string{"hosting", "zxqv"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	response = VisualizationResponse{}
+	decodeJSON(t, rec, &response)
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], `"zxqv"`) {
+		t.Errorf("expected a warning naming the weak axis, got %v", response.Warnings)
+	}
+
+	for name, body := range map[string]interface{}{
+		"blank word":    map[string]interface{}{"words": []string{"  "}},
+		"no letters":    map[string]interface{}{"words": []string{"123"}},
+		"long negative": map[string]interface{}{"axes": []map[string]string{{"positive": "cheap", "negative": strings.Repeat("x", 100)}}},
+	} {
+		if rec := env.do(t, http.MethodPost, path, userID.String(), body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, rec.Code)
+		}
+	}
+
+	rec = env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/visualization?method=semantic&words=%%3F%%3F", project.ID), userID.String(), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with an invalid word: expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetVisualization_UsesStoredClustering(t *testing.T) {
+	env := newTestEnv(t, "")
+	userID := uuid.New()
+	project := env.seedProject(t, userID)
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("statement number %d about topic %d", i, i%3)
+	}
+	env.seedDocument(t, project.ID, "a.md", texts...)
+
+	if rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/clusters", project.ID), userID.String(), nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Relabel the stored clustering so its labels cannot come from a new fit
+	stored, _ := env.clusters.GetByProjectID(context.Background(), project.ID)
+	if stored == nil {
+		t.Fatal("expected a stored clustering")
+	}
+	want := make(map[string]int)
+	for _, c := range stored.Clusters {
+		c.Label += 100
+		c.Keywords = []string{fmt.Sprintf("stored-%d", c.Label)}
+		for _, id := range c.StatementIDs {
+			want[id.String()] = c.Label
+		}
+	}
+	if err := env.clusters.Replace(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() VisualizationResponse {
+		t.Helper()
+		rec := env.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/visualization", project.ID), userID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response VisualizationResponse
+		decodeJSON(t, rec, &response)
+		return response
+	}
+
+	response := get()
+	if len(response.Points) != len(texts) {
+		t.Fatalf("expected %d points, got %d", len(texts), len(response.Points))
+	}
+	for _, p := range response.Points {
+		if p.ClusterID != want[p.ID] {
+			t.Errorf("point %s: expected stored cluster %d, got %d", p.ID, want[p.ID], p.ClusterID)
+		}
+	}
+	if len(response.Clusters) != len(stored.Clusters) {
+		t.Fatalf("expected %d clusters, got %d", len(stored.Clusters), len(response.Clusters))
+	}
+	for i, c := range response.Clusters {
+		if c.ID != stored.Clusters[i].Label || !reflect.DeepEqual(c.Keywords, stored.Clusters[i].Keywords) || c.Size != len(stored.Clusters[i].StatementIDs) {
+			t.Errorf("cluster %d: expected the stored cluster, got %+v", i, c)
+		}
+	}
+
+	// Once the statements change the stored clustering no longer applies
+	env.seedDocument(t, project.ID, "b.md", "a brand new statement")
+	for _, p := range get().Points {
+		if p.ClusterID >= 100 {
+			t.Fatalf("expected fresh cluster labels after the statements changed, got %d", p.ClusterID)
+		}
+	}
+}
+
+func TestSampleStatements_StratifiesByDocument(t *testing.T) {
+	large, small, other := uuid.New(), uuid.New(), uuid.New()
+	var statements []*storage.Statement
+	add := func(doc uuid.UUID, n int) {
+		for i := 0; i < n; i++ {
+			statements = append(statements, &storage.Statement{ID: uuid.New(), DocumentID: doc, Position: i})
+		}
+	}
+	// A small document between two large ones falls between evenly spaced
+	// picks over the whole project
+	add(large, 2500)
+	add(small, 2)
+	add(other, 2500)
+
+	sampled := sampleStatements(statements, 1000)
+	if len(sampled) != 1000 {
+		t.Fatalf("expected 1000 statements, got %d", len(sampled))
+	}
+	counts := make(map[uuid.UUID]int)
+	for _, stmt := range sampled {
+		counts[stmt.DocumentID]++
+	}
+	if counts[small] == 0 {
+		t.Error("expected the small document to be sampled")
+	}
+	if counts[large] < 495 || counts[other] < 495 {
+		t.Errorf("expected the large documents to keep proportional shares, got %v", counts)
+	}
+
+	// Deterministic and in input order
+	again := sampleStatements(statements, 1000)
+	for i := range sampled {
+		if sampled[i] != again[i] {
+			t.Fatal("expected the same sample on every call")
+		}
+		if i > 0 && indexOf(statements, sampled[i-1]) >= indexOf(statements, sampled[i]) {
+			t.Fatal("expected the sample to keep the input order")
+		}
+	}
+
+	// More documents than picks: one statement from evenly spaced documents
+	statements = nil
+	for i := 0; i < 30; i++ {
+		add(uuid.New(), 2)
+	}
+	if got := sampleStatements(statements, 10); len(got) != 10 {
+		t.Errorf("expected 10 statements, got %d", len(got))
+	}
+}
+
+func indexOf(statements []*storage.Statement, stmt *storage.Statement) int {
+	for i, s := range statements {
+		if s == stmt {
+			return i
+		}
+	}
+	return -1
+}
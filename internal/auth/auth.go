@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -23,12 +24,18 @@ type User struct {
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// TokenVersion is bumped whenever the password changes; access tokens
+	// carrying an older version are rejected
+	TokenVersion int `json:"-"`
 }
 
 // Claims represents the JWT claims
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// TokenVersion is the user's token version when the token was issued
+	TokenVersion int `json:"tv,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -37,41 +44,63 @@ type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
 }
 
 // Service defines the authentication service interface
 type Service interface {
 	Register(ctx context.Context, email, password string) (*User, error)
-	Login(ctx context.Context, email, password string) (string, error)
-	ValidateToken(tokenString string) (*Claims, error)
+	Login(ctx context.Context, email, password string) (*TokenPair, error)
+	RefreshToken(ctx context.Context, refresh string) (string, error)
+	RevokeRefreshToken(ctx context.Context, refresh string) error
+	RevokeToken(ctx context.Context, claims *Claims) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ValidateToken(ctx context.Context, tokenString string) (*Claims, error)
 }
 
 // Config holds authentication configuration
 type Config struct {
 	SecretKey     string
 	TokenDuration time.Duration
+	// RefreshTokenDuration is the lifetime of refresh tokens issued on login
+	RefreshTokenDuration time.Duration
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
 	return Config{
-		SecretKey:     "change-me-in-production",
-		TokenDuration: 24 * time.Hour,
+		SecretKey:            "change-me-in-production",
+		TokenDuration:        24 * time.Hour,
+		RefreshTokenDuration: 30 * 24 * time.Hour,
 	}
 }
 
 // JWTService implements the Service interface
 type JWTService struct {
-	config Config
-	repo   UserRepository
+	config      Config
+	repo        UserRepository
+	refreshRepo RefreshTokenRepository
+	denylist    TokenDenylist
+	resetRepo   ResetTokenRepository
+	resetSender ResetSender
 }
 
-// NewJWTService creates a new JWT-based authentication service
-func NewJWTService(config Config, repo UserRepository) *JWTService {
-	return &JWTService{
+// NewJWTService creates a new JWT-based authentication service. Refresh
+// tokens are only issued when a store is supplied with WithRefreshTokens.
+func NewJWTService(config Config, repo UserRepository, opts ...ServiceOption) *JWTService {
+	if config.RefreshTokenDuration <= 0 {
+		config.RefreshTokenDuration = DefaultConfig().RefreshTokenDuration
+	}
+
+	s := &JWTService{
 		config: config,
 		repo:   repo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Register creates a new user with hashed password
@@ -103,22 +132,41 @@ func (s *JWTService) Register(ctx context.Context, email, password string) (*Use
 	return user, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *JWTService) Login(ctx context.Context, email, password string) (string, error) {
+// Login authenticates a user and returns a JWT access token, plus a refresh
+// token if refresh tokens are enabled
+func (s *JWTService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
 	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) || (err == nil && user == nil) {
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
+	}
+
+	access, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	tokens := &TokenPair{AccessToken: access}
+
+	if s.refreshRepo != nil {
+		tokens.RefreshToken, err = s.issueRefreshToken(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return s.generateToken(user)
+	return tokens, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates a JWT token and returns the claims. Revoked tokens
+// and tokens issued before the user's password last changed are rejected
+// with ErrInvalidToken.
+func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -133,14 +181,37 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if s.denylist != nil && claims.ID != "" {
+		revoked, err := s.denylist.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	user, err := s.repo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, ErrUserNotFound) || (err == nil && user == nil) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, ErrInvalidToken
+	}
+
 	return claims, nil
 }
 
 func (s *JWTService) generateToken(user *User) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:       user.ID,
+		Email:        user.Email,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.TokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
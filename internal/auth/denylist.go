@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TokenDenylist records revoked access tokens by their jti claim
+type TokenDenylist interface {
+	// Revoke denylists a token until it expires
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// WithTokenDenylist enables access token revocation backed by denylist
+func WithTokenDenylist(denylist TokenDenylist) ServiceOption {
+	return func(s *JWTService) {
+		s.denylist = denylist
+	}
+}
+
+// RevokeToken invalidates an access token before it expires, e.g. on logout.
+// Tokens issued without a jti cannot be revoked.
+func (s *JWTService) RevokeToken(ctx context.Context, claims *Claims) error {
+	if s.denylist == nil || claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.config.TokenDuration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return s.denylist.Revoke(ctx, claims.ID, expiresAt)
+}
+
+// PostgresTokenDenylist implements TokenDenylist using PostgreSQL
+type PostgresTokenDenylist struct {
+	db *sql.DB
+}
+
+// NewPostgresTokenDenylist creates a new PostgreSQL token denylist
+func NewPostgresTokenDenylist(db *sql.DB) *PostgresTokenDenylist {
+	return &PostgresTokenDenylist{db: db}
+}
+
+// Revoke adds a token to the denylist
+func (d *PostgresTokenDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at, revoked_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	if _, err := d.db.ExecContext(ctx, query, jti, expiresAt, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether a token is on the denylist
+func (d *PostgresTokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+	if err := d.db.QueryRowContext(ctx, query, jti).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check revoked token: %w", err)
+	}
+
+	return revoked, nil
+}
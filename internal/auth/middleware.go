@@ -19,13 +19,13 @@ func Middleware(service Service) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			token := extractToken(r)
 			if token == "" {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				respondError(w, http.StatusUnauthorized, "missing bearer token")
 				return
 			}
 
-			claims, err := service.ValidateToken(token)
+			claims, err := service.ValidateToken(r.Context(), token)
 			if err != nil {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				respondError(w, http.StatusUnauthorized, "invalid or expired token")
 				return
 			}
 
@@ -42,7 +42,7 @@ func OptionalMiddleware(service Service) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			token := extractToken(r)
 			if token != "" {
-				claims, err := service.ValidateToken(token)
+				claims, err := service.ValidateToken(r.Context(), token)
 				if err == nil {
 					ctx := context.WithValue(r.Context(), UserContextKey, claims)
 					r = r.WithContext(ctx)
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a stored long-lived token used to obtain new access tokens.
+// Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RefreshTokenRepository defines the interface for refresh token persistence
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	// GetByHash returns ErrInvalidToken if no token has the hash
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	Revoke(ctx context.Context, id string) error
+	// RevokeAllForUser revokes every outstanding refresh token of a user
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+// TokenPair holds the tokens issued on login
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// ServiceOption configures a JWTService
+type ServiceOption func(*JWTService)
+
+// WithRefreshTokens enables refresh tokens stored in repo
+func WithRefreshTokens(repo RefreshTokenRepository) ServiceOption {
+	return func(s *JWTService) {
+		s.refreshRepo = repo
+	}
+}
+
+// hashOpaqueToken returns the hex SHA-256 of a refresh or reset token.
+// Tokens are random, so a fast unsalted hash is sufficient.
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateOpaqueToken returns a random URL-safe token
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// issueRefreshToken creates and stores a new refresh token for a user
+func (s *JWTService) issueRefreshToken(ctx context.Context, userID string) (string, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	rt := &RefreshToken{
+		UserID:    userID,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: now.Add(s.config.RefreshTokenDuration),
+		CreatedAt: now,
+	}
+	if err := s.refreshRepo.Create(ctx, rt); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// lookupRefreshToken returns the stored token if it is valid
+func (s *JWTService) lookupRefreshToken(ctx context.Context, refresh string) (*RefreshToken, error) {
+	if s.refreshRepo == nil || refresh == "" {
+		return nil, ErrInvalidToken
+	}
+
+	rt, err := s.refreshRepo.GetByHash(ctx, hashOpaqueToken(refresh))
+	if err != nil {
+		return nil, err
+	}
+	if rt.RevokedAt != nil || time.Now().After(rt.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	return rt, nil
+}
+
+// RefreshToken exchanges a valid refresh token for a new access token
+func (s *JWTService) RefreshToken(ctx context.Context, refresh string) (string, error) {
+	rt, err := s.lookupRefreshToken(ctx, refresh)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.repo.GetByID(ctx, rt.UserID)
+	if err != nil || user == nil {
+		return "", ErrInvalidToken
+	}
+
+	return s.generateToken(user)
+}
+
+// RevokeRefreshToken invalidates a refresh token, e.g. on logout
+func (s *JWTService) RevokeRefreshToken(ctx context.Context, refresh string) error {
+	rt, err := s.lookupRefreshToken(ctx, refresh)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshRepo.Revoke(ctx, rt.ID)
+}
+
+// PostgresRefreshTokenRepository implements RefreshTokenRepository using PostgreSQL
+type PostgresRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRefreshTokenRepository creates a new PostgreSQL refresh token repository
+func NewPostgresRefreshTokenRepository(db *sql.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+// Create inserts a new refresh token
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *RefreshToken) error {
+	token.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &RefreshToken{}
+	var revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// Revoke marks a refresh token as revoked
+func (r *PostgresRefreshTokenRepository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser marks every unrevoked refresh token of a user as revoked
+func (r *PostgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
@@ -49,7 +50,7 @@ func (r *PostgresRepository) Create(ctx context.Context, user *User) error {
 // GetByID retrieves a user by their ID
 func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT id, email, password_hash, token_version, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -59,6 +60,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*User, err
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
+		&user.TokenVersion,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -76,7 +78,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*User, err
 // GetByEmail retrieves a user by their email address
 func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT id, email, password_hash, token_version, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -86,6 +88,7 @@ func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*Use
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
+		&user.TokenVersion,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -99,3 +102,28 @@ func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*Use
 
 	return user, nil
 }
+
+// UpdatePassword replaces a user's password hash and bumps the token
+// version, invalidating access tokens issued before the change
+func (r *PostgresRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $2, token_version = token_version + 1, updated_at = $3
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, passwordHash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
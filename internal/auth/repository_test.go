@@ -58,8 +58,8 @@ func TestPostgresRepository_GetByID(t *testing.T) {
 	createdAt := time.Now()
 	updatedAt := time.Now()
 
-	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "created_at", "updated_at"}).
-		AddRow(userID, email, passwordHash, createdAt, updatedAt)
+	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "token_version", "created_at", "updated_at"}).
+		AddRow(userID, email, passwordHash, 2, createdAt, updatedAt)
 
 	mock.ExpectQuery("SELECT (.+) FROM users WHERE id").
 		WithArgs(userID).
@@ -82,6 +82,10 @@ func TestPostgresRepository_GetByID(t *testing.T) {
 		t.Errorf("expected email %s, got %s", email, user.Email)
 	}
 
+	if user.TokenVersion != 2 {
+		t.Errorf("expected token version 2, got %d", user.TokenVersion)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled expectations: %v", err)
 	}
@@ -131,8 +135,8 @@ func TestPostgresRepository_GetByEmail(t *testing.T) {
 	createdAt := time.Now()
 	updatedAt := time.Now()
 
-	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "created_at", "updated_at"}).
-		AddRow(userID, email, passwordHash, createdAt, updatedAt)
+	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "token_version", "created_at", "updated_at"}).
+		AddRow(userID, email, passwordHash, 2, createdAt, updatedAt)
 
 	mock.ExpectQuery("SELECT (.+) FROM users WHERE email").
 		WithArgs(email).
@@ -155,6 +159,10 @@ func TestPostgresRepository_GetByEmail(t *testing.T) {
 		t.Errorf("expected email %s, got %s", email, user.Email)
 	}
 
+	if user.TokenVersion != 2 {
+		t.Errorf("expected token version 2, got %d", user.TokenVersion)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled expectations: %v", err)
 	}
@@ -188,3 +196,33 @@ func TestPostgresRepository_GetByEmail_NotFound(t *testing.T) {
 		t.Errorf("unfulfilled expectations: %v", err)
 	}
 }
+
+func TestPostgresRepository_UpdatePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresRepository(db)
+
+	userID := "123e4567-e89b-12d3-a456-426614174000"
+
+	mock.ExpectExec("UPDATE users SET password_hash = \\$2, token_version = token_version \\+ 1").
+		WithArgs(userID, "new_hash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET password_hash = \\$2, token_version = token_version \\+ 1").
+		WithArgs("missing", "new_hash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.UpdatePassword(context.Background(), userID, "new_hash"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := repo.UpdatePassword(context.Background(), "missing", "new_hash"); err != ErrUserNotFound {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordResetTokenDuration is how long a password reset token is valid
+const PasswordResetTokenDuration = time.Hour
+
+// ErrPasswordResetDisabled is returned when no reset token store is configured
+var ErrPasswordResetDisabled = errors.New("password reset not configured")
+
+// PasswordResetToken is a stored single-use token for resetting a password.
+// Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// ResetTokenRepository defines the interface for password reset token persistence
+type ResetTokenRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	// GetByHash returns ErrInvalidToken if no token has the hash
+	GetByHash(ctx context.Context, hash string) (*PasswordResetToken, error)
+	// MarkUsed consumes a token, returning ErrInvalidToken if it was already used
+	MarkUsed(ctx context.Context, id string) error
+}
+
+// ResetSender delivers password reset tokens to users
+type ResetSender interface {
+	SendPasswordReset(ctx context.Context, email, token string) error
+}
+
+// WithPasswordReset enables password reset with tokens stored in repo and
+// delivered by sender
+func WithPasswordReset(repo ResetTokenRepository, sender ResetSender) ServiceOption {
+	return func(s *JWTService) {
+		s.resetRepo = repo
+		s.resetSender = sender
+	}
+}
+
+// passwordResetSendTimeout bounds issuing and delivering one reset token
+const passwordResetSendTimeout = 30 * time.Second
+
+// RequestPasswordReset issues a reset token for the user with email and
+// sends it. The work happens in the background and failures are only
+// logged, so neither the result nor the response time reveals whether the
+// email belongs to an account. Only ErrPasswordResetDisabled is returned.
+func (s *JWTService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.resetRepo == nil || s.resetSender == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetSendTimeout)
+		defer cancel()
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			log.Printf("[auth] failed to send password reset for %s: %v", email, err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset stores a new reset token for the user with email and
+// delivers it. Unknown emails are ignored.
+func (s *JWTService) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) || (err == nil && user == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rt := &PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: now.Add(PasswordResetTokenDuration),
+		CreatedAt: now,
+	}
+	if err := s.resetRepo.Create(ctx, rt); err != nil {
+		return err
+	}
+
+	return s.resetSender.SendPasswordReset(ctx, user.Email, token)
+}
+
+// ResetPassword sets a new password using a reset token. The token is
+// consumed before the password changes, so it cannot be replayed. Sessions
+// opened with the old password are signed out: the password change bumps
+// the user's token version, invalidating issued access tokens, and the
+// user's refresh tokens are revoked.
+func (s *JWTService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if s.resetRepo == nil {
+		return ErrPasswordResetDisabled
+	}
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	rt, err := s.resetRepo.GetByHash(ctx, hashOpaqueToken(token))
+	if err != nil {
+		return err
+	}
+	if rt.UsedAt != nil || time.Now().After(rt.ExpiresAt) {
+		return ErrInvalidToken
+	}
+
+	if err := s.resetRepo.MarkUsed(ctx, rt.ID); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, rt.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	if s.refreshRepo != nil {
+		return s.refreshRepo.RevokeAllForUser(ctx, rt.UserID)
+	}
+	return nil
+}
+
+// PostgresResetTokenRepository implements ResetTokenRepository using PostgreSQL
+type PostgresResetTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresResetTokenRepository creates a new PostgreSQL reset token repository
+func NewPostgresResetTokenRepository(db *sql.DB) *PostgresResetTokenRepository {
+	return &PostgresResetTokenRepository{db: db}
+}
+
+// Create inserts a new reset token
+func (r *PostgresResetTokenRepository) Create(ctx context.Context, token *PasswordResetToken) error {
+	token.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a reset token by the hash of its value
+func (r *PostgresResetTokenRepository) GetByHash(ctx context.Context, hash string) (*PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &PasswordResetToken{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get reset token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed consumes a reset token. The used_at check makes concurrent
+// resets with the same token race safely: only one update succeeds.
+func (r *PostgresResetTokenRepository) MarkUsed(ctx context.Context, id string) error {
+	query := `UPDATE password_reset_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark reset token used: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark reset token used: %w", err)
+	}
+	if rows == 0 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds a reset webhook call when no client is supplied
+const webhookTimeout = 10 * time.Second
+
+// WebhookResetSender delivers password reset tokens by POSTing them as JSON
+// to a URL, leaving the email itself to the receiving service (a mailer,
+// a notification queue). The body is {"email", "token", "expires_at"}.
+type WebhookResetSender struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewWebhookResetSender creates a sender posting to url. A non-empty secret
+// is sent as a bearer token so the receiver can authenticate the call. A nil
+// httpClient uses one with a 10 second timeout.
+func NewWebhookResetSender(url, secret string, httpClient *http.Client) *WebhookResetSender {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: webhookTimeout}
+	}
+	return &WebhookResetSender{url: url, secret: secret, httpClient: httpClient}
+}
+
+// webhookResetPayload is the body of a reset webhook call
+type webhookResetPayload struct {
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SendPasswordReset posts the token for email to the webhook. Any non-2xx
+// response is an error.
+func (s *WebhookResetSender) SendPasswordReset(ctx context.Context, email, token string) error {
+	body, err := json.Marshal(webhookResetPayload{
+		Email:     email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(PasswordResetTokenDuration).UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create reset webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+s.secret)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call reset webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("reset webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookResetSender(t *testing.T) {
+	var got webhookResetPayload
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode webhook body: %v", err)
+		}
+		if got.Email == "fail@example.com" {
+			http.Error(w, "mailbox unavailable", http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sender := NewWebhookResetSender(srv.URL, "hook-secret", nil)
+	if err := sender.SendPasswordReset(context.Background(), "dave@example.com", "reset-token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Email != "dave@example.com" || got.Token != "reset-token" || got.ExpiresAt.IsZero() {
+		t.Errorf("expected the email, token and expiry in the body, got %+v", got)
+	}
+	if authHeader != "Bearer hook-secret" {
+		t.Errorf("expected the secret as a bearer token, got %q", authHeader)
+	}
+
+	if err := sender.SendPasswordReset(context.Background(), "fail@example.com", "reset-token"); err == nil {
+		t.Error("expected an error for a failed delivery")
+	}
+}
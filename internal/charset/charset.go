@@ -0,0 +1,216 @@
+// Package charset detects the text encoding of uploaded documents and
+// transcodes them to UTF-8.
+package charset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings reported by Decode
+const (
+	UTF8        = "utf-8"
+	UTF16LE     = "utf-16le"
+	UTF16BE     = "utf-16be"
+	Windows1252 = "windows-1252"
+)
+
+// Fallback selects how content that is neither UTF-8 nor UTF-16 is handled
+type Fallback string
+
+const (
+	// FallbackWindows1252 decodes it as Windows-1252, a superset of Latin-1
+	FallbackWindows1252 Fallback = "windows-1252"
+	// FallbackReplace keeps it as UTF-8, replacing invalid sequences with U+FFFD
+	FallbackReplace Fallback = "replace"
+	// FallbackReject rejects it with ErrUnsupportedEncoding
+	FallbackReject Fallback = "reject"
+)
+
+// ParseFallback validates a fallback name (empty means windows-1252)
+func ParseFallback(name string) (Fallback, error) {
+	switch Fallback(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FallbackWindows1252, "latin1":
+		return FallbackWindows1252, nil
+	case FallbackReplace:
+		return FallbackReplace, nil
+	case FallbackReject:
+		return FallbackReject, nil
+	default:
+		return "", fmt.Errorf("unknown encoding fallback %q (expected windows-1252, replace or reject)", name)
+	}
+}
+
+var (
+	// ErrBinary is returned for content that does not decode to text
+	ErrBinary = errors.New("content appears to be binary")
+	// ErrUnsupportedEncoding is returned by FallbackReject for content that
+	// is neither UTF-8 nor UTF-16
+	ErrUnsupportedEncoding = errors.New("content is not UTF-8 or UTF-16")
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// maxControlShare is the share of control characters above which decoded
+// text is considered binary
+const maxControlShare = 0.1
+
+// maxReplacementShare is the share of U+FFFD replacement characters, which
+// stand for undecodable bytes, above which decoded text is considered binary
+const maxReplacementShare = 0.3
+
+// maxHighByteShare is the share of non-ASCII bytes up to which content is
+// recognized as Windows-1252. Western text in a single-byte encoding is
+// mostly ASCII with the occasional accented letter; content with more high
+// bytes is another encoding (e.g. Cyrillic Windows-1251) or binary and would
+// decode to nonsense.
+const maxHighByteShare = 0.3
+
+// Decode detects the encoding of data and returns it transcoded to UTF-8
+// along with the detected encoding. A byte order mark decides the encoding
+// and is removed; otherwise UTF-16 without a BOM is recognized by its zero
+// bytes, valid UTF-8 is returned unchanged, and anything else is handled by
+// fallback. The Windows-1252 fallback only applies to content that looks
+// like it (see maxHighByteShare); other content is rejected with
+// ErrUnsupportedEncoding. Text containing NUL, many control characters or
+// mostly replacement characters is rejected with ErrBinary.
+func Decode(data []byte, fallback Fallback) (string, string, error) {
+	var text, enc string
+	sniffed := sniffUTF16(data)
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		text, enc = strings.ToValidUTF8(string(data[len(bomUTF8):]), "�"), UTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		text, enc = decodeUTF16(data[2:], binary.LittleEndian), UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		text, enc = decodeUTF16(data[2:], binary.BigEndian), UTF16BE
+	case sniffed == UTF16LE:
+		text, enc = decodeUTF16(data, binary.LittleEndian), UTF16LE
+	case sniffed == UTF16BE:
+		text, enc = decodeUTF16(data, binary.BigEndian), UTF16BE
+	case utf8.Valid(data):
+		text, enc = string(data), UTF8
+	default:
+		switch fallback {
+		case FallbackReject:
+			return "", "", ErrUnsupportedEncoding
+		case FallbackReplace:
+			text, enc = strings.ToValidUTF8(string(data), "�"), UTF8
+		default:
+			if highByteShare(data) > maxHighByteShare {
+				return "", "", ErrUnsupportedEncoding
+			}
+			text, enc = decodeWindows1252(data), Windows1252
+		}
+	}
+
+	if looksBinary(text) {
+		return "", "", ErrBinary
+	}
+	return text, enc, nil
+}
+
+// sniffUTF16 recognizes BOM-less UTF-16 by the zero high bytes of ASCII
+// characters: at least a third of the odd (little-endian) or even
+// (big-endian) bytes are zero while the other half has almost none. It
+// returns UTF16LE, UTF16BE or "" if data does not look like UTF-16.
+func sniffUTF16(data []byte) string {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return ""
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	units := len(data) / 2
+	switch {
+	case oddZeros*3 >= units && evenZeros*20 < units:
+		return UTF16LE
+	case evenZeros*3 >= units && oddZeros*20 < units:
+		return UTF16BE
+	}
+	return ""
+}
+
+// decodeUTF16 decodes UTF-16 code units; a trailing odd byte is dropped
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// windows1252 maps bytes 0x80-0x9F, where Windows-1252 differs from
+// Latin-1; unassigned bytes map to U+FFFD
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// decodeWindows1252 decodes single-byte Windows-1252 text
+func decodeWindows1252(data []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(data) + len(data)/4)
+	for _, b := range data {
+		switch {
+		case b >= 0x80 && b <= 0x9F:
+			sb.WriteRune(windows1252[b-0x80])
+		default:
+			sb.WriteRune(rune(b))
+		}
+	}
+	return sb.String()
+}
+
+// highByteShare returns the share of bytes outside ASCII
+func highByteShare(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	high := 0
+	for _, b := range data {
+		if b >= 0x80 {
+			high++
+		}
+	}
+	return float64(high) / float64(len(data))
+}
+
+// looksBinary reports whether text contains NUL, more than maxControlShare
+// control characters other than whitespace or more than
+// maxReplacementShare replacement characters
+func looksBinary(text string) bool {
+	total, control, replaced := 0, 0, 0
+	for _, r := range text {
+		total++
+		if r == 0 {
+			return true
+		}
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			control++
+		}
+		if r == utf8.RuneError {
+			replaced++
+		}
+	}
+	if total > 0 && float64(replaced) > maxReplacementShare*float64(total) {
+		return true
+	}
+	return total > 0 && float64(control) > maxControlShare*float64(total)
+}
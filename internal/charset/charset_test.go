@@ -0,0 +1,102 @@
+package charset
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(text string, order binary.AppendByteOrder, bom bool) []byte {
+	var out []byte
+	if bom {
+		out = order.AppendUint16(out, 0xFEFF)
+	}
+	for _, u := range utf16.Encode([]rune(text)) {
+		out = order.AppendUint16(out, u)
+	}
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	text := "Préférez les remboursements “rapides” — €5"
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		enc  string
+	}{
+		{"utf-8", []byte(text), text, UTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), text, UTF8},
+		{"utf-16le bom", encodeUTF16(text, binary.LittleEndian, true), text, UTF16LE},
+		{"utf-16be bom", encodeUTF16(text, binary.BigEndian, true), text, UTF16BE},
+		{"utf-16le no bom", encodeUTF16("plain ascii text", binary.LittleEndian, false), "plain ascii text", UTF16LE},
+		{"windows-1252", []byte("caf\xe9 \x93quoted\x94 \x80"), "café “quoted” €", Windows1252},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc, err := Decode(tt.data, FallbackWindows1252)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.want || enc != tt.enc {
+				t.Errorf("expected %q (%s), got %q (%s)", tt.want, tt.enc, got, enc)
+			}
+		})
+	}
+}
+
+func TestDecode_Fallbacks(t *testing.T) {
+	latin1 := []byte("caf\xe9")
+
+	if got, _, err := Decode(latin1, FallbackReplace); err != nil || got != "caf�" {
+		t.Errorf("replace: expected %q, got %q, %v", "caf�", got, err)
+	}
+	if _, _, err := Decode(latin1, FallbackReject); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("reject: expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestDecode_InvalidSequences(t *testing.T) {
+	// Mostly ASCII with Latin-1 accents is transcoded
+	got, enc, err := Decode([]byte("Cr\xe8me br\xfbl\xe9e costs \x80 5 at the caf\xe9."), FallbackWindows1252)
+	if err != nil || got != "Crème brûlée costs € 5 at the café." || enc != Windows1252 {
+		t.Errorf("expected transcoded Windows-1252, got %q (%s), %v", got, enc, err)
+	}
+
+	// Windows-1251 Cyrillic would decode to nonsense as Windows-1252
+	cyrillic := []byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2, 0x20, 0xec, 0xe8, 0xf0}
+	if _, _, err := Decode(cyrillic, FallbackWindows1252); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("cyrillic: expected ErrUnsupportedEncoding, got %v", err)
+	}
+
+	// Invalid UTF-8 kept with replacement characters is mostly undecodable
+	garbage := []byte("a\xff\xfe\xfd\xfc\xfb b\xc3\x28\xa0\xa1")
+	if _, _, err := Decode(garbage, FallbackReplace); !errors.Is(err, ErrBinary) {
+		t.Errorf("replace: expected ErrBinary, got %v", err)
+	}
+}
+
+func TestDecode_Binary(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"nul":     []byte("text\x00with nul"),
+		"control": []byte("\x01\x02\x03\x04\x05ab"),
+		"png":     {0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0, 0, 0, 0x0D, 'I', 'H', 'D', 'R'},
+	} {
+		if _, _, err := Decode(data, FallbackWindows1252); !errors.Is(err, ErrBinary) {
+			t.Errorf("%s: expected ErrBinary, got %v", name, err)
+		}
+	}
+}
+
+func TestParseFallback(t *testing.T) {
+	for _, name := range []string{"", "latin1", "Windows-1252", "replace", "reject"} {
+		if _, err := ParseFallback(name); err != nil {
+			t.Errorf("ParseFallback(%q): unexpected error %v", name, err)
+		}
+	}
+	if _, err := ParseFallback("ebcdic"); err == nil {
+		t.Error("expected error for unknown fallback")
+	}
+}
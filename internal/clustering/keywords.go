@@ -11,13 +11,74 @@ import (
 type KeywordExtractor struct {
 	stopWords map[string]bool
 	minLength int
+	nGrams    int
+	stem      bool
+	// detect adds the stop words of the languages detected in each set of
+	// texts; it is off when languages were chosen explicitly
+	detect bool
+}
+
+// KeywordOptions configures a KeywordExtractor
+type KeywordOptions struct {
+	// Languages selects the stop word lists to use (en, de, es, fr). When
+	// empty, English stop words are used together with those of the
+	// languages detected in each set of texts.
+	Languages []string
+	// ExtraStopWords are ignored in addition to the language stop words
+	ExtraStopWords []string
+	// MinLength is the minimum word length (default 3)
+	MinLength int
+	// NGrams is the longest phrase length: 1 for single words, 2 to also
+	// score adjacent word pairs (default 1)
+	NGrams int
+	// Stem reduces words to a common stem ("policies" and "policy" -> "polici")
+	Stem bool
+}
+
+// ExtractorOption configures a KeywordExtractor created by NewKeywordExtractor
+type ExtractorOption func(*KeywordOptions)
+
+// WithStopWords uses the stop word list of lang instead of detecting the
+// language. Repeat it to combine the lists of several languages.
+func WithStopWords(lang string) ExtractorOption {
+	return func(o *KeywordOptions) {
+		o.Languages = append(o.Languages, lang)
+	}
 }
 
 // NewKeywordExtractor creates a new keyword extractor
-func NewKeywordExtractor() *KeywordExtractor {
+func NewKeywordExtractor(opts ...ExtractorOption) *KeywordExtractor {
+	var options KeywordOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return NewKeywordExtractorWithOptions(options)
+}
+
+// NewKeywordExtractorWithOptions creates a keyword extractor with custom
+// stop words, n-grams and stemming
+func NewKeywordExtractorWithOptions(opts KeywordOptions) *KeywordExtractor {
+	if opts.MinLength <= 0 {
+		opts.MinLength = 3
+	}
+	if opts.NGrams <= 0 {
+		opts.NGrams = 1
+	}
+
+	stopWords := defaultStopWords()
+	if len(opts.Languages) > 0 {
+		stopWords = stopWordsFor(opts.Languages...)
+	}
+	for _, w := range opts.ExtraStopWords {
+		stopWords[strings.ToLower(strings.TrimSpace(w))] = true
+	}
+
 	return &KeywordExtractor{
-		stopWords: defaultStopWords(),
-		minLength: 3,
+		stopWords: stopWords,
+		minLength: opts.MinLength,
+		nGrams:    opts.NGrams,
+		stem:      opts.Stem,
+		detect:    len(opts.Languages) == 0,
 	}
 }
 
@@ -29,18 +90,29 @@ type Keyword struct {
 
 // ExtractKeywords extracts top-k keywords from texts using TF-IDF
 func (ke *KeywordExtractor) ExtractKeywords(texts []string, topK int) []Keyword {
+	return ke.ExtractKeywordsWeighted(texts, nil, topK)
+}
+
+// ExtractKeywordsWeighted extracts top-k keywords from texts using TF-IDF,
+// scaling each text's contribution by its weight. weights must be indexed
+// like texts; nil weighs every text equally, as ExtractKeywords does.
+func (ke *KeywordExtractor) ExtractKeywordsWeighted(texts []string, weights []float64, topK int) []Keyword {
 	if len(texts) == 0 {
 		return []Keyword{}
 	}
+	if weights != nil && len(weights) != len(texts) {
+		return nil
+	}
 
 	// Tokenize all documents
+	stopWords := ke.stopWordsFor(texts)
 	docs := make([][]string, len(texts))
 	for i, text := range texts {
-		docs[i] = ke.tokenize(text)
+		docs[i] = ke.tokenize(text, stopWords)
 	}
 
 	// Compute TF-IDF scores
-	tfidf := ke.computeTFIDF(docs)
+	tfidf := ke.computeTFIDF(docs, weights)
 
 	// Sort by score
 	keywords := make([]Keyword, 0, len(tfidf))
@@ -49,7 +121,10 @@ func (ke *KeywordExtractor) ExtractKeywords(texts []string, topK int) []Keyword
 	}
 
 	sort.Slice(keywords, func(i, j int) bool {
-		return keywords[i].Score > keywords[j].Score
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Word < keywords[j].Word
 	})
 
 	// Return top-k
@@ -60,28 +135,64 @@ func (ke *KeywordExtractor) ExtractKeywords(texts []string, topK int) []Keyword
 	return keywords
 }
 
-// ExtractClusterKeywords extracts keywords for each cluster
+// ExtractClusterKeywords extracts keywords for each cluster. Languages are
+// detected per cluster, so a mixed-language cluster filters the union of the
+// detected stop word lists.
 func (ke *KeywordExtractor) ExtractClusterKeywords(texts []string, labels []int, numClusters int, topK int) map[int][]Keyword {
-	if len(texts) != len(labels) {
+	return ke.ExtractClusterKeywordsWeighted(texts, labels, nil, numClusters, topK)
+}
+
+// ExtractClusterKeywordsWeighted extracts keywords for each cluster like
+// ExtractClusterKeywords, weighting each text's TF-IDF contribution, e.g. by
+// its proximity to the cluster centroid so the keywords follow the cluster
+// core rather than its edge members. weights must be indexed like texts; nil
+// weighs every text equally.
+func (ke *KeywordExtractor) ExtractClusterKeywordsWeighted(texts []string, labels []int, weights []float64, numClusters int, topK int) map[int][]Keyword {
+	if len(texts) != len(labels) || (weights != nil && len(weights) != len(texts)) {
 		return nil
 	}
 
 	// Group texts by cluster
 	clusterTexts := make(map[int][]string)
+	var clusterWeights map[int][]float64
+	if weights != nil {
+		clusterWeights = make(map[int][]float64)
+	}
 	for i, label := range labels {
 		clusterTexts[label] = append(clusterTexts[label], texts[i])
+		if weights != nil {
+			clusterWeights[label] = append(clusterWeights[label], weights[i])
+		}
 	}
 
 	// Extract keywords for each cluster
 	result := make(map[int][]Keyword)
 	for cluster, cTexts := range clusterTexts {
-		result[cluster] = ke.ExtractKeywords(cTexts, topK)
+		result[cluster] = ke.ExtractKeywordsWeighted(cTexts, clusterWeights[cluster], topK)
 	}
 
 	return result
 }
 
-func (ke *KeywordExtractor) tokenize(text string) []string {
+// stopWordsFor returns the extractor's stop words plus, when detecting, those
+// of the languages detected in texts
+func (ke *KeywordExtractor) stopWordsFor(texts []string) map[string]bool {
+	if !ke.detect {
+		return ke.stopWords
+	}
+	langs := DetectLanguages(texts)
+	if len(langs) == 0 || (len(langs) == 1 && langs[0] == LanguageEnglish) {
+		return ke.stopWords
+	}
+
+	stopWords := stopWordsFor(langs...)
+	for w := range ke.stopWords {
+		stopWords[w] = true
+	}
+	return stopWords
+}
+
+func (ke *KeywordExtractor) tokenize(text string, stopWords map[string]bool) []string {
 	// Convert to lowercase
 	text = strings.ToLower(text)
 
@@ -90,18 +201,35 @@ func (ke *KeywordExtractor) tokenize(text string) []string {
 		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
 	})
 
-	// Filter stop words and short words
+	// Filter stop words and short words. Filtered positions are kept as ""
+	// so phrases never span a removed word.
+	kept := make([]string, len(words))
 	result := make([]string, 0)
-	for _, word := range words {
-		if len(word) >= ke.minLength && !ke.stopWords[word] {
+	for i, word := range words {
+		if len(word) >= ke.minLength && !stopWords[word] {
+			if ke.stem {
+				word = stem(word)
+			}
+			kept[i] = word
 			result = append(result, word)
 		}
 	}
 
+	// Add adjacent word pairs as bigram terms
+	if ke.nGrams >= 2 {
+		for i := 0; i+1 < len(kept); i++ {
+			if kept[i] != "" && kept[i+1] != "" {
+				result = append(result, kept[i]+" "+kept[i+1])
+			}
+		}
+	}
+
 	return result
 }
 
-func (ke *KeywordExtractor) computeTFIDF(docs [][]string) map[string]float64 {
+// computeTFIDF averages the TF-IDF of each term over docs, weighted by
+// weights when given. Document frequencies are unweighted.
+func (ke *KeywordExtractor) computeTFIDF(docs [][]string, weights []float64) map[string]float64 {
 	n := len(docs)
 	if n == 0 {
 		return nil
@@ -121,7 +249,14 @@ func (ke *KeywordExtractor) computeTFIDF(docs [][]string) map[string]float64 {
 
 	// Compute TF-IDF for each term across all documents
 	tfidf := make(map[string]float64)
-	for _, doc := range docs {
+	totalWeight := 0.0
+	for i, doc := range docs {
+		weight := 1.0
+		if weights != nil {
+			weight = weights[i]
+		}
+		totalWeight += weight
+
 		// Term frequency in this document
 		tf := make(map[string]int)
 		for _, word := range doc {
@@ -139,38 +274,21 @@ func (ke *KeywordExtractor) computeTFIDF(docs [][]string) map[string]float64 {
 			termFreq := float64(count) / float64(docLen)
 			// IDF: log(N / df)
 			idf := math.Log(float64(n) / float64(df[word]))
-			tfidf[word] += termFreq * idf
+			tfidf[word] += weight * termFreq * idf
 		}
 	}
 
-	// Normalize by number of documents
+	// Normalize by the total weight (the number of documents when unweighted)
+	if totalWeight <= 0 {
+		return tfidf
+	}
 	for word := range tfidf {
-		tfidf[word] /= float64(n)
+		tfidf[word] /= totalWeight
 	}
 
 	return tfidf
 }
 
 func defaultStopWords() map[string]bool {
-	words := []string{
-		"a", "an", "and", "are", "as", "at", "be", "by", "for", "from",
-		"has", "have", "he", "in", "is", "it", "its", "of", "on", "or",
-		"she", "that", "the", "they", "this", "to", "was", "were", "will",
-		"with", "you", "your", "we", "our", "their", "them", "there", "these",
-		"those", "been", "being", "had", "having", "do", "does", "did", "doing",
-		"would", "could", "should", "may", "might", "must", "can", "cannot",
-		"about", "above", "after", "again", "against", "all", "am", "any",
-		"because", "before", "below", "between", "both", "but", "during",
-		"each", "few", "further", "here", "how", "if", "into", "just", "more",
-		"most", "no", "nor", "not", "now", "only", "other", "out", "own",
-		"same", "so", "some", "such", "than", "then", "through", "too", "under",
-		"until", "up", "very", "what", "when", "where", "which", "while", "who",
-		"whom", "why", "also", "however", "therefore", "thus", "hence", "yet",
-	}
-
-	result := make(map[string]bool)
-	for _, w := range words {
-		result[w] = true
-	}
-	return result
+	return stopWordsFor(LanguageEnglish)
 }
@@ -0,0 +1,137 @@
+package clustering
+
+import (
+	"reflect"
+	"testing"
+)
+
+func keywordSet(keywords []Keyword) map[string]bool {
+	words := make(map[string]bool, len(keywords))
+	for _, kw := range keywords {
+		words[kw.Word] = true
+	}
+	return words
+}
+
+var germanTexts = []string{
+	"Die Rückerstattung der Jahresabos erfolgt innerhalb von dreißig Tagen.",
+	"Der Kunde muss die Kündigung schriftlich einreichen und das Formular ausfüllen.",
+	"Das Support-Team beantwortet die Anfragen und die Beschwerden werktags.",
+}
+
+var spanishTexts = []string{
+	"Los reembolsos de los planes anuales se procesan en treinta días.",
+	"El cliente debe enviar la cancelación por escrito con una firma.",
+}
+
+func TestDetectLanguages(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{"english", []string{"The refund is issued within thirty days of the purchase and it is final."}, []string{LanguageEnglish}},
+		{"german", germanTexts, []string{LanguageGerman}},
+		{"none", []string{"Refunds processed quickly"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguages(tt.texts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	mixed := DetectLanguages(append(append([]string{}, germanTexts...), spanishTexts...))
+	found := map[string]bool{}
+	for _, lang := range mixed {
+		found[lang] = true
+	}
+	if !found[LanguageGerman] || !found[LanguageSpanish] {
+		t.Errorf("expected German and Spanish in mixed texts, got %v", mixed)
+	}
+}
+
+func TestExtractKeywords_DetectsStopWordLanguage(t *testing.T) {
+	keywords := keywordSet(NewKeywordExtractor().ExtractKeywords(germanTexts, 0))
+	for _, stop := range []string{"der", "die", "das", "und", "von"} {
+		if keywords[stop] {
+			t.Errorf("expected German stop word %q to be filtered, got %v", stop, keywords)
+		}
+	}
+	if !keywords["kunde"] || !keywords["rückerstattung"] {
+		t.Errorf("expected content words to remain, got %v", keywords)
+	}
+}
+
+func TestExtractClusterKeywords_UnionsMixedLanguages(t *testing.T) {
+	texts := []string{
+		"Die Rückerstattung der Jahresabos erfolgt innerhalb von dreißig Tagen.",
+		"Los reembolsos de los planes anuales se procesan en treinta días.",
+		"Der Kunde muss die Kündigung schriftlich einreichen.",
+		"El cliente debe enviar la cancelación por escrito.",
+	}
+	labels := []int{0, 0, 0, 0}
+
+	keywords := keywordSet(NewKeywordExtractor().ExtractClusterKeywords(texts, labels, 1, 0)[0])
+	for _, stop := range []string{"der", "die", "los", "por", "debe", "muss"} {
+		if keywords[stop] {
+			t.Errorf("expected stop word %q of a detected language to be filtered, got %v", stop, keywords)
+		}
+	}
+}
+
+func TestWithStopWords(t *testing.T) {
+	// An explicit language disables detection
+	english := keywordSet(NewKeywordExtractor(WithStopWords(LanguageEnglish)).ExtractKeywords(germanTexts, 0))
+	if !english["die"] || !english["und"] {
+		t.Errorf("expected German stop words to be kept with only English stop words, got %v", english)
+	}
+
+	german := keywordSet(NewKeywordExtractor(WithStopWords(LanguageGerman)).ExtractKeywords(germanTexts, 0))
+	if german["die"] || german["und"] {
+		t.Errorf("expected German stop words to be filtered, got %v", german)
+	}
+
+	both := NewKeywordExtractor(WithStopWords(LanguageGerman), WithStopWords(LanguageFrench))
+	if !both.stopWords["und"] || !both.stopWords["avec"] || both.stopWords["the"] {
+		t.Error("expected the union of the German and French lists only")
+	}
+}
+
+func TestExtractKeywordsWeighted(t *testing.T) {
+	ke := NewKeywordExtractor()
+	texts := []string{"Refunds", "Shipping"}
+
+	unweighted := ke.ExtractKeywords(texts, 0)
+	if got := ke.ExtractKeywordsWeighted(texts, nil, 0); !reflect.DeepEqual(got, unweighted) {
+		t.Errorf("expected nil weights to match ExtractKeywords, got %v want %v", got, unweighted)
+	}
+	if got := ke.ExtractKeywordsWeighted(texts, []float64{1, 1}, 0); !reflect.DeepEqual(got, unweighted) {
+		t.Errorf("expected equal weights to match ExtractKeywords, got %v want %v", got, unweighted)
+	}
+
+	weighted := ke.ExtractKeywordsWeighted(texts, []float64{0.2, 1}, 1)
+	if len(weighted) != 1 || weighted[0].Word != "shipping" {
+		t.Errorf("expected the heavier text to lead, got %v", weighted)
+	}
+
+	if got := ke.ExtractKeywordsWeighted(texts, []float64{1}, 0); got != nil {
+		t.Errorf("expected nil for mismatched weights, got %v", got)
+	}
+}
+
+func TestExtractClusterKeywordsWeighted(t *testing.T) {
+	ke := NewKeywordExtractor()
+	texts := []string{"Refunds", "Shipping", "Invoices"}
+	labels := []int{0, 0, 1}
+
+	result := ke.ExtractClusterKeywordsWeighted(texts, labels, []float64{1, 0.2, 1}, 2, 1)
+	if len(result[0]) != 1 || result[0][0].Word != "refunds" {
+		t.Errorf("expected the core member to lead cluster 0, got %v", result[0])
+	}
+	result = ke.ExtractClusterKeywordsWeighted(texts, labels, []float64{0.2, 1, 1}, 2, 1)
+	if len(result[0]) != 1 || result[0][0].Word != "shipping" {
+		t.Errorf("expected the core member to lead cluster 0, got %v", result[0])
+	}
+}
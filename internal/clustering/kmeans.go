@@ -48,12 +48,21 @@ func (km *KMeans) Fit(embeddings [][]float32) []int {
 		}
 	}
 
-	dim := len(data[0])
-
 	// Initialize centroids using k-means++ algorithm
 	km.Centroids = kMeansPlusPlusInit(data, k)
+	km.iterate(data)
+
+	return km.Labels
+}
+
+// iterate runs Lloyd's iterations from the current centroids until the
+// inertia converges or MaxIter is reached
+func (km *KMeans) iterate(data [][]float64) {
+	k := len(km.Centroids)
+	dim := len(data[0])
 
-	km.Labels = make([]int, n)
+	km.Labels = make([]int, len(data))
+	dists := make([]float64, len(data))
 	var prevInertia float64
 
 	for iter := 0; iter < km.MaxIter; iter++ {
@@ -70,15 +79,10 @@ func (km *KMeans) Fit(embeddings [][]float32) []int {
 				}
 			}
 			km.Labels[i] = minIdx
+			dists[i] = minDist
 			km.Inertia += minDist
 		}
 
-		// Check convergence
-		if iter > 0 && math.Abs(prevInertia-km.Inertia) < km.Tolerance {
-			break
-		}
-		prevInertia = km.Inertia
-
 		// Update centroids
 		counts := make([]int, k)
 		newCentroids := make([][]float64, k)
@@ -91,6 +95,14 @@ func (km *KMeans) Fit(embeddings [][]float32) []int {
 			floats.Add(newCentroids[label], data[i])
 		}
 
+		repaired := km.repairEmpty(data, dists, counts, newCentroids)
+
+		// Check convergence
+		if !repaired && iter > 0 && math.Abs(prevInertia-km.Inertia) < km.Tolerance {
+			break
+		}
+		prevInertia = km.Inertia
+
 		for i := range newCentroids {
 			if counts[i] > 0 {
 				floats.Scale(1.0/float64(counts[i]), newCentroids[i])
@@ -98,8 +110,41 @@ func (km *KMeans) Fit(embeddings [][]float32) []int {
 		}
 		km.Centroids = newCentroids
 	}
+}
 
-	return km.Labels
+// repairEmpty moves, for each cluster without points, the point farthest
+// from its assigned centroid into that cluster, taking it only from clusters
+// with other members. sums and counts are the per-cluster coordinate sums
+// and sizes, updated in place, and dists the squared distance of each point
+// to its centroid. It reports whether any cluster was repaired.
+func (km *KMeans) repairEmpty(data [][]float64, dists []float64, counts []int, sums [][]float64) bool {
+	repaired := false
+	for c := range counts {
+		if counts[c] > 0 {
+			continue
+		}
+
+		farthest := -1
+		for i, d := range dists {
+			if counts[km.Labels[i]] > 1 && d > 0 && (farthest < 0 || d > dists[farthest]) {
+				farthest = i
+			}
+		}
+		// Only duplicates of populated centroids are left to move
+		if farthest < 0 {
+			continue
+		}
+
+		from := km.Labels[farthest]
+		counts[from]--
+		floats.Sub(sums[from], data[farthest])
+		counts[c] = 1
+		copy(sums[c], data[farthest])
+		km.Labels[farthest] = c
+		dists[farthest] = 0
+		repaired = true
+	}
+	return repaired
 }
 
 // Predict assigns new points to the nearest cluster
@@ -225,6 +270,12 @@ func copySlice(s []float64) []float64 {
 
 // ElbowMethod helps find optimal k using the elbow method
 func ElbowMethod(embeddings [][]float32, maxK int) []float64 {
+	return elbowMethod(embeddings, maxK, NewKMeans)
+}
+
+// elbowMethod computes the inertias of ElbowMethod with clusterers created
+// by newKMeans
+func elbowMethod(embeddings [][]float32, maxK int, newKMeans func(k int) *KMeans) []float64 {
 	if maxK <= 0 {
 		maxK = 10
 	}
@@ -234,7 +285,7 @@ func ElbowMethod(embeddings [][]float32, maxK int) []float64 {
 
 	inertias := make([]float64, maxK)
 	for k := 1; k <= maxK; k++ {
-		km := NewKMeans(k)
+		km := newKMeans(k)
 		km.Fit(embeddings)
 		inertias[k-1] = km.Inertia
 	}
@@ -0,0 +1,67 @@
+package clustering
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKMeans_RepairsEmptyClusters(t *testing.T) {
+	data := [][]float64{{20}, {21}, {30}, {31}}
+
+	// The third centroid is closer to no point than the other two
+	km := NewKMeans(3)
+	km.Centroids = [][]float64{{20.5}, {30.5}, {100}}
+	km.iterate(data)
+
+	counts := make([]int, 3)
+	for _, label := range km.Labels {
+		counts[label]++
+	}
+	for c, n := range counts {
+		if n == 0 {
+			t.Errorf("cluster %d: expected members, got labels %v", c, km.Labels)
+		}
+	}
+	if km.Centroids[2][0] == 100 || km.Centroids[2][0] == 0 {
+		t.Errorf("expected the empty cluster's centroid to move, got %v", km.Centroids)
+	}
+}
+
+func TestKMeans_FitKeepsClustersPopulated(t *testing.T) {
+	// A dense blob of near duplicates next to a few outliers leaves k-means
+	// prone to centroids that lose all their points
+	var points [][]float32
+	for i := 0; i < 40; i++ {
+		points = append(points, []float32{50 + float32(i%4)*0.001, 50 + float32(i/4)*0.001})
+	}
+	points = append(points, []float32{55, 55}, []float32{55.1, 55}, []float32{45, 53}, []float32{59, 41})
+
+	for k := 2; k <= 12; k++ {
+		t.Run(fmt.Sprintf("k=%d", k), func(t *testing.T) {
+			km := NewKMeans(k)
+			labels := km.Fit(points)
+
+			counts := make([]int, k)
+			for _, label := range labels {
+				counts[label]++
+			}
+			for c, n := range counts {
+				if n == 0 {
+					t.Errorf("cluster %d is empty, sizes %v", c, counts)
+				}
+			}
+		})
+	}
+}
+
+func TestKMeans_RepairSkipsDuplicates(t *testing.T) {
+	// Only two distinct points: the third cluster cannot be populated
+	data := [][]float64{{0}, {0}, {1}}
+	km := NewKMeans(3)
+	km.Centroids = [][]float64{{0}, {1}, {50}}
+	km.iterate(data)
+
+	if km.Labels[0] != 0 || km.Labels[1] != 0 || km.Labels[2] != 1 {
+		t.Errorf("expected the duplicates to stay together, got %v", km.Labels)
+	}
+}
@@ -0,0 +1,139 @@
+package clustering
+
+import (
+	"math"
+)
+
+// MergeCloseClusters merges clusters whose centroids are closer than
+// minDistance (Euclidean) in the clustered space, so groups that overlap
+// visually share one label. Clusters are merged transitively, labels are
+// renumbered in order of first appearance, and centroids, sizes, keywords,
+// density and inertia are recomputed for the merged clusters. The result is
+// returned unchanged if minDistance <= 0 or no clusters are close.
+func (s *Service) MergeCloseClusters(coords [][]float64, texts []string, result *ClusterResult, minDistance float64) *ClusterResult {
+	if minDistance <= 0 || result == nil || len(result.Clusters) < 2 {
+		return result
+	}
+
+	k := len(result.Clusters)
+	parent := make([]int, k)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	merged := false
+	for i := 0; i < k; i++ {
+		for j := i + 1; j < k; j++ {
+			if result.Clusters[i].Size == 0 || result.Clusters[j].Size == 0 {
+				continue
+			}
+			if centroidDistance(result.Clusters[i].Centroid, result.Clusters[j].Centroid) < minDistance {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[rj] = ri
+					merged = true
+				}
+			}
+		}
+	}
+	if !merged {
+		return result
+	}
+
+	// Renumber merged groups in order of first appearance
+	newID := make(map[int]int)
+	labels := make([]int, len(result.Labels))
+	for i, label := range result.Labels {
+		root := find(label)
+		id, ok := newID[root]
+		if !ok {
+			id = len(newID)
+			newID[root] = id
+		}
+		labels[i] = id
+	}
+	newK := len(newID)
+
+	points := make([][]float32, len(coords))
+	for i, coord := range coords {
+		points[i] = make([]float32, len(coord))
+		for j, v := range coord {
+			points[i][j] = float32(v)
+		}
+	}
+
+	// Recompute centroids as member means
+	dims := 0
+	if len(points) > 0 {
+		dims = len(points[0])
+	}
+	centroids := make([][]float32, newK)
+	sizes := make([]int, newK)
+	sums := make([][]float64, newK)
+	for c := range sums {
+		sums[c] = make([]float64, dims)
+	}
+	for i, label := range labels {
+		sizes[label]++
+		for j, v := range points[i] {
+			sums[label][j] += float64(v)
+		}
+	}
+	for c := range centroids {
+		centroids[c] = make([]float32, dims)
+		if sizes[c] == 0 {
+			continue
+		}
+		for j := range sums[c] {
+			centroids[c][j] = float32(sums[c][j] / float64(sizes[c]))
+		}
+	}
+
+	inertia := 0.0
+	for i, label := range labels {
+		for j, v := range points[i] {
+			diff := float64(v - centroids[label][j])
+			inertia += diff * diff
+		}
+	}
+
+	clusterKeywords := s.keywordExtractor.ExtractClusterKeywords(texts, labels, newK, s.keywordsPerCluster)
+
+	clusters := make([]Cluster, newK)
+	for c := 0; c < newK; c++ {
+		clusters[c] = Cluster{
+			ID:             c,
+			Centroid:       centroids[c],
+			Size:           sizes[c],
+			Keywords:       clusterKeywords[c],
+			Density:        s.computeDensity(points, labels, c, centroids[c]),
+			Representative: representative(points, labels, c, centroids[c]),
+		}
+	}
+
+	return &ClusterResult{
+		Clusters: clusters,
+		Labels:   labels,
+		K:        newK,
+		Inertia:  inertia,
+	}
+}
+
+// centroidDistance returns the Euclidean distance between two centroids
+func centroidDistance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	sum := 0.0
+	for i := range a {
+		diff := float64(a[i] - b[i])
+		sum += diff * diff
+	}
+	return math.Sqrt(sum)
+}
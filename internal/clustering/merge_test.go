@@ -0,0 +1,61 @@
+package clustering
+
+import (
+	"testing"
+)
+
+func TestMergeCloseClusters(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	// Two near-identical groups around (0.5, 0.5) and one distinct group
+	coords := [][]float64{
+		{0.50, 0.50}, {0.52, 0.50},
+		{0.51, 0.52}, {0.53, 0.51},
+		{-0.8, -0.8}, {-0.82, -0.79},
+	}
+	texts := []string{
+		"refund policy applies", "refund requests handled",
+		"refund window policy", "refund approval process",
+		"server uptime monitoring", "uptime alerts configured",
+	}
+	input := &ClusterResult{
+		Clusters: []Cluster{
+			{ID: 0, Centroid: []float32{0.51, 0.50}, Size: 2},
+			{ID: 1, Centroid: []float32{0.52, 0.515}, Size: 2},
+			{ID: 2, Centroid: []float32{-0.81, -0.795}, Size: 2},
+		},
+		Labels: []int{0, 0, 1, 1, 2, 2},
+		K:      3,
+	}
+
+	result := svc.MergeCloseClusters(coords, texts, input, 0.1)
+
+	if result.K != 2 || len(result.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters after merge, got K=%d with %d clusters", result.K, len(result.Clusters))
+	}
+
+	want := []int{0, 0, 0, 0, 1, 1}
+	for i, label := range result.Labels {
+		if label != want[i] {
+			t.Fatalf("expected labels %v, got %v", want, result.Labels)
+		}
+	}
+
+	if result.Clusters[0].Size != 4 || result.Clusters[1].Size != 2 {
+		t.Errorf("expected sizes 4 and 2, got %d and %d", result.Clusters[0].Size, result.Clusters[1].Size)
+	}
+
+	// Keywords are recomputed over the members of both original clusters
+	words := make(map[string]bool)
+	for _, kw := range result.Clusters[0].Keywords {
+		words[kw.Word] = true
+	}
+	if !words["handled"] || !(words["window"] || words["approval"]) {
+		t.Errorf("expected merged keywords from both groups, got %v", result.Clusters[0].Keywords)
+	}
+
+	// A distance below every centroid gap leaves the clusters alone
+	if same := svc.MergeCloseClusters(coords, texts, input, 0.001); same.K != 3 {
+		t.Errorf("expected no merge with small distance, got K=%d", same.K)
+	}
+}
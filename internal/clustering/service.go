@@ -1,6 +1,9 @@
 package clustering
 
 import (
+	"encoding/binary"
+	"math"
+
 	"github.com/todmy/doc-analyzer/pkg/models"
 )
 
@@ -9,19 +12,37 @@ type Service struct {
 	keywordExtractor *KeywordExtractor
 	defaultK         int
 	keywordsPerCluster int
+	minClusterDistance float64
+	minPointsPerCluster int
+	maxIter int
+	tolerance float64
 }
 
 // Config holds clustering service configuration
 type Config struct {
 	DefaultK           int
 	KeywordsPerCluster int
+	// MinClusterDistance merges coordinate clusters whose centroids are
+	// closer than this in the projected space (0 disables merging)
+	MinClusterDistance float64
+	// MinPointsPerCluster bounds the k chosen automatically for coordinate
+	// clustering to one cluster per this many points
+	MinPointsPerCluster int
+	// MaxIter caps the Lloyd iterations of each k-means fit
+	MaxIter int
+	// Tolerance stops a k-means fit once the inertia changes by less than
+	// this between iterations
+	Tolerance float64
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
 	return Config{
-		DefaultK:           5,
-		KeywordsPerCluster: 5,
+		DefaultK:            5,
+		KeywordsPerCluster:  5,
+		MinPointsPerCluster: 2,
+		MaxIter:             100,
+		Tolerance:           1e-4,
 	}
 }
 
@@ -33,20 +54,61 @@ func NewService(config Config) *Service {
 	if config.KeywordsPerCluster <= 0 {
 		config.KeywordsPerCluster = DefaultConfig().KeywordsPerCluster
 	}
+	if config.MinPointsPerCluster <= 0 {
+		config.MinPointsPerCluster = DefaultConfig().MinPointsPerCluster
+	}
+	if config.MaxIter <= 0 {
+		config.MaxIter = DefaultConfig().MaxIter
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = DefaultConfig().Tolerance
+	}
 
 	return &Service{
-		keywordExtractor:   NewKeywordExtractor(),
-		defaultK:           config.DefaultK,
-		keywordsPerCluster: config.KeywordsPerCluster,
+		keywordExtractor:    NewKeywordExtractor(),
+		defaultK:            config.DefaultK,
+		keywordsPerCluster:  config.KeywordsPerCluster,
+		minClusterDistance:  config.MinClusterDistance,
+		minPointsPerCluster: config.MinPointsPerCluster,
+		maxIter:             config.MaxIter,
+		tolerance:           config.Tolerance,
 	}
 }
 
+// elbowMethod computes the inertias of ElbowMethod with the service's
+// iteration limit and tolerance
+func (s *Service) elbowMethod(embeddings [][]float32, maxK int) []float64 {
+	return elbowMethod(embeddings, maxK, func(k int) *KMeans {
+		return s.newKMeans(k, 0)
+	})
+}
+
+// newKMeans creates a k-means clusterer with the service's iteration limit
+// and tolerance. maxIter > 0 overrides the limit.
+func (s *Service) newKMeans(k, maxIter int) *KMeans {
+	km := NewKMeans(k)
+	km.MaxIter = s.maxIter
+	if maxIter > 0 {
+		km.MaxIter = maxIter
+	}
+	km.Tolerance = s.tolerance
+	return km
+}
+
+// DefaultK returns the k used when a caller does not specify one
+func (s *Service) DefaultK() int {
+	return s.defaultK
+}
+
 // ClusterResult represents the result of clustering
 type ClusterResult struct {
 	Clusters []Cluster
 	Labels   []int
 	K        int
-	Inertia  float64
+	// RequestedK is the k asked for before clamping; K is lower when there
+	// are fewer unique embeddings than requested clusters
+	RequestedK int
+	Inertia    float64
 }
 
 // Cluster represents a single cluster with its metadata
@@ -56,10 +118,19 @@ type Cluster struct {
 	Size      int
 	Keywords  []Keyword
 	Density   float64
+	// Representative is the index of the member closest to the centroid
+	// in the clustered input, -1 for an empty cluster
+	Representative int
 }
 
 // ClusterStatements clusters statements and returns detailed results
 func (s *Service) ClusterStatements(statements []models.Statement, k int) *ClusterResult {
+	return s.ClusterStatementsWithMaxIter(statements, k, 0)
+}
+
+// ClusterStatementsWithMaxIter is ClusterStatements with at most maxIter
+// k-means iterations; maxIter <= 0 uses the configured limit
+func (s *Service) ClusterStatementsWithMaxIter(statements []models.Statement, k, maxIter int) *ClusterResult {
 	if len(statements) == 0 {
 		return &ClusterResult{}
 	}
@@ -67,9 +138,7 @@ func (s *Service) ClusterStatements(statements []models.Statement, k int) *Clust
 	if k <= 0 {
 		k = s.defaultK
 	}
-	if k > len(statements) {
-		k = len(statements)
-	}
+	requestedK := k
 
 	// Extract embeddings
 	embeddings := make([][]float32, len(statements))
@@ -79,12 +148,21 @@ func (s *Service) ClusterStatements(statements []models.Statement, k int) *Clust
 		texts[i] = stmt.Text
 	}
 
+	// Duplicate embeddings can only ever share a cluster, so asking for more
+	// clusters than distinct points would leave some of them empty
+	if unique := CountUnique(embeddings); k > unique {
+		k = unique
+	}
+
 	// Run K-means
-	km := NewKMeans(k)
+	km := s.newKMeans(k, maxIter)
 	labels := km.Fit(embeddings)
 
-	// Extract keywords for each cluster
-	clusterKeywords := s.keywordExtractor.ExtractClusterKeywords(texts, labels, k, s.keywordsPerCluster)
+	centroids := km.GetCentroids()
+
+	// Extract keywords for each cluster, favoring statements near its centroid
+	weights := centroidWeights(embeddings, labels, centroids)
+	clusterKeywords := s.keywordExtractor.ExtractClusterKeywordsWeighted(texts, labels, weights, k, s.keywordsPerCluster)
 
 	// Build cluster metadata
 	clusters := make([]Cluster, k)
@@ -93,49 +171,172 @@ func (s *Service) ClusterStatements(statements []models.Statement, k int) *Clust
 		clusterSizes[label]++
 	}
 
-	centroids := km.GetCentroids()
 	for i := 0; i < k; i++ {
 		clusters[i] = Cluster{
-			ID:       i,
-			Centroid: centroids[i],
-			Size:     clusterSizes[i],
-			Keywords: clusterKeywords[i],
-			Density:  s.computeDensity(embeddings, labels, i, centroids[i]),
+			ID:             i,
+			Centroid:       centroids[i],
+			Size:           clusterSizes[i],
+			Keywords:       clusterKeywords[i],
+			Density:        s.computeDensity(embeddings, labels, i, centroids[i]),
+			Representative: representative(embeddings, labels, i, centroids[i]),
 		}
 	}
 
 	return &ClusterResult{
-		Clusters: clusters,
-		Labels:   labels,
-		K:        k,
-		Inertia:  km.Inertia,
+		Clusters:   clusters,
+		Labels:     labels,
+		K:          k,
+		RequestedK: requestedK,
+		Inertia:    km.Inertia,
 	}
 }
 
+// AssignToExistingClusters assigns statements to the nearest of centroids
+// from an earlier fit, without re-running k-means. Sizes, keywords, density
+// and inertia are computed for the new assignment; the centroids are kept as
+// given. It returns nil when centroids is empty or their dimension differs
+// from the embeddings', in which case a full fit is needed.
+func (s *Service) AssignToExistingClusters(statements []models.Statement, centroids [][]float32) *ClusterResult {
+	if len(statements) == 0 {
+		return &ClusterResult{}
+	}
+	k := len(centroids)
+	if k == 0 {
+		return nil
+	}
+
+	embeddings := make([][]float32, len(statements))
+	texts := make([]string, len(statements))
+	for i, stmt := range statements {
+		if len(stmt.Embedding) != len(centroids[0]) {
+			return nil
+		}
+		embeddings[i] = stmt.Embedding
+		texts[i] = stmt.Text
+	}
+
+	km := s.newKMeans(k, 0)
+	km.Centroids = make([][]float64, k)
+	for i, c := range centroids {
+		if len(c) != len(centroids[0]) {
+			return nil
+		}
+		km.Centroids[i] = make([]float64, len(c))
+		for j, v := range c {
+			km.Centroids[i][j] = float64(v)
+		}
+	}
+	labels := km.Predict(embeddings)
+
+	inertia := 0.0
+	clusterSizes := make([]int, k)
+	for i, label := range labels {
+		clusterSizes[label]++
+		for j, v := range embeddings[i] {
+			diff := float64(v) - km.Centroids[label][j]
+			inertia += diff * diff
+		}
+	}
+
+	weights := centroidWeights(embeddings, labels, centroids)
+	clusterKeywords := s.keywordExtractor.ExtractClusterKeywordsWeighted(texts, labels, weights, k, s.keywordsPerCluster)
+
+	clusters := make([]Cluster, k)
+	for i := 0; i < k; i++ {
+		clusters[i] = Cluster{
+			ID:             i,
+			Centroid:       centroids[i],
+			Size:           clusterSizes[i],
+			Keywords:       clusterKeywords[i],
+			Density:        s.computeDensity(embeddings, labels, i, centroids[i]),
+			Representative: representative(embeddings, labels, i, centroids[i]),
+		}
+	}
+
+	return &ClusterResult{
+		Clusters:   clusters,
+		Labels:     labels,
+		K:          k,
+		RequestedK: k,
+		Inertia:    inertia,
+	}
+}
+
+// RecomputeKeywords returns a copy of result with each cluster's keywords
+// re-extracted from texts by extractor. Labels, centroids, sizes and density
+// are kept, so keyword settings can change without re-running k-means. texts
+// must be indexed like result.Labels. topK <= 0 uses the service default.
+func (s *Service) RecomputeKeywords(result *ClusterResult, texts []string, extractor *KeywordExtractor, topK int) *ClusterResult {
+	if len(texts) != len(result.Labels) {
+		return nil
+	}
+	if topK <= 0 {
+		topK = s.keywordsPerCluster
+	}
+
+	clusterKeywords := extractor.ExtractClusterKeywords(texts, result.Labels, len(result.Clusters), topK)
+
+	updated := *result
+	updated.Clusters = make([]Cluster, len(result.Clusters))
+	for i, c := range result.Clusters {
+		c.Keywords = clusterKeywords[c.ID]
+		if c.Keywords == nil {
+			c.Keywords = []Keyword{}
+		}
+		updated.Clusters[i] = c
+	}
+
+	return &updated
+}
+
+// CountUnique returns the number of distinct vectors in embeddings
+func CountUnique(embeddings [][]float32) int {
+	seen := make(map[string]struct{}, len(embeddings))
+	var buf []byte
+	for _, emb := range embeddings {
+		buf = buf[:0]
+		for _, v := range emb {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+		seen[string(buf)] = struct{}{}
+	}
+	return len(seen)
+}
+
 // AutoCluster determines optimal k using elbow method
 func (s *Service) AutoCluster(statements []models.Statement, maxK int) *ClusterResult {
 	if len(statements) == 0 {
 		return &ClusterResult{}
 	}
 
+	// Find optimal k using elbow method
+	_, optimalK := s.ElbowCurve(statements, maxK)
+
+	return s.ClusterStatements(statements, optimalK)
+}
+
+// ElbowCurve returns the k-means inertia of statements for k = 1..maxK and
+// the elbow of that curve, the k AutoCluster picks. maxK <= 0 uses 10, and
+// maxK is clamped to the number of distinct embeddings. inertias is indexed
+// by k-1.
+func (s *Service) ElbowCurve(statements []models.Statement, maxK int) (inertias []float64, elbowK int) {
+	if len(statements) == 0 {
+		return []float64{}, 0
+	}
 	if maxK <= 0 {
 		maxK = 10
 	}
-	if maxK > len(statements) {
-		maxK = len(statements)
-	}
 
-	// Extract embeddings
 	embeddings := make([][]float32, len(statements))
 	for i, stmt := range statements {
 		embeddings[i] = stmt.Embedding
 	}
+	if unique := CountUnique(embeddings); maxK > unique {
+		maxK = unique
+	}
 
-	// Find optimal k using elbow method
-	inertias := ElbowMethod(embeddings, maxK)
-	optimalK := findElbow(inertias)
-
-	return s.ClusterStatements(statements, optimalK)
+	inertias = s.elbowMethod(embeddings, maxK)
+	return inertias, findElbow(inertias)
 }
 
 // ClusterCoordinates clusters points using their 2D/3D coordinates
@@ -147,25 +348,24 @@ func (s *Service) ClusterCoordinates(coords [][]float64, texts []string, k int)
 	if k <= 0 {
 		k = s.defaultK
 	}
-	if k > len(coords) {
-		k = len(coords)
-	}
+	requestedK := k
 
-	// Convert float64 coords to float32 for K-means
-	embeddings := make([][]float32, len(coords))
-	for i, coord := range coords {
-		embeddings[i] = make([]float32, len(coord))
-		for j, v := range coord {
-			embeddings[i][j] = float32(v)
-		}
+	embeddings := coordinatesToFloat32(coords)
+
+	// Points that coincide in the projection can only share a cluster
+	if unique := CountUnique(embeddings); k > unique {
+		k = unique
 	}
 
 	// Run K-means
-	km := NewKMeans(k)
+	km := s.newKMeans(k, 0)
 	labels := km.Fit(embeddings)
 
-	// Extract keywords for each cluster
-	clusterKeywords := s.keywordExtractor.ExtractClusterKeywords(texts, labels, k, s.keywordsPerCluster)
+	centroids := km.GetCentroids()
+
+	// Extract keywords for each cluster, favoring statements near its centroid
+	weights := centroidWeights(embeddings, labels, centroids)
+	clusterKeywords := s.keywordExtractor.ExtractClusterKeywordsWeighted(texts, labels, weights, k, s.keywordsPerCluster)
 
 	// Build cluster metadata
 	clusters := make([]Cluster, k)
@@ -174,26 +374,33 @@ func (s *Service) ClusterCoordinates(coords [][]float64, texts []string, k int)
 		clusterSizes[label]++
 	}
 
-	centroids := km.GetCentroids()
 	for i := 0; i < k; i++ {
 		clusters[i] = Cluster{
-			ID:       i,
-			Centroid: centroids[i],
-			Size:     clusterSizes[i],
-			Keywords: clusterKeywords[i],
-			Density:  s.computeDensity(embeddings, labels, i, centroids[i]),
+			ID:             i,
+			Centroid:       centroids[i],
+			Size:           clusterSizes[i],
+			Keywords:       clusterKeywords[i],
+			Density:        s.computeDensity(embeddings, labels, i, centroids[i]),
+			Representative: representative(embeddings, labels, i, centroids[i]),
 		}
 	}
 
-	return &ClusterResult{
-		Clusters: clusters,
-		Labels:   labels,
-		K:        k,
-		Inertia:  km.Inertia,
+	result := &ClusterResult{
+		Clusters:   clusters,
+		Labels:     labels,
+		K:          k,
+		RequestedK: requestedK,
+		Inertia:    km.Inertia,
 	}
+
+	// Merge clusters that overlap in the projection
+	return s.MergeCloseClusters(coords, texts, result, s.minClusterDistance)
 }
 
-// AutoClusterCoordinates determines optimal k using elbow method on coordinate space
+// AutoClusterCoordinates determines optimal k using elbow method on coordinate space.
+// The k tried is at most the number of distinct points and one cluster per
+// MinPointsPerCluster points; points that all coincide, e.g. after a
+// degenerate projection, form a single cluster.
 func (s *Service) AutoClusterCoordinates(coords [][]float64, texts []string, maxK int) *ClusterResult {
 	if len(coords) == 0 {
 		return &ClusterResult{}
@@ -202,11 +409,29 @@ func (s *Service) AutoClusterCoordinates(coords [][]float64, texts []string, max
 	if maxK <= 0 {
 		maxK = 10
 	}
-	if maxK > len(coords) {
-		maxK = len(coords)
+
+	embeddings := coordinatesToFloat32(coords)
+
+	unique := CountUnique(embeddings)
+	if maxK > unique {
+		maxK = unique
+	}
+	if limit := len(coords) / s.minPointsPerCluster; maxK > limit {
+		maxK = limit
 	}
+	if maxK <= 1 {
+		return s.ClusterCoordinates(coords, texts, 1)
+	}
+
+	// Find optimal k using elbow method
+	inertias := s.elbowMethod(embeddings, maxK)
+	optimalK := findElbow(inertias)
+
+	return s.ClusterCoordinates(coords, texts, optimalK)
+}
 
-	// Convert float64 coords to float32 for elbow method
+// coordinatesToFloat32 converts projected coordinates for K-means
+func coordinatesToFloat32(coords [][]float64) [][]float32 {
 	embeddings := make([][]float32, len(coords))
 	for i, coord := range coords {
 		embeddings[i] = make([]float32, len(coord))
@@ -214,12 +439,48 @@ func (s *Service) AutoClusterCoordinates(coords [][]float64, texts []string, max
 			embeddings[i][j] = float32(v)
 		}
 	}
+	return embeddings
+}
 
-	// Find optimal k using elbow method
-	inertias := ElbowMethod(embeddings, maxK)
-	optimalK := findElbow(inertias)
+// centroidWeights returns the weight of each point's keyword contribution:
+// 1/(1 + d/m), where d is the point's distance to its cluster centroid and m
+// the mean distance in that cluster. A point at the centroid weighs 1, one
+// at the mean distance 0.5.
+func centroidWeights(points [][]float32, labels []int, centroids [][]float32) []float64 {
+	dists := make([]float64, len(points))
+	sums := make([]float64, len(centroids))
+	counts := make([]int, len(centroids))
+	for i, label := range labels {
+		dists[i] = euclideanDistance32(points[i], centroids[label])
+		sums[label] += dists[i]
+		counts[label]++
+	}
 
-	return s.ClusterCoordinates(coords, texts, optimalK)
+	weights := make([]float64, len(points))
+	for i, label := range labels {
+		mean := sums[label] / float64(counts[label])
+		if mean == 0 {
+			weights[i] = 1
+			continue
+		}
+		weights[i] = 1 / (1 + dists[i]/mean)
+	}
+	return weights
+}
+
+// representative returns the index of the member of cluster clusterID
+// closest to its centroid, or -1 if the cluster has no members
+func representative(points [][]float32, labels []int, clusterID int, centroid []float32) int {
+	best, bestDist := -1, math.Inf(1)
+	for i, label := range labels {
+		if label != clusterID {
+			continue
+		}
+		if d := euclideanDistance32(points[i], centroid); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
 }
 
 // computeDensity calculates the average distance of cluster members to centroid
@@ -283,8 +544,8 @@ func findElbow(inertias []float64) int {
 		nx2, ny2 := 1.0, 0.0
 
 		// Distance from point to line
-		num := abs((ny2-ny1)*x0 - (nx2-nx1)*y0 + nx2*ny1 - ny2*nx1)
-		den := sqrt((ny2-ny1)*(ny2-ny1) + (nx2-nx1)*(nx2-nx1))
+		num := math.Abs((ny2-ny1)*x0 - (nx2-nx1)*y0 + nx2*ny1 - ny2*nx1)
+		den := math.Sqrt((ny2-ny1)*(ny2-ny1) + (nx2-nx1)*(nx2-nx1))
 
 		dist := num / den
 		if dist > maxDist {
@@ -295,22 +556,3 @@ func findElbow(inertias []float64) int {
 
 	return elbow
 }
-
-func abs(x float64) float64 {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
-
-func sqrt(x float64) float64 {
-	if x <= 0 {
-		return 0
-	}
-	// Newton's method
-	z := x
-	for i := 0; i < 10; i++ {
-		z -= (z*z - x) / (2 * z)
-	}
-	return z
-}
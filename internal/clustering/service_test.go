@@ -0,0 +1,335 @@
+package clustering
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/todmy/doc-analyzer/pkg/models"
+)
+
+func TestClusterStatements_ClampsKToUniqueEmbeddings(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	// 100 statements sharing only 5 distinct embeddings
+	unique := [][]float32{
+		{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 0}, {0, 1, 1},
+	}
+	statements := make([]models.Statement, 100)
+	for i := range statements {
+		statements[i] = models.Statement{
+			Text:      fmt.Sprintf("statement %d", i),
+			Embedding: unique[i%len(unique)],
+		}
+	}
+
+	result := svc.ClusterStatements(statements, 20)
+	if result.K != len(unique) {
+		t.Errorf("expected effective k %d, got %d", len(unique), result.K)
+	}
+	if result.RequestedK != 20 {
+		t.Errorf("expected requested k 20, got %d", result.RequestedK)
+	}
+	if len(result.Clusters) != len(unique) {
+		t.Fatalf("expected %d clusters, got %d", len(unique), len(result.Clusters))
+	}
+	for _, c := range result.Clusters {
+		if c.Size == 0 {
+			t.Errorf("cluster %d is empty", c.ID)
+		}
+	}
+
+	if auto := svc.AutoCluster(statements, 20); auto.K > len(unique) {
+		t.Errorf("expected auto k at most %d, got %d", len(unique), auto.K)
+	}
+}
+
+func TestElbowCurve(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	// Three tight groups of four along a line
+	var statements []models.Statement
+	for _, center := range []float32{0, 10, 20} {
+		for i := 0; i < 4; i++ {
+			statements = append(statements, models.Statement{
+				Embedding: []float32{center + float32(i)*0.1, 1},
+			})
+		}
+	}
+
+	inertias, elbowK := svc.ElbowCurve(statements, 6)
+	if len(inertias) != 6 {
+		t.Fatalf("expected 6 inertias, got %d", len(inertias))
+	}
+	if elbowK != 3 {
+		t.Errorf("expected elbow at k 3, got %d (inertias %v)", elbowK, inertias)
+	}
+
+	// maxK is clamped to the distinct embeddings
+	if inertias, _ := svc.ElbowCurve(statements[:2], 6); len(inertias) != 2 {
+		t.Errorf("expected 2 inertias for 2 points, got %d", len(inertias))
+	}
+	if inertias, elbowK := svc.ElbowCurve(nil, 6); len(inertias) != 0 || elbowK != 0 {
+		t.Errorf("expected an empty curve for no statements, got %v and %d", inertias, elbowK)
+	}
+}
+
+func TestNewService_KMeansLimits(t *testing.T) {
+	km := NewService(DefaultConfig()).newKMeans(3, 0)
+	if km.MaxIter != 100 || km.Tolerance != 1e-4 {
+		t.Errorf("expected default limits 100 and 1e-4, got %d and %g", km.MaxIter, km.Tolerance)
+	}
+
+	svc := NewService(Config{MaxIter: 7, Tolerance: 0.5})
+	km = svc.newKMeans(3, 0)
+	if km.K != 3 || km.MaxIter != 7 || km.Tolerance != 0.5 {
+		t.Errorf("expected k 3 with limits 7 and 0.5, got %d with %d and %g", km.K, km.MaxIter, km.Tolerance)
+	}
+	if km = svc.newKMeans(3, 2); km.MaxIter != 2 {
+		t.Errorf("expected the per-call limit 2, got %d", km.MaxIter)
+	}
+}
+
+func TestElbowCurve_UsesKMeansLimits(t *testing.T) {
+	var statements []models.Statement
+	embeddings := make([][]float32, 30)
+	for i := range embeddings {
+		embeddings[i] = []float32{float32(i % 10), float32(i / 10)}
+		statements = append(statements, models.Statement{Embedding: embeddings[i]})
+	}
+
+	svc := NewService(Config{MaxIter: 1, Tolerance: 0.5})
+	got, _ := svc.ElbowCurve(statements, 5)
+	want := elbowMethod(embeddings, 5, func(k int) *KMeans {
+		km := NewKMeans(k)
+		km.MaxIter = 1
+		km.Tolerance = 0.5
+		return km
+	})
+	if len(got) != len(want) {
+		t.Fatalf("expected %d inertias, got %d", len(want), len(got))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("k=%d: expected inertia %g with the configured limits, got %g", i+1, want[i], got[i])
+		}
+	}
+}
+
+func TestClusterStatementsWithMaxIter(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	var statements []models.Statement
+	for i := 0; i < 30; i++ {
+		statements = append(statements, models.Statement{
+			Text:      fmt.Sprintf("statement %d", i),
+			Embedding: []float32{float32(i % 10), float32(i / 10)},
+		})
+	}
+
+	// One iteration only assigns points to the initial centroids, which
+	// further iterations can only improve on
+	quick := svc.ClusterStatementsWithMaxIter(statements, 4, 1)
+	full := svc.ClusterStatements(statements, 4)
+	if quick.K != 4 || len(quick.Labels) != len(statements) {
+		t.Fatalf("expected k 4 with %d labels, got k %d with %d", len(statements), quick.K, len(quick.Labels))
+	}
+	if full.Inertia > quick.Inertia+1e-9 {
+		t.Errorf("expected the full fit's inertia %g to be at most the single iteration's %g", full.Inertia, quick.Inertia)
+	}
+}
+
+func TestAutoClusterCoordinates_IdenticalPoints(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	// Every point collapsed onto the same spot by the projection
+	coords := make([][]float64, 12)
+	texts := make([]string, len(coords))
+	for i := range coords {
+		coords[i] = []float64{0.5, -0.5}
+		texts[i] = fmt.Sprintf("statement %d", i)
+	}
+
+	result := svc.AutoClusterCoordinates(coords, texts, 10)
+	if result.K != 1 || len(result.Clusters) != 1 {
+		t.Fatalf("expected a single cluster, got k %d with %d clusters", result.K, len(result.Clusters))
+	}
+	if result.Clusters[0].Size != len(coords) {
+		t.Errorf("expected all %d points in the cluster, got %d", len(coords), result.Clusters[0].Size)
+	}
+	for i, label := range result.Labels {
+		if label != 0 {
+			t.Errorf("point %d: expected label 0, got %d", i, label)
+		}
+	}
+}
+
+func TestAutoClusterCoordinates_ClampsKToPoints(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	coords := [][]float64{{-1, -1}, {1, 1}, {1, -1}}
+	texts := []string{"a", "b", "c"}
+
+	// Three points allow at most one cluster per two points
+	result := svc.AutoClusterCoordinates(coords, texts, 10)
+	if result.K != 1 {
+		t.Errorf("expected k 1 for 3 points, got %d", result.K)
+	}
+	if len(result.Labels) != len(coords) {
+		t.Errorf("expected %d labels, got %d", len(coords), len(result.Labels))
+	}
+}
+
+func TestCountUnique(t *testing.T) {
+	if got := CountUnique(nil); got != 0 {
+		t.Errorf("expected 0 for no embeddings, got %d", got)
+	}
+	got := CountUnique([][]float32{{1, 2}, {1, 2}, {2, 1}, {1, 2, 0}})
+	if got != 3 {
+		t.Errorf("expected 3 unique embeddings, got %d", got)
+	}
+}
+
+func TestRecomputeKeywords_KeepsLabels(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	statements := []models.Statement{
+		{Text: "refund requests for annual plans", Embedding: []float32{1, 0}},
+		{Text: "refund requests for monthly plans", Embedding: []float32{0.9, 0.1}},
+		{Text: "refund timing for hardware", Embedding: []float32{0.95, 0.05}},
+		{Text: "support tickets answered quickly", Embedding: []float32{0, 1}},
+		{Text: "support tickets escalated overnight", Embedding: []float32{0.1, 0.9}},
+	}
+	texts := make([]string, len(statements))
+	for i, stmt := range statements {
+		texts[i] = stmt.Text
+	}
+
+	result := svc.ClusterStatements(statements, 2)
+	labels := append([]int(nil), result.Labels...)
+
+	// Stop the current top keyword of every cluster
+	var stopWords []string
+	for _, c := range result.Clusters {
+		if len(c.Keywords) == 0 {
+			t.Fatalf("cluster %d: expected keywords", c.ID)
+		}
+		stopWords = append(stopWords, c.Keywords[0].Word)
+	}
+
+	extractor := NewKeywordExtractorWithOptions(KeywordOptions{ExtraStopWords: stopWords})
+	updated := svc.RecomputeKeywords(result, texts, extractor, 0)
+	if updated == nil {
+		t.Fatal("expected a result")
+	}
+
+	for i := range labels {
+		if updated.Labels[i] != labels[i] || result.Labels[i] != labels[i] {
+			t.Fatalf("expected labels %v to be unchanged, got %v", labels, updated.Labels)
+		}
+	}
+
+	for i, c := range updated.Clusters {
+		before := result.Clusters[i]
+		if c.ID != before.ID || c.Size != before.Size || c.Density != before.Density {
+			t.Errorf("cluster %d: expected metadata to be unchanged, got %+v", before.ID, c)
+		}
+		for _, kw := range c.Keywords {
+			for _, stop := range stopWords {
+				if kw.Word == stop {
+					t.Errorf("cluster %d: expected stop word %q to be excluded, got %v", c.ID, stop, c.Keywords)
+				}
+			}
+		}
+		if before.Keywords[0].Word != stopWords[i] {
+			t.Errorf("cluster %d: expected the original keywords to be left untouched", c.ID)
+		}
+	}
+
+	if svc.RecomputeKeywords(result, texts[:2], extractor, 0) != nil {
+		t.Error("expected nil when texts do not match labels")
+	}
+}
+
+func TestAssignToExistingClusters(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	centroids := [][]float32{{1, 0}, {0, 1}}
+	statements := []models.Statement{
+		{Text: "a", Embedding: []float32{0.9, 0.1}},
+		{Text: "b", Embedding: []float32{0.1, 0.9}},
+		{Text: "c", Embedding: []float32{0.8, 0.3}},
+	}
+
+	result := svc.AssignToExistingClusters(statements, centroids)
+	if result == nil {
+		t.Fatal("expected a result")
+	}
+	want := []int{0, 1, 0}
+	for i, label := range result.Labels {
+		if label != want[i] {
+			t.Fatalf("expected labels %v, got %v", want, result.Labels)
+		}
+	}
+	if result.Clusters[0].Size != 2 || result.Clusters[1].Size != 1 {
+		t.Errorf("expected sizes [2 1], got [%d %d]", result.Clusters[0].Size, result.Clusters[1].Size)
+	}
+	if result.Clusters[0].Centroid[0] != 1 {
+		t.Errorf("expected the existing centroids to be kept, got %v", result.Clusters[0].Centroid)
+	}
+
+	// Centroids of another dimension cannot be reused
+	wide := []models.Statement{{Text: "d", Embedding: []float32{1, 0, 0}}}
+	if svc.AssignToExistingClusters(wide, centroids) != nil {
+		t.Error("expected nil for mismatched dimensions")
+	}
+}
+
+func TestCentroidWeights(t *testing.T) {
+	points := [][]float32{{0, 0}, {1, 0}, {3, 0}, {10, 10}}
+	labels := []int{0, 0, 0, 1}
+	centroids := [][]float32{{1, 0}, {10, 10}}
+
+	// Mean distance in cluster 0 is (1+0+2)/3 = 1
+	want := []float64{0.5, 1, 1.0 / 3, 1}
+	got := centroidWeights(points, labels, centroids)
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("point %d: expected weight %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestRepresentative(t *testing.T) {
+	points := [][]float32{{0, 0}, {1, 0}, {3, 0}, {10, 10}}
+	labels := []int{0, 0, 0, 1}
+
+	if got := representative(points, labels, 0, []float32{1.2, 0}); got != 1 {
+		t.Errorf("expected the member closest to the centroid, got %d", got)
+	}
+	if got := representative(points, labels, 2, []float32{0, 0}); got != -1 {
+		t.Errorf("expected -1 for an empty cluster, got %d", got)
+	}
+}
+
+func TestFindElbow(t *testing.T) {
+	tests := []struct {
+		name     string
+		inertias []float64
+		want     int
+	}{
+		{"sharp elbow", []float64{100, 40, 20, 15, 12, 10}, 3},
+		{"early elbow", []float64{100, 20, 15, 12, 10, 9}, 2},
+		{"late elbow", []float64{100, 60, 30, 10, 9, 8}, 4},
+		{"flat", []float64{10, 10, 10, 10}, 1},
+		{"two values", []float64{10, 5}, 2},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findElbow(tt.inertias); got != tt.want {
+				t.Errorf("expected k=%d, got %d", tt.want, got)
+			}
+		})
+	}
+}
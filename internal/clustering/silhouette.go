@@ -0,0 +1,146 @@
+package clustering
+
+import (
+	"math"
+)
+
+// Silhouette returns the mean silhouette coefficient of a clustering, in
+// [-1, 1]. Values near 1 mean points are much closer to their own cluster
+// than to the nearest other cluster. Points in singleton clusters score 0,
+// and a clustering with fewer than two non-empty clusters scores 0.
+func Silhouette(embeddings [][]float32, labels []int) float64 {
+	n := len(embeddings)
+	if n == 0 || len(labels) != n {
+		return 0
+	}
+
+	k := 0
+	for _, label := range labels {
+		if label+1 > k {
+			k = label + 1
+		}
+	}
+	sizes := make([]int, k)
+	for _, label := range labels {
+		sizes[label]++
+	}
+	nonEmpty := 0
+	for _, size := range sizes {
+		if size > 0 {
+			nonEmpty++
+		}
+	}
+	if nonEmpty < 2 {
+		return 0
+	}
+
+	total := 0.0
+	sums := make([]float64, k)
+	for i := 0; i < n; i++ {
+		own := labels[i]
+		if sizes[own] <= 1 {
+			continue
+		}
+
+		for c := range sums {
+			sums[c] = 0
+		}
+		for j := 0; j < n; j++ {
+			if i != j {
+				sums[labels[j]] += euclideanDistance32(embeddings[i], embeddings[j])
+			}
+		}
+
+		a := sums[own] / float64(sizes[own]-1)
+		b := math.Inf(1)
+		for c, sum := range sums {
+			if c == own || sizes[c] == 0 {
+				continue
+			}
+			if mean := sum / float64(sizes[c]); mean < b {
+				b = mean
+			}
+		}
+
+		if denom := math.Max(a, b); denom > 0 {
+			total += (b - a) / denom
+		}
+	}
+
+	return total / float64(n)
+}
+
+// SilhouetteMethod returns the silhouette score of k-means clusterings for
+// k = 1..maxK, indexed like ElbowMethod. The score for k = 1 is always 0.
+func SilhouetteMethod(embeddings [][]float32, maxK int) []float64 {
+	if maxK <= 0 {
+		maxK = 10
+	}
+	if maxK > len(embeddings) {
+		maxK = len(embeddings)
+	}
+
+	scores := make([]float64, maxK)
+	for k := 2; k <= maxK; k++ {
+		km := NewKMeans(k)
+		labels := km.Fit(embeddings)
+		scores[k-1] = Silhouette(embeddings, labels)
+	}
+
+	return scores
+}
+
+// KMetrics holds the elbow and silhouette curves used to choose k
+type KMetrics struct {
+	// Inertia and Silhouette are indexed by k-1
+	Inertia    []float64
+	Silhouette []float64
+	// ElbowK is the knee of the inertia curve, SilhouetteK the k with the
+	// highest silhouette score
+	ElbowK      int
+	SilhouetteK int
+	// RecommendedK prefers SilhouetteK and falls back to ElbowK when no
+	// clustering with k >= 2 has a positive silhouette
+	RecommendedK int
+}
+
+// EvaluateK computes elbow and silhouette metrics for k = 1..maxK
+func EvaluateK(embeddings [][]float32, maxK int) *KMetrics {
+	if len(embeddings) == 0 {
+		return &KMetrics{Inertia: []float64{}, Silhouette: []float64{}}
+	}
+
+	inertias := ElbowMethod(embeddings, maxK)
+	silhouettes := SilhouetteMethod(embeddings, maxK)
+
+	metrics := &KMetrics{
+		Inertia:    inertias,
+		Silhouette: silhouettes,
+		ElbowK:     findElbow(inertias),
+	}
+
+	best := 0.0
+	for i, score := range silhouettes {
+		if score > best {
+			best = score
+			metrics.SilhouetteK = i + 1
+		}
+	}
+
+	metrics.RecommendedK = metrics.SilhouetteK
+	if metrics.RecommendedK == 0 {
+		metrics.RecommendedK = metrics.ElbowK
+	}
+
+	return metrics
+}
+
+// euclideanDistance32 returns the Euclidean distance between two vectors
+func euclideanDistance32(a, b []float32) float64 {
+	sum := 0.0
+	for i := range a {
+		diff := float64(a[i] - b[i])
+		sum += diff * diff
+	}
+	return math.Sqrt(sum)
+}
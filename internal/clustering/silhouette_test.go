@@ -0,0 +1,51 @@
+package clustering
+
+import (
+	"testing"
+)
+
+// threeBlobs returns three tight, well-separated groups of four points
+func threeBlobs() [][]float32 {
+	centers := [][2]float32{{0, 0}, {10, 0}, {0, 10}}
+	offsets := [][2]float32{{0, 0}, {0.1, 0}, {0, 0.1}, {0.1, 0.1}}
+	var points [][]float32
+	for _, c := range centers {
+		for _, o := range offsets {
+			points = append(points, []float32{c[0] + o[0], c[1] + o[1]})
+		}
+	}
+	return points
+}
+
+func TestSilhouette(t *testing.T) {
+	points := threeBlobs()
+	good := []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}
+	bad := []int{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2}
+
+	if score := Silhouette(points, good); score < 0.9 {
+		t.Errorf("expected near-1 silhouette for separated blobs, got %f", score)
+	}
+	if score := Silhouette(points, bad); score >= 0 {
+		t.Errorf("expected negative silhouette for mixed labels, got %f", score)
+	}
+	if score := Silhouette(points, make([]int, len(points))); score != 0 {
+		t.Errorf("expected 0 for a single cluster, got %f", score)
+	}
+}
+
+func TestEvaluateK(t *testing.T) {
+	metrics := EvaluateK(threeBlobs(), 6)
+
+	if len(metrics.Inertia) != 6 || len(metrics.Silhouette) != 6 {
+		t.Fatalf("expected 6 inertias and silhouettes, got %d and %d", len(metrics.Inertia), len(metrics.Silhouette))
+	}
+	if metrics.Silhouette[0] != 0 {
+		t.Errorf("expected silhouette 0 for k=1, got %f", metrics.Silhouette[0])
+	}
+	if metrics.SilhouetteK != 3 {
+		t.Errorf("expected best silhouette at k=3, got %d (scores %v)", metrics.SilhouetteK, metrics.Silhouette)
+	}
+	if metrics.RecommendedK != 3 {
+		t.Errorf("expected recommended k=3, got %d", metrics.RecommendedK)
+	}
+}
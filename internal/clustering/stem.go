@@ -0,0 +1,48 @@
+package clustering
+
+import "strings"
+
+// stemSuffixes are stripped in order; the first match wins. Each entry maps
+// a suffix to its replacement.
+var stemSuffixes = []struct {
+	suffix, replace string
+}{
+	{"ational", "ate"},
+	{"ization", "ize"},
+	{"fulness", "ful"},
+	{"iveness", "ive"},
+	{"ements", ""},
+	{"ement", ""},
+	{"ments", ""},
+	{"ment", ""},
+	{"ingly", ""},
+	{"ies", "i"},
+	{"ing", ""},
+	{"edly", ""},
+	{"ed", ""},
+	{"ly", ""},
+	{"es", ""},
+	{"s", ""},
+	{"y", "i"},
+}
+
+// minStemLength keeps stems from becoming too short to be meaningful
+const minStemLength = 3
+
+// stem is a light English suffix stripper. It is not a full Porter stemmer
+// but maps common inflections ("policies", "policy"; "requires", "required",
+// "requiring") to a shared stem, which is what keyword grouping needs.
+func stem(word string) string {
+	for _, s := range stemSuffixes {
+		if !strings.HasSuffix(word, s.suffix) {
+			continue
+		}
+		base := strings.TrimSuffix(word, s.suffix) + s.replace
+		if len(base) < minStemLength || strings.HasSuffix(word, "ss") {
+			return word
+		}
+		// "requir" + "e" forms: drop a trailing e so "require" matches "required"
+		return strings.TrimSuffix(base, "e")
+	}
+	return strings.TrimSuffix(word, "e")
+}
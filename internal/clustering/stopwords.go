@@ -0,0 +1,152 @@
+package clustering
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Stop word languages, as ISO 639-1 codes
+const (
+	LanguageEnglish = "en"
+	LanguageGerman  = "de"
+	LanguageSpanish = "es"
+	LanguageFrench  = "fr"
+)
+
+// stopWordLists holds the stop words of each supported language
+var stopWordLists = map[string][]string{
+	LanguageEnglish: {
+		"a", "an", "and", "are", "as", "at", "be", "by", "for", "from",
+		"has", "have", "he", "in", "is", "it", "its", "of", "on", "or",
+		"she", "that", "the", "they", "this", "to", "was", "were", "will",
+		"with", "you", "your", "we", "our", "their", "them", "there", "these",
+		"those", "been", "being", "had", "having", "do", "does", "did", "doing",
+		"would", "could", "should", "may", "might", "must", "can", "cannot",
+		"about", "above", "after", "again", "against", "all", "am", "any",
+		"because", "before", "below", "between", "both", "but", "during",
+		"each", "few", "further", "here", "how", "if", "into", "just", "more",
+		"most", "no", "nor", "not", "now", "only", "other", "out", "own",
+		"same", "so", "some", "such", "than", "then", "through", "too", "under",
+		"until", "up", "very", "what", "when", "where", "which", "while", "who",
+		"whom", "why", "also", "however", "therefore", "thus", "hence", "yet",
+	},
+	LanguageGerman: {
+		"der", "die", "das", "den", "dem", "des", "ein", "eine", "einer",
+		"eines", "einem", "einen", "und", "oder", "aber", "ist", "sind", "war",
+		"waren", "wird", "werden", "wurde", "wurden", "hat", "haben", "hatte",
+		"sein", "seine", "ihr", "ihre", "sie", "wir", "ich", "du", "er", "es",
+		"nicht", "kein", "keine", "mit", "für", "von", "auf", "aus", "bei",
+		"nach", "über", "unter", "vor", "zu", "zum", "zur", "im", "in", "an",
+		"am", "als", "auch", "noch", "nur", "so", "wie", "wenn", "dass", "daß",
+		"muss", "müssen", "kann", "können", "soll", "sollen", "darf", "dürfen",
+		"diese", "dieser", "dieses", "diesem", "diesen", "durch", "gegen",
+		"ohne", "bis", "sich", "man", "mehr", "sehr", "dann", "denn", "doch",
+	},
+	LanguageSpanish: {
+		"el", "la", "los", "las", "un", "una", "unos", "unas", "y", "o", "pero",
+		"de", "del", "al", "en", "con", "por", "para", "sin", "sobre", "entre",
+		"es", "son", "fue", "fueron", "era", "ser", "está", "están", "estar",
+		"ha", "han", "haber", "hay", "que", "se", "su", "sus", "lo", "le",
+		"les", "no", "más", "muy", "ya", "como", "cuando", "donde", "si",
+		"también", "este", "esta", "estos", "estas", "ese", "esa", "esos",
+		"esas", "nos", "nuestro", "nuestra", "debe", "deben", "puede",
+		"pueden", "será", "serán", "todo", "todos", "toda", "todas", "cada",
+	},
+	LanguageFrench: {
+		"le", "la", "les", "un", "une", "des", "du", "de", "et", "ou", "mais",
+		"en", "dans", "avec", "pour", "par", "sans", "sur", "sous", "entre",
+		"est", "sont", "était", "étaient", "être", "été", "a", "ont", "avoir",
+		"que", "qui", "quoi", "ce", "cet", "cette", "ces", "se", "sa", "son",
+		"ses", "leur", "leurs", "ne", "pas", "plus", "très", "comme", "quand",
+		"où", "si", "aussi", "il", "elle", "ils", "elles", "nous", "vous",
+		"doit", "doivent", "peut", "peuvent", "sera", "seront", "tout", "tous",
+		"toute", "toutes", "chaque", "au", "aux",
+	},
+}
+
+// IsStopWordLanguage reports whether lang has a stop word list
+func IsStopWordLanguage(lang string) bool {
+	_, ok := stopWordLists[lang]
+	return ok
+}
+
+// StopWordLanguages returns the supported stop word languages, sorted
+func StopWordLanguages() []string {
+	langs := make([]string, 0, len(stopWordLists))
+	for lang := range stopWordLists {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// stopWordSets indexes stopWordLists for lookups
+var stopWordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(stopWordLists))
+	for lang, words := range stopWordLists {
+		set := make(map[string]bool, len(words))
+		for _, w := range words {
+			set[w] = true
+		}
+		sets[lang] = set
+	}
+	return sets
+}()
+
+const (
+	// minLanguageHits is the number of stop words a language needs before it
+	// is detected at all
+	minLanguageHits = 2
+	// minLanguageShare is the share of all stop word hits a language needs to
+	// be detected alongside others in mixed-language text
+	minLanguageShare = 0.2
+)
+
+// DetectLanguages guesses the languages of texts from stop word frequency.
+// Mixed-language texts can yield several languages, sorted by frequency.
+// Words shared between lists count for each of them. It returns nil when
+// there is no clear signal.
+func DetectLanguages(texts []string) []string {
+	hits := make(map[string]int)
+	total := 0
+	for _, text := range texts {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		for _, w := range words {
+			for lang, set := range stopWordSets {
+				if set[w] {
+					hits[lang]++
+					total++
+				}
+			}
+		}
+	}
+
+	var langs []string
+	for lang, n := range hits {
+		if n >= minLanguageHits && float64(n)/float64(total) >= minLanguageShare {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if hits[langs[i]] != hits[langs[j]] {
+			return hits[langs[i]] > hits[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	return langs
+}
+
+// stopWordsFor returns the union of the stop word lists of langs. Unknown
+// languages are ignored.
+func stopWordsFor(langs ...string) map[string]bool {
+	result := make(map[string]bool)
+	for _, lang := range langs {
+		for w := range stopWordSets[lang] {
+			result[w] = true
+		}
+	}
+	return result
+}
@@ -1,68 +1,103 @@
 package contradiction
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// Analyzer detects contradictions between statement pairs using Claude API
+// Analyzer detects contradictions between statement pairs using an LLM
 type Analyzer struct {
-	apiKey     string
-	baseURL    string
-	model      string
-	httpClient *http.Client
+	backend LLMBackend
+	prompt  *prompt
 }
 
 // Config holds analyzer configuration
 type Config struct {
-	APIKey  string
-	BaseURL string
-	Model   string
-	Timeout time.Duration
+	Provider Provider // anthropic (default) or openai
+	APIKey   string
+	BaseURL  string // Defaults to the provider's public API
+	Model    string // Defaults to a small, fast model for the provider
+	Timeout  time.Duration
+
+	// PromptTemplate replaces the built-in analysis prompt with a
+	// text/template executed with PromptData for every language. It must
+	// include {{.Statement1}} and {{.Statement2}} and should include
+	// {{.ResponseFormat}}. Empty uses DefaultPromptTemplate (localized).
+	PromptTemplate string
+	// Types adds contradiction types to BuiltinTypes, e.g. "dosage"
+	Types []ContradictionType
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
+	baseURL, model := providerDefaults(ProviderAnthropic)
 	return Config{
-		BaseURL: "https://api.anthropic.com/v1",
-		Model:   "claude-3-haiku-20240307",
-		Timeout: 30 * time.Second,
+		Provider: ProviderAnthropic,
+		BaseURL:  baseURL,
+		Model:    model,
+		Timeout:  30 * time.Second,
 	}
 }
 
-// NewAnalyzer creates a new contradiction analyzer
-func NewAnalyzer(config Config) *Analyzer {
+// NewAnalyzer creates a new contradiction analyzer using the configured
+// provider. An invalid prompt template or type name is an error.
+func NewAnalyzer(config Config) (*Analyzer, error) {
+	prompt, err := newPrompt(config.PromptTemplate, config.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Provider == "" {
+		config.Provider = ProviderAnthropic
+	}
+	baseURL, model := providerDefaults(config.Provider)
 	if config.BaseURL == "" {
-		config.BaseURL = DefaultConfig().BaseURL
+		config.BaseURL = baseURL
 	}
 	if config.Model == "" {
-		config.Model = DefaultConfig().Model
+		config.Model = model
 	}
 	if config.Timeout == 0 {
 		config.Timeout = DefaultConfig().Timeout
 	}
 
-	return &Analyzer{
-		apiKey:  config.APIKey,
-		baseURL: config.BaseURL,
-		model:   config.Model,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
 	}
+
+	var backend LLMBackend
+	switch config.Provider {
+	case ProviderOpenAI:
+		backend = NewOpenAIBackend(config.APIKey, config.BaseURL, config.Model, httpClient)
+	default:
+		backend = NewAnthropicBackend(config.APIKey, config.BaseURL, config.Model, httpClient)
+	}
+
+	return &Analyzer{backend: backend, prompt: prompt}, nil
+}
+
+// NewAnalyzerWithBackend creates an analyzer that delegates to the given
+// backend using the built-in prompt
+func NewAnalyzerWithBackend(backend LLMBackend) *Analyzer {
+	return &Analyzer{backend: backend, prompt: defaultPrompt}
 }
 
 // AnalyzePair analyzes a single pair for contradictions
 func (a *Analyzer) AnalyzePair(ctx context.Context, pair StatementPair) (*ContradictionResult, error) {
-	prompt := buildPrompt(pair)
+	prompt, err := a.prompt.build(pair)
+	if err != nil {
+		return nil, fmt.Errorf("build prompt: %w", err)
+	}
 
-	response, err := a.callClaude(ctx, prompt)
+	response, err := a.backend.Complete(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("call claude: %w", err)
+		return nil, fmt.Errorf("call llm: %w", err)
 	}
 
 	result, err := parseResponse(response, pair)
@@ -73,125 +108,91 @@ func (a *Analyzer) AnalyzePair(ctx context.Context, pair StatementPair) (*Contra
 	return result, nil
 }
 
-// AnalyzePairs analyzes multiple pairs concurrently
+// ErrRateLimited is returned when most pairs could not be analyzed because
+// the LLM API was rate limiting or failing, so an empty result would be
+// indistinguishable from "no contradictions"
+var ErrRateLimited = errors.New("LLM rate limited or unavailable")
+
+// AnalyzePairs analyzes multiple pairs concurrently. Pairs that fail are
+// skipped unless most of them failed with a rate limit or server error, in
+// which case an error wrapping ErrRateLimited is returned.
 func (a *Analyzer) AnalyzePairs(ctx context.Context, pairs []StatementPair, maxConcurrent int) ([]ContradictionResult, error) {
-	if maxConcurrent <= 0 {
-		maxConcurrent = 5
+	outcomes := a.analyzeAll(ctx, pairs, 1, maxConcurrent)
+	if err := checkOutcomes(outcomes); err != nil {
+		return nil, err
 	}
 
 	results := make([]ContradictionResult, 0)
-	sem := make(chan struct{}, maxConcurrent)
-
-	type result struct {
-		contradiction *ContradictionResult
-		err           error
-	}
-	resultChan := make(chan result, len(pairs))
-
-	for _, pair := range pairs {
-		sem <- struct{}{}
-		go func(p StatementPair) {
-			defer func() { <-sem }()
-
-			cr, err := a.AnalyzePair(ctx, p)
-			resultChan <- result{contradiction: cr, err: err}
-		}(pair)
-	}
-
-	for range pairs {
-		r := <-resultChan
-		if r.err != nil {
-			continue // Skip errors, log them in production
+	for _, o := range outcomes {
+		if o.err != nil {
+			continue
 		}
-		if r.contradiction != nil && r.contradiction.Type != "" {
-			results = append(results, *r.contradiction)
+		if o.result != nil && o.result.Type != "" {
+			results = append(results, *o.result)
 		}
 	}
 
 	return results, nil
 }
 
-func buildPrompt(pair StatementPair) string {
-	return fmt.Sprintf(`Analyze these two statements for contradictions:
-
-Statement 1: "%s"
-Statement 2: "%s"
-
-Determine if they contradict each other. If yes, respond with JSON:
-{
-  "is_contradiction": true,
-  "type": "direct|numerical|temporal|implicit",
-  "severity": "high|medium|low",
-  "explanation": "brief explanation",
-  "confidence": 0.0-1.0
-}
-
-If no contradiction, respond:
-{"is_contradiction": false}
-
-Respond ONLY with valid JSON.`, pair.Statement1, pair.Statement2)
-}
-
-type claudeRequest struct {
-	Model     string    `json:"model"`
-	MaxTokens int       `json:"max_tokens"`
-	Messages  []message `json:"messages"`
-}
+// checkOutcomes logs failed pairs and returns an error if a majority failed
+// with a temporary API error
+func checkOutcomes(outcomes []pairOutcome) error {
+	failed, temporary := 0, 0
+	for _, o := range outcomes {
+		if o.err == nil {
+			continue
+		}
+		failed++
+		var apiErr *APIError
+		if errors.As(o.err, &apiErr) && apiErr.Temporary() {
+			temporary++
+		}
+		log.Printf("[contradictions] failed to analyze pair %s/%s: %v", o.pair.Statement1ID, o.pair.Statement2ID, o.err)
+	}
 
-type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	if temporary*2 > len(outcomes) {
+		return fmt.Errorf("%w: %d of %d pairs failed", ErrRateLimited, failed, len(outcomes))
+	}
+	return nil
 }
 
-type claudeResponse struct {
-	Content []struct {
-		Text string `json:"text"`
-	} `json:"content"`
+// pairOutcome is the analysis outcome of a single pair. A nil result with a
+// nil error means the pair was analyzed and does not contradict; a non-nil
+// error means it could not be analyzed.
+type pairOutcome struct {
+	pair   StatementPair
+	result *ContradictionResult
+	err    error
 }
 
-func (a *Analyzer) callClaude(ctx context.Context, prompt string) (string, error) {
-	reqBody := claudeRequest{
-		Model:     a.model,
-		MaxTokens: 500,
-		Messages: []message{
-			{Role: "user", Content: prompt},
-		},
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/messages", bytes.NewReader(jsonBody))
-	if err != nil {
-		return "", err
+// analyzeAll analyzes pairs concurrently and returns every outcome. With a
+// batchSize above 1, up to batchSize pairs of the same language share one
+// LLM request; see analyzeBatch.
+func (a *Analyzer) analyzeAll(ctx context.Context, pairs []StatementPair, batchSize, maxConcurrent int) []pairOutcome {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 5
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", a.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := a.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	batches := batchPairs(pairs, batchSize)
+	sem := make(chan struct{}, maxConcurrent)
+	outcomes := make(chan []pairOutcome, len(batches))
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: status %d", resp.StatusCode)
-	}
+	for _, batch := range batches {
+		sem <- struct{}{}
+		go func(batch []StatementPair) {
+			defer func() { <-sem }()
 
-	var cr claudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
-		return "", err
+			outcomes <- a.analyzeBatch(ctx, batch)
+		}(batch)
 	}
 
-	if len(cr.Content) == 0 {
-		return "", fmt.Errorf("empty response")
+	results := make([]pairOutcome, 0, len(pairs))
+	for range batches {
+		results = append(results, <-outcomes...)
 	}
 
-	return cr.Content[0].Text, nil
+	return results
 }
 
 type analysisResponse struct {
@@ -203,13 +204,23 @@ type analysisResponse struct {
 }
 
 func parseResponse(response string, pair StatementPair) (*ContradictionResult, error) {
+	raw, err := extractJSON(response)
+	if err != nil {
+		return nil, err
+	}
+
 	var ar analysisResponse
-	if err := json.Unmarshal([]byte(response), &ar); err != nil {
+	if err := json.Unmarshal([]byte(raw), &ar); err != nil {
 		return nil, err
 	}
 
+	return ar.result(pair), nil
+}
+
+// result converts a reply about pair to a result, nil without a contradiction
+func (ar analysisResponse) result(pair StatementPair) *ContradictionResult {
 	if !ar.IsContradiction {
-		return nil, nil
+		return nil
 	}
 
 	return &ContradictionResult{
@@ -223,5 +234,73 @@ func parseResponse(response string, pair StatementPair) (*ContradictionResult, e
 		Severity:     Severity(ar.Severity),
 		Explanation:  ar.Explanation,
 		Confidence:   ar.Confidence,
-	}, nil
+	}
+}
+
+// extractJSON returns the first balanced JSON object in a model reply.
+// Models often wrap JSON in ```json fences or add prose around it, so
+// fences are stripped and the object is located by matching braces,
+// ignoring braces inside string literals.
+func extractJSON(response string) (string, error) {
+	return extractDelimited(response, '{', '}', "object")
+}
+
+// extractJSONArray returns the first balanced JSON array in a model reply,
+// handled as in extractJSON
+func extractJSONArray(response string) (string, error) {
+	return extractDelimited(response, '[', ']', "array")
+}
+
+// extractDelimited returns the first balanced JSON value opened by open and
+// closed by close, after stripping code fences. kind names the value in
+// errors.
+func extractDelimited(response string, open, close byte, kind string) (string, error) {
+	text := strings.TrimSpace(response)
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		// Drop the info string (e.g. "json") on the opening fence line
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+			body = body[nl+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		text = body
+	}
+
+	start := strings.IndexByte(text, open)
+	if start < 0 {
+		return "", fmt.Errorf("no JSON %s in response", kind)
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unterminated JSON %s in response", kind)
 }
@@ -0,0 +1,94 @@
+package contradiction
+
+import "testing"
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "plain",
+			response: `{"is_contradiction": false}`,
+			want:     `{"is_contradiction": false}`,
+		},
+		{
+			name:     "fenced with language",
+			response: "```json\n{\"is_contradiction\": true, \"type\": \"direct\"}\n```",
+			want:     `{"is_contradiction": true, "type": "direct"}`,
+		},
+		{
+			name:     "fenced without language",
+			response: "```\n{\"is_contradiction\": false}\n```",
+			want:     `{"is_contradiction": false}`,
+		},
+		{
+			name:     "prefixed",
+			response: "Here is my analysis:\n{\"is_contradiction\": false}",
+			want:     `{"is_contradiction": false}`,
+		},
+		{
+			name:     "prefixed and fenced",
+			response: "Sure.\n```json\n{\"is_contradiction\": false}\n```\nLet me know if you need more.",
+			want:     `{"is_contradiction": false}`,
+		},
+		{
+			name:     "trailing text",
+			response: `{"is_contradiction": false} I hope this helps {not json}`,
+			want:     `{"is_contradiction": false}`,
+		},
+		{
+			name:     "braces inside strings",
+			response: `{"explanation": "uses } and { and \"quotes\"", "confidence": 0.5} trailing`,
+			want:     `{"explanation": "uses } and { and \"quotes\"", "confidence": 0.5}`,
+		},
+		{
+			name:     "nested object",
+			response: `Answer: {"a": {"b": 1}, "c": 2}.`,
+			want:     `{"a": {"b": 1}, "c": 2}`,
+		},
+		{
+			name:     "no object",
+			response: "I cannot determine this.",
+			wantErr:  true,
+		},
+		{
+			name:     "unterminated",
+			response: `{"is_contradiction": true, "type": "dir`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractJSON(tt.response)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseResponse_Fenced(t *testing.T) {
+	pair := StatementPair{Statement1: "a", Statement2: "b"}
+	response := "The statements conflict.\n```json\n{\"is_contradiction\": true, \"type\": \"direct\", \"severity\": \"high\", \"explanation\": \"x\", \"confidence\": 0.8}\n```"
+
+	result, err := parseResponse(response, pair)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.Type != "direct" || result.Confidence != 0.8 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
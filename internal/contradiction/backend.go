@@ -0,0 +1,234 @@
+package contradiction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when an LLM API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API error: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error: status %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the error is a rate limit or server-side failure
+// that is likely to succeed on retry
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// maxErrorBodyBytes bounds how much of an error response is kept
+const maxErrorBodyBytes = 512
+
+// newAPIError builds an APIError from a failed response
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
+
+// LLMBackend sends a prompt to a language model and returns its text reply
+type LLMBackend interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// Provider identifies an LLM backend implementation
+type Provider string
+
+const (
+	ProviderAnthropic Provider = "anthropic"
+	ProviderOpenAI    Provider = "openai"
+)
+
+// ParseProvider validates a provider name (empty means Anthropic)
+func ParseProvider(name string) (Provider, error) {
+	switch Provider(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ProviderAnthropic:
+		return ProviderAnthropic, nil
+	case ProviderOpenAI:
+		return ProviderOpenAI, nil
+	default:
+		return "", fmt.Errorf("unknown LLM provider %q (expected anthropic or openai)", name)
+	}
+}
+
+// providerDefaults returns the default base URL and model for a provider
+func providerDefaults(p Provider) (baseURL, model string) {
+	if p == ProviderOpenAI {
+		return "https://api.openai.com/v1", "gpt-4o-mini"
+	}
+	return "https://api.anthropic.com/v1", "claude-3-haiku-20240307"
+}
+
+// maxCompletionTokens bounds the reply length for a single analysis
+const maxCompletionTokens = 500
+
+// AnthropicBackend calls the Anthropic Messages API
+type AnthropicBackend struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAnthropicBackend creates a backend for the Anthropic Messages API
+func NewAnthropicBackend(apiKey, baseURL, model string, httpClient *http.Client) *AnthropicBackend {
+	return &AnthropicBackend{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		model:      model,
+		httpClient: httpClient,
+	}
+}
+
+type claudeRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []message `json:"messages"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type claudeResponse struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// Complete sends the prompt as a single user message
+func (b *AnthropicBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	reqBody := claudeRequest{
+		Model:     b.model,
+		MaxTokens: maxCompletionTokens,
+		Messages: []message{
+			{Role: "user", Content: prompt},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.baseURL+"/messages", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", b.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var cr claudeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return "", err
+	}
+
+	if len(cr.Content) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+
+	return cr.Content[0].Text, nil
+}
+
+// OpenAIBackend calls an OpenAI-compatible chat completions API. This also
+// covers local model servers that expose the same endpoint.
+type OpenAIBackend struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIBackend creates a backend for an OpenAI-compatible chat API
+func NewOpenAIBackend(apiKey, baseURL, model string, httpClient *http.Client) *OpenAIBackend {
+	return &OpenAIBackend{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		model:      model,
+		httpClient: httpClient,
+	}
+}
+
+type openAIChatRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []message `json:"messages"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete sends the prompt as a single user chat message
+func (b *OpenAIBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	reqBody := openAIChatRequest{
+		Model:     b.model,
+		MaxTokens: maxCompletionTokens,
+		Messages: []message{
+			{Role: "user", Content: prompt},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var cr openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return "", err
+	}
+
+	if len(cr.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+
+	return cr.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,219 @@
+package contradiction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// batchResponseContract is responseContract with the index of the pair an
+// answer belongs to
+const batchResponseContract = `{
+  "pair": 1,
+  "is_contradiction": true,
+  "type": "%s",
+  "severity": "high|medium|low",
+  "explanation": "%s",
+  "confidence": 0.0-1.0
+}`
+
+const batchNoContradictionContract = `{"pair": 1, "is_contradiction": false}`
+
+// batchPromptTemplate asks for the analysis of several pairs at once
+const batchPromptTemplate = `Analyze each of these statement pairs for contradictions:
+{{range .Pairs}}
+Pair {{.Index}}:
+Statement 1{{with .Section1}} (Section: {{.}}){{end}}: "{{.Statement1}}"
+Statement 2{{with .Section2}} (Section: {{.}}){{end}}: "{{.Statement2}}"
+{{end}}
+For every pair, determine if its two statements contradict each other. Respond with a JSON array holding one object per pair, in pair order, with "pair" set to the pair number. For a contradiction:
+{{.ResponseFormat}}
+
+For no contradiction:
+{{.NoContradiction}}
+
+Respond ONLY with a valid JSON array.`
+
+// germanBatchPromptTemplate is the German version of batchPromptTemplate
+const germanBatchPromptTemplate = `Analysiere jedes dieser Aussagenpaare auf Widersprüche:
+{{range .Pairs}}
+Paar {{.Index}}:
+Aussage 1{{with .Section1}} (Abschnitt: {{.}}){{end}}: "{{.Statement1}}"
+Aussage 2{{with .Section2}} (Abschnitt: {{.}}){{end}}: "{{.Statement2}}"
+{{end}}
+Entscheide für jedes Paar, ob sich seine beiden Aussagen widersprechen. Antworte mit einem JSON-Array mit einem Objekt pro Paar, in der Reihenfolge der Paare, wobei "pair" die Nummer des Paars ist. Bei einem Widerspruch:
+{{.ResponseFormat}}
+
+Ohne Widerspruch:
+{{.NoContradiction}}
+
+Antworte AUSSCHLIESSLICH mit einem gültigen JSON-Array. Übersetze weder die JSON-Schlüssel noch die Werte für "type" und "severity"; schreibe nur "explanation" auf Deutsch.`
+
+// builtinBatchPrompts are the parsed batch templates per language
+var builtinBatchPrompts = map[Language]*template.Template{
+	LanguageEnglish: template.Must(template.New("en").Parse(batchPromptTemplate)),
+	LanguageGerman:  template.Must(template.New("de").Parse(germanBatchPromptTemplate)),
+}
+
+// batchItem is one numbered pair of a batch prompt
+type batchItem struct {
+	Index      int
+	Statement1 string
+	Statement2 string
+	Section1   string
+	Section2   string
+}
+
+// batchData is what a batch template is executed with
+type batchData struct {
+	Pairs           []batchItem
+	ResponseFormat  string
+	NoContradiction string
+}
+
+// buildBatch renders one prompt for pairs sharing a language. Pairs are
+// numbered from 1. Custom templates describe a single pair, so batching is
+// not supported with them.
+func (p *prompt) buildBatch(pairs []StatementPair) (string, error) {
+	if p.custom != nil {
+		return "", fmt.Errorf("batch prompts need the built-in template")
+	}
+
+	lang := promptLanguage(pairs[0].Language)
+
+	data := batchData{
+		Pairs:           make([]batchItem, len(pairs)),
+		ResponseFormat:  fmt.Sprintf(batchResponseContract, p.typeNames(), explanationHints[lang]),
+		NoContradiction: batchNoContradictionContract,
+	}
+	for i, pair := range pairs {
+		data.Pairs[i] = batchItem{
+			Index:      i + 1,
+			Statement1: pair.Statement1,
+			Statement2: pair.Statement2,
+			Section1:   pair.Section1,
+			Section2:   pair.Section2,
+		}
+	}
+
+	var b strings.Builder
+	if err := builtinBatchPrompts[lang].Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// batchResponse is one element of the reply to a batch prompt
+type batchResponse struct {
+	Pair *int `json:"pair"`
+	analysisResponse
+}
+
+// parseBatchResponse parses the reply to a batch prompt. Answers are matched
+// to pairs by their "pair" number, or by position when it is missing.
+// answered reports which pairs got a usable answer; the others must be
+// analyzed on their own.
+func parseBatchResponse(response string, pairs []StatementPair) (results []*ContradictionResult, answered []bool, err error) {
+	raw, err := extractJSONArray(response)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, nil, err
+	}
+
+	results = make([]*ContradictionResult, len(pairs))
+	answered = make([]bool, len(pairs))
+	for i, item := range items {
+		var br batchResponse
+		if err := json.Unmarshal(item, &br); err != nil {
+			continue
+		}
+		index := i
+		if br.Pair != nil {
+			index = *br.Pair - 1
+		}
+		if index < 0 || index >= len(pairs) || answered[index] {
+			continue
+		}
+		results[index] = br.result(pairs[index])
+		answered[index] = true
+	}
+
+	return results, answered, nil
+}
+
+// batchPairs splits pairs into batches of at most size pairs of the same
+// language, keeping their order. A size of 1 or less yields single pairs.
+func batchPairs(pairs []StatementPair, size int) [][]StatementPair {
+	if size < 1 {
+		size = 1
+	}
+
+	var batches [][]StatementPair
+	open := make(map[Language]int) // language -> index of its unfilled batch
+	for _, pair := range pairs {
+		i, ok := open[pair.Language]
+		if !ok || len(batches[i]) >= size {
+			batches = append(batches, make([]StatementPair, 0, size))
+			i = len(batches) - 1
+			open[pair.Language] = i
+		}
+		batches[i] = append(batches[i], pair)
+	}
+	return batches
+}
+
+// analyzeBatch analyzes a batch of pairs with one LLM request. Pairs the
+// reply does not answer, or the whole batch if the reply cannot be parsed,
+// fall back to one request per pair. A failed request fails every pair of
+// the batch.
+func (a *Analyzer) analyzeBatch(ctx context.Context, batch []StatementPair) []pairOutcome {
+	outcomes := make([]pairOutcome, len(batch))
+	for i, pair := range batch {
+		outcomes[i].pair = pair
+	}
+
+	if len(batch) == 1 || a.prompt.custom != nil {
+		for i, pair := range batch {
+			outcomes[i].result, outcomes[i].err = a.AnalyzePair(ctx, pair)
+		}
+		return outcomes
+	}
+
+	prompt, err := a.prompt.buildBatch(batch)
+	if err != nil {
+		for i := range outcomes {
+			outcomes[i].err = fmt.Errorf("build prompt: %w", err)
+		}
+		return outcomes
+	}
+
+	response, err := a.backend.Complete(ctx, prompt)
+	if err != nil {
+		for i := range outcomes {
+			outcomes[i].err = fmt.Errorf("call llm: %w", err)
+		}
+		return outcomes
+	}
+
+	results, answered, err := parseBatchResponse(response, batch)
+	if err != nil {
+		log.Printf("[contradictions] failed to parse batch of %d pairs, analyzing them one by one: %v", len(batch), err)
+		answered = make([]bool, len(batch))
+	}
+
+	for i, pair := range batch {
+		if answered[i] {
+			outcomes[i].result = results[i]
+			continue
+		}
+		outcomes[i].result, outcomes[i].err = a.AnalyzePair(ctx, pair)
+	}
+	return outcomes
+}
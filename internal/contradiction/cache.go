@@ -0,0 +1,154 @@
+package contradiction
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PairKey identifies an analyzed statement pair independent of order, along
+// with the prompt language and version it was analyzed with. An analysis
+// made in another language or with another prompt is not reused.
+type PairKey struct {
+	Statement1ID  string
+	Statement2ID  string
+	Language      Language
+	PromptVersion string
+}
+
+// NewPairKey returns the canonical key for two statement IDs with no
+// language or prompt version set
+func NewPairKey(id1, id2 string) PairKey {
+	if id1 > id2 {
+		id1, id2 = id2, id1
+	}
+	return PairKey{Statement1ID: id1, Statement2ID: id2}
+}
+
+// CachedAnalysis is the stored outcome of analyzing a pair. Pairs found not
+// to contradict are cached too so they are not sent to the LLM again.
+type CachedAnalysis struct {
+	IsContradiction bool
+	Type            ContradictionType
+	Severity        Severity
+	Explanation     string
+	Confidence      float64
+}
+
+// Cache stores LLM analysis results per statement pair
+type Cache interface {
+	// Lookup returns the cached analyses for the keys that have one
+	Lookup(ctx context.Context, keys []PairKey) (map[PairKey]CachedAnalysis, error)
+	Store(ctx context.Context, key PairKey, analysis CachedAnalysis) error
+	// InvalidateDocument drops analyses involving any statement of a document
+	InvalidateDocument(ctx context.Context, documentID string) error
+}
+
+// PostgresCache implements Cache using PostgreSQL
+type PostgresCache struct {
+	db *sql.DB
+}
+
+// NewPostgresCache creates a new PostgresCache
+func NewPostgresCache(db *sql.DB) *PostgresCache {
+	return &PostgresCache{db: db}
+}
+
+// Lookup retrieves cached analyses for the given pairs
+func (c *PostgresCache) Lookup(ctx context.Context, keys []PairKey) (map[PairKey]CachedAnalysis, error) {
+	result := make(map[PairKey]CachedAnalysis)
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	ids1 := make([]string, len(keys))
+	ids2 := make([]string, len(keys))
+	langs := make([]string, len(keys))
+	versions := make([]string, len(keys))
+	for i, k := range keys {
+		ids1[i] = k.Statement1ID
+		ids2[i] = k.Statement2ID
+		langs[i] = string(k.Language)
+		versions[i] = k.PromptVersion
+	}
+
+	query := `
+		SELECT ca.statement1_id, ca.statement2_id, ca.language, ca.prompt_version,
+			ca.is_contradiction, ca.type, ca.severity, ca.explanation, ca.confidence
+		FROM contradiction_analyses ca
+		JOIN unnest($1::uuid[], $2::uuid[], $3::text[], $4::text[]) AS k(s1, s2, lang, version)
+			ON ca.statement1_id = k.s1 AND ca.statement2_id = k.s2
+			AND ca.language = k.lang AND ca.prompt_version = k.version
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, pq.Array(ids1), pq.Array(ids2), pq.Array(langs), pq.Array(versions))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key PairKey
+		var a CachedAnalysis
+		if err := rows.Scan(
+			&key.Statement1ID,
+			&key.Statement2ID,
+			&key.Language,
+			&key.PromptVersion,
+			&a.IsContradiction,
+			&a.Type,
+			&a.Severity,
+			&a.Explanation,
+			&a.Confidence,
+		); err != nil {
+			return nil, err
+		}
+		result[key] = a
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Store saves or replaces the analysis of a pair
+func (c *PostgresCache) Store(ctx context.Context, key PairKey, a CachedAnalysis) error {
+	query := `
+		INSERT INTO contradiction_analyses (statement1_id, statement2_id, language, prompt_version,
+			is_contradiction, type, severity, explanation, confidence, analyzed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (statement1_id, statement2_id, language, prompt_version) DO UPDATE
+		SET is_contradiction = EXCLUDED.is_contradiction, type = EXCLUDED.type,
+			severity = EXCLUDED.severity, explanation = EXCLUDED.explanation,
+			confidence = EXCLUDED.confidence, analyzed_at = EXCLUDED.analyzed_at
+	`
+
+	_, err := c.db.ExecContext(ctx, query,
+		key.Statement1ID,
+		key.Statement2ID,
+		key.Language,
+		key.PromptVersion,
+		a.IsContradiction,
+		a.Type,
+		a.Severity,
+		a.Explanation,
+		a.Confidence,
+		time.Now(),
+	)
+	return err
+}
+
+// InvalidateDocument removes cached analyses involving the document's statements
+func (c *PostgresCache) InvalidateDocument(ctx context.Context, documentID string) error {
+	query := `
+		DELETE FROM contradiction_analyses
+		WHERE statement1_id IN (SELECT id FROM statements WHERE document_id = $1)
+			OR statement2_id IN (SELECT id FROM statements WHERE document_id = $1)
+	`
+	_, err := c.db.ExecContext(ctx, query, documentID)
+	return err
+}
@@ -0,0 +1,126 @@
+package contradiction
+
+import (
+	"sort"
+)
+
+// Graph is an undirected graph of statements (nodes) joined by detected
+// contradictions (edges)
+type Graph struct {
+	adjacent map[string]map[string]bool
+}
+
+// NewGraph builds a contradiction graph from statement ID pairs. Duplicate
+// pairs and self-loops are ignored.
+func NewGraph(pairs [][2]string) *Graph {
+	g := &Graph{adjacent: make(map[string]map[string]bool)}
+	for _, p := range pairs {
+		a, b := p[0], p[1]
+		if a == b || g.adjacent[a][b] {
+			continue
+		}
+		if g.adjacent[a] == nil {
+			g.adjacent[a] = make(map[string]bool)
+		}
+		if g.adjacent[b] == nil {
+			g.adjacent[b] = make(map[string]bool)
+		}
+		g.adjacent[a][b] = true
+		g.adjacent[b][a] = true
+	}
+	return g
+}
+
+// Degree returns the number of statements node contradicts
+func (g *Graph) Degree(node string) int {
+	return len(g.adjacent[node])
+}
+
+// Component is a connected set of mutually reachable statements
+type Component struct {
+	Nodes []string
+	Edges int
+	// Cyclic is set when the contradictions form a cycle (A contradicts B,
+	// B contradicts C and C contradicts A), i.e. there are at least as many
+	// edges as nodes
+	Cyclic bool
+}
+
+// Components returns the connected components, largest first. Node IDs are
+// sorted within each component.
+func (g *Graph) Components() []Component {
+	seen := make(map[string]bool, len(g.adjacent))
+	var components []Component
+	for _, start := range g.nodes() {
+		if seen[start] {
+			continue
+		}
+
+		var nodes []string
+		degrees := 0
+		queue := []string{start}
+		seen[start] = true
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			nodes = append(nodes, node)
+			degrees += len(g.adjacent[node])
+			for next := range g.adjacent[node] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		sort.Strings(nodes)
+		edges := degrees / 2
+		components = append(components, Component{Nodes: nodes, Edges: edges, Cyclic: edges >= len(nodes)})
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i].Nodes) > len(components[j].Nodes)
+	})
+	return components
+}
+
+// Chain is a path A - B - C where A contradicts B and B contradicts C, but A
+// and C do not contradict each other
+type Chain [3]string
+
+// Chains returns up to limit chains (all if limit <= 0), ordered by middle
+// statement and then by ends. Each chain is reported once, with the ends in
+// sorted order.
+func (g *Graph) Chains(limit int) []Chain {
+	var chains []Chain
+	for _, middle := range g.nodes() {
+		neighbors := make([]string, 0, len(g.adjacent[middle]))
+		for n := range g.adjacent[middle] {
+			neighbors = append(neighbors, n)
+		}
+		sort.Strings(neighbors)
+
+		for i, a := range neighbors {
+			for _, c := range neighbors[i+1:] {
+				if g.adjacent[a][c] {
+					continue
+				}
+				chains = append(chains, Chain{a, middle, c})
+				if limit > 0 && len(chains) >= limit {
+					return chains
+				}
+			}
+		}
+	}
+	return chains
+}
+
+// nodes returns all node IDs, sorted
+func (g *Graph) nodes() []string {
+	nodes := make([]string, 0, len(g.adjacent))
+	for n := range g.adjacent {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
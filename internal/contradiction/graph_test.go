@@ -0,0 +1,41 @@
+package contradiction
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraph_ComponentsAndChains(t *testing.T) {
+	g := NewGraph([][2]string{
+		{"a", "b"}, {"b", "c"}, {"b", "a"}, // chain a - b - c, duplicate edge
+		{"x", "y"}, {"y", "z"}, {"z", "x"}, // triangle
+		{"q", "q"}, // self-loop
+	})
+
+	components := g.Components()
+	want := []Component{
+		{Nodes: []string{"a", "b", "c"}, Edges: 2},
+		{Nodes: []string{"x", "y", "z"}, Edges: 3, Cyclic: true},
+	}
+	if !reflect.DeepEqual(components, want) {
+		t.Errorf("expected components %+v, got %+v", want, components)
+	}
+
+	if chains := g.Chains(0); !reflect.DeepEqual(chains, []Chain{{"a", "b", "c"}}) {
+		t.Errorf("expected the single chain a-b-c, got %v", chains)
+	}
+	if g.Degree("b") != 2 || g.Degree("q") != 0 {
+		t.Errorf("unexpected degrees b=%d q=%d", g.Degree("b"), g.Degree("q"))
+	}
+}
+
+func TestGraph_ChainsLimit(t *testing.T) {
+	// A star has a chain between every pair of leaves
+	g := NewGraph([][2]string{{"hub", "a"}, {"hub", "b"}, {"hub", "c"}, {"hub", "d"}})
+	if got := len(g.Chains(0)); got != 6 {
+		t.Errorf("expected 6 chains, got %d", got)
+	}
+	if got := len(g.Chains(4)); got != 4 {
+		t.Errorf("expected the limit of 4 chains, got %d", got)
+	}
+}
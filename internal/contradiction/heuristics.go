@@ -0,0 +1,143 @@
+package contradiction
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// negationWords mark a negated statement, in English and German. Words
+// ending in "n't" count too.
+var negationWords = setOf(
+	"not", "no", "never", "none", "nobody", "nothing", "neither", "nor", "cannot", "without",
+	"nicht", "nie", "niemals", "kein", "keine", "keinen", "keinem", "keiner", "keines", "ohne",
+)
+
+// antonyms pair words whose presence on opposite sides of a pair suggests a
+// contradiction. Each pair is listed once; lookups go both ways.
+var antonyms = [][2]string{
+	{"always", "never"},
+	{"all", "none"},
+	{"required", "optional"},
+	{"mandatory", "optional"},
+	{"allowed", "forbidden"},
+	{"allowed", "prohibited"},
+	{"permitted", "prohibited"},
+	{"enabled", "disabled"},
+	{"increase", "decrease"},
+	{"increases", "decreases"},
+	{"before", "after"},
+	{"include", "exclude"},
+	{"includes", "excludes"},
+	{"accept", "reject"},
+	{"accepted", "rejected"},
+	{"minimum", "maximum"},
+	{"true", "false"},
+	{"public", "private"},
+	{"immer", "nie"},
+	{"erlaubt", "verboten"},
+	{"pflicht", "optional"},
+	{"vor", "nach"},
+}
+
+// likelihood scores how likely a pair is to contradict, from 0 to 3, with
+// one point each for a negation on only one side, an antonym on opposite
+// sides and differing numbers. It is a cheap heuristic for ranking pairs,
+// not a verdict.
+func likelihood(pair StatementPair) int {
+	words1, numbers1 := tokenize(pair.Statement1)
+	words2, numbers2 := tokenize(pair.Statement2)
+
+	score := 0
+	if negated(words1) != negated(words2) {
+		score++
+	}
+	for _, a := range antonyms {
+		if words1[a[0]] && words2[a[1]] && !words1[a[1]] && !words2[a[0]] ||
+			words1[a[1]] && words2[a[0]] && !words1[a[0]] && !words2[a[1]] {
+			score++
+			break
+		}
+	}
+	if len(numbers1) > 0 && len(numbers2) > 0 && !slices.Equal(numbers1, numbers2) {
+		score++
+	}
+	return score
+}
+
+// rankPairs sorts pairs by likelihood, then by similarity, most promising
+// first
+func rankPairs(pairs []StatementPair) {
+	scores := make([]int, len(pairs))
+	for i, p := range pairs {
+		scores[i] = likelihood(p)
+	}
+	sort.Stable(byLikelihood{pairs, scores})
+}
+
+type byLikelihood struct {
+	pairs  []StatementPair
+	scores []int
+}
+
+func (b byLikelihood) Len() int { return len(b.pairs) }
+
+func (b byLikelihood) Less(i, j int) bool {
+	if b.scores[i] != b.scores[j] {
+		return b.scores[i] > b.scores[j]
+	}
+	return b.pairs[i].Similarity > b.pairs[j].Similarity
+}
+
+func (b byLikelihood) Swap(i, j int) {
+	b.pairs[i], b.pairs[j] = b.pairs[j], b.pairs[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
+
+// tokenize returns the lowercase words of text and its numbers, sorted
+func tokenize(text string) (map[string]bool, []string) {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '.' && r != ','
+	})
+
+	words := make(map[string]bool, len(fields))
+	var numbers []string
+	for _, f := range fields {
+		f = strings.Trim(f, "'.,")
+		if f == "" {
+			continue
+		}
+		if isNumber(f) {
+			numbers = append(numbers, f)
+			continue
+		}
+		words[f] = true
+	}
+	slices.Sort(numbers)
+	return words, numbers
+}
+
+// isNumber reports whether a token is a number such as 30, 2.5 or 1,000
+func isNumber(token string) bool {
+	digits := false
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			digits = true
+		case r != '.' && r != ',':
+			return false
+		}
+	}
+	return digits
+}
+
+// negated reports whether any word negates the statement
+func negated(words map[string]bool) bool {
+	for w := range words {
+		if negationWords[w] || strings.HasSuffix(w, "n't") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package contradiction
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLikelihood(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"The API supports pagination.", "The API supports filtering.", 0},
+		{"Refunds are available.", "Refunds are not available.", 1},
+		{"Refunds are no longer available.", "Refunds are available.", 1},
+		{"Users can't export data.", "Users can export data.", 1},
+		{"Refunds aren't available.", "Refunds are not available.", 0},
+		{"Backups always run nightly.", "Backups never run nightly.", 2}, // never is also a negation
+		{"The field is required.", "The field is optional.", 1},
+		{"Refunds take 30 days.", "Refunds take 14 days.", 1},
+		{"Refunds take 30 days.", "Refunds take 30 days at most.", 0},
+		{"The limit is 1,000 requests.", "The limit is not 2,000 requests.", 2},
+		{"Die Erstattung ist erlaubt.", "Die Erstattung ist verboten.", 1},
+		{"Die Erstattung ist möglich.", "Die Erstattung ist nicht möglich.", 1},
+	}
+
+	for _, tt := range tests {
+		if got := likelihood(StatementPair{Statement1: tt.s1, Statement2: tt.s2}); got != tt.want {
+			t.Errorf("likelihood(%q, %q) = %d, want %d", tt.s1, tt.s2, got, tt.want)
+		}
+	}
+}
+
+func TestDetectContradictions_RanksLikelyPairs(t *testing.T) {
+	backend := &fakeBackend{}
+	svc := NewService(NewAnalyzerWithBackend(backend), DefaultServiceConfig())
+
+	pairs := []StatementPair{
+		{Statement1: "the sky is blue", Statement2: "the sky is blue today", Statement1ID: "a", Statement2ID: "b", Similarity: 0.95},
+		{Statement1: "the sea is calm", Statement2: "the sea is calm at night", Statement1ID: "c", Statement2ID: "d", Similarity: 0.9},
+		{Statement1: "refunds are always allowed", Statement2: "refunds are never allowed", Statement1ID: "e", Statement2ID: "f", Similarity: 0.6},
+	}
+
+	// With a budget of one pair, the least similar but negated pair is the
+	// one analyzed
+	results, err := svc.DetectContradictions(context.Background(), pairs, DetectOptions{MaxPairs: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 1 {
+		t.Errorf("expected 1 LLM call, got %d", backend.callCount())
+	}
+	if len(results) != 1 || results[0].Statement1ID != "e" {
+		t.Errorf("expected the negated pair to be analyzed, got %+v", results)
+	}
+}
@@ -0,0 +1,275 @@
+package contradiction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Language selects the language of the analysis prompt. Statements are
+// analyzed more reliably when the prompt matches their language; the JSON
+// response contract stays the same in every language.
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageGerman  Language = "de"
+)
+
+// ParseLanguage validates a language code (empty means unset)
+func ParseLanguage(code string) (Language, error) {
+	switch l := Language(strings.ToLower(strings.TrimSpace(code))); l {
+	case "", LanguageEnglish, LanguageGerman:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unsupported language %q (expected en or de)", code)
+	}
+}
+
+// languageStopWords are frequent function words used to guess the language
+var languageStopWords = map[Language]map[string]bool{
+	LanguageEnglish: setOf("the", "and", "is", "are", "of", "to", "in", "not", "for", "with", "a", "an", "be", "must", "will"),
+	LanguageGerman:  setOf("der", "die", "das", "und", "ist", "sind", "nicht", "mit", "für", "ein", "eine", "zu", "den", "von", "auf", "wird", "werden", "muss", "dem", "des"),
+}
+
+func setOf(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// DetectLanguage guesses the language of texts by counting stop words,
+// falling back to English when there is no clear signal
+func DetectLanguage(texts []string) Language {
+	counts := make(map[Language]int)
+	for _, text := range texts {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		for _, w := range words {
+			for lang, stop := range languageStopWords {
+				if stop[w] {
+					counts[lang]++
+				}
+			}
+		}
+	}
+
+	if counts[LanguageGerman] > counts[LanguageEnglish] {
+		return LanguageGerman
+	}
+	return LanguageEnglish
+}
+
+// responseContract is the JSON schema every prompt asks for. Keys and enum
+// values are never translated so parseResponse works in all languages.
+const responseContract = `{
+  "is_contradiction": true,
+  "type": "%s",
+  "severity": "high|medium|low",
+  "explanation": "%s",
+  "confidence": 0.0-1.0
+}`
+
+const noContradictionContract = `{"is_contradiction": false}`
+
+// DefaultPromptTemplate is the English analysis prompt. Custom templates are
+// executed with PromptData.
+const DefaultPromptTemplate = `Analyze these two statements for contradictions:
+
+Statement 1{{with .Section1}} (Section: {{.}}){{end}}: "{{.Statement1}}"
+Statement 2{{with .Section2}} (Section: {{.}}){{end}}: "{{.Statement2}}"
+
+Determine if they contradict each other. If yes, respond with JSON:
+{{.ResponseFormat}}
+
+If no contradiction, respond:
+{{.NoContradiction}}
+
+Respond ONLY with valid JSON.`
+
+// germanPromptTemplate is the German version of DefaultPromptTemplate
+const germanPromptTemplate = `Analysiere diese beiden Aussagen auf Widersprüche:
+
+Aussage 1{{with .Section1}} (Abschnitt: {{.}}){{end}}: "{{.Statement1}}"
+Aussage 2{{with .Section2}} (Abschnitt: {{.}}){{end}}: "{{.Statement2}}"
+
+Entscheide, ob sie sich widersprechen. Falls ja, antworte mit JSON:
+{{.ResponseFormat}}
+
+Falls kein Widerspruch besteht, antworte:
+{{.NoContradiction}}
+
+Antworte AUSSCHLIESSLICH mit gültigem JSON. Übersetze weder die JSON-Schlüssel noch die Werte für "type" und "severity"; schreibe nur "explanation" auf Deutsch.`
+
+// explanationHints describe the explanation field in each language
+var explanationHints = map[Language]string{
+	LanguageEnglish: "brief explanation",
+	LanguageGerman:  "kurze Erklärung",
+}
+
+// PromptData is what a prompt template is executed with
+type PromptData struct {
+	Statement1 string
+	Statement2 string
+	// Section1 and Section2 are the header hierarchies the statements sit
+	// under, e.g. "Billing > Refunds", or empty
+	Section1 string
+	Section2 string
+	File1    string
+	File2    string
+	// Language is the language the statements were detected or configured in
+	Language Language
+	// Types are the contradiction types the model may answer with
+	Types []ContradictionType
+	// ResponseFormat is the JSON the model must answer with for a
+	// contradiction, NoContradiction the JSON for none
+	ResponseFormat  string
+	NoContradiction string
+}
+
+// prompt renders analysis prompts, either from the built-in localized
+// templates or from one custom template used for every language
+type prompt struct {
+	custom *template.Template
+	types  []ContradictionType
+	// version identifies the template text and types, so cached analyses
+	// made with a different prompt are not reused
+	version string
+}
+
+// builtinPrompts are the parsed default templates per language
+var builtinPrompts = map[Language]*template.Template{
+	LanguageEnglish: template.Must(template.New("en").Parse(DefaultPromptTemplate)),
+	LanguageGerman:  template.Must(template.New("de").Parse(germanPromptTemplate)),
+}
+
+// typeNamePattern restricts contradiction type names to what fits the
+// response contract unambiguously
+var typeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// newPrompt parses a custom template (empty uses the built-in prompts) and
+// adds extraTypes to the built-in contradiction types. The template is
+// executed once with sample data, so unknown fields and templates that omit
+// either statement are rejected here rather than on the first analysis.
+func newPrompt(text string, extraTypes []ContradictionType) (*prompt, error) {
+	p := &prompt{types: append([]ContradictionType(nil), BuiltinTypes...)}
+	for _, t := range extraTypes {
+		if !typeNamePattern.MatchString(string(t)) {
+			return nil, fmt.Errorf("invalid contradiction type %q: use lowercase letters, digits and underscores", t)
+		}
+		if !slices.Contains(p.types, t) {
+			p.types = append(p.types, t)
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		p.version = promptVersion("", p.types)
+		return p, nil
+	}
+	p.version = promptVersion(text, p.types)
+
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	p.custom = tmpl
+
+	sample := StatementPair{Statement1: "<sample statement 1>", Statement2: "<sample statement 2>"}
+	rendered, err := p.build(sample)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	if !strings.Contains(rendered, sample.Statement1) || !strings.Contains(rendered, sample.Statement2) {
+		return nil, fmt.Errorf("invalid prompt template: it must include {{.Statement1}} and {{.Statement2}}")
+	}
+	return p, nil
+}
+
+// defaultPrompt uses the built-in templates and types
+var defaultPrompt = &prompt{types: BuiltinTypes, version: promptVersion("", BuiltinTypes)}
+
+// promptVersion hashes a custom template (empty stands for the built-in
+// templates), the response contracts and the type names. Editing any of
+// them yields a new version.
+func promptVersion(text string, types []ContradictionType) string {
+	h := sha256.New()
+	if text == "" {
+		for _, t := range []string{DefaultPromptTemplate, germanPromptTemplate, batchResponseContract, batchNoContradictionContract} {
+			h.Write([]byte(t))
+			h.Write([]byte{0})
+		}
+	} else {
+		h.Write([]byte(text))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(responseContract))
+	h.Write([]byte{0})
+	h.Write([]byte(noContradictionContract))
+	for _, t := range types {
+		h.Write([]byte{0})
+		h.Write([]byte(t))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// promptLanguage is the language a pair is analyzed in: its own if a
+// built-in prompt exists for it, English otherwise
+func promptLanguage(lang Language) Language {
+	if _, ok := explanationHints[lang]; !ok {
+		return LanguageEnglish
+	}
+	return lang
+}
+
+// build renders the analysis prompt for a pair in the pair's language
+// (English if unset). The section of each statement, when known, is given
+// as context.
+func (p *prompt) build(pair StatementPair) (string, error) {
+	lang := promptLanguage(pair.Language)
+
+	data := PromptData{
+		Statement1:      pair.Statement1,
+		Statement2:      pair.Statement2,
+		Section1:        pair.Section1,
+		Section2:        pair.Section2,
+		File1:           pair.File1,
+		File2:           pair.File2,
+		Language:        lang,
+		Types:           p.types,
+		ResponseFormat:  fmt.Sprintf(responseContract, p.typeNames(), explanationHints[lang]),
+		NoContradiction: noContradictionContract,
+	}
+
+	tmpl := p.custom
+	if tmpl == nil {
+		tmpl = builtinPrompts[lang]
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// typeNames lists the contradiction types for the response contract
+func (p *prompt) typeNames() string {
+	names := make([]string, len(p.types))
+	for i, t := range p.types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, "|")
+}
+
+// buildPrompt renders the built-in analysis prompt for a pair
+func buildPrompt(pair StatementPair) string {
+	prompt, _ := defaultPrompt.build(pair)
+	return prompt
+}
@@ -0,0 +1,146 @@
+package contradiction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_German(t *testing.T) {
+	pair := StatementPair{
+		Statement1: "Rückerstattungen sind 30 Tage lang möglich.",
+		Statement2: "Rückerstattungen sind nicht möglich.",
+		Language:   LanguageGerman,
+	}
+
+	prompt := buildPrompt(pair)
+	for _, want := range []string{"Widersprüche", "Aussage 1", "Aussage 2", pair.Statement1, pair.Statement2} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected German prompt to contain %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Analyze these two statements") {
+		t.Error("German prompt must not contain the English instructions")
+	}
+
+	// The response contract is identical to the English prompt's
+	english := buildPrompt(StatementPair{Statement1: pair.Statement1, Statement2: pair.Statement2})
+	for _, contract := range []string{
+		`"is_contradiction": true`,
+		`"type": "direct|numerical|temporal|implicit"`,
+		`"severity": "high|medium|low"`,
+		`"confidence": 0.0-1.0`,
+		`{"is_contradiction": false}`,
+	} {
+		if !strings.Contains(prompt, contract) || !strings.Contains(english, contract) {
+			t.Errorf("expected both prompts to contain %s", contract)
+		}
+	}
+
+	// A German reply in that contract parses like an English one
+	result, err := parseResponse(`{"is_contradiction": true, "type": "direct", "severity": "high", "explanation": "Die Aussagen widersprechen sich.", "confidence": 0.9}`, pair)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if result.Type != TypeDirect || result.Severity != SeverityHigh {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	german := []string{"Die Rückerstattung ist nicht möglich", "Der Vertrag wird mit dem Kunden geschlossen"}
+	if got := DetectLanguage(german); got != LanguageGerman {
+		t.Errorf("expected de, got %q", got)
+	}
+	english := []string{"The refund is not available", "Contracts are signed with the customer"}
+	if got := DetectLanguage(english); got != LanguageEnglish {
+		t.Errorf("expected en, got %q", got)
+	}
+	if got := DetectLanguage(nil); got != LanguageEnglish {
+		t.Errorf("expected English fallback, got %q", got)
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	if l, err := ParseLanguage(" DE "); err != nil || l != LanguageGerman {
+		t.Errorf("expected de, got %q, %v", l, err)
+	}
+	if _, err := ParseLanguage("fr"); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+}
+
+func TestBuildPrompt_Sections(t *testing.T) {
+	pair := StatementPair{
+		Statement1: "Refunds are available for 30 days.",
+		Statement2: "Refunds are not available.",
+		Section1:   "Billing > Refund Policy",
+	}
+
+	prompt := buildPrompt(pair)
+	if !strings.Contains(prompt, `Statement 1 (Section: Billing > Refund Policy): "Refunds are available for 30 days."`) {
+		t.Errorf("expected the section of statement 1:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, `Statement 2: "Refunds are not available."`) {
+		t.Errorf("expected statement 2 without a section:\n%s", prompt)
+	}
+
+	pair.Language = LanguageGerman
+	if prompt := buildPrompt(pair); !strings.Contains(prompt, "Aussage 1 (Abschnitt: Billing > Refund Policy)") {
+		t.Errorf("expected the localized section label:\n%s", prompt)
+	}
+}
+
+func TestNewPrompt_CustomTemplate(t *testing.T) {
+	text := `Medical guideline review ({{.Language}}).
+A: {{.Statement1}}
+B: {{.Statement2}}
+Types: {{range $i, $t := .Types}}{{if $i}}, {{end}}{{$t}}{{end}}
+{{.ResponseFormat}}`
+
+	p, err := newPrompt(text, []ContradictionType{"dosage", TypeDirect})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prompt, err := p.build(StatementPair{Statement1: "Give 5 mg daily.", Statement2: "Give 10 mg daily."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"Medical guideline review (en).",
+		"A: Give 5 mg daily.",
+		"Types: direct, numerical, temporal, implicit, dosage",
+		`"type": "direct|numerical|temporal|implicit|dosage"`,
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+
+	// A custom type in a reply is kept
+	result, err := parseResponse(`{"is_contradiction": true, "type": "dosage", "severity": "high"}`, StatementPair{})
+	if err != nil || result.Type != "dosage" {
+		t.Errorf("expected a dosage contradiction, got %+v, %v", result, err)
+	}
+}
+
+func TestNewPrompt_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		types []ContradictionType
+	}{
+		{"syntax", `{{.Statement1}} {{.Statement2`, nil},
+		{"unknown field", `{{.Statement1}} {{.Statement2}} {{.Dosage}}`, nil},
+		{"missing statement", `Compare: {{.Statement1}}`, nil},
+		{"type name", "", []ContradictionType{"Dosage Level"}},
+	}
+	for _, tt := range tests {
+		if _, err := newPrompt(tt.text, tt.types); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, err := NewAnalyzer(Config{PromptTemplate: `{{.Statement1}}`}); err == nil {
+		t.Error("expected NewAnalyzer to reject the template")
+	}
+}
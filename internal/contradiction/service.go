@@ -2,6 +2,9 @@ package contradiction
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"sort"
 )
 
@@ -16,6 +19,39 @@ type ServiceConfig struct {
 	MaxPairsToAnalyze int
 	MinSimilarity     float64
 	MaxConcurrent     int
+
+	// MinConfidence drops contradictions the model reports with a lower
+	// confidence. Analyses are cached regardless, so changing it needs no
+	// re-analysis.
+	MinConfidence float64
+
+	// BatchSize is the number of pairs sent to the LLM in one request; 1
+	// (the default) sends each pair on its own. Batches are only used with
+	// the built-in prompt, not with a custom PromptTemplate.
+	BatchSize int
+
+	// Cache, when set, stores analysis results so pairs that were already
+	// analyzed are not sent to the LLM again
+	Cache Cache
+}
+
+// MaxPairsCeiling is the hard upper bound for a per-request MaxPairs
+// override, bounding the LLM cost of a single request
+const MaxPairsCeiling = 1000
+
+// DetectOptions tunes a single DetectContradictions call
+type DetectOptions struct {
+	// Force re-analyzes pairs even if a cached result exists
+	Force bool
+	// MaxPairs overrides the service's MaxPairsToAnalyze for this call when
+	// positive; it is clamped to MaxPairsCeiling
+	MaxPairs int
+	// MinSimilarity overrides the service's MinSimilarity for this call when
+	// positive, e.g. to match a tighter candidate threshold
+	MinSimilarity float64
+	// MinConfidence overrides the service's MinConfidence for this call
+	// when set; 0 keeps every contradiction
+	MinConfidence *float64
 }
 
 // DefaultServiceConfig returns default service configuration
@@ -24,6 +60,8 @@ func DefaultServiceConfig() ServiceConfig {
 		MaxPairsToAnalyze: 100,
 		MinSimilarity:     0.5,
 		MaxConcurrent:     5,
+		MinConfidence:     0.5,
+		BatchSize:         1,
 	}
 }
 
@@ -38,6 +76,12 @@ func NewService(analyzer *Analyzer, config ServiceConfig) *Service {
 	if config.MaxConcurrent <= 0 {
 		config.MaxConcurrent = DefaultServiceConfig().MaxConcurrent
 	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = DefaultServiceConfig().MinConfidence
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultServiceConfig().BatchSize
+	}
 
 	return &Service{
 		analyzer: analyzer,
@@ -45,34 +89,192 @@ func NewService(analyzer *Analyzer, config ServiceConfig) *Service {
 	}
 }
 
+// MinSimilarity returns the similarity a pair needs to be analyzed
+func (s *Service) MinSimilarity() float64 {
+	return s.config.MinSimilarity
+}
+
+// MinConfidence returns the confidence a contradiction found by a call with
+// opts needs to be reported
+func (s *Service) MinConfidence(opts DetectOptions) float64 {
+	if opts.MinConfidence != nil {
+		return *opts.MinConfidence
+	}
+	return s.config.MinConfidence
+}
+
+// MaxPairs returns the number of pairs a call with opts analyzes at most
+func (s *Service) MaxPairs(opts DetectOptions) int {
+	if opts.MaxPairs <= 0 {
+		return s.config.MaxPairsToAnalyze
+	}
+	if opts.MaxPairs > MaxPairsCeiling {
+		return MaxPairsCeiling
+	}
+	return opts.MaxPairs
+}
+
 // DetectContradictions finds contradictions in statement pairs
-func (s *Service) DetectContradictions(ctx context.Context, pairs []StatementPair) ([]ContradictionResult, error) {
+func (s *Service) DetectContradictions(ctx context.Context, pairs []StatementPair, opts DetectOptions) ([]ContradictionResult, error) {
 	// Filter pairs by similarity threshold
-	filtered := filterPairs(pairs, s.config.MinSimilarity)
+	minSimilarity := s.config.MinSimilarity
+	if opts.MinSimilarity > 0 {
+		minSimilarity = opts.MinSimilarity
+	}
+	filtered := filterPairs(pairs, minSimilarity)
 
 	// Limit number of pairs to analyze
-	if len(filtered) > s.config.MaxPairsToAnalyze {
-		// Sort by similarity and take top N
-		sort.Slice(filtered, func(i, j int) bool {
-			return filtered[i].Similarity > filtered[j].Similarity
-		})
-		filtered = filtered[:s.config.MaxPairsToAnalyze]
+	maxPairs := s.MaxPairs(opts)
+	if len(filtered) > maxPairs {
+		// Spend the budget on the pairs most likely to contradict and take
+		// top N
+		rankPairs(filtered)
+		filtered = filtered[:maxPairs]
 	}
 
-	// Analyze pairs
-	results, err := s.analyzer.AnalyzePairs(ctx, filtered, s.config.MaxConcurrent)
-	if err != nil {
+	// Reuse cached analyses and only send the remaining pairs to the LLM
+	results := make([]ContradictionResult, 0)
+	pending := filtered
+	if s.config.Cache != nil && !opts.Force {
+		results, pending = s.fromCache(ctx, filtered)
+	}
+
+	outcomes := s.analyzer.analyzeAll(ctx, pending, s.config.BatchSize, s.config.MaxConcurrent)
+	for _, o := range outcomes {
+		if o.err != nil {
+			continue // Not cached, so the pair is retried next time
+		}
+		s.storeInCache(ctx, o.pair, o.result)
+		if o.result != nil && o.result.Type != "" {
+			results = append(results, *o.result)
+		}
+	}
+
+	// Report an outage rather than an empty list when most pairs failed
+	if err := checkOutcomes(outcomes); err != nil {
 		return nil, err
 	}
 
+	// Drop low-confidence noise
+	minConfidence := s.MinConfidence(opts)
+	confident := results[:0]
+	for _, r := range results {
+		if r.Confidence >= minConfidence {
+			confident = append(confident, r)
+		}
+	}
+	results = confident
+
 	// Sort results by severity
-	sort.Slice(results, func(i, j int) bool {
+	sort.SliceStable(results, func(i, j int) bool {
 		return severityOrder(results[i].Severity) > severityOrder(results[j].Severity)
 	})
 
 	return results, nil
 }
 
+// AnalyzePair analyzes a single pair regardless of its similarity and of
+// any cached analysis, and caches the outcome. It returns nil if the pair
+// does not contradict. A rate limit or server-side failure is returned
+// wrapping ErrRateLimited.
+func (s *Service) AnalyzePair(ctx context.Context, pair StatementPair) (*ContradictionResult, error) {
+	result, err := s.analyzer.AnalyzePair(ctx, pair)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Temporary() {
+			return nil, fmt.Errorf("%w: %v", ErrRateLimited, err)
+		}
+		return nil, err
+	}
+
+	s.storeInCache(ctx, pair, result)
+	if result == nil || result.Type == "" {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// InvalidateDocument drops cached analyses for a document's statements
+func (s *Service) InvalidateDocument(ctx context.Context, documentID string) error {
+	if s.config.Cache == nil {
+		return nil
+	}
+	return s.config.Cache.InvalidateDocument(ctx, documentID)
+}
+
+// fromCache splits pairs into cached contradictions and pairs still to analyze
+func (s *Service) fromCache(ctx context.Context, pairs []StatementPair) ([]ContradictionResult, []StatementPair) {
+	keys := make([]PairKey, 0, len(pairs))
+	for _, p := range pairs {
+		if p.Statement1ID != "" && p.Statement2ID != "" {
+			keys = append(keys, s.cacheKey(p))
+		}
+	}
+
+	cached, err := s.config.Cache.Lookup(ctx, keys)
+	if err != nil {
+		log.Printf("[contradictions] cache lookup failed: %v", err)
+		return []ContradictionResult{}, pairs
+	}
+
+	results := make([]ContradictionResult, 0)
+	pending := make([]StatementPair, 0, len(pairs))
+	for _, p := range pairs {
+		a, ok := cached[s.cacheKey(p)]
+		if !ok || p.Statement1ID == "" || p.Statement2ID == "" {
+			pending = append(pending, p)
+			continue
+		}
+		if a.IsContradiction && a.Type != "" {
+			results = append(results, ContradictionResult{
+				Statement1:   p.Statement1,
+				Statement2:   p.Statement2,
+				Statement1ID: p.Statement1ID,
+				Statement2ID: p.Statement2ID,
+				File1:        p.File1,
+				File2:        p.File2,
+				Type:         a.Type,
+				Severity:     a.Severity,
+				Explanation:  a.Explanation,
+				Confidence:   a.Confidence,
+			})
+		}
+	}
+
+	return results, pending
+}
+
+// cacheKey returns the cache key of a pair in its prompt language and the
+// analyzer's prompt version
+func (s *Service) cacheKey(p StatementPair) PairKey {
+	key := NewPairKey(p.Statement1ID, p.Statement2ID)
+	key.Language = promptLanguage(p.Language)
+	key.PromptVersion = s.analyzer.prompt.version
+	return key
+}
+
+// storeInCache records the outcome of analyzing a pair (nil means no contradiction)
+func (s *Service) storeInCache(ctx context.Context, pair StatementPair, result *ContradictionResult) {
+	if s.config.Cache == nil || pair.Statement1ID == "" || pair.Statement2ID == "" {
+		return
+	}
+
+	a := CachedAnalysis{}
+	if result != nil && result.Type != "" {
+		a = CachedAnalysis{
+			IsContradiction: true,
+			Type:            result.Type,
+			Severity:        result.Severity,
+			Explanation:     result.Explanation,
+			Confidence:      result.Confidence,
+		}
+	}
+
+	if err := s.config.Cache.Store(ctx, s.cacheKey(pair), a); err != nil {
+		log.Printf("[contradictions] failed to cache analysis: %v", err)
+	}
+}
+
 // GroupBySeverity groups contradictions by severity level
 func GroupBySeverity(results []ContradictionResult) map[Severity][]ContradictionResult {
 	grouped := make(map[Severity][]ContradictionResult)
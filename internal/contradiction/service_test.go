@@ -0,0 +1,328 @@
+package contradiction
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeBackend reports a contradiction when both statements mention "never"
+// or "always", and counts calls. Prompts containing failOn get a 429.
+type fakeBackend struct {
+	mu     sync.Mutex
+	calls  int
+	failOn string
+}
+
+func (b *fakeBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	b.mu.Lock()
+	b.calls++
+	b.mu.Unlock()
+	if b.failOn != "" && strings.Contains(prompt, b.failOn) {
+		return "", &APIError{StatusCode: http.StatusTooManyRequests}
+	}
+	if strings.Contains(prompt, "never") && strings.Contains(prompt, "always") {
+		return `{"is_contradiction": true, "type": "direct", "severity": "high", "explanation": "x", "confidence": 0.9}`, nil
+	}
+	return `{"is_contradiction": false}`, nil
+}
+
+func (b *fakeBackend) callCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+// memCache is an in-memory Cache
+type memCache struct {
+	mu    sync.Mutex
+	items map[PairKey]CachedAnalysis
+}
+
+func (c *memCache) Lookup(ctx context.Context, keys []PairKey) (map[PairKey]CachedAnalysis, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[PairKey]CachedAnalysis)
+	for _, k := range keys {
+		if a, ok := c.items[k]; ok {
+			result[k] = a
+		}
+	}
+	return result, nil
+}
+
+func (c *memCache) Store(ctx context.Context, key PairKey, a CachedAnalysis) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = a
+	return nil
+}
+
+func (c *memCache) InvalidateDocument(ctx context.Context, documentID string) error {
+	return nil
+}
+
+func TestDetectContradictions_Cache(t *testing.T) {
+	backend := &fakeBackend{}
+	cache := &memCache{items: map[PairKey]CachedAnalysis{}}
+	config := DefaultServiceConfig()
+	config.Cache = cache
+	svc := NewService(NewAnalyzerWithBackend(backend), config)
+
+	pairs := []StatementPair{
+		{Statement1: "refunds are never allowed", Statement2: "refunds are always allowed", Statement1ID: "b", Statement2ID: "a", Similarity: 0.9},
+		{Statement1: "the sky is blue", Statement2: "the sky is clear", Statement1ID: "c", Statement2ID: "d", Similarity: 0.8},
+	}
+
+	first, err := svc.DetectContradictions(context.Background(), pairs, DetectOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", backend.callCount())
+	}
+	if len(first) != 1 {
+		t.Fatalf("expected 1 contradiction, got %d", len(first))
+	}
+
+	// Both the contradiction and the non-contradiction are served from cache,
+	// regardless of pair order
+	swapped := []StatementPair{pairs[1], {
+		Statement1: pairs[0].Statement2, Statement2: pairs[0].Statement1,
+		Statement1ID: "a", Statement2ID: "b", Similarity: 0.9,
+	}}
+	second, err := svc.DetectContradictions(context.Background(), swapped, DetectOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 2 {
+		t.Errorf("expected cached results to skip the LLM, got %d calls", backend.callCount())
+	}
+	if len(second) != 1 || second[0].Type != TypeDirect || second[0].Statement1ID != "a" {
+		t.Errorf("unexpected cached results: %+v", second)
+	}
+
+	// Force re-analyzes every pair
+	if _, err := svc.DetectContradictions(context.Background(), pairs, DetectOptions{Force: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 4 {
+		t.Errorf("expected force to re-analyze both pairs, got %d calls", backend.callCount())
+	}
+
+	// Analyses made in another language are not reused
+	german := append([]StatementPair(nil), pairs...)
+	for i := range german {
+		german[i].Language = LanguageGerman
+	}
+	if _, err := svc.DetectContradictions(context.Background(), german, DetectOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 6 {
+		t.Errorf("expected a language change to re-analyze both pairs, got %d calls", backend.callCount())
+	}
+
+	// Nor are analyses made with another prompt
+	analyzer, err := NewAnalyzer(Config{PromptTemplate: "Compare {{.Statement1}} with {{.Statement2}}. {{.ResponseFormat}}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	analyzer.backend = backend
+	custom := NewService(analyzer, config)
+	if _, err := custom.DetectContradictions(context.Background(), pairs, DetectOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.callCount() != 8 {
+		t.Errorf("expected a prompt change to re-analyze both pairs, got %d calls", backend.callCount())
+	}
+}
+
+func TestPromptVersion(t *testing.T) {
+	builtin, err := newPrompt("", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if builtin.version != defaultPrompt.version {
+		t.Errorf("expected the built-in prompt to match defaultPrompt, got %q and %q", builtin.version, defaultPrompt.version)
+	}
+
+	extra, err := newPrompt("", []ContradictionType{"dosage"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	custom, err := newPrompt("{{.Statement1}} vs {{.Statement2}}", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if extra.version == builtin.version || custom.version == builtin.version || custom.version == extra.version {
+		t.Errorf("expected distinct versions, got %q, %q and %q", builtin.version, extra.version, custom.version)
+	}
+}
+
+func TestDetectContradictions_RateLimited(t *testing.T) {
+	pairs := []StatementPair{
+		{Statement1: "limited a", Statement2: "limited b", Similarity: 0.9},
+		{Statement1: "limited c", Statement2: "limited d", Similarity: 0.9},
+		{Statement1: "fine e", Statement2: "fine f", Similarity: 0.9},
+	}
+
+	tests := []struct {
+		name    string
+		failOn  string
+		wantErr bool
+	}{
+		{"majority rate limited", "limited", true},
+		{"minority rate limited", "fine", false},
+		{"no failures", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{failOn: tt.failOn}
+			svc := NewService(NewAnalyzerWithBackend(backend), DefaultServiceConfig())
+
+			_, err := svc.DetectContradictions(context.Background(), pairs, DetectOptions{})
+			if tt.wantErr != errors.Is(err, ErrRateLimited) {
+				t.Errorf("expected ErrRateLimited=%v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAnalyzePair(t *testing.T) {
+	backend := &fakeBackend{failOn: "limited"}
+	cache := &memCache{items: map[PairKey]CachedAnalysis{}}
+	config := DefaultServiceConfig()
+	config.Cache = cache
+	svc := NewService(NewAnalyzerWithBackend(backend), config)
+
+	// Dissimilar pairs are analyzed too, and the outcome is cached
+	pair := StatementPair{Statement1: "refunds are never allowed", Statement2: "refunds are always allowed", Statement1ID: "a", Statement2ID: "b", Similarity: 0.1}
+	result, err := svc.AnalyzePair(context.Background(), pair)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.Type != TypeDirect {
+		t.Fatalf("expected a direct contradiction, got %+v", result)
+	}
+	if a, ok := cache.items[svc.cacheKey(pair)]; !ok || !a.IsContradiction {
+		t.Errorf("expected the contradiction to be cached, got %+v", cache.items)
+	}
+
+	result, err = svc.AnalyzePair(context.Background(), StatementPair{Statement1: "the sky is blue", Statement2: "the sky is clear"})
+	if err != nil || result != nil {
+		t.Errorf("expected no contradiction and no error, got %+v, %v", result, err)
+	}
+
+	if _, err := svc.AnalyzePair(context.Background(), StatementPair{Statement1: "limited a", Statement2: "limited b"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+// batchBackend answers batch prompts with reply and single-pair prompts like
+// fakeBackend
+type batchBackend struct {
+	fakeBackend
+	reply string
+}
+
+func (b *batchBackend) Complete(ctx context.Context, prompt string) (string, error) {
+	if strings.Contains(prompt, "Pair 1:") {
+		b.mu.Lock()
+		b.calls++
+		b.mu.Unlock()
+		return b.reply, nil
+	}
+	return b.fakeBackend.Complete(ctx, prompt)
+}
+
+func TestDetectContradictions_Batch(t *testing.T) {
+	pairs := []StatementPair{
+		{Statement1: "the sky is blue", Statement2: "the sky is green", Statement1ID: "a", Statement2ID: "b", Similarity: 0.9},
+		{Statement1: "refunds are never allowed", Statement2: "refunds are always allowed", Statement1ID: "c", Statement2ID: "d", Similarity: 0.9},
+		{Statement1: "the sea is wet", Statement2: "the sea is deep", Statement1ID: "e", Statement2ID: "f", Similarity: 0.9},
+	}
+
+	tests := []struct {
+		name      string
+		reply     string
+		wantCalls int
+		wantIDs   []string
+	}{
+		{
+			name:      "every pair answered",
+			reply:     `[{"pair": 1, "is_contradiction": true, "type": "direct", "severity": "high", "explanation": "x", "confidence": 0.8}, {"pair": 2, "is_contradiction": false}, {"pair": 3, "is_contradiction": false}]`,
+			wantCalls: 1,
+			wantIDs:   []string{"a"},
+		},
+		{
+			name:      "answers matched by position",
+			reply:     "```json\n[{\"is_contradiction\": false}, {\"is_contradiction\": true, \"type\": \"direct\", \"severity\": \"low\", \"explanation\": \"x\", \"confidence\": 0.5}, {\"is_contradiction\": false}]\n```",
+			wantCalls: 1,
+			wantIDs:   []string{"c"},
+		},
+		{
+			name:      "missing pair analyzed on its own",
+			reply:     `[{"pair": 3, "is_contradiction": false}, {"pair": 1, "is_contradiction": false}]`,
+			wantCalls: 2,
+			wantIDs:   []string{"c"},
+		},
+		{
+			name:      "malformed reply falls back to every pair",
+			reply:     `I cannot answer in JSON`,
+			wantCalls: 4,
+			wantIDs:   []string{"c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &batchBackend{reply: tt.reply}
+			config := DefaultServiceConfig()
+			config.BatchSize = 3
+			svc := NewService(NewAnalyzerWithBackend(backend), config)
+
+			results, err := svc.DetectContradictions(context.Background(), pairs, DetectOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if backend.callCount() != tt.wantCalls {
+				t.Errorf("expected %d LLM calls, got %d", tt.wantCalls, backend.callCount())
+			}
+			var ids []string
+			for _, r := range results {
+				ids = append(ids, r.Statement1ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("expected contradictions %v, got %v", tt.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestBatchPairs(t *testing.T) {
+	pairs := []StatementPair{
+		{Statement1ID: "1", Language: LanguageEnglish},
+		{Statement1ID: "2", Language: LanguageGerman},
+		{Statement1ID: "3", Language: LanguageEnglish},
+		{Statement1ID: "4", Language: LanguageEnglish},
+	}
+
+	var got []string
+	for _, batch := range batchPairs(pairs, 2) {
+		var ids []string
+		for _, p := range batch {
+			ids = append(ids, p.Statement1ID)
+		}
+		got = append(got, strings.Join(ids, ""))
+	}
+	if strings.Join(got, " ") != "13 2 4" {
+		t.Errorf("expected batches [13 2 4], got %v", got)
+	}
+}
@@ -10,6 +10,10 @@ const (
 	TypeImplicit  ContradictionType = "implicit"
 )
 
+// BuiltinTypes are the contradiction types every prompt offers; Config.Types
+// adds domain-specific ones
+var BuiltinTypes = []ContradictionType{TypeDirect, TypeNumerical, TypeTemporal, TypeImplicit}
+
 // Severity represents contradiction severity
 type Severity string
 
@@ -27,7 +31,10 @@ type StatementPair struct {
 	Statement2ID string
 	File1        string
 	File2        string
+	Section1     string // Header hierarchy of Statement1, e.g. "Billing > Refunds"
+	Section2     string
 	Similarity   float64
+	Language     Language // Prompt language; English if empty
 }
 
 // ContradictionResult represents a detected contradiction
@@ -27,6 +27,7 @@ type Client struct {
 	model         string
 	batchSize     int
 	maxConcurrent int
+	limiter       *rateLimiter
 }
 
 // ClientOption configures the Client
@@ -60,6 +61,20 @@ func WithMaxConcurrent(n int) ClientOption {
 	}
 }
 
+// WithRateLimit caps outgoing API requests to rps per second, allowing
+// bursts of up to burst requests. This is independent of WithMaxConcurrent:
+// concurrency bounds in-flight requests, the rate limit bounds how fast new
+// ones start. A non-positive rps disables rate limiting.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		if rps <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(rps, burst)
+	}
+}
+
 // WithTimeout sets the HTTP client timeout
 func WithTimeout(d time.Duration) ClientOption {
 	return func(c *Client) {
@@ -87,10 +102,32 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 	return c
 }
 
+// ProgressFunc is called after each successful batch with the number of
+// texts embedded so far and the total. Calls are serialized.
+type ProgressFunc func(embedded, total int)
+
 // EmbedTexts generates embeddings for a list of texts
 func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.EmbedTextsWithProgress(ctx, texts, nil)
+}
+
+// EmbedTextsWithProgress is EmbedTexts reporting batch completion to progress,
+// which may be nil
+func (c *Client) EmbedTextsWithProgress(ctx context.Context, texts []string, progress ProgressFunc) ([][]float32, error) {
+	embeddings, _, err := c.EmbedTextsWithUsage(ctx, texts, progress)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// EmbedTextsWithUsage is EmbedTextsWithProgress also returning the total
+// tokens consumed across batches, as reported by the API. On error the
+// embeddings and tokens of the batches that succeeded are still returned,
+// since they were billed; the embeddings of texts in failed batches are nil.
+func (c *Client) EmbedTextsWithUsage(ctx context.Context, texts []string, progress ProgressFunc) ([][]float32, int, error) {
 	if len(texts) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Split into batches
@@ -107,6 +144,8 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, e
 	var mu sync.Mutex
 	var firstErr error
 	var completedBatches int
+	var embeddedTexts int
+	var totalTokens int
 
 	resultOffset := 0
 	for batchIdx, batch := range batches {
@@ -121,7 +160,7 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, e
 			defer func() { <-sem }() // Release
 
 			batchStartTime := time.Now()
-			embeddings, err := c.embedBatch(ctx, batch)
+			embeddings, tokens, err := c.embedBatch(ctx, batch)
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -142,6 +181,12 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, e
 			for i, emb := range embeddings {
 				results[start+i] = emb
 			}
+			totalTokens += tokens
+
+			embeddedTexts += len(batch)
+			if progress != nil {
+				progress(embeddedTexts, len(texts))
+			}
 		}(batchIdx, batch, batchStart)
 	}
 
@@ -149,11 +194,86 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, e
 
 	if firstErr != nil {
 		log.Printf("[embeddings] completed with errors: %d/%d batches succeeded", completedBatches-1, totalBatches)
-		return nil, firstErr
+		return results, totalTokens, firstErr
 	}
 
-	log.Printf("[embeddings] all %d batches completed successfully", totalBatches)
-	return results, nil
+	log.Printf("[embeddings] all %d batches completed successfully (%d tokens)", totalBatches, totalTokens)
+	return results, totalTokens, nil
+}
+
+// StreamFunc receives the embeddings of texts[start:start+len(embeddings)]
+// and the tokens the batch consumed as one batch completes. Returning an
+// error stops the stream.
+type StreamFunc func(start int, embeddings [][]float32, tokens int) error
+
+// EmbedTextsStream embeds texts batch by batch like EmbedTexts, but hands
+// each batch to fn as soon as it completes instead of collecting the whole
+// result, so callers can persist embeddings incrementally. Batches may
+// complete out of order; calls to fn are serialized. The first batch error
+// or error returned by fn cancels the batches still running and is
+// returned. If ctx ends, its error is returned only when some batch was
+// never delivered.
+func (c *Client) EmbedTextsStream(ctx context.Context, texts []string, fn StreamFunc) error {
+	if len(texts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := c.splitIntoBatches(texts)
+	log.Printf("[embeddings] streaming %d texts in %d batches (batch size: %d, max concurrent: %d)",
+		len(texts), len(batches), c.batchSize, c.maxConcurrent)
+
+	// Workers pull batch indexes, so at most maxConcurrent batches are held
+	// in memory at once
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	delivered := 0
+
+	for w := 0; w < c.maxConcurrent && w < len(batches); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range next {
+				embeddings, tokens, err := c.embedBatch(ctx, batches[idx])
+
+				mu.Lock()
+				if firstErr == nil {
+					if err != nil {
+						firstErr = fmt.Errorf("batch %d: %w", idx, err)
+					} else if err := fn(idx*c.batchSize, embeddings, tokens); err != nil {
+						firstErr = err
+					} else {
+						delivered++
+					}
+					if firstErr != nil {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for idx := range batches {
+		select {
+		case next <- idx:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr == nil && delivered < len(batches) {
+		// The caller's context ended before every batch was started
+		firstErr = ctx.Err()
+	}
+	return firstErr
 }
 
 // EmbedText generates an embedding for a single text
@@ -168,6 +288,11 @@ func (c *Client) EmbedText(ctx context.Context, text string) ([]float32, error)
 	return results[0], nil
 }
 
+// Model returns the configured embedding model
+func (c *Client) Model() string {
+	return c.model
+}
+
 // GetDimension returns the embedding dimension for the configured model
 func (c *Client) GetDimension() int {
 	return GetEmbeddingDimension(c.model)
@@ -185,7 +310,14 @@ func (c *Client) splitIntoBatches(texts []string) [][]string {
 	return batches
 }
 
-func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+// embedBatch embeds one batch, returning the embeddings and the tokens used
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, int, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, 0, fmt.Errorf("rate limit wait: %w", err)
+		}
+	}
+
 	reqBody := EmbeddingRequest{
 		Model: c.model,
 		Input: texts,
@@ -193,12 +325,12 @@ func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, e
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+		return nil, 0, fmt.Errorf("marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewReader(jsonBody))
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, 0, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -206,23 +338,23 @@ func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, e
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("do request (timeout=%v): %w", c.httpClient.Timeout, err)
+		return nil, 0, fmt.Errorf("do request (timeout=%v): %w", c.httpClient.Timeout, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, 0, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[embeddings] API error: status=%d, body=%s", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, 0, parseAPIError(resp.StatusCode, body)
 	}
 
 	var embResp EmbeddingResponse
 	if err := json.Unmarshal(body, &embResp); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+		return nil, 0, fmt.Errorf("unmarshal response: %w", err)
 	}
 
 	// Sort by index to ensure order matches input
@@ -233,5 +365,5 @@ func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, e
 		}
 	}
 
-	return embeddings, nil
+	return embeddings, embResp.Usage.TotalTokens, nil
 }
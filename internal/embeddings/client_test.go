@@ -0,0 +1,288 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFakeEmbeddingServer returns a server answering /embeddings with
+// fixed 3-dim vectors, reporting 10 tokens per input and recording the
+// arrival time of each request
+func newFakeEmbeddingServer(t *testing.T) (*httptest.Server, *[]time.Time, *sync.Mutex) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var arrivals []time.Time
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+
+		var req EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		resp := EmbeddingResponse{Model: req.Model}
+		for i := range req.Input {
+			resp.Data = append(resp.Data, EmbeddingData{Index: i, Embedding: []float32{1, 0, 0}})
+		}
+		resp.Usage.TotalTokens = 10 * len(req.Input)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &arrivals, &mu
+}
+
+func TestClient_EmbedTextsWithUsage(t *testing.T) {
+	srv, _, _ := newFakeEmbeddingServer(t)
+	client := NewClient("test-key", WithBaseURL(srv.URL), WithBatchSize(2))
+
+	texts := []string{"a", "b", "c", "d", "e"}
+	embeddings, tokens, err := client.EmbedTextsWithUsage(context.Background(), texts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embeddings) != len(texts) {
+		t.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+	if tokens != 50 {
+		t.Errorf("expected 50 tokens summed over 3 batches, got %d", tokens)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	srv, arrivals, mu := newFakeEmbeddingServer(t)
+
+	const rps = 20.0
+	client := NewClient("test-key",
+		WithBaseURL(srv.URL),
+		WithBatchSize(1),
+		WithMaxConcurrent(10),
+		WithRateLimit(rps, 1),
+	)
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = "text"
+	}
+
+	start := time.Now()
+	embs, err := client.EmbedTexts(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if len(embs) != len(texts) {
+		t.Fatalf("expected %d embeddings, got %d", len(texts), len(embs))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(*arrivals) != len(texts) {
+		t.Fatalf("expected %d requests, got %d", len(texts), len(*arrivals))
+	}
+
+	// With a burst of 1, n requests need at least (n-1)/rps seconds
+	minElapsed := time.Duration(float64(len(texts)-1) / rps * float64(time.Second))
+	if elapsed < minElapsed-10*time.Millisecond {
+		t.Errorf("expected requests to take at least %v, took %v", minElapsed, elapsed)
+	}
+
+	// No sliding one-second window may contain more than rps+burst requests
+	for i, a := range *arrivals {
+		count := 0
+		for _, b := range (*arrivals)[i:] {
+			if b.Sub(a) < time.Second {
+				count++
+			}
+		}
+		if count > int(rps)+1 {
+			t.Errorf("window starting at request %d saw %d requests, limit %v", i, count, rps)
+		}
+	}
+}
+
+func TestClient_RateLimitCanceled(t *testing.T) {
+	srv, _, _ := newFakeEmbeddingServer(t)
+
+	client := NewClient("test-key",
+		WithBaseURL(srv.URL),
+		WithBatchSize(1),
+		WithRateLimit(0.1, 1),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.EmbedTexts(ctx, []string{"a", "b"}); err == nil {
+		t.Error("expected error when context expires while waiting for rate limit")
+	}
+}
+
+func TestClient_StructuredError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    ErrorKind
+		message string
+	}{
+		{
+			name:    "openrouter credits",
+			status:  http.StatusPaymentRequired,
+			body:    `{"error":{"message":"Insufficient credits","code":402}}`,
+			kind:    ErrorInsufficientCredits,
+			message: "Insufficient credits",
+		},
+		{
+			name:    "invalid model",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"message":"foo/bar is not a valid model ID","code":400}}`,
+			kind:    ErrorInvalidModel,
+			message: "foo/bar is not a valid model ID",
+		},
+		{
+			name:    "openai rate limit",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			kind:    ErrorRateLimited,
+			message: "Rate limit reached",
+		},
+		{
+			name:    "unparseable body",
+			status:  http.StatusBadGateway,
+			body:    `<html>bad gateway</html>`,
+			kind:    ErrorUnavailable,
+			message: "<html>bad gateway</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-key", WithBaseURL(srv.URL))
+			_, err := client.EmbedTexts(context.Background(), []string{"hello"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %v", err)
+			}
+			if apiErr.Kind != tt.kind {
+				t.Errorf("expected kind %s, got %s", tt.kind, apiErr.Kind)
+			}
+			if apiErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, apiErr.Message)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, apiErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestClient_EmbedTextsStream(t *testing.T) {
+	srv, _, _ := newFakeEmbeddingServer(t)
+	client := NewClient("test-key", WithBaseURL(srv.URL), WithBatchSize(2), WithMaxConcurrent(2))
+
+	texts := []string{"a", "b", "c", "d", "e"}
+	received := make([][]float32, len(texts))
+	calls, tokens := 0, 0
+	err := client.EmbedTextsStream(context.Background(), texts, func(start int, embeddings [][]float32, batchTokens int) error {
+		calls++
+		tokens += batchTokens
+		for i, emb := range embeddings {
+			if received[start+i] != nil {
+				t.Errorf("text %d delivered twice", start+i)
+			}
+			received[start+i] = emb
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected one call per batch, got %d", calls)
+	}
+	if tokens != 50 {
+		t.Errorf("expected 50 tokens across batches, got %d", tokens)
+	}
+	for i, emb := range received {
+		if len(emb) != 3 {
+			t.Errorf("text %d: expected an embedding, got %v", i, emb)
+		}
+	}
+}
+
+func TestClient_EmbedTextsStreamContextEnded(t *testing.T) {
+	srv, _, _ := newFakeEmbeddingServer(t)
+	client := NewClient("test-key", WithBaseURL(srv.URL), WithBatchSize(1), WithMaxConcurrent(1))
+
+	// Ending the context after the last batch was delivered is not an error
+	ctx, cancel := context.WithCancel(context.Background())
+	texts := []string{"a", "b"}
+	calls := 0
+	err := client.EmbedTextsStream(ctx, texts, func(start int, embeddings [][]float32, tokens int) error {
+		calls++
+		if calls == len(texts) {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected no error once every batch was delivered, got %v", err)
+	}
+
+	// Ending it earlier leaves batches undelivered
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	err = client.EmbedTextsStream(ctx, []string{"a", "b", "c", "d"}, func(start int, embeddings [][]float32, tokens int) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled with batches left, got %v", err)
+	}
+}
+
+func TestClient_EmbedTextsStreamStopsOnError(t *testing.T) {
+	srv, arrivals, mu := newFakeEmbeddingServer(t)
+	client := NewClient("test-key", WithBaseURL(srv.URL), WithBatchSize(1), WithMaxConcurrent(1))
+
+	stop := errors.New("disk full")
+	texts := []string{"a", "b", "c", "d", "e"}
+	calls := 0
+	err := client.EmbedTextsStream(context.Background(), texts, func(start int, embeddings [][]float32, tokens int) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no calls after the error, got %d", calls)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*arrivals) >= len(texts) {
+		t.Errorf("expected the remaining batches to be skipped, got %d requests", len(*arrivals))
+	}
+}
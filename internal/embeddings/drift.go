@@ -0,0 +1,77 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+)
+
+// DriftReport summarizes how far statement embeddings moved after
+// re-embedding. A mean similarity near 1 means analyses computed on the old
+// vectors are still representative; lower values mean they should be redone.
+type DriftReport struct {
+	// Compared is the number of statements with both an old and a new vector
+	Compared int `json:"compared"`
+	// Skipped counts statements missing an old or new vector
+	Skipped int `json:"skipped"`
+	// Projected counts pairs of different dimensions, compared after
+	// truncating the larger vector as ReconcileProject does
+	Projected int `json:"projected"`
+	// MeanSimilarity is the average cosine similarity between old and new
+	// vectors, and MinSimilarity the lowest
+	MeanSimilarity float64 `json:"mean_similarity"`
+	MinSimilarity  float64 `json:"min_similarity"`
+}
+
+// Drift compares the old (before) and new (after) embeddings of the same
+// statements, matched by index. Vectors of different dimensions are only
+// meaningfully comparable for models that share a prefix (such as
+// text-embedding-3 small/large).
+func Drift(before, after [][]float32) (*DriftReport, error) {
+	if len(before) != len(after) {
+		return nil, fmt.Errorf("drift: %d old embeddings but %d new", len(before), len(after))
+	}
+
+	report := &DriftReport{}
+	sum := 0.0
+	for i := range before {
+		a, b := before[i], after[i]
+		if len(a) == 0 || len(b) == 0 {
+			report.Skipped++
+			continue
+		}
+		if len(a) != len(b) {
+			if len(a) > len(b) {
+				a = truncateNormalize(a, len(b))
+			} else {
+				b = truncateNormalize(b, len(a))
+			}
+			report.Projected++
+		}
+
+		sim := cosine(a, b)
+		if report.Compared == 0 || sim < report.MinSimilarity {
+			report.MinSimilarity = sim
+		}
+		sum += sim
+		report.Compared++
+	}
+
+	if report.Compared > 0 {
+		report.MeanSimilarity = sum / float64(report.Compared)
+	}
+	return report, nil
+}
+
+// cosine returns the cosine similarity of two equal-length vectors
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
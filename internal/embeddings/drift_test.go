@@ -0,0 +1,65 @@
+package embeddings
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDrift(t *testing.T) {
+	old := [][]float32{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0.6, 0.8, 0},
+	}
+
+	t.Run("unchanged model", func(t *testing.T) {
+		report, err := Drift(old, old)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Compared != 3 {
+			t.Errorf("expected 3 compared, got %d", report.Compared)
+		}
+		if math.Abs(report.MeanSimilarity-1) > 1e-9 || math.Abs(report.MinSimilarity-1) > 1e-9 {
+			t.Errorf("expected drift ~1.0, got mean %v min %v", report.MeanSimilarity, report.MinSimilarity)
+		}
+	})
+
+	t.Run("changed vectors", func(t *testing.T) {
+		changed := [][]float32{
+			{1, 0, 0},
+			{0, 0, 1},
+			{0.8, 0.6, 0},
+		}
+		report, err := Drift(old, changed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.MeanSimilarity >= 0.9 {
+			t.Errorf("expected drift well below 1, got %v", report.MeanSimilarity)
+		}
+		if math.Abs(report.MinSimilarity) > 1e-9 {
+			t.Errorf("expected min similarity 0 for orthogonal pair, got %v", report.MinSimilarity)
+		}
+	})
+
+	t.Run("missing and mismatched vectors", func(t *testing.T) {
+		report, err := Drift(
+			[][]float32{{1, 0}, {}, {1, 0}},
+			[][]float32{{1, 0, 5}, {1, 0}, {1, 0}},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Compared != 2 || report.Skipped != 1 || report.Projected != 1 {
+			t.Errorf("expected 2 compared/1 skipped/1 projected, got %+v", report)
+		}
+		if math.Abs(report.MeanSimilarity-1) > 1e-6 {
+			t.Errorf("expected truncated prefix to match, got %v", report.MeanSimilarity)
+		}
+	})
+
+	if _, err := Drift(old, old[:2]); err == nil {
+		t.Error("expected error for mismatched statement counts")
+	}
+}
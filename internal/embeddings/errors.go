@@ -0,0 +1,101 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind classifies embedding API failures
+type ErrorKind string
+
+const (
+	ErrorInsufficientCredits ErrorKind = "insufficient_credits"
+	ErrorInvalidModel        ErrorKind = "invalid_model"
+	ErrorRateLimited         ErrorKind = "rate_limited"
+	ErrorUnauthorized        ErrorKind = "unauthorized"
+	ErrorUnavailable         ErrorKind = "unavailable"
+	ErrorUnknown             ErrorKind = "unknown"
+)
+
+// APIError is returned when the embedding API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Kind       ErrorKind
+	// Message is the provider's error message, or the raw body if it could
+	// not be parsed
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d, %s): %s", e.StatusCode, e.Kind, e.Message)
+}
+
+// UserMessage returns an actionable description suitable for API clients
+func (e *APIError) UserMessage() string {
+	switch e.Kind {
+	case ErrorInsufficientCredits:
+		return "embedding provider reports insufficient credits - top up the OpenRouter account"
+	case ErrorInvalidModel:
+		return "embedding model is not available (" + e.Message + ") - check the configured model"
+	case ErrorRateLimited:
+		return "embedding provider is rate limiting requests - try again later or lower EMBEDDING_RPS"
+	case ErrorUnauthorized:
+		return "embedding provider rejected the API key - check OPENROUTER_API_KEY"
+	case ErrorUnavailable:
+		return "embedding provider is temporarily unavailable - try again later"
+	default:
+		return "embedding provider error: " + e.Message
+	}
+}
+
+// providerError is the error envelope used by OpenRouter and OpenAI. The
+// code is a number on OpenRouter and a string on OpenAI.
+type providerError struct {
+	Error struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+	} `json:"error"`
+}
+
+// maxErrorMessageLength bounds unparsed error bodies kept in messages
+const maxErrorMessageLength = 300
+
+// parseAPIError builds an APIError from a failed response body
+func parseAPIError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status}
+
+	var pe providerError
+	code := ""
+	if err := json.Unmarshal(body, &pe); err == nil && pe.Error.Message != "" {
+		e.Message = pe.Error.Message
+		code = strings.Trim(string(pe.Error.Code), `"`)
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+		if len(e.Message) > maxErrorMessageLength {
+			e.Message = e.Message[:maxErrorMessageLength] + "..."
+		}
+	}
+
+	lowerMsg := strings.ToLower(e.Message)
+	switch {
+	case status == http.StatusPaymentRequired || pe.Error.Type == "insufficient_quota" ||
+		strings.Contains(lowerMsg, "insufficient credits"):
+		e.Kind = ErrorInsufficientCredits
+	case status == http.StatusTooManyRequests:
+		e.Kind = ErrorRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		e.Kind = ErrorUnauthorized
+	case code == "model_not_found" ||
+		((status == http.StatusBadRequest || status == http.StatusNotFound) && strings.Contains(lowerMsg, "model")):
+		e.Kind = ErrorInvalidModel
+	case status >= 500:
+		e.Kind = ErrorUnavailable
+	default:
+		e.Kind = ErrorUnknown
+	}
+
+	return e
+}
@@ -15,15 +15,39 @@ const (
 
 // GetEmbeddingDimension returns the dimension for a given model
 func GetEmbeddingDimension(model string) int {
+	if dim, ok := LookupEmbeddingDimension(model); ok {
+		return dim
+	}
+	return DimTextEmbedding3Small
+}
+
+// LookupEmbeddingDimension returns the dimension of a known model
+func LookupEmbeddingDimension(model string) (int, bool) {
+	switch model {
+	case ModelTextEmbedding3Small:
+		return DimTextEmbedding3Small, true
+	case ModelTextEmbedding3Large:
+		return DimTextEmbedding3Large, true
+	case ModelTextEmbeddingAda002:
+		return DimTextEmbeddingAda002, true
+	default:
+		return 0, false
+	}
+}
+
+// PricePerMillionTokens returns the list price in USD per million input
+// tokens of a known model. Prices change; estimates built on them are
+// approximate.
+func PricePerMillionTokens(model string) (float64, bool) {
 	switch model {
 	case ModelTextEmbedding3Small:
-		return DimTextEmbedding3Small
+		return 0.02, true
 	case ModelTextEmbedding3Large:
-		return DimTextEmbedding3Large
+		return 0.13, true
 	case ModelTextEmbeddingAda002:
-		return DimTextEmbeddingAda002
+		return 0.10, true
 	default:
-		return DimTextEmbedding3Small
+		return 0, false
 	}
 }
 
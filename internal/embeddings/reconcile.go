@@ -0,0 +1,166 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ReconcileMode selects how embeddings of different dimensions are brought
+// to a common dimension before analysis
+type ReconcileMode string
+
+const (
+	// ReconcileNone leaves embeddings untouched
+	ReconcileNone ReconcileMode = ""
+	// ReconcileExclude keeps only embeddings of the most common dimension
+	ReconcileExclude ReconcileMode = "exclude"
+	// ReconcileProject truncates larger embeddings to the smallest dimension
+	// and re-normalizes them. This matches how text-embedding-3 models
+	// shorten vectors; for unrelated models the result is approximate.
+	ReconcileProject ReconcileMode = "project"
+)
+
+// ParseReconcileMode validates a reconciliation mode name (empty means none)
+func ParseReconcileMode(name string) (ReconcileMode, error) {
+	switch ReconcileMode(strings.ToLower(strings.TrimSpace(name))) {
+	case "", "none":
+		return ReconcileNone, nil
+	case ReconcileExclude:
+		return ReconcileExclude, nil
+	case ReconcileProject:
+		return ReconcileProject, nil
+	default:
+		return "", fmt.Errorf("unknown reconcile mode %q (expected none, exclude or project)", name)
+	}
+}
+
+// ReconcileResult describes the outcome of Reconcile
+type ReconcileResult struct {
+	// Embeddings holds the reconciled vectors, all of length Dimension
+	Embeddings [][]float32
+	// Indices maps each reconciled vector to its position in the input
+	Indices   []int
+	Dimension int
+	Excluded  int
+	Projected int
+}
+
+// Reconcile brings embeddings to a common dimension according to mode.
+// Empty embeddings are excluded by any mode other than ReconcileNone,
+// which returns the input unchanged.
+func Reconcile(embeddings [][]float32, mode ReconcileMode) ReconcileResult {
+	if mode == ReconcileNone {
+		result := ReconcileResult{
+			Embeddings: embeddings,
+			Indices:    make([]int, len(embeddings)),
+		}
+		for i, emb := range embeddings {
+			result.Indices[i] = i
+			if len(emb) > result.Dimension {
+				result.Dimension = len(emb)
+			}
+		}
+		return result
+	}
+
+	counts := make(map[int]int)
+	for _, emb := range embeddings {
+		if len(emb) > 0 {
+			counts[len(emb)]++
+		}
+	}
+
+	// Pick the target dimension: the most common one for exclusion (ties go
+	// to the larger, newer dimension), the smallest one for projection
+	target := 0
+	for dim, count := range counts {
+		switch mode {
+		case ReconcileProject:
+			if target == 0 || dim < target {
+				target = dim
+			}
+		default:
+			if target == 0 || count > counts[target] || (count == counts[target] && dim > target) {
+				target = dim
+			}
+		}
+	}
+
+	result := ReconcileResult{Dimension: target}
+	for i, emb := range embeddings {
+		switch {
+		case len(emb) == target:
+			result.Embeddings = append(result.Embeddings, emb)
+		case mode == ReconcileProject && len(emb) > target:
+			result.Embeddings = append(result.Embeddings, truncateNormalize(emb, target))
+			result.Projected++
+		default:
+			result.Excluded++
+			continue
+		}
+		result.Indices = append(result.Indices, i)
+	}
+
+	return result
+}
+
+// DimensionError reports embeddings that cannot be compared with the rest:
+// empty ones and ones whose dimension differs from the most common one
+type DimensionError struct {
+	Missing    int
+	Mismatched int
+	Dimension  int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%d embeddings are missing and %d differ from dimension %d", e.Missing, e.Mismatched, e.Dimension)
+}
+
+// ValidateDimensions checks that every embedding is non-empty and that all
+// have the same dimension, returning a *DimensionError otherwise. Analyses
+// silently compare garbage, or index out of range, on such input.
+func ValidateDimensions(embeddings [][]float32) error {
+	counts := make(map[int]int)
+	missing := 0
+	for _, emb := range embeddings {
+		if len(emb) == 0 {
+			missing++
+			continue
+		}
+		counts[len(emb)]++
+	}
+
+	// Ties go to the larger, newer dimension as in ReconcileExclude
+	target := 0
+	for dim, count := range counts {
+		if target == 0 || count > counts[target] || (count == counts[target] && dim > target) {
+			target = dim
+		}
+	}
+
+	mismatched := len(embeddings) - missing - counts[target]
+	if missing == 0 && mismatched == 0 {
+		return nil
+	}
+	return &DimensionError{Missing: missing, Mismatched: mismatched, Dimension: target}
+}
+
+// truncateNormalize keeps the first dim components and rescales to unit length
+func truncateNormalize(emb []float32, dim int) []float32 {
+	out := make([]float32, dim)
+	copy(out, emb[:dim])
+
+	var norm float64
+	for _, v := range out {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range out {
+		out[i] *= scale
+	}
+	return out
+}
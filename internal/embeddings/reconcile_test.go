@@ -0,0 +1,87 @@
+package embeddings
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	vecs := [][]float32{
+		{1, 0},
+		{0, 1},
+		{3, 4, 12},
+		{1, 1},
+		{},
+	}
+
+	tests := []struct {
+		name      string
+		mode      ReconcileMode
+		dimension int
+		indices   []int
+		excluded  int
+		projected int
+	}{
+		{"none", ReconcileNone, 3, []int{0, 1, 2, 3, 4}, 0, 0},
+		{"exclude", ReconcileExclude, 2, []int{0, 1, 3}, 2, 0},
+		{"project", ReconcileProject, 2, []int{0, 1, 2, 3}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Reconcile(vecs, tt.mode)
+			if got.Dimension != tt.dimension {
+				t.Errorf("expected dimension %d, got %d", tt.dimension, got.Dimension)
+			}
+			if got.Excluded != tt.excluded || got.Projected != tt.projected {
+				t.Errorf("expected %d excluded/%d projected, got %d/%d", tt.excluded, tt.projected, got.Excluded, got.Projected)
+			}
+			if len(got.Indices) != len(tt.indices) || len(got.Embeddings) != len(tt.indices) {
+				t.Fatalf("expected indices %v, got %v", tt.indices, got.Indices)
+			}
+			for i, idx := range tt.indices {
+				if got.Indices[i] != idx {
+					t.Fatalf("expected indices %v, got %v", tt.indices, got.Indices)
+				}
+			}
+		})
+	}
+
+	// The projected vector is truncated and re-normalized
+	got := Reconcile(vecs, ReconcileProject)
+	projected := got.Embeddings[2]
+	if math.Abs(float64(projected[0])-0.6) > 1e-6 || math.Abs(float64(projected[1])-0.8) > 1e-6 {
+		t.Errorf("expected projected vector [0.6 0.8], got %v", projected)
+	}
+}
+
+func TestParseReconcileMode(t *testing.T) {
+	for _, name := range []string{"", "none", "exclude", "Project"} {
+		if _, err := ParseReconcileMode(name); err != nil {
+			t.Errorf("ParseReconcileMode(%q): unexpected error %v", name, err)
+		}
+	}
+	if _, err := ParseReconcileMode("pad"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	if err := ValidateDimensions([][]float32{{1, 0}, {0, 1}}); err != nil {
+		t.Errorf("expected uniform embeddings to be valid, got %v", err)
+	}
+	if err := ValidateDimensions(nil); err != nil {
+		t.Errorf("expected no embeddings to be valid, got %v", err)
+	}
+
+	// Two missing and one 3-dim vector among 2-dim ones
+	err := ValidateDimensions([][]float32{{1, 0}, {}, {0, 1}, {1, 0, 0}, nil, {1, 1}})
+	var dimErr *DimensionError
+	if !errors.As(err, &dimErr) {
+		t.Fatalf("expected *DimensionError, got %v", err)
+	}
+	if dimErr.Missing != 2 || dimErr.Mismatched != 1 || dimErr.Dimension != 2 {
+		t.Errorf("expected 2 missing, 1 mismatched, dimension 2, got %+v", dimErr)
+	}
+}
@@ -0,0 +1,207 @@
+// Package jsonpath selects values from decoded JSON with a subset of
+// JSONPath: the root $, child names (.name or ['name']), wildcards (.* or
+// [*]), array indexes ([0], [-1] counting from the end) and recursive
+// descent (..name or ..*).
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepChild stepKind = iota
+	stepWildcard
+	stepIndex
+	stepDescendant         // ..name
+	stepDescendantWildcard // ..*
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// Path is a compiled selector
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// String returns the expression the path was parsed from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Parse compiles a selector such as "$.items[*].description"
+func Parse(expr string) (*Path, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", expr)
+	}
+
+	p := &Path{expr: expr}
+	rest := expr[1:]
+	for rest != "" {
+		var s step
+		var err error
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			s, rest, err = parseDot(rest[2:], true)
+		case rest[0] == '.':
+			s, rest, err = parseDot(rest[1:], false)
+		case rest[0] == '[':
+			s, rest, err = parseBracket(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected %q", rest[:1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON path %q: %w", expr, err)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// parseDot parses the name or * following . or ..
+func parseDot(rest string, descendant bool) (step, string, error) {
+	if strings.HasPrefix(rest, "*") {
+		if descendant {
+			return step{kind: stepDescendantWildcard}, rest[1:], nil
+		}
+		return step{kind: stepWildcard}, rest[1:], nil
+	}
+
+	end := 0
+	for end < len(rest) && isNameByte(rest[end]) {
+		end++
+	}
+	if end == 0 {
+		return step{}, "", errors.New("expected a name or * after .")
+	}
+	if descendant {
+		return step{kind: stepDescendant, name: rest[:end]}, rest[end:], nil
+	}
+	return step{kind: stepChild, name: rest[:end]}, rest[end:], nil
+}
+
+// parseBracket parses [*], [n], ['name'] or ["name"] after the opening [
+func parseBracket(rest string) (step, string, error) {
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return step{}, "", errors.New("unclosed [")
+	}
+	inner, rest := strings.TrimSpace(rest[:end]), rest[end+1:]
+
+	switch {
+	case inner == "*":
+		return step{kind: stepWildcard}, rest, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return step{kind: stepChild, name: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, "", fmt.Errorf("expected *, an index or a quoted name in [%s]", inner)
+	}
+	return step{kind: stepIndex, index: index}, rest, nil
+}
+
+func isNameByte(b byte) bool {
+	return b == '_' || b == '-' || b == '$' ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') ||
+		b >= 0x80
+}
+
+// Select returns the values of data matched by the path, as decoded by
+// encoding/json into interface{}. Object members are visited in key order.
+func (p *Path) Select(data interface{}) []interface{} {
+	current := []interface{}{data}
+	for _, s := range p.steps {
+		var next []interface{}
+		for _, node := range current {
+			next = s.apply(node, next)
+		}
+		current = next
+	}
+	return current
+}
+
+func (s step) apply(node interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case stepChild:
+		if obj, ok := node.(map[string]interface{}); ok {
+			if v, ok := obj[s.name]; ok {
+				out = append(out, v)
+			}
+		}
+	case stepWildcard:
+		out = appendChildren(node, out)
+	case stepIndex:
+		if arr, ok := node.([]interface{}); ok {
+			i := s.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				out = append(out, arr[i])
+			}
+		}
+	case stepDescendant:
+		walk(node, func(v interface{}) {
+			if obj, ok := v.(map[string]interface{}); ok {
+				if child, ok := obj[s.name]; ok {
+					out = append(out, child)
+				}
+			}
+		})
+	case stepDescendantWildcard:
+		walk(node, func(v interface{}) {
+			out = appendChildren(v, out)
+		})
+	}
+	return out
+}
+
+// appendChildren appends the members of an object or the elements of an array
+func appendChildren(node interface{}, out []interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			out = append(out, v[key])
+		}
+	case []interface{}:
+		out = append(out, v...)
+	}
+	return out
+}
+
+// walk calls fn for node and every object or array nested in it
+func walk(node interface{}, fn func(interface{})) {
+	fn(node)
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			walk(v[key], fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walk(item, fn)
+		}
+	}
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
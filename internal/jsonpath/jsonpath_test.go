@@ -0,0 +1,56 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPath_Select(t *testing.T) {
+	var data interface{}
+	doc := `{
+		"id": "resp-1",
+		"items": [
+			{"id": 1, "description": "first", "meta": {"description": "nested"}},
+			{"id": 2, "description": "second"},
+			{"id": 3, "title": "third"}
+		],
+		"summary": {"text": "overall", "tags": ["a", "b"]}
+	}`
+	if err := json.Unmarshal([]byte(doc), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"$.items[*].description", []interface{}{"first", "second"}},
+		{"$['items'][1][\"description\"]", []interface{}{"second"}},
+		{"$.items[-1].title", []interface{}{"third"}},
+		{"$.items[5].title", nil},
+		{"$.summary.*", []interface{}{[]interface{}{"a", "b"}, "overall"}},
+		{"$..description", []interface{}{"first", "nested", "second"}},
+		{"$.summary..*", []interface{}{[]interface{}{"a", "b"}, "overall", "a", "b"}},
+		{"$.missing.description", nil},
+		{"$.id", []interface{}{"resp-1"}},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.expr, err)
+			continue
+		}
+		if got := p.Select(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "items", "$.", "$..", "$[", "$[abc]", "$.items[*", "$ items", "$.a b"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
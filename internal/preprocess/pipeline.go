@@ -0,0 +1,95 @@
+// Package preprocess normalizes statement text before it is embedded or
+// analyzed. The stored and displayed text is never changed.
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Step is a named text transformation
+type Step string
+
+const (
+	// StepLowercase converts text to lower case
+	StepLowercase Step = "lowercase"
+	// StepStripPunctuation replaces punctuation and symbols with spaces
+	StepStripPunctuation Step = "strip_punctuation"
+	// StepMaskNumbers replaces numbers, including decimals and thousands
+	// separators, with NumberMask
+	StepMaskNumbers Step = "mask_numbers"
+	// StepCollapseWhitespace trims text and collapses runs of whitespace
+	StepCollapseWhitespace Step = "collapse_whitespace"
+)
+
+// NumberMask replaces each number masked by StepMaskNumbers
+const NumberMask = "NUM"
+
+var numberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+var steps = map[Step]func(string) string{
+	StepLowercase: strings.ToLower,
+	StepStripPunctuation: func(text string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+				return ' '
+			}
+			return r
+		}, text)
+	},
+	StepMaskNumbers: func(text string) string {
+		return numberPattern.ReplaceAllString(text, NumberMask)
+	},
+	StepCollapseWhitespace: func(text string) string {
+		return strings.Join(strings.Fields(text), " ")
+	},
+}
+
+// Steps returns the names of all supported steps
+func Steps() []string {
+	return []string{
+		string(StepLowercase),
+		string(StepStripPunctuation),
+		string(StepMaskNumbers),
+		string(StepCollapseWhitespace),
+	}
+}
+
+// Pipeline is an ordered list of steps. The empty pipeline leaves text
+// unchanged.
+type Pipeline []Step
+
+// Parse validates step names and returns them as a pipeline in the given
+// order. Names are case-insensitive.
+func Parse(names []string) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(names))
+	for _, name := range names {
+		step := Step(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := steps[step]; !ok {
+			return nil, fmt.Errorf("unknown preprocessing step %q (expected one of %s)", name, strings.Join(Steps(), ", "))
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline, nil
+}
+
+// Apply runs every step on text in order
+func (p Pipeline) Apply(text string) string {
+	for _, step := range p {
+		if fn, ok := steps[step]; ok {
+			text = fn(text)
+		}
+	}
+	return text
+}
+
+// Names returns the step names, as accepted by Parse
+func (p Pipeline) Names() []string {
+	names := make([]string, len(p))
+	for i, step := range p {
+		names[i] = string(step)
+	}
+	return names
+}
@@ -0,0 +1,37 @@
+package preprocess
+
+import "testing"
+
+func TestPipeline_Apply(t *testing.T) {
+	text := "  Refunds of $1,200.50 are paid within 30 days!  "
+
+	tests := []struct {
+		name  string
+		steps []string
+		want  string
+	}{
+		{"empty", nil, text},
+		{"lowercase", []string{"lowercase"}, "  refunds of $1,200.50 are paid within 30 days!  "},
+		{"mask then strip", []string{"mask_numbers", "strip_punctuation", "collapse_whitespace"}, "Refunds of NUM are paid within NUM days"},
+		{"strip then mask", []string{"strip_punctuation", "mask_numbers", "collapse_whitespace"}, "Refunds of NUM NUM NUM are paid within NUM days"},
+		{"all", []string{"Lowercase", "mask_numbers", "strip_punctuation", "collapse_whitespace"}, "refunds of NUM are paid within NUM days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := Parse(tt.steps)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := pipeline.Apply(text); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_UnknownStep(t *testing.T) {
+	if _, err := Parse([]string{"lowercase", "stem"}); err == nil {
+		t.Error("expected error for unknown step")
+	}
+}
@@ -0,0 +1,119 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Metric names a measure of how similar two embeddings are
+type Metric string
+
+const (
+	// MetricCosine is the cosine of the angle between the embeddings
+	MetricCosine Metric = "cosine"
+	// MetricDotProduct is the dot product of the embeddings, equal to the
+	// cosine for unit-length embeddings
+	MetricDotProduct Metric = "dot"
+	// MetricEuclidean maps the Euclidean distance d to 1 / (1 + d), so
+	// identical embeddings score 1 and distant ones approach 0
+	MetricEuclidean Metric = "euclidean"
+)
+
+// ParseMetric parses a metric name, defaulting to cosine when empty
+func ParseMetric(name string) (Metric, error) {
+	switch m := Metric(strings.ToLower(strings.TrimSpace(name))); m {
+	case "":
+		return MetricCosine, nil
+	case MetricCosine, MetricDotProduct, MetricEuclidean:
+		return m, nil
+	case "dot_product", "dotproduct":
+		return MetricDotProduct, nil
+	default:
+		return "", fmt.Errorf("unknown similarity metric %q (expected cosine, dot or euclidean)", name)
+	}
+}
+
+// ValidThreshold reports whether t is a usable similarity threshold for the
+// metric. Cosine and Euclidean similarities never exceed 1, while the dot
+// product of embeddings that are not unit length can.
+func (m Metric) ValidThreshold(t float64) bool {
+	if m == MetricDotProduct {
+		return t > 0 && !math.IsInf(t, 1)
+	}
+	return t > 0 && t <= 1
+}
+
+// Similarity compares two embeddings with the metric. Embeddings of
+// different dimensions have similarity 0.
+func (m Metric) Similarity(a, b []float32) float64 {
+	switch m {
+	case MetricDotProduct:
+		return DotProductSimilarity(a, b)
+	case MetricEuclidean:
+		return EuclideanSimilarity(a, b)
+	default:
+		return CosineSimilarity(a, b)
+	}
+}
+
+// DotProductSimilarity calculates the dot product of two vectors. For
+// normalized embeddings it equals the cosine similarity without the cost
+// of computing magnitudes.
+func DotProductSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// EuclideanDistance calculates the Euclidean distance between two vectors
+func EuclideanDistance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// EuclideanSimilarity converts the Euclidean distance between two vectors
+// to a similarity in (0, 1]: 1 / (1 + distance).
+func EuclideanSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	return 1 / (1 + EuclideanDistance(a, b))
+}
+
+// SimilarityMatrix calculates pairwise similarity for all embeddings with
+// the metric. The diagonal holds each embedding's similarity to itself.
+func SimilarityMatrix(embeddings [][]float32, metric Metric) [][]float64 {
+	if metric == "" || metric == MetricCosine {
+		return CosineSimilarityMatrix(embeddings)
+	}
+
+	n := len(embeddings)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		matrix[i][i] = metric.Similarity(embeddings[i], embeddings[i])
+		for j := i + 1; j < n; j++ {
+			sim := metric.Similarity(embeddings[i], embeddings[j])
+			matrix[i][j] = sim
+			matrix[j][i] = sim
+		}
+	}
+	return matrix
+}
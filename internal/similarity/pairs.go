@@ -17,6 +17,18 @@ const DefaultThreshold = 0.75
 // It skips self-pairs (i,i) and duplicate pairs (i,j) and (j,i), only keeping (i,j) where i < j.
 // Returns pairs sorted by similarity score in descending order.
 func FindSimilarPairs(embeddings [][]float32, threshold float64) []SimilarPair {
+	return FindSimilarPairsByMetric(embeddings, threshold, MetricCosine)
+}
+
+// FindSimilarPairsByMetric is FindSimilarPairs comparing embeddings with the
+// given metric instead of cosine similarity.
+func FindSimilarPairsByMetric(embeddings [][]float32, threshold float64, metric Metric) []SimilarPair {
+	return findPairs(embeddings, threshold, metric, nil)
+}
+
+// findPairs finds the pairs at or above threshold under metric, leaving out
+// pairs for which skip, when set, returns true
+func findPairs(embeddings [][]float32, threshold float64, metric Metric, skip func(i, j int) bool) []SimilarPair {
 	if len(embeddings) == 0 {
 		return []SimilarPair{}
 	}
@@ -31,7 +43,10 @@ func FindSimilarPairs(embeddings [][]float32, threshold float64) []SimilarPair {
 	// Only iterate upper triangle to avoid duplicates
 	for i := 0; i < len(embeddings); i++ {
 		for j := i + 1; j < len(embeddings); j++ {
-			sim := CosineSimilarity(embeddings[i], embeddings[j])
+			if skip != nil && skip(i, j) {
+				continue
+			}
+			sim := metric.Similarity(embeddings[i], embeddings[j])
 			if sim >= threshold {
 				pairs = append(pairs, SimilarPair{
 					Idx1:       i,
@@ -7,6 +7,7 @@ import (
 // Service provides similarity analysis functionality.
 type Service struct {
 	threshold float64
+	metric    Metric
 }
 
 // NewService creates a new similarity service with the specified threshold.
@@ -17,6 +18,7 @@ func NewService(threshold float64) *Service {
 	}
 	return &Service{
 		threshold: threshold,
+		metric:    MetricCosine,
 	}
 }
 
@@ -28,18 +30,38 @@ type SimilarPairResult struct {
 	File2       string  `json:"file2"`
 	Line1       int     `json:"line1"`
 	Line2       int     `json:"line2"`
+	DocumentID1 string  `json:"document_id1"`
+	DocumentID2 string  `json:"document_id2"`
 	Similarity  float64 `json:"similarity"`
 	Index1      int     `json:"index1"`
 	Index2      int     `json:"index2"`
 }
 
+// FindOptions selects which statement pairs FindSimilarStatementsWithOptions
+// returns
+type FindOptions struct {
+	// Threshold is the minimum similarity; 0 uses the service's threshold
+	Threshold float64
+	// Metric compares embeddings; empty uses the service's metric
+	Metric Metric
+	// CrossDocumentOnly drops pairs of statements from the same document
+	CrossDocumentOnly bool
+}
+
 // FindSimilarStatements finds similar statement pairs from a list of statements.
 // Returns detailed results including statement text, file info, and similarity scores.
 func (s *Service) FindSimilarStatements(statements []models.Statement, threshold float64) []SimilarPairResult {
+	return s.FindSimilarStatementsWithOptions(statements, FindOptions{Threshold: threshold})
+}
+
+// FindSimilarStatementsWithOptions is FindSimilarStatements with a choice of
+// metric and of whether pairs within one document are kept.
+func (s *Service) FindSimilarStatementsWithOptions(statements []models.Statement, opts FindOptions) []SimilarPairResult {
 	if len(statements) == 0 {
 		return []SimilarPairResult{}
 	}
 
+	threshold := opts.Threshold
 	// Use service threshold if not specified
 	if threshold <= 0 {
 		threshold = s.threshold
@@ -51,8 +73,19 @@ func (s *Service) FindSimilarStatements(statements []models.Statement, threshold
 		embeddings[i] = stmt.Embedding
 	}
 
-	// Find similar pairs
-	pairs := FindSimilarPairs(embeddings, threshold)
+	metric := opts.Metric
+	if metric == "" {
+		metric = s.metric
+	}
+
+	// Find similar pairs, skipping same-document pairs unless wanted
+	var skip func(i, j int) bool
+	if opts.CrossDocumentOnly {
+		skip = func(i, j int) bool {
+			return statements[i].DocumentID == statements[j].DocumentID
+		}
+	}
+	pairs := findPairs(embeddings, threshold, metric, skip)
 
 	// Convert to detailed results
 	results := make([]SimilarPairResult, len(pairs))
@@ -61,15 +94,17 @@ func (s *Service) FindSimilarStatements(statements []models.Statement, threshold
 		stmt2 := statements[pair.Idx2]
 
 		results[i] = SimilarPairResult{
-			Statement1: stmt1.Text,
-			Statement2: stmt2.Text,
-			File1:      stmt1.File,
-			File2:      stmt2.File,
-			Line1:      stmt1.Line,
-			Line2:      stmt2.Line,
-			Similarity: pair.Similarity,
-			Index1:     pair.Idx1,
-			Index2:     pair.Idx2,
+			Statement1:  stmt1.Text,
+			Statement2:  stmt2.Text,
+			File1:       stmt1.File,
+			File2:       stmt2.File,
+			Line1:       stmt1.Line,
+			Line2:       stmt2.Line,
+			DocumentID1: stmt1.DocumentID,
+			DocumentID2: stmt2.DocumentID,
+			Similarity:  pair.Similarity,
+			Index1:      pair.Idx1,
+			Index2:      pair.Idx2,
 		}
 	}
 
@@ -98,15 +133,17 @@ func (s *Service) FindSimilarStatementsWithMatrix(statements []models.Statement,
 		stmt2 := statements[pair.Idx2]
 
 		results[i] = SimilarPairResult{
-			Statement1: stmt1.Text,
-			Statement2: stmt2.Text,
-			File1:      stmt1.File,
-			File2:      stmt2.File,
-			Line1:      stmt1.Line,
-			Line2:      stmt2.Line,
-			Similarity: pair.Similarity,
-			Index1:     pair.Idx1,
-			Index2:     pair.Idx2,
+			Statement1:  stmt1.Text,
+			Statement2:  stmt2.Text,
+			File1:       stmt1.File,
+			File2:       stmt2.File,
+			Line1:       stmt1.Line,
+			Line2:       stmt2.Line,
+			DocumentID1: stmt1.DocumentID,
+			DocumentID2: stmt2.DocumentID,
+			Similarity:  pair.Similarity,
+			Index1:      pair.Idx1,
+			Index2:      pair.Idx2,
 		}
 	}
 
@@ -125,7 +162,20 @@ func (s *Service) GetThreshold() float64 {
 	return s.threshold
 }
 
+// SetMetric updates the default similarity metric for the service.
+func (s *Service) SetMetric(metric Metric) {
+	if metric != "" {
+		s.metric = metric
+	}
+}
+
+// GetMetric returns the current default similarity metric.
+func (s *Service) GetMetric() Metric {
+	return s.metric
+}
+
 // ComputeSimilarityMatrix computes and returns the full similarity matrix for statements
+// using the service's metric
 func (s *Service) ComputeSimilarityMatrix(statements []models.Statement) [][]float64 {
 	if len(statements) == 0 {
 		return [][]float64{}
@@ -136,5 +186,5 @@ func (s *Service) ComputeSimilarityMatrix(statements []models.Statement) [][]flo
 		embeddings[i] = stmt.Embedding
 	}
 
-	return CosineSimilarityMatrix(embeddings)
+	return SimilarityMatrix(embeddings, s.metric)
 }
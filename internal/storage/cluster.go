@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Cluster is one cluster of a project's stored clustering
+type Cluster struct {
+	ID           uuid.UUID
+	ProjectID    uuid.UUID
+	Label        int
+	Keywords     []string
+	Size         int
+	Density      float64
+	StatementIDs []uuid.UUID
+	// RepresentativeID is the member closest to the centroid, uuid.Nil when
+	// unknown
+	RepresentativeID uuid.UUID
+	CreatedAt        time.Time
+}
+
+// Clustering is the last clustering computed for a project, along with the
+// inputs it was computed from so callers can tell whether it still applies.
+// Unlike ClusterModel it records every full or incremental clustering and is
+// what the clusters and visualization endpoints read.
+type Clustering struct {
+	ProjectID   uuid.UUID
+	RequestedK  int    // k asked for, 0 when chosen automatically
+	Fingerprint string // hash of the clustered statements and embeddings
+	Clusters    []*Cluster
+}
+
+// ClusterRepository stores one clustering per project
+type ClusterRepository interface {
+	// GetByProjectID returns the project's clustering, or nil if it has none
+	GetByProjectID(ctx context.Context, projectID uuid.UUID) (*Clustering, error)
+	// Replace stores clustering in place of the project's previous one
+	Replace(ctx context.Context, clustering *Clustering) error
+}
+
+// PostgresClusterRepository implements ClusterRepository using PostgreSQL
+type PostgresClusterRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresClusterRepository creates a new PostgresClusterRepository
+func NewPostgresClusterRepository(db *sql.DB) *PostgresClusterRepository {
+	return &PostgresClusterRepository{db: db}
+}
+
+// GetByProjectID retrieves the stored clustering of a project with the
+// statement IDs of each cluster, ordered by label
+func (r *PostgresClusterRepository) GetByProjectID(ctx context.Context, projectID uuid.UUID) (*Clustering, error) {
+	query := `
+		SELECT c.id, c.label, c.keywords, c.size, COALESCE(c.density, 0), c.requested_k, c.fingerprint, c.representative_id, c.created_at,
+			COALESCE(array_agg(cs.statement_id) FILTER (WHERE cs.statement_id IS NOT NULL), '{}')
+		FROM clusters c
+		LEFT JOIN cluster_statements cs ON cs.cluster_id = c.id
+		WHERE c.project_id = $1
+		GROUP BY c.id
+		ORDER BY c.label ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clustering *Clustering
+	for rows.Next() {
+		c := &Cluster{ProjectID: projectID}
+		var requestedK int
+		var fingerprint string
+		var statementIDs []string
+		var representativeID uuid.NullUUID
+		if err := rows.Scan(
+			&c.ID,
+			&c.Label,
+			pq.Array(&c.Keywords),
+			&c.Size,
+			&c.Density,
+			&requestedK,
+			&fingerprint,
+			&representativeID,
+			&c.CreatedAt,
+			pq.Array(&statementIDs),
+		); err != nil {
+			return nil, err
+		}
+
+		c.RepresentativeID = representativeID.UUID
+
+		c.StatementIDs = make([]uuid.UUID, len(statementIDs))
+		for i, id := range statementIDs {
+			if c.StatementIDs[i], err = uuid.Parse(id); err != nil {
+				return nil, err
+			}
+		}
+
+		if clustering == nil {
+			clustering = &Clustering{ProjectID: projectID, RequestedK: requestedK, Fingerprint: fingerprint}
+		}
+		clustering.Clusters = append(clustering.Clusters, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clustering, nil
+}
+
+// Replace deletes the project's stored clusters and inserts the clusters of
+// clustering with their memberships in a single transaction
+func (r *PostgresClusterRepository) Replace(ctx context.Context, clustering *Clustering) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM clusters WHERE project_id = $1`, clustering.ProjectID); err != nil {
+		return err
+	}
+
+	insertCluster := `
+		INSERT INTO clusters (project_id, label, keywords, size, density, requested_k, fingerprint, representative_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	insertMembers := `
+		INSERT INTO cluster_statements (cluster_id, statement_id)
+		SELECT $1, unnest($2::uuid[])
+	`
+
+	for _, c := range clustering.Clusters {
+		c.ProjectID = clustering.ProjectID
+		keywords := c.Keywords
+		if keywords == nil {
+			keywords = []string{}
+		}
+		if err := tx.QueryRowContext(ctx, insertCluster,
+			c.ProjectID,
+			c.Label,
+			pq.Array(keywords),
+			c.Size,
+			c.Density,
+			clustering.RequestedK,
+			clustering.Fingerprint,
+			uuid.NullUUID{UUID: c.RepresentativeID, Valid: c.RepresentativeID != uuid.Nil},
+		).Scan(&c.ID, &c.CreatedAt); err != nil {
+			return err
+		}
+
+		if len(c.StatementIDs) == 0 {
+			continue
+		}
+		ids := make([]string, len(c.StatementIDs))
+		for i, id := range c.StatementIDs {
+			ids[i] = id.String()
+		}
+		if _, err := tx.ExecContext(ctx, insertMembers, c.ID, pq.Array(ids)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClusterModel is the result of a project's last full k-means fit. New
+// statements are assigned to its centroids until the project grows enough
+// to warrant a refit. It only decides how the next clustering is computed;
+// the clustering served to readers is the project's Clustering.
+type ClusterModel struct {
+	ProjectID        uuid.UUID
+	RequestedK       int // k asked for, 0 when chosen automatically
+	Centroids        [][]float32
+	FittedStatements int // statement count at the time of the fit
+	FittedAt         time.Time
+}
+
+// ClusterModelRepository stores one cluster model per project
+type ClusterModelRepository interface {
+	// GetByProjectID returns the project's model, or nil if it has none
+	GetByProjectID(ctx context.Context, projectID uuid.UUID) (*ClusterModel, error)
+	// Save inserts or replaces the project's model
+	Save(ctx context.Context, model *ClusterModel) error
+}
+
+// PostgresClusterModelRepository implements ClusterModelRepository using PostgreSQL
+type PostgresClusterModelRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresClusterModelRepository creates a new PostgresClusterModelRepository
+func NewPostgresClusterModelRepository(db *sql.DB) *PostgresClusterModelRepository {
+	return &PostgresClusterModelRepository{db: db}
+}
+
+// GetByProjectID retrieves the cluster model of a project
+func (r *PostgresClusterModelRepository) GetByProjectID(ctx context.Context, projectID uuid.UUID) (*ClusterModel, error) {
+	query := `
+		SELECT project_id, requested_k, centroids, fitted_statements, fitted_at
+		FROM cluster_models
+		WHERE project_id = $1
+	`
+
+	model := &ClusterModel{}
+	var centroids []byte
+	err := r.db.QueryRowContext(ctx, query, projectID).Scan(
+		&model.ProjectID,
+		&model.RequestedK,
+		&centroids,
+		&model.FittedStatements,
+		&model.FittedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(centroids, &model.Centroids); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// Save upserts the cluster model of a project
+func (r *PostgresClusterModelRepository) Save(ctx context.Context, model *ClusterModel) error {
+	if model.FittedAt.IsZero() {
+		model.FittedAt = time.Now()
+	}
+
+	centroids, err := json.Marshal(model.Centroids)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO cluster_models (project_id, requested_k, centroids, fitted_statements, fitted_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id) DO UPDATE
+		SET requested_k = EXCLUDED.requested_k, centroids = EXCLUDED.centroids,
+			fitted_statements = EXCLUDED.fitted_statements, fitted_at = EXCLUDED.fitted_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		model.ProjectID,
+		model.RequestedK,
+		centroids,
+		model.FittedStatements,
+		model.FittedAt,
+	)
+
+	return err
+}
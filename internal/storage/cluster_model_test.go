@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestPostgresClusterModelRepository_GetByProjectID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresClusterModelRepository(db)
+	projectID := uuid.New()
+
+	rows := sqlmock.NewRows([]string{"project_id", "requested_k", "centroids", "fitted_statements", "fitted_at"}).
+		AddRow(projectID, 0, []byte(`[[1,0],[0,0.5]]`), 40, time.Now())
+	mock.ExpectQuery(`SELECT (.+) FROM cluster_models WHERE project_id = \$1`).
+		WithArgs(projectID).
+		WillReturnRows(rows)
+
+	model, err := repo.GetByProjectID(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(model.Centroids) != 2 || model.Centroids[1][1] != 0.5 || model.FittedStatements != 40 {
+		t.Errorf("unexpected model %+v", model)
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM cluster_models`).WithArgs(projectID).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}))
+	if model, err := repo.GetByProjectID(context.Background(), projectID); err != nil || model != nil {
+		t.Errorf("expected no model and no error, got %v, %v", model, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestPostgresClusterRepository_GetByProjectID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresClusterRepository(db)
+	projectID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	rows := sqlmock.NewRows([]string{"id", "label", "keywords", "size", "density", "requested_k", "fingerprint", "representative_id", "created_at", "statement_ids"}).
+		AddRow(uuid.New(), 0, `{refund,policy}`, 2, 0.8, 3, "abc", second.String(), time.Now(), "{"+first.String()+","+second.String()+"}").
+		AddRow(uuid.New(), 1, `{}`, 0, 0.0, 3, "abc", nil, time.Now(), `{}`)
+	mock.ExpectQuery(`SELECT (.+) FROM clusters c LEFT JOIN cluster_statements cs (.+) WHERE c.project_id = \$1`).
+		WithArgs(projectID).
+		WillReturnRows(rows)
+
+	clustering, err := repo.GetByProjectID(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if clustering == nil || clustering.RequestedK != 3 || clustering.Fingerprint != "abc" || len(clustering.Clusters) != 2 {
+		t.Fatalf("unexpected clustering %+v", clustering)
+	}
+	c := clustering.Clusters[0]
+	if len(c.Keywords) != 2 || c.Keywords[0] != "refund" || len(c.StatementIDs) != 2 || c.StatementIDs[1] != second || c.RepresentativeID != second {
+		t.Errorf("unexpected cluster %+v", c)
+	}
+	if clustering.Clusters[1].RepresentativeID != uuid.Nil {
+		t.Errorf("expected no representative for the empty cluster, got %s", clustering.Clusters[1].RepresentativeID)
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM clusters`).WithArgs(projectID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if clustering, err := repo.GetByProjectID(context.Background(), projectID); err != nil || clustering != nil {
+		t.Errorf("expected no clustering and no error, got %v, %v", clustering, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresClusterRepository_Replace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresClusterRepository(db)
+	projectID := uuid.New()
+	clusterID := uuid.New()
+	representative := uuid.New()
+	clustering := &Clustering{
+		ProjectID:   projectID,
+		RequestedK:  0,
+		Fingerprint: "abc",
+		Clusters: []*Cluster{
+			{Label: 0, Keywords: []string{"refund"}, Size: 1, Density: 1, StatementIDs: []uuid.UUID{representative}, RepresentativeID: representative},
+			{Label: 1, Size: 1, Density: 1, StatementIDs: []uuid.UUID{uuid.New()}},
+		},
+	}
+
+	// Failing to insert the second cluster rolls back the delete and the first
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM clusters WHERE project_id = \$1`).WithArgs(projectID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectQuery(`INSERT INTO clusters`).
+		WithArgs(projectID, 0, sqlmock.AnyArg(), 1, 1.0, 0, "abc", uuid.NullUUID{UUID: representative, Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(clusterID, time.Now()))
+	mock.ExpectExec(`INSERT INTO cluster_statements`).WithArgs(clusterID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO clusters`).
+		WithArgs(projectID, 1, sqlmock.AnyArg(), 1, 1.0, 0, "abc", uuid.NullUUID{}).
+		WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	if err := repo.Replace(context.Background(), clustering); err == nil {
+		t.Fatal("expected an error")
+	}
+	if clustering.Clusters[0].ID != clusterID {
+		t.Errorf("expected the inserted cluster ID to be set")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review statuses for contradictions in the review worklist
+const (
+	ReviewStatusOpen      = "open"
+	ReviewStatusAccepted  = "accepted"
+	ReviewStatusDismissed = "dismissed"
+)
+
+// IsValidReviewStatus reports whether status is a known review status
+func IsValidReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusOpen, ReviewStatusAccepted, ReviewStatusDismissed:
+		return true
+	}
+	return false
+}
+
+// Contradiction represents a detected contradiction between two statements.
+// Statement IDs are stored in canonical order (Statement1ID < Statement2ID)
+// so a pair is identified regardless of the order it was detected in.
+type Contradiction struct {
+	ID           uuid.UUID
+	ProjectID    uuid.UUID
+	Statement1ID uuid.UUID
+	Statement2ID uuid.UUID
+	Type         string
+	Severity     string
+	Explanation  string
+	Confidence   float64
+	Status       string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	// Populated on reads from the joined statements/documents
+	Text1 string
+	Text2 string
+	File1 string
+	File2 string
+}
+
+// ContradictionRepository defines the interface for contradiction storage operations
+type ContradictionRepository interface {
+	// Upsert inserts a contradiction or refreshes its analysis fields,
+	// keeping the existing review status
+	Upsert(ctx context.Context, contradiction *Contradiction) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Contradiction, error)
+	// GetByProjectID lists contradictions, optionally filtered by status
+	GetByProjectID(ctx context.Context, projectID uuid.UUID, status string) ([]*Contradiction, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+}
+
+// CanonicalPair orders two statement IDs so a pair has a single representation
+func CanonicalPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if a.String() > b.String() {
+		return b, a
+	}
+	return a, b
+}
+
+// PostgresContradictionRepository implements ContradictionRepository using PostgreSQL
+type PostgresContradictionRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresContradictionRepository creates a new PostgresContradictionRepository
+func NewPostgresContradictionRepository(db *sql.DB) *PostgresContradictionRepository {
+	return &PostgresContradictionRepository{db: db}
+}
+
+// Upsert inserts a contradiction or updates the analysis of an existing pair
+func (r *PostgresContradictionRepository) Upsert(ctx context.Context, c *Contradiction) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if c.Status == "" {
+		c.Status = ReviewStatusOpen
+	}
+	c.Statement1ID, c.Statement2ID = CanonicalPair(c.Statement1ID, c.Statement2ID)
+
+	now := time.Now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	query := `
+		INSERT INTO contradictions (id, project_id, statement1_id, statement2_id, type, severity,
+			explanation, confidence, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (statement1_id, statement2_id) DO UPDATE
+		SET type = EXCLUDED.type, severity = EXCLUDED.severity, explanation = EXCLUDED.explanation,
+			confidence = EXCLUDED.confidence, updated_at = EXCLUDED.updated_at
+		RETURNING id, status, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		c.ID,
+		c.ProjectID,
+		c.Statement1ID,
+		c.Statement2ID,
+		c.Type,
+		c.Severity,
+		c.Explanation,
+		c.Confidence,
+		c.Status,
+		c.CreatedAt,
+		c.UpdatedAt,
+	).Scan(&c.ID, &c.Status, &c.CreatedAt)
+}
+
+const contradictionSelect = `
+	SELECT c.id, c.project_id, c.statement1_id, c.statement2_id, c.type, c.severity,
+		c.explanation, c.confidence, c.status, c.created_at, c.updated_at,
+		s1.text, s2.text, d1.filename, d2.filename
+	FROM contradictions c
+	JOIN statements s1 ON c.statement1_id = s1.id
+	JOIN statements s2 ON c.statement2_id = s2.id
+	JOIN documents d1 ON s1.document_id = d1.id
+	JOIN documents d2 ON s2.document_id = d2.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanContradiction(row rowScanner) (*Contradiction, error) {
+	c := &Contradiction{}
+	err := row.Scan(
+		&c.ID,
+		&c.ProjectID,
+		&c.Statement1ID,
+		&c.Statement2ID,
+		&c.Type,
+		&c.Severity,
+		&c.Explanation,
+		&c.Confidence,
+		&c.Status,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.Text1,
+		&c.Text2,
+		&c.File1,
+		&c.File2,
+	)
+	return c, err
+}
+
+// GetByID retrieves a contradiction by its ID
+func (r *PostgresContradictionRepository) GetByID(ctx context.Context, id uuid.UUID) (*Contradiction, error) {
+	c, err := scanContradiction(r.db.QueryRowContext(ctx, contradictionSelect+`WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetByProjectID retrieves contradictions for a project, filtered by status if non-empty
+func (r *PostgresContradictionRepository) GetByProjectID(ctx context.Context, projectID uuid.UUID, status string) ([]*Contradiction, error) {
+	query := contradictionSelect + `
+		WHERE c.project_id = $1 AND ($2 = '' OR c.status = $2)
+		ORDER BY c.created_at ASC, c.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contradictions []*Contradiction
+	for rows.Next() {
+		c, err := scanContradiction(rows)
+		if err != nil {
+			return nil, err
+		}
+		contradictions = append(contradictions, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contradictions, nil
+}
+
+// UpdateStatus sets the review status of a contradiction
+func (r *PostgresContradictionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	query := `UPDATE contradictions SET status = $2, updated_at = $3 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, status, time.Now())
+	return err
+}
@@ -10,22 +10,30 @@ import (
 
 // Document represents a document in the system
 type Document struct {
-	ID          uuid.UUID
-	ProjectID   uuid.UUID
-	Filename    string
-	Content     string
-	ContentHash string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID              uuid.UUID
+	ProjectID       uuid.UUID
+	Filename        string
+	Content         string
+	ContentHash     string
+	EmbeddingTokens int    // Tokens spent embedding the document's statements
+	EmbeddingError  string // Why embedding failed on upload, empty otherwise
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 // DocumentRepository defines the interface for document storage operations
 type DocumentRepository interface {
 	Create(ctx context.Context, document *Document) error
+	CreateWithStatements(ctx context.Context, document *Document, statements []*Statement, partial bool) (*BatchResult, error)
+	UpdateWithStatements(ctx context.Context, document *Document, statements []*Statement, partial bool) (*BatchResult, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Document, error)
 	GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*Document, error)
+	ListWithStats(ctx context.Context, projectID uuid.UUID, filter DocumentFilter) ([]*DocumentStats, error)
 	GetByHash(ctx context.Context, projectID uuid.UUID, hash string) (*Document, error)
+	ListDuplicatesByUser(ctx context.Context, userID uuid.UUID) ([]*Document, error)
 	Update(ctx context.Context, document *Document) error
+	AddEmbeddingTokens(ctx context.Context, id uuid.UUID, tokens int) error
+	SetEmbeddingError(ctx context.Context, id uuid.UUID, message string) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByProjectID(ctx context.Context, projectID uuid.UUID) error
 }
@@ -42,6 +50,117 @@ func NewPostgresDocumentRepository(db *sql.DB) *PostgresDocumentRepository {
 
 // Create inserts a new document into the database
 func (r *PostgresDocumentRepository) Create(ctx context.Context, document *Document) error {
+	_, err := r.db.ExecContext(ctx, insertDocumentQuery, documentInsertArgs(document)...)
+	return err
+}
+
+// CreateWithStatements inserts a document and its statements in a single
+// transaction, so a document is never stored without the statements
+// extracted from it. With partial set, statements that fail to insert are
+// reported in the result instead of aborting, but nothing is stored if no
+// statement could be inserted.
+func (r *PostgresDocumentRepository) CreateWithStatements(ctx context.Context, document *Document, statements []*Statement, partial bool) (*BatchResult, error) {
+	if err := validatePositions(statements); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, insertDocumentQuery, documentInsertArgs(document)...); err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Inserted: len(statements)}
+	if len(statements) > 0 {
+		if partial {
+			result, err = insertStatementsPartial(ctx, tx, statements)
+		} else {
+			err = insertStatements(ctx, tx, statements)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result.Inserted == 0 {
+			return result, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateWithStatements replaces the content of a document and all of its
+// statements in a single transaction, so the stored statements always match
+// the stored content. Partial behaves as in CreateWithStatements: nothing is
+// changed if no statement could be inserted.
+func (r *PostgresDocumentRepository) UpdateWithStatements(ctx context.Context, document *Document, statements []*Statement, partial bool) (*BatchResult, error) {
+	if err := validatePositions(statements); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	document.UpdatedAt = time.Now()
+	query := `
+		UPDATE documents
+		SET filename = $2, content = $3, content_hash = $4, embedding_tokens = $5, embedding_error = $6, updated_at = $7
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		document.ID,
+		document.Filename,
+		document.Content,
+		document.ContentHash,
+		document.EmbeddingTokens,
+		document.EmbeddingError,
+		document.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM statements WHERE document_id = $1`, document.ID); err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Inserted: len(statements)}
+	if len(statements) > 0 {
+		if partial {
+			result, err = insertStatementsPartial(ctx, tx, statements)
+		} else {
+			err = insertStatements(ctx, tx, statements)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result.Inserted == 0 {
+			return result, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+const insertDocumentQuery = `
+	INSERT INTO documents (id, project_id, filename, content, content_hash, embedding_tokens, embedding_error, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+// documentInsertArgs fills in a missing ID and timestamps and returns the
+// arguments of insertDocumentQuery
+func documentInsertArgs(document *Document) []interface{} {
 	if document.ID == uuid.Nil {
 		document.ID = uuid.New()
 	}
@@ -54,28 +173,23 @@ func (r *PostgresDocumentRepository) Create(ctx context.Context, document *Docum
 		document.UpdatedAt = now
 	}
 
-	query := `
-		INSERT INTO documents (id, project_id, filename, content, content_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-
-	_, err := r.db.ExecContext(ctx, query,
+	return []interface{}{
 		document.ID,
 		document.ProjectID,
 		document.Filename,
 		document.Content,
 		document.ContentHash,
+		document.EmbeddingTokens,
+		document.EmbeddingError,
 		document.CreatedAt,
 		document.UpdatedAt,
-	)
-
-	return err
+	}
 }
 
 // GetByID retrieves a document by its ID
 func (r *PostgresDocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*Document, error) {
 	query := `
-		SELECT id, project_id, filename, content, content_hash, created_at, updated_at
+		SELECT id, project_id, filename, content, content_hash, embedding_tokens, embedding_error, created_at, updated_at
 		FROM documents
 		WHERE id = $1
 	`
@@ -87,6 +201,8 @@ func (r *PostgresDocumentRepository) GetByID(ctx context.Context, id uuid.UUID)
 		&document.Filename,
 		&document.Content,
 		&document.ContentHash,
+		&document.EmbeddingTokens,
+		&document.EmbeddingError,
 		&document.CreatedAt,
 		&document.UpdatedAt,
 	)
@@ -104,7 +220,7 @@ func (r *PostgresDocumentRepository) GetByID(ctx context.Context, id uuid.UUID)
 // GetByProjectID retrieves all documents for a specific project
 func (r *PostgresDocumentRepository) GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*Document, error) {
 	query := `
-		SELECT id, project_id, filename, content, content_hash, created_at, updated_at
+		SELECT id, project_id, filename, content, content_hash, embedding_tokens, embedding_error, created_at, updated_at
 		FROM documents
 		WHERE project_id = $1
 		ORDER BY filename ASC
@@ -125,6 +241,8 @@ func (r *PostgresDocumentRepository) GetByProjectID(ctx context.Context, project
 			&document.Filename,
 			&document.Content,
 			&document.ContentHash,
+			&document.EmbeddingTokens,
+			&document.EmbeddingError,
 			&document.CreatedAt,
 			&document.UpdatedAt,
 		)
@@ -141,10 +259,97 @@ func (r *PostgresDocumentRepository) GetByProjectID(ctx context.Context, project
 	return documents, nil
 }
 
+// Embedding statuses of a document
+const (
+	// EmbeddingStatusDone means every statement has an embedding
+	EmbeddingStatusDone = "done"
+	// EmbeddingStatusPending means some statements await an embedding
+	EmbeddingStatusPending = "pending"
+	// EmbeddingStatusFailed means embedding failed and some statements have
+	// no embedding
+	EmbeddingStatusFailed = "failed"
+)
+
+// DocumentFilter narrows a document listing. Zero values match every
+// document.
+type DocumentFilter struct {
+	EmbeddingStatus string
+	MinStatements   int
+}
+
+// DocumentStats is a document with counts of its statements. The document
+// content is not loaded.
+type DocumentStats struct {
+	Document
+	StatementCount  int
+	EmbeddedCount   int
+	EmbeddingStatus string
+}
+
+// ListWithStats retrieves the documents of a project with their statement
+// counts and embedding status, keeping those matching filter
+func (r *PostgresDocumentRepository) ListWithStats(ctx context.Context, projectID uuid.UUID, filter DocumentFilter) ([]*DocumentStats, error) {
+	query := `
+		SELECT id, project_id, filename, content_hash, embedding_tokens, embedding_error, created_at, updated_at,
+			statement_count, embedded_count, embedding_status
+		FROM (
+			SELECT d.id, d.project_id, d.filename, d.content_hash, d.embedding_tokens, d.embedding_error,
+				d.created_at, d.updated_at,
+				COUNT(s.id) AS statement_count,
+				COUNT(s.embedding) AS embedded_count,
+				CASE
+					WHEN COUNT(s.id) = COUNT(s.embedding) THEN 'done'
+					WHEN d.embedding_error <> '' THEN 'failed'
+					ELSE 'pending'
+				END AS embedding_status
+			FROM documents d
+			LEFT JOIN statements s ON s.document_id = d.id
+			WHERE d.project_id = $1
+			GROUP BY d.id
+		) stats
+		WHERE statement_count >= $2 AND ($3 = '' OR embedding_status = $3)
+		ORDER BY filename ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID, filter.MinStatements, filter.EmbeddingStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*DocumentStats
+	for rows.Next() {
+		document := &DocumentStats{}
+		err := rows.Scan(
+			&document.ID,
+			&document.ProjectID,
+			&document.Filename,
+			&document.ContentHash,
+			&document.EmbeddingTokens,
+			&document.EmbeddingError,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+			&document.StatementCount,
+			&document.EmbeddedCount,
+			&document.EmbeddingStatus,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
 // GetByHash retrieves a document by its content hash within a project
 func (r *PostgresDocumentRepository) GetByHash(ctx context.Context, projectID uuid.UUID, hash string) (*Document, error) {
 	query := `
-		SELECT id, project_id, filename, content, content_hash, created_at, updated_at
+		SELECT id, project_id, filename, content, content_hash, embedding_tokens, embedding_error, created_at, updated_at
 		FROM documents
 		WHERE project_id = $1 AND content_hash = $2
 	`
@@ -156,6 +361,8 @@ func (r *PostgresDocumentRepository) GetByHash(ctx context.Context, projectID uu
 		&document.Filename,
 		&document.Content,
 		&document.ContentHash,
+		&document.EmbeddingTokens,
+		&document.EmbeddingError,
 		&document.CreatedAt,
 		&document.UpdatedAt,
 	)
@@ -170,6 +377,57 @@ func (r *PostgresDocumentRepository) GetByHash(ctx context.Context, projectID uu
 	return document, nil
 }
 
+// ListDuplicatesByUser retrieves the documents of a user's projects whose
+// content hash is shared by another of the user's documents, ordered by hash
+// and then upload time. The document content is not loaded.
+func (r *PostgresDocumentRepository) ListDuplicatesByUser(ctx context.Context, userID uuid.UUID) ([]*Document, error) {
+	query := `
+		SELECT d.id, d.project_id, d.filename, d.content_hash, d.embedding_tokens, d.embedding_error, d.created_at, d.updated_at
+		FROM documents d
+		JOIN projects p ON p.id = d.project_id
+		WHERE p.user_id = $1 AND d.content_hash IN (
+			SELECT d2.content_hash
+			FROM documents d2
+			JOIN projects p2 ON p2.id = d2.project_id
+			WHERE p2.user_id = $1
+			GROUP BY d2.content_hash
+			HAVING COUNT(*) > 1
+		)
+		ORDER BY d.content_hash, d.created_at, d.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*Document
+	for rows.Next() {
+		document := &Document{}
+		err := rows.Scan(
+			&document.ID,
+			&document.ProjectID,
+			&document.Filename,
+			&document.ContentHash,
+			&document.EmbeddingTokens,
+			&document.EmbeddingError,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
 // Update modifies an existing document
 func (r *PostgresDocumentRepository) Update(ctx context.Context, document *Document) error {
 	document.UpdatedAt = time.Now()
@@ -191,14 +449,31 @@ func (r *PostgresDocumentRepository) Update(ctx context.Context, document *Docum
 	return err
 }
 
-// Delete removes a document from the database
+// AddEmbeddingTokens adds tokens to the document's embedding token count
+func (r *PostgresDocumentRepository) AddEmbeddingTokens(ctx context.Context, id uuid.UUID, tokens int) error {
+	query := `UPDATE documents SET embedding_tokens = embedding_tokens + $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, tokens)
+	return err
+}
+
+// SetEmbeddingError records why embedding the document's statements failed,
+// or clears it with an empty message
+func (r *PostgresDocumentRepository) SetEmbeddingError(ctx context.Context, id uuid.UUID, message string) error {
+	query := `UPDATE documents SET embedding_error = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, message)
+	return err
+}
+
+// Delete removes a document from the database; its statements are removed
+// by ON DELETE CASCADE
 func (r *PostgresDocumentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM documents WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
-// DeleteByProjectID removes all documents for a project
+// DeleteByProjectID removes all documents for a project; their statements
+// are removed by ON DELETE CASCADE
 func (r *PostgresDocumentRepository) DeleteByProjectID(ctx context.Context, projectID uuid.UUID) error {
 	query := `DELETE FROM documents WHERE project_id = $1`
 	_, err := r.db.ExecContext(ctx, query, projectID)
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestPostgresDocumentRepository_CreateWithStatements(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDocumentRepository(db)
+	doc := &Document{ProjectID: uuid.New(), Filename: "a.md", Content: "x", ContentHash: "h"}
+	statements := []*Statement{
+		{DocumentID: doc.ID, Text: "first", Position: 0},
+		{DocumentID: doc.ID, Text: "second", Position: 1},
+	}
+
+	// A failing statement rolls back the document inserted before it
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO documents`).WillReturnResult(sqlmock.NewResult(0, 1))
+	insert := mock.ExpectPrepare(`INSERT INTO statements`)
+	insert.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	insert.ExpectExec().WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	if _, err := repo.CreateWithStatements(context.Background(), doc, statements, false); err == nil {
+		t.Fatal("expected an error")
+	}
+	if doc.ID == uuid.Nil {
+		t.Error("expected the document ID to be assigned")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDocumentRepository_CreateWithStatementsPartial(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDocumentRepository(db)
+	doc := &Document{ID: uuid.New(), ProjectID: uuid.New(), Filename: "a.md"}
+	statements := []*Statement{{DocumentID: doc.ID, Text: "only", Position: 0}}
+
+	// With every statement failing nothing is committed
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO documents`).WillReturnResult(sqlmock.NewResult(0, 1))
+	insert := mock.ExpectPrepare(`INSERT INTO statements`)
+	mock.ExpectExec(`SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WillReturnError(errors.New("boom"))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	result, err := repo.CreateWithStatements(context.Background(), doc, statements, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Inserted != 0 || len(result.Failures) != 1 {
+		t.Errorf("expected one reported failure, got %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDocumentRepository_UpdateWithStatements(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDocumentRepository(db)
+	doc := &Document{ID: uuid.New(), ProjectID: uuid.New(), Filename: "a.md", Content: "y", ContentHash: "h2"}
+	statements := []*Statement{{DocumentID: doc.ID, Text: "revised", Position: 0}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE documents`).
+		WithArgs(doc.ID, "a.md", "y", "h2", 0, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM statements WHERE document_id`).
+		WithArgs(doc.ID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	insert := mock.ExpectPrepare(`INSERT INTO statements`)
+	insert.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := repo.UpdateWithStatements(context.Background(), doc, statements, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Inserted != 1 {
+		t.Errorf("expected 1 statement inserted, got %d", result.Inserted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDocumentRepository_ListWithStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDocumentRepository(db)
+	projectID := uuid.New()
+	docID := uuid.New()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "project_id", "filename", "content_hash", "embedding_tokens", "embedding_error", "created_at", "updated_at",
+		"statement_count", "embedded_count", "embedding_status",
+	}).AddRow(docID, projectID, "a.md", "h", 12, "timed out", now, now, 4, 1, "failed")
+	mock.ExpectQuery(`LEFT JOIN statements`).
+		WithArgs(projectID, 2, "failed").
+		WillReturnRows(rows)
+
+	docs, err := repo.ListWithStats(context.Background(), projectID, DocumentFilter{EmbeddingStatus: EmbeddingStatusFailed, MinStatements: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got %d", len(docs))
+	}
+	d := docs[0]
+	if d.ID != docID || d.StatementCount != 4 || d.EmbeddedCount != 1 || d.EmbeddingStatus != EmbeddingStatusFailed || d.EmbeddingError != "timed out" {
+		t.Errorf("unexpected document %+v", d)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDocumentRepository_ListDuplicatesByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDocumentRepository(db)
+	userID := uuid.New()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "project_id", "filename", "content_hash", "embedding_tokens", "embedding_error", "created_at", "updated_at",
+	}).
+		AddRow(uuid.New(), uuid.New(), "spec.md", "h", 40, "", now, now).
+		AddRow(uuid.New(), uuid.New(), "spec-copy.md", "h", 40, "", now, now)
+	mock.ExpectQuery(`HAVING COUNT\(\*\) > 1`).
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	docs, err := repo.ListDuplicatesByUser(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(docs) != 2 || docs[0].ContentHash != "h" || docs[1].Filename != "spec-copy.md" {
+		t.Errorf("unexpected documents %+v", docs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -3,18 +3,53 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Project represents a project in the system
 type Project struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Name          string
+	Language      string   // Optional language code for LLM prompts
+	Preprocessing []string // Steps applied to statement text before embedding
+	Analysis      AnalysisConfig
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// AnalysisConfig holds a project's overrides of the server's analysis
+// defaults; zero values keep the default
+type AnalysisConfig struct {
+	ClusterK                   int     `json:"cluster_k,omitempty"`
+	SimilarityThreshold        float64 `json:"similarity_threshold,omitempty"`
+	ContradictionMinSimilarity float64 `json:"contradiction_min_similarity,omitempty"`
+}
+
+// Value stores the config as JSONB
+func (c AnalysisConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the config from JSONB
+func (c *AnalysisConfig) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = AnalysisConfig{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into AnalysisConfig", src)
+	}
 }
 
 // ProjectRepository defines the interface for project storage operations
@@ -51,14 +86,17 @@ func (r *PostgresProjectRepository) Create(ctx context.Context, project *Project
 	}
 
 	query := `
-		INSERT INTO projects (id, user_id, name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO projects (id, user_id, name, language, preprocessing, analysis_config, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		project.ID,
 		project.UserID,
 		project.Name,
+		project.Language,
+		pq.Array(preprocessingSteps(project.Preprocessing)),
+		project.Analysis,
 		project.CreatedAt,
 		project.UpdatedAt,
 	)
@@ -69,7 +107,7 @@ func (r *PostgresProjectRepository) Create(ctx context.Context, project *Project
 // GetByID retrieves a project by its ID
 func (r *PostgresProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*Project, error) {
 	query := `
-		SELECT id, user_id, name, created_at, updated_at
+		SELECT id, user_id, name, language, preprocessing, analysis_config, created_at, updated_at
 		FROM projects
 		WHERE id = $1
 	`
@@ -79,6 +117,9 @@ func (r *PostgresProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (
 		&project.ID,
 		&project.UserID,
 		&project.Name,
+		&project.Language,
+		pq.Array(&project.Preprocessing),
+		&project.Analysis,
 		&project.CreatedAt,
 		&project.UpdatedAt,
 	)
@@ -96,7 +137,7 @@ func (r *PostgresProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (
 // GetByUserID retrieves all projects for a specific user
 func (r *PostgresProjectRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Project, error) {
 	query := `
-		SELECT id, user_id, name, created_at, updated_at
+		SELECT id, user_id, name, language, preprocessing, analysis_config, created_at, updated_at
 		FROM projects
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -115,6 +156,9 @@ func (r *PostgresProjectRepository) GetByUserID(ctx context.Context, userID uuid
 			&project.ID,
 			&project.UserID,
 			&project.Name,
+			&project.Language,
+			pq.Array(&project.Preprocessing),
+			&project.Analysis,
 			&project.CreatedAt,
 			&project.UpdatedAt,
 		)
@@ -137,22 +181,35 @@ func (r *PostgresProjectRepository) Update(ctx context.Context, project *Project
 
 	query := `
 		UPDATE projects
-		SET name = $2, updated_at = $3
+		SET name = $2, language = $3, preprocessing = $4, analysis_config = $5, updated_at = $6
 		WHERE id = $1
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		project.ID,
 		project.Name,
+		project.Language,
+		pq.Array(preprocessingSteps(project.Preprocessing)),
+		project.Analysis,
 		project.UpdatedAt,
 	)
 
 	return err
 }
 
-// Delete removes a project from the database
+// Delete removes a project from the database; its documents, statements
+// and analysis results are removed by ON DELETE CASCADE
 func (r *PostgresProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM projects WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// preprocessingSteps stores a nil step list as an empty array, since the
+// column is NOT NULL
+func preprocessingSteps(steps []string) []string {
+	if steps == nil {
+		return []string{}
+	}
+	return steps
+}
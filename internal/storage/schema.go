@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// EmbeddingDimensionError reports that the statements.embedding column cannot
+// store vectors of the embedding model's dimension
+type EmbeddingDimensionError struct {
+	Column    int // declared dimension of statements.embedding
+	Embedding int // dimension produced by the embedding model
+}
+
+func (e *EmbeddingDimensionError) Error() string {
+	return fmt.Sprintf("statements.embedding is vector(%d) but embeddings have %d dimensions", e.Column, e.Embedding)
+}
+
+// EmbeddingColumnDimension returns the declared dimension of the
+// statements.embedding pgvector column, or 0 if it has none. pgvector keeps
+// the dimension in the column's type modifier.
+func EmbeddingColumnDimension(ctx context.Context, db *sql.DB) (int, error) {
+	query := `
+		SELECT atttypmod
+		FROM pg_attribute
+		WHERE attrelid = 'statements'::regclass AND attname = 'embedding' AND NOT attisdropped
+	`
+
+	var typmod int
+	if err := db.QueryRowContext(ctx, query).Scan(&typmod); err != nil {
+		return 0, fmt.Errorf("read statements.embedding dimension: %w", err)
+	}
+	if typmod < 0 {
+		return 0, nil
+	}
+	return typmod, nil
+}
+
+// CheckEmbeddingDimension verifies that statements.embedding can store
+// vectors of dimension dim. A mismatch is returned as
+// *EmbeddingDimensionError; a column without a declared dimension accepts
+// any vector.
+func CheckEmbeddingDimension(ctx context.Context, db *sql.DB, dim int) error {
+	column, err := EmbeddingColumnDimension(ctx, db)
+	if err != nil {
+		return err
+	}
+	if column != 0 && column != dim {
+		return &EmbeddingDimensionError{Column: column, Embedding: dim}
+	}
+	return nil
+}
+
+// maxHNSWDimensions is the largest vector pgvector's HNSW index accepts
+const maxHNSWDimensions = 2000
+
+// ResizeEmbeddingColumn changes statements.embedding to vector(dim) in a
+// single transaction. pgvector cannot convert stored vectors to another
+// dimension, so every embedding is cleared and each project must be
+// re-embedded afterwards (POST /projects/{id}/reembed). The HNSW index is
+// dropped first, since it would fail on the new type, and rebuilt when dim
+// is within its limit; larger embeddings are searched without an index.
+func ResizeEmbeddingColumn(ctx context.Context, db *sql.DB, dim int) error {
+	if dim < 1 {
+		return fmt.Errorf("invalid embedding dimension %d", dim)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DROP INDEX IF EXISTS idx_statements_embedding`,
+		fmt.Sprintf(`ALTER TABLE statements ALTER COLUMN embedding TYPE vector(%d) USING NULL`, dim),
+		`UPDATE statements SET embedding_model = ''`,
+	}
+	if dim <= maxHNSWDimensions {
+		queries = append(queries, `CREATE INDEX idx_statements_embedding ON statements
+			USING hnsw (embedding vector_cosine_ops)
+			WITH (m = 16, ef_construction = 64)`)
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("resize statements.embedding to vector(%d): %w", dim, err)
+		}
+	}
+
+	return tx.Commit()
+}
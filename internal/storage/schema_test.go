@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCheckEmbeddingDimension(t *testing.T) {
+	tests := []struct {
+		name    string
+		typmod  int
+		dim     int
+		wantErr bool
+	}{
+		{"match", 1536, 1536, false},
+		{"mismatch", 1536, 3072, true},
+		{"unconstrained", -1, 3072, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create mock db: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(`SELECT atttypmod FROM pg_attribute WHERE attrelid = 'statements'::regclass AND attname = 'embedding'`).
+				WillReturnRows(sqlmock.NewRows([]string{"atttypmod"}).AddRow(tt.typmod))
+
+			err = CheckEmbeddingDimension(context.Background(), db, tt.dim)
+			var dimErr *EmbeddingDimensionError
+			if tt.wantErr {
+				if !errors.As(err, &dimErr) {
+					t.Fatalf("expected EmbeddingDimensionError, got %v", err)
+				}
+				if dimErr.Column != tt.typmod || dimErr.Embedding != tt.dim {
+					t.Errorf("unexpected mismatch %+v", dimErr)
+				}
+			} else if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestResizeEmbeddingColumn(t *testing.T) {
+	tests := []struct {
+		name    string
+		dim     int
+		indexed bool
+	}{
+		{"indexed", 1024, true},
+		{"beyond the HNSW limit", 3072, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create mock db: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`DROP INDEX IF EXISTS idx_statements_embedding`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(fmt.Sprintf(`ALTER TABLE statements ALTER COLUMN embedding TYPE vector\(%d\) USING NULL`, tt.dim)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`UPDATE statements SET embedding_model = ''`).WillReturnResult(sqlmock.NewResult(0, 5))
+			if tt.indexed {
+				mock.ExpectExec(`CREATE INDEX idx_statements_embedding ON statements USING hnsw`).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			mock.ExpectCommit()
+
+			if err := ResizeEmbeddingColumn(context.Background(), db, tt.dim); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+
+	// A failing step rolls everything back
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DROP INDEX IF EXISTS idx_statements_embedding`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE statements`).WillReturnError(errors.New("permission denied"))
+	mock.ExpectRollback()
+	if err := ResizeEmbeddingColumn(context.Background(), db, 1024); err == nil {
+		t.Error("expected the failed ALTER to be reported")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
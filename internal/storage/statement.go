@@ -3,49 +3,198 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
 
 // Statement represents a statement extracted from a document
 type Statement struct {
-	ID         uuid.UUID
-	DocumentID uuid.UUID
-	Text       string
-	Position   int
-	Line       int
-	Embedding  pgvector.Vector
-	CreatedAt  time.Time
+	ID             uuid.UUID
+	DocumentID     uuid.UUID
+	Text           string
+	Position       int
+	Line           int
+	Embedding      pgvector.Vector
+	EmbeddingModel string // model that produced Embedding, empty if unknown
+	// Section is the markdown header hierarchy the statement sits under,
+	// e.g. "Billing > Refund Policy", empty outside any section
+	Section   string
+	CreatedAt time.Time
 }
 
+// vectorValue stores an empty embedding as NULL, since a vector column
+// cannot hold a vector without dimensions
+func vectorValue(v pgvector.Vector) interface{} {
+	if len(v.Slice()) == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullVector scans a nullable vector column, reading NULL as an empty
+// embedding
+type nullVector struct {
+	v *pgvector.Vector
+}
+
+func (n nullVector) Scan(src interface{}) error {
+	if src == nil {
+		*n.v = pgvector.Vector{}
+		return nil
+	}
+	return n.v.Scan(src)
+}
+
+// ErrDuplicatePosition is returned when a batch contains two statements with
+// the same position in the same document
+var ErrDuplicatePosition = errors.New("duplicate statement position")
+
 // StatementRepository defines the interface for statement storage operations
 type StatementRepository interface {
 	Create(ctx context.Context, statement *Statement) error
 	CreateBatch(ctx context.Context, statements []*Statement) error
+	CreateBatchPartial(ctx context.Context, statements []*Statement) (*BatchResult, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Statement, error)
 	GetByDocumentID(ctx context.Context, documentID uuid.UUID) ([]*Statement, error)
 	GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*Statement, error)
+	ListByProjectID(ctx context.Context, projectID uuid.UUID, limit, offset int) ([]*Statement, error)
+	CountByProjectID(ctx context.Context, projectID uuid.UUID) (int, error)
+	GetMissingEmbeddings(ctx context.Context, projectID uuid.UUID) ([]*Statement, error)
 	FindSimilar(ctx context.Context, embedding pgvector.Vector, limit int, threshold float64) ([]*StatementWithSimilarity, error)
+	FindSimilarForUser(ctx context.Context, userID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*ProjectStatementMatch, error)
+	FindSimilarInProject(ctx context.Context, projectID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*StatementWithSimilarity, error)
+	SearchByText(ctx context.Context, projectID uuid.UUID, query string, limit int) ([]*StatementSearchResult, error)
+	UpdateEmbeddings(ctx context.Context, statements []*Statement) error
+	AddTag(ctx context.Context, statementIDs []uuid.UUID, tag string) (int, error)
+	GetTags(ctx context.Context, statementID uuid.UUID) ([]string, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByDocumentID(ctx context.Context, documentID uuid.UUID) error
 }
 
+// BatchFailure is a statement that could not be inserted by CreateBatchPartial
+type BatchFailure struct {
+	Index    int // index in the input slice
+	Position int
+	Err      error
+}
+
+// BatchResult reports how many statements of a partial batch were inserted
+// and which ones failed
+type BatchResult struct {
+	Inserted int
+	Failures []BatchFailure
+}
+
 // StatementWithSimilarity represents a statement with its similarity score
 type StatementWithSimilarity struct {
 	Statement  *Statement
 	Similarity float64
 }
 
+// ProjectStatementMatch is a similar statement found across projects, tagged
+// with the project and document it belongs to
+type ProjectStatementMatch struct {
+	Statement  *Statement
+	ProjectID  uuid.UUID
+	Filename   string
+	Similarity float64
+}
+
+// StatementSearchResult is a full-text search match with its source document
+type StatementSearchResult struct {
+	Statement *Statement
+	Filename  string
+	Rank      float64
+}
+
 // PostgresStatementRepository implements StatementRepository using PostgreSQL with pgvector
 type PostgresStatementRepository struct {
 	db *sql.DB
+	// efSearch and probes tune the embedding index scans of the FindSimilar
+	// queries (hnsw.ef_search and ivfflat.probes); 0 keeps the server setting
+	efSearch int
+	probes   int
+}
+
+// embeddingIndex is the approximate nearest-neighbor index on
+// statements.embedding created by the initial schema
+const embeddingIndex = "idx_statements_embedding"
+
+// StatementRepositoryOption configures a PostgresStatementRepository
+type StatementRepositoryOption func(*PostgresStatementRepository)
+
+// WithEFSearch sets hnsw.ef_search for similarity queries: the size of the
+// candidate list an HNSW index scan keeps. Higher values improve recall at
+// the cost of speed (pgvector default 40).
+func WithEFSearch(n int) StatementRepositoryOption {
+	return func(r *PostgresStatementRepository) {
+		r.efSearch = n
+	}
+}
+
+// WithProbes sets ivfflat.probes for similarity queries: the number of lists
+// an IVFFlat index scan visits (pgvector default 1). It only matters if the
+// embedding index is IVFFlat.
+func WithProbes(n int) StatementRepositoryOption {
+	return func(r *PostgresStatementRepository) {
+		r.probes = n
+	}
 }
 
 // NewPostgresStatementRepository creates a new PostgresStatementRepository
-func NewPostgresStatementRepository(db *sql.DB) *PostgresStatementRepository {
-	return &PostgresStatementRepository{db: db}
+func NewPostgresStatementRepository(db *sql.DB, opts ...StatementRepositoryOption) *PostgresStatementRepository {
+	r := &PostgresStatementRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// querySimilar runs a nearest-neighbor query. With index tuning configured
+// it runs in a transaction that sets the parameters for that query only;
+// the returned done func ends it and must be called after rows is closed.
+func (r *PostgresStatementRepository) querySimilar(ctx context.Context, query string, args ...interface{}) (*sql.Rows, func(), error) {
+	if r.efSearch <= 0 && r.probes <= 0 {
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		return rows, func() {}, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.efSearch > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", r.efSearch)); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+	}
+	if r.probes > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL ivfflat.probes = %d", r.probes)); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return rows, func() { tx.Rollback() }, nil
+}
+
+// RebuildEmbeddingIndex rebuilds the embedding index without blocking reads
+// or writes. Run it after bulk inserts or deletes, which degrade an HNSW
+// graph's recall and leave an IVFFlat index's lists unbalanced.
+func (r *PostgresStatementRepository) RebuildEmbeddingIndex(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, "REINDEX INDEX CONCURRENTLY "+embeddingIndex)
+	return err
 }
 
 // Create inserts a new statement into the database
@@ -59,8 +208,8 @@ func (r *PostgresStatementRepository) Create(ctx context.Context, statement *Sta
 	}
 
 	query := `
-		INSERT INTO statements (id, document_id, text, position, line, embedding, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO statements (id, document_id, text, position, line, embedding, embedding_model, section, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -69,7 +218,9 @@ func (r *PostgresStatementRepository) Create(ctx context.Context, statement *Sta
 		statement.Text,
 		statement.Position,
 		statement.Line,
-		statement.Embedding,
+		vectorValue(statement.Embedding),
+		statement.EmbeddingModel,
+		statement.Section,
 		statement.CreatedAt,
 	)
 
@@ -82,16 +233,63 @@ func (r *PostgresStatementRepository) CreateBatch(ctx context.Context, statement
 		return nil
 	}
 
+	if err := validatePositions(statements); err != nil {
+		return err
+	}
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO statements (id, document_id, text, position, line, embedding, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`)
+	if err := insertStatements(ctx, tx, statements); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateBatchPartial inserts multiple statements in a single transaction like
+// CreateBatch, but a failing row is rolled back to a savepoint and reported
+// instead of aborting the batch. The remaining rows are committed. Only
+// errors that affect the whole batch (validation, begin or commit) are
+// returned as err.
+func (r *PostgresStatementRepository) CreateBatchPartial(ctx context.Context, statements []*Statement) (*BatchResult, error) {
+	if len(statements) == 0 {
+		return &BatchResult{}, nil
+	}
+
+	if err := validatePositions(statements); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := insertStatementsPartial(ctx, tx, statements)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+const insertStatementQuery = `
+	INSERT INTO statements (id, document_id, text, position, line, embedding, embedding_model, section, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+// insertStatements inserts statements within tx, stopping at the first
+// failing row
+func insertStatements(ctx context.Context, tx *sql.Tx, statements []*Statement) error {
+	stmt, err := tx.PrepareContext(ctx, insertStatementQuery)
 	if err != nil {
 		return err
 	}
@@ -112,7 +310,9 @@ func (r *PostgresStatementRepository) CreateBatch(ctx context.Context, statement
 			s.Text,
 			s.Position,
 			s.Line,
-			s.Embedding,
+			vectorValue(s.Embedding),
+			s.EmbeddingModel,
+			s.Section,
 			s.CreatedAt,
 		)
 		if err != nil {
@@ -120,13 +320,67 @@ func (r *PostgresStatementRepository) CreateBatch(ctx context.Context, statement
 		}
 	}
 
-	return tx.Commit()
+	return nil
+}
+
+// insertStatementsPartial inserts statements within tx, rolling a failing
+// row back to a savepoint and reporting it instead of aborting
+func insertStatementsPartial(ctx context.Context, tx *sql.Tx, statements []*Statement) (*BatchResult, error) {
+	result := &BatchResult{}
+
+	stmt, err := tx.PrepareContext(ctx, insertStatementQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for i, s := range statements {
+		if s.ID == uuid.Nil {
+			s.ID = uuid.New()
+		}
+		if s.CreatedAt.IsZero() {
+			s.CreatedAt = now
+		}
+
+		// A failed statement aborts the transaction in PostgreSQL, so each
+		// row gets its own savepoint to roll back to
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT statement_row"); err != nil {
+			return nil, err
+		}
+
+		_, err := stmt.ExecContext(ctx,
+			s.ID,
+			s.DocumentID,
+			s.Text,
+			s.Position,
+			s.Line,
+			vectorValue(s.Embedding),
+			s.EmbeddingModel,
+			s.Section,
+			s.CreatedAt,
+		)
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT statement_row"); rbErr != nil {
+				return nil, rbErr
+			}
+			result.Failures = append(result.Failures, BatchFailure{Index: i, Position: s.Position, Err: err})
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT statement_row"); err != nil {
+			return nil, err
+		}
+		result.Inserted++
+	}
+
+	return result, nil
 }
 
 // GetByID retrieves a statement by its ID
 func (r *PostgresStatementRepository) GetByID(ctx context.Context, id uuid.UUID) (*Statement, error) {
 	query := `
-		SELECT id, document_id, text, position, line, embedding, created_at
+		SELECT id, document_id, text, position, line, embedding, embedding_model, section, created_at
 		FROM statements
 		WHERE id = $1
 	`
@@ -138,7 +392,9 @@ func (r *PostgresStatementRepository) GetByID(ctx context.Context, id uuid.UUID)
 		&statement.Text,
 		&statement.Position,
 		&statement.Line,
-		&statement.Embedding,
+		nullVector{&statement.Embedding},
+		&statement.EmbeddingModel,
+		&statement.Section,
 		&statement.CreatedAt,
 	)
 
@@ -155,10 +411,10 @@ func (r *PostgresStatementRepository) GetByID(ctx context.Context, id uuid.UUID)
 // GetByDocumentID retrieves all statements for a specific document
 func (r *PostgresStatementRepository) GetByDocumentID(ctx context.Context, documentID uuid.UUID) ([]*Statement, error) {
 	query := `
-		SELECT id, document_id, text, position, line, embedding, created_at
+		SELECT id, document_id, text, position, line, embedding, embedding_model, section, created_at
 		FROM statements
 		WHERE document_id = $1
-		ORDER BY position ASC
+		ORDER BY position ASC, id ASC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, documentID)
@@ -176,7 +432,9 @@ func (r *PostgresStatementRepository) GetByDocumentID(ctx context.Context, docum
 			&statement.Text,
 			&statement.Position,
 			&statement.Line,
-			&statement.Embedding,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
 			&statement.CreatedAt,
 		)
 		if err != nil {
@@ -195,11 +453,11 @@ func (r *PostgresStatementRepository) GetByDocumentID(ctx context.Context, docum
 // GetByProjectID retrieves all statements for a specific project (via documents)
 func (r *PostgresStatementRepository) GetByProjectID(ctx context.Context, projectID uuid.UUID) ([]*Statement, error) {
 	query := `
-		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.created_at
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.embedding_model, s.section, s.created_at
 		FROM statements s
 		JOIN documents d ON s.document_id = d.id
 		WHERE d.project_id = $1
-		ORDER BY d.filename ASC, s.position ASC
+		ORDER BY d.filename ASC, d.id ASC, s.position ASC, s.id ASC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, projectID)
@@ -217,7 +475,115 @@ func (r *PostgresStatementRepository) GetByProjectID(ctx context.Context, projec
 			&statement.Text,
 			&statement.Position,
 			&statement.Line,
-			&statement.Embedding,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
+			&statement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+// ListByProjectID retrieves one page of a project's statements in the order
+// of GetByProjectID. Embeddings are not loaded, so listing stays cheap for
+// large projects.
+func (r *PostgresStatementRepository) ListByProjectID(ctx context.Context, projectID uuid.UUID, limit, offset int) ([]*Statement, error) {
+	query := `
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding_model, s.section, s.created_at
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id
+		WHERE d.project_id = $1
+		ORDER BY d.filename ASC, d.id ASC, s.position ASC, s.id ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []*Statement
+	for rows.Next() {
+		statement := &Statement{}
+		err := rows.Scan(
+			&statement.ID,
+			&statement.DocumentID,
+			&statement.Text,
+			&statement.Position,
+			&statement.Line,
+			&statement.EmbeddingModel,
+			&statement.Section,
+			&statement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+// CountByProjectID returns the number of statements in a project
+func (r *PostgresStatementRepository) CountByProjectID(ctx context.Context, projectID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id
+		WHERE d.project_id = $1
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, projectID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetMissingEmbeddings retrieves the statements of a project stored without
+// an embedding, e.g. because embedding failed on upload, in the order of
+// GetByProjectID
+func (r *PostgresStatementRepository) GetMissingEmbeddings(ctx context.Context, projectID uuid.UUID) ([]*Statement, error) {
+	query := `
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.embedding_model, s.section, s.created_at
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id
+		WHERE d.project_id = $1 AND s.embedding IS NULL
+		ORDER BY d.filename ASC, d.id ASC, s.position ASC, s.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []*Statement
+	for rows.Next() {
+		statement := &Statement{}
+		err := rows.Scan(
+			&statement.ID,
+			&statement.DocumentID,
+			&statement.Text,
+			&statement.Position,
+			&statement.Line,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
 			&statement.CreatedAt,
 		)
 		if err != nil {
@@ -245,7 +611,7 @@ func (r *PostgresStatementRepository) FindSimilar(ctx context.Context, embedding
 	// Use cosine distance: 1 - cosine_similarity
 	// We filter where 1 - distance >= threshold (i.e., distance <= 1 - threshold)
 	query := `
-		SELECT id, document_id, text, position, line, embedding, created_at,
+		SELECT id, document_id, text, position, line, embedding, embedding_model, section, created_at,
 			   1 - (embedding <=> $1) as similarity
 		FROM statements
 		WHERE 1 - (embedding <=> $1) >= $2
@@ -253,10 +619,129 @@ func (r *PostgresStatementRepository) FindSimilar(ctx context.Context, embedding
 		LIMIT $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, embedding, threshold, limit)
+	rows, done, err := r.querySimilar(ctx, query, embedding, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	defer rows.Close()
+
+	var results []*StatementWithSimilarity
+	for rows.Next() {
+		statement := &Statement{}
+		var similarity float64
+		err := rows.Scan(
+			&statement.ID,
+			&statement.DocumentID,
+			&statement.Text,
+			&statement.Position,
+			&statement.Line,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
+			&statement.CreatedAt,
+			&similarity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &StatementWithSimilarity{
+			Statement:  statement,
+			Similarity: similarity,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindSimilarForUser finds statements similar to the given embedding in any
+// project owned by userID
+func (r *PostgresStatementRepository) FindSimilarForUser(ctx context.Context, userID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*ProjectStatementMatch, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if threshold <= 0 {
+		threshold = 0.75
+	}
+
+	query := `
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.embedding_model, s.section, s.created_at,
+			   d.project_id, d.filename, 1 - (s.embedding <=> $2) as similarity
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id
+		JOIN projects p ON d.project_id = p.id
+		WHERE p.user_id = $1 AND 1 - (s.embedding <=> $2) >= $3
+		ORDER BY s.embedding <=> $2
+		LIMIT $4
+	`
+
+	rows, done, err := r.querySimilar(ctx, query, userID, embedding, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	defer rows.Close()
+
+	var results []*ProjectStatementMatch
+	for rows.Next() {
+		statement := &Statement{}
+		match := &ProjectStatementMatch{Statement: statement}
+		err := rows.Scan(
+			&statement.ID,
+			&statement.DocumentID,
+			&statement.Text,
+			&statement.Position,
+			&statement.Line,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
+			&statement.CreatedAt,
+			&match.ProjectID,
+			&match.Filename,
+			&match.Similarity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindSimilarInProject finds statements of one project similar to the given
+// embedding, most similar first
+func (r *PostgresStatementRepository) FindSimilarInProject(ctx context.Context, projectID uuid.UUID, embedding pgvector.Vector, limit int, threshold float64) ([]*StatementWithSimilarity, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if threshold <= 0 {
+		threshold = 0.75
+	}
+
+	query := `
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.embedding_model, s.section, s.created_at,
+			   1 - (s.embedding <=> $2) as similarity
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id
+		WHERE d.project_id = $1 AND 1 - (s.embedding <=> $2) >= $3
+		ORDER BY s.embedding <=> $2
+		LIMIT $4
+	`
+
+	rows, done, err := r.querySimilar(ctx, query, projectID, embedding, threshold, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer done()
 	defer rows.Close()
 
 	var results []*StatementWithSimilarity
@@ -269,7 +754,9 @@ func (r *PostgresStatementRepository) FindSimilar(ctx context.Context, embedding
 			&statement.Text,
 			&statement.Position,
 			&statement.Line,
-			&statement.Embedding,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
 			&statement.CreatedAt,
 			&similarity,
 		)
@@ -289,6 +776,146 @@ func (r *PostgresStatementRepository) FindSimilar(ctx context.Context, embedding
 	return results, nil
 }
 
+// SearchByText finds statements in a project matching query using Postgres
+// full-text search, best matches first. The query is parsed with
+// plainto_tsquery, so every word must match and operators are not supported.
+func (r *PostgresStatementRepository) SearchByText(ctx context.Context, projectID uuid.UUID, query string, limit int) ([]*StatementSearchResult, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// The expression must match idx_statements_text_search for the index to be used
+	sqlQuery := `
+		SELECT s.id, s.document_id, s.text, s.position, s.line, s.embedding, s.embedding_model, s.section, s.created_at,
+			   d.filename, ts_rank(to_tsvector('english', s.text), q) AS rank
+		FROM statements s
+		JOIN documents d ON s.document_id = d.id,
+			 plainto_tsquery('english', $2) q
+		WHERE d.project_id = $1 AND to_tsvector('english', s.text) @@ q
+		ORDER BY rank DESC, d.filename ASC, s.position ASC, s.id ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, projectID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*StatementSearchResult
+	for rows.Next() {
+		statement := &Statement{}
+		result := &StatementSearchResult{Statement: statement}
+		err := rows.Scan(
+			&statement.ID,
+			&statement.DocumentID,
+			&statement.Text,
+			&statement.Position,
+			&statement.Line,
+			nullVector{&statement.Embedding},
+			&statement.EmbeddingModel,
+			&statement.Section,
+			&statement.CreatedAt,
+			&result.Filename,
+			&result.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateEmbeddings stores the embedding and embedding model of each statement
+// in a single transaction, so a project is never left half re-embedded
+func (r *PostgresStatementRepository) UpdateEmbeddings(ctx context.Context, statements []*Statement) error {
+	if len(statements) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE statements SET embedding = $2, embedding_model = $3 WHERE id = $1`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range statements {
+		if _, err := stmt.ExecContext(ctx, s.ID, vectorValue(s.Embedding), s.EmbeddingModel); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddTag attaches tag to every given statement in a single statement and
+// returns how many statements were newly tagged. Statements that already
+// carry the tag are left unchanged.
+func (r *PostgresStatementRepository) AddTag(ctx context.Context, statementIDs []uuid.UUID, tag string) (int, error) {
+	if len(statementIDs) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO statement_tags (statement_id, tag)
+		SELECT unnest($1::uuid[]), $2
+		ON CONFLICT (statement_id, tag) DO NOTHING
+	`
+
+	ids := make([]string, len(statementIDs))
+	for i, id := range statementIDs {
+		ids[i] = id.String()
+	}
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(ids), tag)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// GetTags returns the tags of a statement in alphabetical order
+func (r *PostgresStatementRepository) GetTags(ctx context.Context, statementID uuid.UUID) ([]string, error) {
+	query := `SELECT tag FROM statement_tags WHERE statement_id = $1 ORDER BY tag ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, statementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 // Delete removes a statement from the database
 func (r *PostgresStatementRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM statements WHERE id = $1`
@@ -302,3 +929,21 @@ func (r *PostgresStatementRepository) DeleteByDocumentID(ctx context.Context, do
 	_, err := r.db.ExecContext(ctx, query, documentID)
 	return err
 }
+
+// validatePositions ensures positions are unique per document within a batch
+func validatePositions(statements []*Statement) error {
+	type key struct {
+		documentID uuid.UUID
+		position   int
+	}
+
+	seen := make(map[key]bool, len(statements))
+	for _, s := range statements {
+		k := key{s.DocumentID, s.Position}
+		if seen[k] {
+			return fmt.Errorf("%w: document %s position %d", ErrDuplicatePosition, s.DocumentID, s.Position)
+		}
+		seen[k] = true
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+)
+
+var statementColumns = []string{"id", "document_id", "text", "position", "line", "embedding", "embedding_model", "section", "created_at"}
+
+func TestPostgresStatementRepository_GetByDocumentID_TieBreak(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	docID := uuid.New()
+	idA := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	idB := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	now := time.Now()
+
+	// The query must break position ties by id so row order is stable
+	rows := sqlmock.NewRows(statementColumns).
+		AddRow(idA, docID, "first", 0, 1, "[1,0]", "", "", now).
+		AddRow(idB, docID, "second", 0, 2, "[0,1]", "", "", now)
+
+	mock.ExpectQuery(`SELECT (.+) FROM statements WHERE document_id = \$1 ORDER BY position ASC, id ASC`).
+		WithArgs(docID).
+		WillReturnRows(rows)
+
+	statements, err := repo.GetByDocumentID(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+	if statements[0].ID != idA || statements[1].ID != idB {
+		t.Errorf("expected order [%s %s], got [%s %s]", idA, idB, statements[0].ID, statements[1].ID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_ListByProjectID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	projectID := uuid.New()
+	docID := uuid.New()
+	now := time.Now()
+
+	// The page is selected in SQL and the embedding column is not read
+	rows := sqlmock.NewRows([]string{"id", "document_id", "text", "position", "line", "embedding_model", "section", "created_at"}).
+		AddRow(uuid.New(), docID, "third", 2, 3, "model", "", now)
+	mock.ExpectQuery(`SELECT s\.id, s\.document_id, s\.text, s\.position, s\.line, s\.embedding_model, s\.section, s\.created_at FROM statements s .+ LIMIT \$2 OFFSET \$3`).
+		WithArgs(projectID, 1, 2).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM statements s JOIN documents d ON s\.document_id = d\.id WHERE d\.project_id = \$1`).
+		WithArgs(projectID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	statements, err := repo.ListByProjectID(context.Background(), projectID, 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(statements) != 1 || statements[0].Text != "third" || len(statements[0].Embedding.Slice()) != 0 {
+		t.Errorf("expected the third statement without an embedding, got %+v", statements)
+	}
+
+	total, err := repo.CountByProjectID(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if total != 3 {
+		t.Errorf("expected 3 statements, got %d", total)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_CreateBatch_DuplicatePosition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	docID := uuid.New()
+	statements := []*Statement{
+		{DocumentID: docID, Text: "a", Position: 0, Embedding: pgvector.NewVector(nil)},
+		{DocumentID: docID, Text: "b", Position: 0, Embedding: pgvector.NewVector(nil)},
+	}
+
+	// No database calls are expected; validation fails first
+	err = repo.CreateBatch(context.Background(), statements)
+	if !errors.Is(err, ErrDuplicatePosition) {
+		t.Errorf("expected ErrDuplicatePosition, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_CreateBatchPartial_SkipsFailingRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	docID := uuid.New()
+	statements := []*Statement{
+		{DocumentID: docID, Text: "a", Position: 0, Embedding: pgvector.NewVector([]float32{1, 0})},
+		{DocumentID: docID, Text: "b", Position: 1, Embedding: pgvector.NewVector([]float32{1, 0, 0})},
+		{DocumentID: docID, Text: "c", Position: 2, Embedding: pgvector.NewVector([]float32{0, 1})},
+	}
+
+	mock.ExpectBegin()
+	insert := mock.ExpectPrepare(`INSERT INTO statements`)
+	mock.ExpectExec(`SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WithArgs(sqlmock.AnyArg(), docID, "a", 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WithArgs(sqlmock.AnyArg(), docID, "b", 1, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+		WillReturnError(errors.New("expected 2 dimensions, not 3"))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WithArgs(sqlmock.AnyArg(), docID, "c", 2, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT statement_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	result, err := repo.CreateBatchPartial(context.Background(), statements)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("expected 2 statements inserted, got %d", result.Inserted)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Index != 1 || result.Failures[0].Position != 1 {
+		t.Fatalf("expected the statement at index 1 to fail, got %+v", result.Failures)
+	}
+	if result.Failures[0].Err == nil {
+		t.Error("expected the failure to carry the row error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_SearchByText(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	projectID := uuid.New()
+	docID := uuid.New()
+	id := uuid.New()
+	columns := append(append([]string{}, statementColumns...), "filename", "rank")
+	rows := sqlmock.NewRows(columns).
+		AddRow(id, docID, "Refunds are issued within 14 days", 3, 12, "[1,0]", "text-embedding-3-small", "", time.Now(), "policy.md", 0.6)
+
+	mock.ExpectQuery(`SELECT (.+) FROM statements s JOIN documents d (.+) plainto_tsquery\('english', \$2\) (.+) WHERE d.project_id = \$1 AND to_tsvector\('english', s.text\) @@ q`).
+		WithArgs(projectID, "refund policy", 50).
+		WillReturnRows(rows)
+
+	results, err := repo.SearchByText(context.Background(), projectID, "refund policy", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Statement.ID != id || results[0].Filename != "policy.md" || results[0].Statement.Line != 12 {
+		t.Errorf("unexpected result %+v (statement %+v)", results[0], results[0].Statement)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_FindSimilarForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	userID := uuid.New()
+	projectID := uuid.New()
+	embedding := pgvector.NewVector([]float32{1, 0})
+	columns := append(append([]string{}, statementColumns...), "project_id", "filename", "similarity")
+	rows := sqlmock.NewRows(columns).
+		AddRow(uuid.New(), uuid.New(), "match", 0, 1, "[1,0]", "text-embedding-3-small", "", time.Now(), projectID, "a.md", 0.98)
+
+	// Results must be restricted to projects owned by the user
+	mock.ExpectQuery(`SELECT (.+) FROM statements s JOIN documents d (.+) JOIN projects p ON d.project_id = p.id WHERE p.user_id = \$1`).
+		WithArgs(userID, embedding, 0.8, 5).
+		WillReturnRows(rows)
+
+	matches, err := repo.FindSimilarForUser(context.Background(), userID, embedding, 5, 0.8)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(matches) != 1 || matches[0].ProjectID != projectID || matches[0].Filename != "a.md" {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_AddTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	// Already tagged statements are skipped, so only new tags count
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	mock.ExpectExec(`INSERT INTO statement_tags (.+) ON CONFLICT \(statement_id, tag\) DO NOTHING`).
+		WithArgs(sqlmock.AnyArg(), "refunds").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	added, err := repo.AddTag(context.Background(), ids, "refunds")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if added != 1 {
+		t.Errorf("expected 1 newly tagged statement, got %d", added)
+	}
+
+	if added, err := repo.AddTag(context.Background(), nil, "refunds"); err != nil || added != 0 {
+		t.Errorf("expected an empty batch to be a no-op, got %d, %v", added, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_UpdateEmbeddings_RollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	statements := []*Statement{
+		{ID: uuid.New(), Embedding: pgvector.NewVector([]float32{1, 0}), EmbeddingModel: "m"},
+		{ID: uuid.New(), Embedding: pgvector.NewVector([]float32{0, 1}), EmbeddingModel: "m"},
+	}
+
+	// A failing row must roll back the rows updated before it
+	mock.ExpectBegin()
+	update := mock.ExpectPrepare(`UPDATE statements SET embedding = \$2, embedding_model = \$3 WHERE id = \$1`)
+	update.ExpectExec().WithArgs(statements[0].ID, sqlmock.AnyArg(), "m").WillReturnResult(sqlmock.NewResult(0, 1))
+	update.ExpectExec().WithArgs(statements[1].ID, sqlmock.AnyArg(), "m").WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	if err := repo.UpdateEmbeddings(context.Background(), statements); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_GetMissingEmbeddings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db)
+
+	projectID := uuid.New()
+	rows := sqlmock.NewRows(statementColumns).
+		AddRow(uuid.New(), uuid.New(), "not embedded", 0, 1, nil, "", "", time.Now())
+
+	mock.ExpectQuery(`SELECT (.+) FROM statements s JOIN documents d ON s.document_id = d.id WHERE d.project_id = \$1 AND s.embedding IS NULL`).
+		WithArgs(projectID).
+		WillReturnRows(rows)
+
+	statements, err := repo.GetMissingEmbeddings(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(statements) != 1 || len(statements[0].Embedding.Slice()) != 0 {
+		t.Errorf("expected 1 statement with an empty embedding, got %+v", statements)
+	}
+
+	// Statements without an embedding are stored with NULL
+	stmt := &Statement{ID: uuid.New(), DocumentID: uuid.New(), Text: "not embedded"}
+	mock.ExpectExec(`INSERT INTO statements`).
+		WithArgs(stmt.ID, stmt.DocumentID, stmt.Text, 0, 0, nil, "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.Create(context.Background(), stmt); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_FindSimilarIndexTuning(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStatementRepository(db, WithEFSearch(100), WithProbes(8))
+	projectID := uuid.New()
+	embedding := pgvector.NewVector([]float32{1, 0})
+	columns := append(append([]string{}, statementColumns...), "similarity")
+
+	// The parameters apply to the query's own transaction only
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET LOCAL hnsw.ef_search = 100`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SET LOCAL ivfflat.probes = 8`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT (.+) FROM statements s JOIN documents d (.+) WHERE d.project_id = \$1`).
+		WithArgs(projectID, embedding, 0.8, 5).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), uuid.New(), "match", 0, 1, "[1,0]", "text-embedding-3-small", "", time.Now(), 0.98))
+	mock.ExpectRollback()
+
+	results, err := repo.FindSimilarInProject(context.Background(), projectID, embedding, 5, 0.8)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result, got %d", len(results))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStatementRepository_RebuildEmbeddingIndex(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`REINDEX INDEX CONCURRENTLY idx_statements_embedding`).WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := NewPostgresStatementRepository(db).RebuildEmbeddingIndex(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
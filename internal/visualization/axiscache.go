@@ -0,0 +1,62 @@
+package visualization
+
+import (
+	"container/list"
+	"sync"
+)
+
+// axisCache is an LRU cache of axis word embeddings. Axis words are few and
+// their embeddings stable, so entries never expire.
+type axisCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type axisCacheEntry struct {
+	key       string
+	embedding []float32
+}
+
+// newAxisCache creates a cache holding up to maxEntries embeddings
+func newAxisCache(maxEntries int) *axisCache {
+	return &axisCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached embedding for key
+func (c *axisCache) get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*axisCacheEntry).embedding, true
+}
+
+// add stores embedding under key, evicting the least recently used entry
+// when the cache is full
+func (c *axisCache) add(key string, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*axisCacheEntry).embedding = embedding
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&axisCacheEntry{key: key, embedding: embedding})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*axisCacheEntry).key)
+	}
+}
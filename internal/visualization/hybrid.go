@@ -0,0 +1,135 @@
+package visualization
+
+import (
+	"fmt"
+	"math"
+)
+
+// HybridReducer implements Reducer with semantic axes for the first
+// dimensions and PCA for the rest. The PCA components are taken from the
+// embeddings with the semantic directions projected out, so they show the
+// spread the semantic axes do not already explain.
+type HybridReducer struct {
+	axes []SemanticAxis
+}
+
+// NewHybridReducer creates a reducer combining semantic axes with PCA
+func NewHybridReducer(axes []SemanticAxis) *HybridReducer {
+	return &HybridReducer{axes: axes}
+}
+
+// Name returns the reducer name
+func (r *HybridReducer) Name() string {
+	return "hybrid"
+}
+
+// Reduce projects embeddings onto the semantic axes, then fills the
+// remaining dimensions with the principal components of the residual.
+// Every dimension is normalized to [-1, 1] on its own.
+func (r *HybridReducer) Reduce(embeddings [][]float32, dims int) ([][]float64, error) {
+	if len(r.axes) == 0 {
+		return nil, fmt.Errorf("no semantic axes defined")
+	}
+	if len(embeddings) == 0 {
+		return nil, nil
+	}
+
+	axes := r.axes
+	if dims < len(axes) {
+		axes = axes[:dims]
+	}
+	semantic := ProjectToAxes(embeddings, axes)
+
+	rest := dims - len(axes)
+	if rest <= 0 {
+		return semantic, nil
+	}
+
+	// PCA only looks at the first maxPCADimensions dimensions, so the
+	// residual is taken in that subspace where its components must be
+	// orthogonal to the axes
+	d := len(embeddings[0])
+	if d > maxPCADimensions {
+		d = maxPCADimensions
+	}
+	basis := orthonormalBasis(axes, d)
+
+	residuals := make([][]float32, len(embeddings))
+	for i, emb := range embeddings {
+		v := make([]float64, d)
+		for j := 0; j < d && j < len(emb); j++ {
+			v[j] = float64(emb[j])
+		}
+		for _, u := range basis {
+			dot := 0.0
+			for j := range v {
+				dot += v[j] * u[j]
+			}
+			for j := range v {
+				v[j] -= dot * u[j]
+			}
+		}
+		residuals[i] = make([]float32, d)
+		for j, x := range v {
+			residuals[i][j] = float32(x)
+		}
+	}
+
+	components, err := NewPCAReducer().Reduce(residuals, rest)
+	if err != nil {
+		return nil, fmt.Errorf("pca of residual: %w", err)
+	}
+
+	// PCA yields fewer components than asked for with few points or
+	// dimensions; the missing ones stay 0
+	coords := make([][]float64, len(embeddings))
+	for i := range coords {
+		coords[i] = make([]float64, dims)
+		copy(coords[i], semantic[i])
+		copy(coords[i][len(axes):], components[i])
+	}
+	return coords, nil
+}
+
+// orthonormalBasis returns unit vectors spanning the first d dimensions of
+// the axis embeddings, by Gram-Schmidt. Axes that are (nearly) a combination
+// of earlier ones add no vector.
+func orthonormalBasis(axes []SemanticAxis, d int) [][]float64 {
+	var basis [][]float64
+	for _, axis := range axes {
+		v := make([]float64, d)
+		for j := 0; j < d && j < len(axis.Embedding); j++ {
+			v[j] = float64(axis.Embedding[j])
+		}
+		original := vectorNorm64(v)
+
+		for _, u := range basis {
+			dot := 0.0
+			for j := range v {
+				dot += v[j] * u[j]
+			}
+			for j := range v {
+				v[j] -= dot * u[j]
+			}
+		}
+
+		norm := vectorNorm64(v)
+		if original == 0 || norm < 1e-9*original {
+			continue
+		}
+		for j := range v {
+			v[j] /= norm
+		}
+		basis = append(basis, v)
+	}
+	return basis
+}
+
+// vectorNorm64 computes the Euclidean norm of a vector
+func vectorNorm64(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,115 @@
+package visualization
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// correlation returns the Pearson correlation of x and y
+func correlation(x, y []float64) float64 {
+	n := float64(len(x))
+	var sx, sy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func TestHybridReducer_PCAIsOrthogonalToAxes(t *testing.T) {
+	// Most of the spread is along the first dimension, which plain PCA would
+	// pick as its first component
+	a := []float64{10, -10, 5, -5, 8, -3, 0, 2}
+	b := []float64{1, 2, -1, -2, 0.5, -0.5, 1.5, -1.5}
+	embeddings := make([][]float32, len(a))
+	for i := range a {
+		embeddings[i] = []float32{float32(a[i]), float32(b[i]), 0, 0}
+	}
+
+	axes := []SemanticAxis{{Word: "risk", Embedding: []float32{1, 0, 0, 0}}}
+	coords, err := NewHybridReducer(axes).Reduce(embeddings, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	x := make([]float64, len(coords))
+	y := make([]float64, len(coords))
+	for i, c := range coords {
+		if len(c) != 2 {
+			t.Fatalf("expected 2 dimensions, got %v", c)
+		}
+		x[i], y[i] = c[0], c[1]
+	}
+	if r := correlation(x, a); r < 0.999 {
+		t.Errorf("expected the first axis to follow the semantic direction, correlation %v", r)
+	}
+	if r := math.Abs(correlation(y, b)); r < 0.999 {
+		t.Errorf("expected the PCA axis to follow the residual spread, correlation %v", r)
+	}
+}
+
+func TestHybridReducer_AxesOnly(t *testing.T) {
+	embeddings := [][]float32{{1, 0}, {0, 1}, {1, 1}}
+	axes := []SemanticAxis{{Word: "a", Embedding: []float32{1, 0}}, {Word: "b", Embedding: []float32{0, 1}}}
+
+	hybrid, err := NewHybridReducer(axes).Reduce(embeddings, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	semantic := ProjectToAxes(embeddings, axes)
+	for i := range hybrid {
+		for j := range hybrid[i] {
+			if hybrid[i][j] != semantic[i][j] {
+				t.Fatalf("expected the semantic projection when axes fill every dimension, got %v want %v", hybrid, semantic)
+			}
+		}
+	}
+}
+
+func TestOrthonormalBasis_SkipsDependentAxes(t *testing.T) {
+	axes := []SemanticAxis{
+		{Word: "a", Embedding: []float32{1, 1, 0}},
+		{Word: "b", Embedding: []float32{2, 2, 0}},
+		{Word: "c", Embedding: []float32{1, 0, 0}},
+	}
+	basis := orthonormalBasis(axes, 3)
+	if len(basis) != 2 {
+		t.Fatalf("expected 2 basis vectors, got %d", len(basis))
+	}
+	dot := 0.0
+	for j := range basis[0] {
+		dot += basis[0][j] * basis[1][j]
+	}
+	if math.Abs(dot) > 1e-9 || math.Abs(vectorNorm64(basis[1])-1) > 1e-9 {
+		t.Errorf("expected orthonormal vectors, got %v", basis)
+	}
+}
+
+func TestGetVisualization_Hybrid(t *testing.T) {
+	embedder := stubEmbedder{"risk": {1, 0, 0}, "cost": {0, 1, 0}}
+	svc := NewService(DefaultConfig(), embedder)
+	embeddings := [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 1}}
+
+	result, err := svc.GetVisualization(context.Background(), embeddings, "hybrid", 3, []string{"risk"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Method != "hybrid" || result.Dimensions != 3 || len(result.Axes) != 1 || len(result.Points) != len(embeddings) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	if _, err := svc.GetVisualization(context.Background(), embeddings, "hybrid", 2, nil); err == nil {
+		t.Error("expected an error without axis words")
+	}
+	if _, err := svc.GetVisualization(context.Background(), embeddings, "hybrid", 1, []string{"risk", "cost"}); err == nil {
+		t.Error("expected an error with more axis words than dimensions")
+	}
+}
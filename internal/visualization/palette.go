@@ -0,0 +1,87 @@
+package visualization
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultPalette is the base set of cluster colors
+var DefaultPalette = []string{
+	"#3498db", "#e74c3c", "#2ecc71", "#f39c12", "#9b59b6",
+	"#1abc9c", "#e91e63", "#00bcd4", "#ff5722", "#607d8b",
+}
+
+// goldenAngle spaces generated hues so consecutive colors are far apart
+const goldenAngle = 137.50776405
+
+// ClusterColors returns n distinct colors, one per cluster index.
+// Colors come from the palette first; beyond that they are generated by
+// stepping the hue by the golden angle in HSL space, cycling through a few
+// lightness levels so colors stay distinct even for large n. The result is
+// deterministic for a given palette and n.
+func ClusterColors(palette []string, n int) []string {
+	if n <= 0 {
+		return []string{}
+	}
+	if len(palette) == 0 {
+		palette = DefaultPalette
+	}
+
+	colors := make([]string, 0, n)
+	used := make(map[string]bool, n)
+	for _, c := range palette {
+		if len(colors) == n {
+			break
+		}
+		c = strings.ToLower(c)
+		if used[c] {
+			continue
+		}
+		used[c] = true
+		colors = append(colors, c)
+	}
+
+	lightness := []float64{0.45, 0.60, 0.35, 0.70}
+	for i := 0; len(colors) < n; i++ {
+		hue := math.Mod(float64(i)*goldenAngle, 360)
+		l := lightness[(i/12)%len(lightness)]
+		c := hslToHex(hue, 0.65, l)
+		if used[c] {
+			continue
+		}
+		used[c] = true
+		colors = append(colors, c)
+	}
+
+	return colors
+}
+
+// hslToHex converts an HSL color (h in degrees, s and l in 0-1) to #rrggbb
+func hslToHex(h, s, l float64) string {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	m := l - c/2
+	toByte := func(v float64) int {
+		return int(math.Round((v + m) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", toByte(r), toByte(g), toByte(b))
+}
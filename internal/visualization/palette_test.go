@@ -0,0 +1,44 @@
+package visualization
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestClusterColors_Distinct(t *testing.T) {
+	colors := ClusterColors(DefaultPalette, 15)
+
+	if len(colors) != 15 {
+		t.Fatalf("expected 15 colors, got %d", len(colors))
+	}
+
+	hex := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	seen := make(map[string]bool)
+	for i, c := range colors {
+		if !hex.MatchString(c) {
+			t.Errorf("color %d is not a hex color: %q", i, c)
+		}
+		if seen[c] {
+			t.Errorf("color %q repeated at index %d", c, i)
+		}
+		seen[c] = true
+	}
+
+	// Palette colors are used first, in order
+	for i, c := range DefaultPalette {
+		if colors[i] != c {
+			t.Errorf("expected palette color %q at index %d, got %q", c, i, colors[i])
+		}
+	}
+}
+
+func TestClusterColors_Deterministic(t *testing.T) {
+	a := ClusterColors([]string{"#000000"}, 40)
+	b := ClusterColors([]string{"#000000"}, 40)
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("expected deterministic colors, index %d differs: %q vs %q", i, a[i], b[i])
+		}
+	}
+}
@@ -32,8 +32,17 @@ const maxPCADimensions = 256
 
 // Reduce performs PCA dimensionality reduction
 func (r *PCAReducer) Reduce(embeddings [][]float32, dims int) ([][]float64, error) {
+	reduced, _, err := r.ReduceWithVariance(embeddings, dims)
+	return reduced, err
+}
+
+// ReduceWithVariance performs PCA dimensionality reduction and also returns
+// the fraction of the variance explained by each returned component. The
+// fractions are relative to the first maxPCADimensions input dimensions
+// that PCA looks at.
+func (r *PCAReducer) ReduceWithVariance(embeddings [][]float32, dims int) ([][]float64, []float64, error) {
 	if len(embeddings) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	n := len(embeddings)
@@ -78,7 +87,21 @@ func (r *PCAReducer) Reduce(embeddings [][]float32, dims int) ([][]float64, erro
 	var svd mat.SVD
 	ok := svd.Factorize(centered, mat.SVDThin)
 	if !ok {
-		return nil, fmt.Errorf("SVD factorization failed")
+		return nil, nil, fmt.Errorf("SVD factorization failed")
+	}
+
+	// The variance along each component is proportional to its squared
+	// singular value
+	values := svd.Values(nil)
+	total := 0.0
+	for _, v := range values {
+		total += v * v
+	}
+	explained := make([]float64, dims)
+	if total > 0 {
+		for j := 0; j < dims && j < len(values); j++ {
+			explained[j] = values[j] * values[j] / total
+		}
 	}
 
 	// Get V matrix (right singular vectors)
@@ -102,7 +125,7 @@ func (r *PCAReducer) Reduce(embeddings [][]float32, dims int) ([][]float64, erro
 	// Normalize to [-1, 1] range for visualization
 	reduced = normalizeCoordinates(reduced)
 
-	return reduced, nil
+	return reduced, explained, nil
 }
 
 // normalizeCoordinates scales coordinates to [-1, 1] range
@@ -0,0 +1,37 @@
+package visualization
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestPCAReducer_ExplainedVariance(t *testing.T) {
+	// Variance 4x larger along the first dimension than the second, none
+	// along the third
+	embeddings := [][]float32{{2, 0, 0}, {-2, 0, 0}, {0, 1, 0}, {0, -1, 0}}
+
+	coords, explained, err := NewPCAReducer().ReduceWithVariance(embeddings, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(coords) != len(embeddings) || len(explained) != 2 {
+		t.Fatalf("expected %d points and 2 ratios, got %d and %v", len(embeddings), len(coords), explained)
+	}
+	if math.Abs(explained[0]-0.8) > 1e-9 || math.Abs(explained[1]-0.2) > 1e-9 {
+		t.Errorf("expected ratios [0.8 0.2], got %v", explained)
+	}
+}
+
+func TestGetVisualization_ExplainedVariance(t *testing.T) {
+	svc := NewService(DefaultConfig(), nil)
+	embeddings := [][]float32{{2, 0, 0}, {-2, 0, 0}, {0, 1, 0}, {0, -1, 0}}
+
+	result, err := svc.GetVisualization(context.Background(), embeddings, "pca", 2, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.ExplainedVariance) != 2 || result.ExplainedVariance[0] < result.ExplainedVariance[1] {
+		t.Errorf("expected descending ratios for 2 dimensions, got %v", result.ExplainedVariance)
+	}
+}
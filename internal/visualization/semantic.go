@@ -2,15 +2,108 @@ package visualization
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-// SemanticAxis represents a user-defined semantic dimension
+// DefaultMinAxisNorm is the smallest embedding norm accepted for an axis word
+const DefaultMinAxisNorm = 1e-6
+
+// DefaultMinAxisAlignment is the mean absolute cosine similarity between an
+// axis and the embeddings below which the axis is reported as weak
+const DefaultMinAxisAlignment = 0.05
+
+// MaxAxisWordLength bounds the characters of an axis word or phrase
+const MaxAxisWordLength = 64
+
+// NormalizeAxisWord trims an axis word and collapses runs of whitespace.
+// With lower it is also lowercased, so "Customer  Satisfaction" and
+// "customer satisfaction" name the same axis.
+func NormalizeAxisWord(word string, lower bool) string {
+	word = strings.Join(strings.Fields(word), " ")
+	if lower {
+		word = strings.ToLower(word)
+	}
+	return word
+}
+
+// ValidateAxisWord reports why word cannot name an axis: it is empty, too
+// long, has no letters or contains control characters
+func ValidateAxisWord(word string) error {
+	if strings.TrimSpace(word) == "" {
+		return errors.New("axis words must not be empty")
+	}
+	if utf8.RuneCountInString(word) > MaxAxisWordLength {
+		return fmt.Errorf("axis word %q is longer than %d characters", word, MaxAxisWordLength)
+	}
+	letters := false
+	for _, r := range word {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("axis word %q contains control characters", word)
+		}
+		if unicode.IsLetter(r) {
+			letters = true
+		}
+	}
+	if !letters {
+		return fmt.Errorf("axis word %q contains no letters", word)
+	}
+	return nil
+}
+
+// DegenerateAxisError is returned when an axis word embeds to a zero or
+// near-zero vector, which would project every statement to the same
+// coordinate on that axis
+type DegenerateAxisError struct {
+	Word string
+	Norm float64
+}
+
+func (e *DegenerateAxisError) Error() string {
+	return fmt.Sprintf("axis word %q has a degenerate embedding (norm %.2g) - choose a different word", e.Word, e.Norm)
+}
+
+// SemanticAxis represents a user-defined semantic dimension: a single word,
+// or a contrast from Negative to Word
 type SemanticAxis struct {
 	Word      string    `json:"word"`
+	Negative  string    `json:"negative,omitempty"`
 	Embedding []float32 `json:"-"` // Full embedding vector for projection
 }
 
+// Label names the axis: the word, or "positive-negative" for a contrast
+func (a SemanticAxis) Label() string {
+	return AxisPoles{Positive: a.Word, Negative: a.Negative}.Label()
+}
+
+// AxisPoles names the words of a semantic axis. Without Negative the axis
+// points at Positive's embedding; with it, from Negative to Positive.
+type AxisPoles struct {
+	Positive string
+	Negative string
+}
+
+// Label names the axis like the presets: the word, or "positive-negative"
+func (p AxisPoles) Label() string {
+	if p.Negative == "" {
+		return p.Positive
+	}
+	return p.Positive + "-" + p.Negative
+}
+
+// WordPoles returns single-word axes for words
+func WordPoles(words []string) []AxisPoles {
+	poles := make([]AxisPoles, len(words))
+	for i, word := range words {
+		poles[i] = AxisPoles{Positive: word}
+	}
+	return poles
+}
+
 // PresetAxis represents a preset axis configuration
 type PresetAxis struct {
 	Name        string   `json:"name"`
@@ -49,23 +142,59 @@ type EmbeddingProvider interface {
 	EmbedText(ctx context.Context, text string) ([]float32, error)
 }
 
+// maxAxisCacheEntries bounds the axis word embeddings kept by a projector
+const maxAxisCacheEntries = 256
+
 // SemanticProjector handles semantic axis projection
 type SemanticProjector struct {
 	embedder EmbeddingProvider
+	minNorm  float64 // axis embeddings below this norm are rejected; negative disables
+	cache    *axisCache
 }
 
-// NewSemanticProjector creates a new semantic projector
+// NewSemanticProjector creates a new semantic projector. Axis word
+// embeddings are cached, keyed by the embedder's model when it reports one.
 func NewSemanticProjector(embedder EmbeddingProvider) *SemanticProjector {
-	return &SemanticProjector{embedder: embedder}
+	return &SemanticProjector{
+		embedder: embedder,
+		minNorm:  DefaultMinAxisNorm,
+		cache:    newAxisCache(maxAxisCacheEntries),
+	}
+}
+
+// embedWord returns the embedding of an axis word, from the cache when the
+// word was embedded before with the same model
+func (p *SemanticProjector) embedWord(ctx context.Context, word string) ([]float32, error) {
+	key := word
+	if m, ok := p.embedder.(interface{ Model() string }); ok {
+		key = m.Model() + "\x00" + word
+	}
+
+	if embedding, ok := p.cache.get(key); ok {
+		return embedding, nil
+	}
+	embedding, err := p.embedder.EmbedText(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.add(key, embedding)
+	return embedding, nil
 }
 
 // FindSemanticAxis creates a semantic axis from a word
 func (p *SemanticProjector) FindSemanticAxis(ctx context.Context, word string) (*SemanticAxis, error) {
-	embedding, err := p.embedder.EmbedText(ctx, word)
+	embedding, err := p.embedWord(ctx, word)
 	if err != nil {
 		return nil, fmt.Errorf("embed word %q: %w", word, err)
 	}
 
+	if p.minNorm >= 0 {
+		norm := vectorNorm(embedding)
+		if norm < p.minNorm || math.IsNaN(norm) {
+			return nil, &DegenerateAxisError{Word: word, Norm: norm}
+		}
+	}
+
 	return &SemanticAxis{
 		Word:      word,
 		Embedding: embedding,
@@ -87,6 +216,60 @@ func (p *SemanticProjector) FindSemanticAxes(ctx context.Context, words []string
 	return axes, nil
 }
 
+// FindBipolarAxis creates an axis pointing from the negative word's
+// embedding to the positive one's, so projections measure which pole a text
+// leans to
+func (p *SemanticProjector) FindBipolarAxis(ctx context.Context, positive, negative string) (*SemanticAxis, error) {
+	pos, err := p.FindSemanticAxis(ctx, positive)
+	if err != nil {
+		return nil, err
+	}
+	neg, err := p.FindSemanticAxis(ctx, negative)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(pos.Embedding)
+	if len(neg.Embedding) < n {
+		n = len(neg.Embedding)
+	}
+	direction := make([]float32, n)
+	for i := range direction {
+		direction[i] = pos.Embedding[i] - neg.Embedding[i]
+	}
+
+	axis := &SemanticAxis{Word: positive, Negative: negative, Embedding: direction}
+	if p.minNorm >= 0 {
+		norm := vectorNorm(direction)
+		if norm < p.minNorm || math.IsNaN(norm) {
+			return nil, &DegenerateAxisError{Word: axis.Label(), Norm: norm}
+		}
+	}
+	return axis, nil
+}
+
+// FindAxes creates an axis for each entry of poles, bipolar when it has a
+// negative word
+func (p *SemanticProjector) FindAxes(ctx context.Context, poles []AxisPoles) ([]SemanticAxis, error) {
+	axes := make([]SemanticAxis, len(poles))
+
+	for i, pole := range poles {
+		var axis *SemanticAxis
+		var err error
+		if pole.Negative == "" {
+			axis, err = p.FindSemanticAxis(ctx, pole.Positive)
+		} else {
+			axis, err = p.FindBipolarAxis(ctx, pole.Positive, pole.Negative)
+		}
+		if err != nil {
+			return nil, err
+		}
+		axes[i] = *axis
+	}
+
+	return axes, nil
+}
+
 // ProjectToAxes projects embeddings onto semantic axes using dot product
 func ProjectToAxes(embeddings [][]float32, axes []SemanticAxis) [][]float64 {
 	if len(embeddings) == 0 || len(axes) == 0 {
@@ -107,6 +290,35 @@ func ProjectToAxes(embeddings [][]float32, axes []SemanticAxis) [][]float64 {
 	return normalizeCoordinates(result)
 }
 
+// AxisAlignment returns the mean absolute cosine similarity between the axis
+// and the embeddings. Near 0 the axis is almost orthogonal to all of them,
+// so projecting onto it barely separates anything. Zero vectors are skipped.
+func AxisAlignment(embeddings [][]float32, axis SemanticAxis) float64 {
+	axisNorm := vectorNorm(axis.Embedding)
+	if axisNorm == 0 {
+		return 0
+	}
+
+	sum, n := 0.0, 0
+	for _, emb := range embeddings {
+		norm := vectorNorm(emb)
+		if norm == 0 {
+			continue
+		}
+		sum += math.Abs(dotProduct(emb, axis.Embedding) / (norm * axisNorm))
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// vectorNorm computes the Euclidean norm of a vector
+func vectorNorm(v []float32) float64 {
+	return math.Sqrt(dotProduct(v, v))
+}
+
 // dotProduct computes the dot product of two vectors
 func dotProduct(a, b []float32) float64 {
 	sum := float64(0)
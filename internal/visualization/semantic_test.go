@@ -0,0 +1,212 @@
+package visualization
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// stubEmbedder returns fixed vectors per word
+type stubEmbedder map[string][]float32
+
+func (e stubEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	return e[text], nil
+}
+
+func TestGetVisualization_DegenerateAxis(t *testing.T) {
+	embedder := stubEmbedder{
+		"risk":  {1, 0, 0},
+		"empty": {0, 0, 0},
+	}
+	svc := NewService(DefaultConfig(), embedder)
+	embeddings := [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+	_, err := svc.GetVisualization(context.Background(), embeddings, "semantic", 2, []string{"risk", "empty"})
+	var degenerate *DegenerateAxisError
+	if !errors.As(err, &degenerate) {
+		t.Fatalf("expected DegenerateAxisError, got %v", err)
+	}
+	if degenerate.Word != "empty" {
+		t.Errorf("expected problematic word %q, got %q", "empty", degenerate.Word)
+	}
+	if !strings.Contains(err.Error(), `"empty"`) {
+		t.Errorf("expected error to name the word, got %q", err.Error())
+	}
+
+	result, err := svc.GetVisualization(context.Background(), embeddings, "semantic", 1, []string{"risk"})
+	if err != nil {
+		t.Fatalf("expected valid axis to succeed, got %v", err)
+	}
+	if len(result.Points) != len(embeddings) {
+		t.Errorf("expected %d points, got %d", len(embeddings), len(result.Points))
+	}
+
+	config := DefaultConfig()
+	config.MinAxisNorm = -1
+	unchecked := NewService(config, embedder)
+	if _, err := unchecked.GetVisualization(context.Background(), embeddings, "semantic", 2, []string{"risk", "empty"}); err != nil {
+		t.Errorf("expected check to be disabled with negative MinAxisNorm, got %v", err)
+	}
+}
+
+func TestGetVisualization_WeakAxisWarning(t *testing.T) {
+	embedder := stubEmbedder{
+		"risk":  {1, 0, 0, 0},
+		"rsik":  {0, 0, 0, 1},
+		"broad": {1, 1, 1, 0},
+	}
+	svc := NewService(DefaultConfig(), embedder)
+	embeddings := [][]float32{{1, 0, 0, 0}, {0.8, 0.6, 0, 0}, {0, 0, 1, 0}}
+
+	result, err := svc.GetVisualization(context.Background(), embeddings, "semantic", 3, []string{"risk", "rsik", "broad"})
+	if err != nil {
+		t.Fatalf("GetVisualization: %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `"rsik"`) {
+		t.Errorf("expected a single warning naming the orthogonal axis, got %v", result.Warnings)
+	}
+
+	config := DefaultConfig()
+	config.MinAxisAlignment = -1
+	result, err = NewService(config, embedder).GetVisualization(context.Background(), embeddings, "semantic", 1, []string{"rsik"})
+	if err != nil {
+		t.Fatalf("GetVisualization: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings with negative MinAxisAlignment, got %v", result.Warnings)
+	}
+}
+
+func TestAxisAlignment(t *testing.T) {
+	axis := SemanticAxis{Word: "x", Embedding: []float32{2, 0}}
+	embeddings := [][]float32{{1, 0}, {-1, 0}, {0, 3}, {0, 0}}
+
+	// |1| + |-1| + 0 over the three non-zero embeddings
+	if got, want := AxisAlignment(embeddings, axis), 2.0/3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected alignment %g, got %g", want, got)
+	}
+	if got := AxisAlignment(embeddings, SemanticAxis{Embedding: []float32{0, 0}}); got != 0 {
+		t.Errorf("expected 0 for a zero axis, got %g", got)
+	}
+}
+
+func TestNormalizeAxisWord(t *testing.T) {
+	tests := []struct {
+		word  string
+		lower bool
+		want  string
+	}{
+		{"  risk ", false, "risk"},
+		{"Customer \t Satisfaction", false, "Customer Satisfaction"},
+		{"Customer \t Satisfaction", true, "customer satisfaction"},
+		{"   ", true, ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAxisWord(tt.word, tt.lower); got != tt.want {
+			t.Errorf("NormalizeAxisWord(%q, %v) = %q, want %q", tt.word, tt.lower, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAxisWord(t *testing.T) {
+	for _, word := range []string{"risk", "customer satisfaction", "sécurité", "B2B"} {
+		if err := ValidateAxisWord(word); err != nil {
+			t.Errorf("ValidateAxisWord(%q): unexpected error %v", word, err)
+		}
+	}
+	for _, word := range []string{"", "  ", "42", "--", "risk\x00", strings.Repeat("a", MaxAxisWordLength+1)} {
+		if err := ValidateAxisWord(word); err == nil {
+			t.Errorf("ValidateAxisWord(%q): expected an error", word)
+		}
+	}
+}
+
+func TestFindBipolarAxis(t *testing.T) {
+	embedder := stubEmbedder{
+		"cheap":     {1, 0, 0},
+		"expensive": {0, 1, 0},
+		"pricey":    {0, 1, 0},
+	}
+	projector := NewSemanticProjector(embedder)
+
+	axis, err := projector.FindBipolarAxis(context.Background(), "cheap", "expensive")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if axis.Label() != "cheap-expensive" {
+		t.Errorf("expected label cheap-expensive, got %q", axis.Label())
+	}
+
+	// Projections order texts from the negative to the positive pole
+	coords := ProjectToAxes([][]float32{{0, 1, 0}, {0.5, 0.5, 0}, {1, 0, 0}}, []SemanticAxis{*axis})
+	if !(coords[0][0] < coords[1][0] && coords[1][0] < coords[2][0]) {
+		t.Errorf("expected coordinates to increase towards cheap, got %v", coords)
+	}
+
+	_, err = projector.FindBipolarAxis(context.Background(), "expensive", "pricey")
+	var degenerate *DegenerateAxisError
+	if !errors.As(err, &degenerate) || degenerate.Word != "expensive-pricey" {
+		t.Errorf("expected DegenerateAxisError for poles with the same embedding, got %v", err)
+	}
+}
+
+// countingEmbedder counts EmbedText calls and reports a model
+type countingEmbedder struct {
+	stubEmbedder
+	model string
+	calls int
+}
+
+func (e *countingEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	return e.stubEmbedder.EmbedText(ctx, text)
+}
+
+func (e *countingEmbedder) Model() string {
+	return e.model
+}
+
+func TestSemanticProjector_CachesAxisEmbeddings(t *testing.T) {
+	embedder := &countingEmbedder{stubEmbedder: stubEmbedder{"cheap": {1, 0}, "expensive": {0, 1}}, model: "a"}
+	projector := NewSemanticProjector(embedder)
+	ctx := context.Background()
+
+	poles := []AxisPoles{{Positive: "cheap", Negative: "expensive"}, {Positive: "cheap"}}
+	for i := 0; i < 3; i++ {
+		if _, err := projector.FindAxes(ctx, poles); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if embedder.calls != 2 {
+		t.Errorf("expected each word to be embedded once, got %d calls", embedder.calls)
+	}
+
+	// A different model does not reuse the cached vectors
+	embedder.model = "b"
+	if _, err := projector.FindSemanticAxis(ctx, "cheap"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if embedder.calls != 3 {
+		t.Errorf("expected a new model to re-embed the word, got %d calls", embedder.calls)
+	}
+}
+
+func TestAxisCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newAxisCache(2)
+	cache.add("a", []float32{1})
+	cache.add("b", []float32{2})
+	cache.get("a")
+	cache.add("c", []float32{3})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Error("expected a to be kept")
+	}
+	if _, ok := cache.get("c"); !ok {
+		t.Error("expected c to be kept")
+	}
+}
@@ -18,12 +18,25 @@ type VisualizationResult struct {
 	Method     string         `json:"method"`
 	Dimensions int            `json:"dimensions"`
 	Axes       []SemanticAxis `json:"axes,omitempty"`
+	// ExplainedVariance is the fraction of the variance shown by each
+	// dimension, set for the pca method
+	ExplainedVariance []float64 `json:"explained_variance,omitempty"`
+	// Warnings name semantic axes that barely separate the embeddings
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // Config holds visualization configuration
 type Config struct {
 	DefaultMethod     string
 	DefaultDimensions int
+	Palette           []string // Base cluster colors, extended as needed
+	// MinAxisNorm rejects semantic axis words whose embedding norm is below
+	// it. Zero uses DefaultMinAxisNorm; negative disables the check.
+	MinAxisNorm float64
+	// MinAxisAlignment warns about semantic axes whose mean absolute cosine
+	// similarity to the embeddings is below it. Zero uses
+	// DefaultMinAxisAlignment; negative disables the warning.
+	MinAxisAlignment float64
 }
 
 // DefaultConfig returns default configuration
@@ -31,6 +44,9 @@ func DefaultConfig() Config {
 	return Config{
 		DefaultMethod:     "pca",
 		DefaultDimensions: 2,
+		Palette:           DefaultPalette,
+		MinAxisNorm:       DefaultMinAxisNorm,
+		MinAxisAlignment:  DefaultMinAxisAlignment,
 	}
 }
 
@@ -45,6 +61,9 @@ func NewService(config Config, embedder EmbeddingProvider) *Service {
 	var projector *SemanticProjector
 	if embedder != nil {
 		projector = NewSemanticProjector(embedder)
+		if config.MinAxisNorm != 0 {
+			projector.minNorm = config.MinAxisNorm
+		}
 	}
 
 	return &Service{
@@ -53,13 +72,27 @@ func NewService(config Config, embedder EmbeddingProvider) *Service {
 	}
 }
 
-// GetVisualization generates visualization coordinates for embeddings
+// GetVisualization generates visualization coordinates for embeddings,
+// using single-word axes for the semantic and hybrid methods
 func (s *Service) GetVisualization(
 	ctx context.Context,
 	embeddings [][]float32,
 	method string,
 	dimensions int,
 	axisWords []string,
+) (*VisualizationResult, error) {
+	return s.GetVisualizationWithAxes(ctx, embeddings, method, dimensions, WordPoles(axisWords))
+}
+
+// GetVisualizationWithAxes generates visualization coordinates for
+// embeddings. The semantic and hybrid methods project onto the axes named by
+// poles, which may be single words or word pairs.
+func (s *Service) GetVisualizationWithAxes(
+	ctx context.Context,
+	embeddings [][]float32,
+	method string,
+	dimensions int,
+	poles []AxisPoles,
 ) (*VisualizationResult, error) {
 	if len(embeddings) == 0 {
 		return &VisualizationResult{
@@ -83,7 +116,7 @@ func (s *Service) GetVisualization(
 	case "pca":
 		reducer = NewPCAReducer()
 	case "semantic":
-		if len(axisWords) == 0 {
+		if len(poles) == 0 {
 			return nil, fmt.Errorf("semantic method requires axis words")
 		}
 		if s.projector == nil {
@@ -91,18 +124,43 @@ func (s *Service) GetVisualization(
 		}
 
 		var err error
-		axes, err = s.projector.FindSemanticAxes(ctx, axisWords)
+		axes, err = s.projector.FindAxes(ctx, poles)
 		if err != nil {
 			return nil, fmt.Errorf("find semantic axes: %w", err)
 		}
 
 		reducer = NewSemanticReducer(axes)
 		dimensions = len(axes)
+	case "hybrid":
+		if len(poles) == 0 {
+			return nil, fmt.Errorf("hybrid method requires axis words")
+		}
+		if len(poles) > dimensions {
+			return nil, fmt.Errorf("hybrid method takes at most %d axis words for %d dimensions", dimensions, dimensions)
+		}
+		if s.projector == nil {
+			return nil, fmt.Errorf("embedding provider not configured")
+		}
+
+		var err error
+		axes, err = s.projector.FindAxes(ctx, poles)
+		if err != nil {
+			return nil, fmt.Errorf("find semantic axes: %w", err)
+		}
+
+		reducer = NewHybridReducer(axes)
 	default:
 		return nil, fmt.Errorf("unknown method: %s", method)
 	}
 
-	coords, err := reducer.Reduce(embeddings, dimensions)
+	var coords [][]float64
+	var explained []float64
+	var err error
+	if pca, ok := reducer.(*PCAReducer); ok {
+		coords, explained, err = pca.ReduceWithVariance(embeddings, dimensions)
+	} else {
+		coords, err = reducer.Reduce(embeddings, dimensions)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("reduce: %w", err)
 	}
@@ -124,9 +182,39 @@ func (s *Service) GetVisualization(
 		Method:     method,
 		Dimensions: dimensions,
 		Axes:       axes,
+
+		ExplainedVariance: explained,
+		Warnings:          s.weakAxisWarnings(embeddings, axes),
 	}, nil
 }
 
+// weakAxisWarnings describes the axes that are nearly orthogonal to all of
+// the embeddings, typically a misspelled or unrelated word
+func (s *Service) weakAxisWarnings(embeddings [][]float32, axes []SemanticAxis) []string {
+	threshold := s.config.MinAxisAlignment
+	if threshold == 0 {
+		threshold = DefaultMinAxisAlignment
+	}
+	if threshold < 0 {
+		return nil
+	}
+
+	var warnings []string
+	for _, axis := range axes {
+		if alignment := AxisAlignment(embeddings, axis); alignment < threshold {
+			warnings = append(warnings, fmt.Sprintf(
+				"axis %q is weak: it is nearly orthogonal to the statements (mean |cosine| %.3f), so it barely separates them - check the spelling or choose a related word",
+				axis.Label(), alignment))
+		}
+	}
+	return warnings
+}
+
+// ClusterColors returns n distinct colors using the configured palette
+func (s *Service) ClusterColors(n int) []string {
+	return ClusterColors(s.config.Palette, n)
+}
+
 // GetPresets returns available axis presets
 func (s *Service) GetPresets() []PresetAxis {
 	return DefaultPresets()
@@ -0,0 +1,12 @@
+-- Positions are unique per document so ORDER BY position is deterministic.
+-- Databases created before this index may hold duplicate positions, so each
+-- document's statements are renumbered in their current order first.
+UPDATE statements s
+SET position = r.new_position
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY document_id ORDER BY position, id) - 1 AS new_position
+    FROM statements
+) r
+WHERE s.id = r.id AND s.position <> r.new_position;
+
+CREATE UNIQUE INDEX idx_statements_document_position ON statements(document_id, position);
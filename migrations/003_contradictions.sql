@@ -0,0 +1,22 @@
+-- Detected contradictions with review status for the compliance worklist.
+-- Statement IDs are stored in canonical order (statement1_id < statement2_id).
+CREATE TABLE contradictions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    statement1_id UUID NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    statement2_id UUID NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    explanation TEXT NOT NULL DEFAULT '',
+    confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'accepted', 'dismissed')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (statement1_id, statement2_id)
+);
+
+CREATE INDEX idx_contradictions_project_status ON contradictions(project_id, status);
+
+CREATE TRIGGER update_contradictions_updated_at BEFORE UPDATE ON contradictions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
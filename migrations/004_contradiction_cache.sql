@@ -0,0 +1,16 @@
+-- Cached LLM contradiction analyses, including pairs found not to contradict,
+-- so unchanged pairs are not re-sent to the model. Statement IDs are stored
+-- in canonical order (statement1_id < statement2_id).
+CREATE TABLE contradiction_analyses (
+    statement1_id UUID NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    statement2_id UUID NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    is_contradiction BOOLEAN NOT NULL,
+    type VARCHAR(50) NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL DEFAULT '',
+    explanation TEXT NOT NULL DEFAULT '',
+    confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    analyzed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (statement1_id, statement2_id)
+);
+
+CREATE INDEX idx_contradiction_analyses_statement2 ON contradiction_analyses(statement2_id);
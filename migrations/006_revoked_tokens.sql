@@ -0,0 +1,9 @@
+-- Denylist of access tokens revoked before expiry, keyed on the JWT jti
+-- claim. Rows past expires_at no longer matter and can be purged.
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
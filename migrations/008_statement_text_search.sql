@@ -0,0 +1,3 @@
+-- Full-text search over statement text. Queries must use the same
+-- to_tsvector('english', text) expression for this index to apply.
+CREATE INDEX idx_statements_text_search ON statements USING GIN (to_tsvector('english', text));
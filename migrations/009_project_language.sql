@@ -0,0 +1,3 @@
+-- Optional per-project language for contradiction prompts (e.g. 'de').
+-- Empty means the language is detected from the project's statements.
+ALTER TABLE projects ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '';
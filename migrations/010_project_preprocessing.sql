@@ -0,0 +1,4 @@
+-- Optional per-project preprocessing steps (e.g. '{lowercase,mask_numbers}')
+-- applied in order to statement text before embedding. Stored statement text
+-- is never changed.
+ALTER TABLE projects ADD COLUMN preprocessing TEXT[] NOT NULL DEFAULT '{}';
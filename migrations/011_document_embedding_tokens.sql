@@ -0,0 +1,3 @@
+-- Embedding tokens consumed per document, as reported by the embedding API.
+-- Re-embedding adds to the count, so it reflects total spend.
+ALTER TABLE documents ADD COLUMN embedding_tokens INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Free-form labels attached to statements, e.g. when labeling data by
+-- similarity. A statement carries each tag at most once.
+CREATE TABLE statement_tags (
+    statement_id UUID NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (statement_id, tag)
+);
+
+CREATE INDEX idx_statement_tags_tag ON statement_tags(tag);
@@ -0,0 +1,4 @@
+-- The embedding model that produced each statement's vector, so statements
+-- embedded with different models can be detected. Empty for statements
+-- embedded before this column existed.
+ALTER TABLE statements ADD COLUMN embedding_model VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Fitted k-means centroids per project, so statements added later can be
+-- assigned to existing clusters without a full refit. requested_k is 0 when
+-- k was chosen automatically; fitted_statements is the statement count of
+-- the last full fit and drives periodic refits.
+CREATE TABLE cluster_models (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    requested_k INTEGER NOT NULL,
+    centroids JSONB NOT NULL,
+    fitted_statements INTEGER NOT NULL,
+    fitted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,9 @@
+-- Keep the last computed clustering of each project in clusters and
+-- cluster_statements. requested_k is the k asked for (0 when chosen
+-- automatically) and fingerprint hashes the clustered statements and their
+-- embeddings, so a stored clustering is only served while both match.
+-- Centroids are kept in cluster_models.
+ALTER TABLE clusters ADD COLUMN requested_k INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clusters ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_cluster_statements_statement_id ON cluster_statements(statement_id);
@@ -0,0 +1,4 @@
+-- Optional per-project overrides of the server's analysis defaults, e.g.
+-- '{"cluster_k": 8, "similarity_threshold": 0.8}'. Absent keys keep the
+-- server default.
+ALTER TABLE projects ADD COLUMN analysis_config JSONB NOT NULL DEFAULT '{}';
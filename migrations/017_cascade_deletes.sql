@@ -0,0 +1,17 @@
+-- Deleting a project removes its documents and deleting a document removes
+-- its statements. The initial schema declares these cascades, but databases
+-- created without them kept orphaned rows, so the constraints are
+-- re-created here after removing any orphans.
+DELETE FROM statements s
+WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.id = s.document_id);
+
+DELETE FROM documents d
+WHERE NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = d.project_id);
+
+ALTER TABLE statements DROP CONSTRAINT IF EXISTS statements_document_id_fkey;
+ALTER TABLE statements ADD CONSTRAINT statements_document_id_fkey
+    FOREIGN KEY (document_id) REFERENCES documents(id) ON DELETE CASCADE;
+
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_project_id_fkey;
+ALTER TABLE documents ADD CONSTRAINT documents_project_id_fkey
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Why embedding a document's statements failed on upload, empty when it
+-- succeeded or has not been attempted. Statements without embeddings are
+-- reported as "failed" while this is set and "pending" otherwise.
+ALTER TABLE documents ADD COLUMN embedding_error TEXT NOT NULL DEFAULT '';
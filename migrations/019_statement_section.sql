@@ -0,0 +1,4 @@
+-- Markdown header hierarchy a statement sits under, e.g.
+-- 'Billing > Refund Policy'. Empty for statements outside any section and
+-- for documents extracted before sections were tracked.
+ALTER TABLE statements ADD COLUMN section TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Statement closest to each stored cluster's centroid, shown as an example
+-- of the cluster. NULL for clusters stored before it was tracked.
+ALTER TABLE clusters ADD COLUMN representative_id UUID REFERENCES statements(id) ON DELETE SET NULL;
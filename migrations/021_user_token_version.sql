@@ -0,0 +1,4 @@
+-- Bumped whenever a user's password changes. Access tokens carry the
+-- version they were issued with and are rejected once it is outdated, so a
+-- password reset signs out existing sessions.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Key cached contradiction analyses by the prompt language and prompt
+-- version too, so switching a project's language or editing the prompt
+-- template re-analyzes pairs instead of returning stale verdicts. Existing
+-- analyses don't record either, so they are dropped and re-analyzed on the
+-- next run.
+DELETE FROM contradiction_analyses;
+
+ALTER TABLE contradiction_analyses
+    ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT 'en',
+    ADD COLUMN prompt_version VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE contradiction_analyses DROP CONSTRAINT contradiction_analyses_pkey;
+ALTER TABLE contradiction_analyses
+    ADD PRIMARY KEY (statement1_id, statement2_id, language, prompt_version);
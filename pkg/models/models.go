@@ -40,6 +40,7 @@ type Statement struct {
 	Position   int       `json:"position"`
 	Line       int       `json:"line"`
 	File       string    `json:"file"`
+	Section    string    `json:"section,omitempty"`
 	Embedding  []float32 `json:"-"`
 }
 
@@ -273,7 +273,7 @@ export default function Dashboard() {
               ref={fileInputRef}
               type="file"
               multiple
-              accept=".md,.txt,.json,.csv"
+              accept=".md,.txt,.json,.csv,.xml"
               onChange={handleUpload}
               className="hidden"
             />